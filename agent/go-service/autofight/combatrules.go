@@ -0,0 +1,413 @@
+package autofight
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/expression"
+)
+
+// combatState 是规则条件求值时看到的一帧战斗状态，由 ScreenAnalyzer 的各 getter 汇总而来。
+// 所有按角色区分的列表都使用干员编号（1..characterCount），终结技槽位已从屏幕右对齐的槽位换算为干员编号。
+type combatState struct {
+	CharacterCount  int
+	Selected        int
+	EnergyLevel     int
+	HasEnemyTarget  bool
+	ComboActive     bool
+	ComboFull       []int
+	EndSkillFull    []int
+	HealthNormal    []int
+	HealthDangerous []int
+	Died            []int
+
+	EnemyTarget            bool
+	EnemyLocked            bool
+	EnemyBoss              bool
+	EnemyAccumulatingPower bool
+	EnemyDodge             bool
+	EnemyDodgeCompat       bool
+	EnemyGroundDodge       bool
+	EnemyFacingLeft        bool
+	EnemyFacingRight       bool
+	EnemyFacingBack        bool
+}
+
+// newCombatState 从 ScreenAnalyzer 读取当前帧的战斗状态。
+// energyLevel / endSkillFull 沿用主循环里已按 unused 语义读取的值，保证与 MarkLabelUsed 配合一致。
+func newCombatState(sa *ScreenAnalyzer, characterCount int, hasEnemyTarget bool, energyLevel int, endSkillFull []int) *combatState {
+	endSkillOperators := make([]int, 0, len(endSkillFull))
+	for _, slot := range endSkillFull {
+		if op := slot + characterCount - 4; op >= 1 {
+			endSkillOperators = append(endSkillOperators, op)
+		}
+	}
+	return &combatState{
+		CharacterCount:  characterCount,
+		Selected:        sa.GetCharacterSelect(),
+		EnergyLevel:     energyLevel,
+		HasEnemyTarget:  hasEnemyTarget,
+		ComboActive:     sa.GetCharacterComboActive(),
+		ComboFull:       sa.GetCharacterComboFull(),
+		EndSkillFull:    endSkillOperators,
+		HealthNormal:    sa.GetCharacterHealthNormal(),
+		HealthDangerous: sa.GetCharacterHealthDangerous(),
+		Died:            sa.GetCharacterDied(),
+
+		EnemyTarget:            sa.GetEnemyTarget(),
+		EnemyLocked:            sa.GetEnemyLocked(),
+		EnemyBoss:              sa.GetEnemyBossHealth(),
+		EnemyAccumulatingPower: sa.GetEnemyAccumulatingPower(true),
+		EnemyDodge:             sa.GetEnemyDodge(),
+		EnemyDodgeCompat:       sa.GetEnemyDodgeCompat(),
+		EnemyGroundDodge:       sa.GetEnemyAttackGroundDodge(),
+		EnemyFacingLeft:        sa.GetEnemyFacingLeft(),
+		EnemyFacingRight:       sa.GetEnemyFacingRight(),
+		EnemyFacingBack:        sa.GetEnemyFacingBack(),
+	}
+}
+
+// combatVariables 是规则中可直接引用的变量（int 或 bool）。
+var combatVariables = map[string]func(s *combatState) any{
+	"character_count":          func(s *combatState) any { return s.CharacterCount },
+	"selected":                 func(s *combatState) any { return s.Selected },
+	"energy_level":             func(s *combatState) any { return s.EnergyLevel },
+	"has_enemy_target":         func(s *combatState) any { return s.HasEnemyTarget },
+	"combo_active":             func(s *combatState) any { return s.ComboActive },
+	"combo_full_count":         func(s *combatState) any { return len(s.ComboFull) },
+	"end_skill_full_count":     func(s *combatState) any { return len(s.EndSkillFull) },
+	"health_normal_count":      func(s *combatState) any { return len(s.HealthNormal) },
+	"health_dangerous_count":   func(s *combatState) any { return len(s.HealthDangerous) },
+	"died_count":               func(s *combatState) any { return len(s.Died) },
+	"enemy_target":             func(s *combatState) any { return s.EnemyTarget },
+	"enemy_locked":             func(s *combatState) any { return s.EnemyLocked },
+	"enemy_boss":               func(s *combatState) any { return s.EnemyBoss },
+	"enemy_accumulating_power": func(s *combatState) any { return s.EnemyAccumulatingPower },
+	"enemy_dodge":              func(s *combatState) any { return s.EnemyDodge },
+	"enemy_dodge_compat":       func(s *combatState) any { return s.EnemyDodgeCompat },
+	"enemy_ground_dodge":       func(s *combatState) any { return s.EnemyGroundDodge },
+	"enemy_facing_left":        func(s *combatState) any { return s.EnemyFacingLeft },
+	"enemy_facing_right":       func(s *combatState) any { return s.EnemyFacingRight },
+	"enemy_facing_back":        func(s *combatState) any { return s.EnemyFacingBack },
+}
+
+// combatFunctions 是规则中按干员编号查询的函数对应的列表，例如 combo_full(2)。
+var combatFunctions = map[string]func(s *combatState) []int{
+	"combo_full":       func(s *combatState) []int { return s.ComboFull },
+	"end_skill_full":   func(s *combatState) []int { return s.EndSkillFull },
+	"health_normal":    func(s *combatState) []int { return s.HealthNormal },
+	"health_dangerous": func(s *combatState) []int { return s.HealthDangerous },
+	"died":             func(s *combatState) []int { return s.Died },
+}
+
+// combatConditionSchema 是规则条件的类型信息，变量类型取自零值状态。
+var combatConditionSchema = func() *expression.Schema {
+	schema := &expression.Schema{
+		Variables: make(map[string]expression.Type, len(combatVariables)),
+		Functions: make(map[string]expression.Function, len(combatFunctions)),
+	}
+	for name, get := range combatVariables {
+		schema.Variables[name] = expression.TypeOf(get(&combatState{}))
+	}
+	for name := range combatFunctions {
+		schema.Functions[name] = expression.Function{Params: []expression.Type{expression.TypeInt}, Result: expression.TypeBool}
+	}
+	return schema
+}()
+
+// Variable 实现 expression.Env。
+func (s *combatState) Variable(name string) (any, bool) {
+	get, ok := combatVariables[name]
+	if !ok {
+		return nil, false
+	}
+	return get(s), true
+}
+
+// Call 实现 expression.Env。参数已经过 combatConditionSchema 的类型检查。
+func (s *combatState) Call(name string, args []any) (any, error) {
+	get, ok := combatFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("function %s expects 1 argument, got %d", name, len(args))
+	}
+	op, ok := args[0].(int)
+	if !ok {
+		return nil, fmt.Errorf("function %s expects int argument, got %T", name, args[0])
+	}
+	return slices.Contains(get(s), op), nil
+}
+
+// combatRuleActionNames 是规则 do 列表中不带参数的动作名。
+var combatRuleActionNames = map[string]ActionType{
+	"attack":       ActionAttack,
+	"combo":        ActionCombo,
+	"lock_target":  ActionLockTarget,
+	"dodge":        ActionDodge,
+	"sleep":        ActionSleepSecond,
+	"move_back":    ActionMoveBack,
+	"move_forward": ActionMoveForward,
+	"move_left":    ActionMoveLeft,
+	"move_right":   ActionMoveRight,
+}
+
+// combatRuleActionTargets 是带干员参数的动作（如 "skill:2"）允许的特殊目标。
+var combatRuleActionTargets = map[string][]string{
+	"skill":     {"next", "selected"},
+	"end_skill": {"ready", "selected"},
+	"switch":    {"healthy"},
+}
+
+// CombatRule 是一条战斗规则：条件 When 成立且冷却结束时，按顺序入队 Do 中的动作。
+type CombatRule struct {
+	Name string `json:"name"`
+	// When 是条件表达式，语法与 ExpressionRecognition 相同（整数、布尔、比较与逻辑运算），
+	// 额外支持战斗状态变量（如 energy_level）与按干员编号查询的函数（如 end_skill_full(3)）。
+	When string `json:"when"`
+	// Do 是动作序列，如 ["skill:next"]、["move_left", "sleep", "lock_target"]。
+	Do []string `json:"do"`
+	// Priority 越大越先求值，相同优先级按书写顺序。
+	Priority int `json:"priority,omitempty"`
+	// Cooldown 是规则两次触发之间的最短间隔（秒）。
+	Cooldown float64 `json:"cooldown,omitempty"`
+	// Stop 为 true 时，该规则触发后本帧不再求值更低优先级的规则。
+	Stop bool `json:"stop,omitempty"`
+}
+
+// CombatRuleScript 是可分享的战斗规则脚本。
+type CombatRuleScript struct {
+	Name  string       `json:"name"`
+	Rules []CombatRule `json:"rules"`
+}
+
+type combatRuleAction struct {
+	action ActionType
+	// kind 非空时动作需要在触发时按干员解析，取值为 "skill" / "end_skill" / "switch"。
+	kind   string
+	target string
+	op     int
+}
+
+type compiledCombatRule struct {
+	CombatRule
+	when      ast.Expr
+	actions   []combatRuleAction
+	lastFired time.Time
+}
+
+// CombatRules 是编译后的战斗规则，负责按优先级与冷却逐帧做出决策。
+type CombatRules struct {
+	Name            string
+	rules           []*compiledCombatRule
+	skillCycleIndex int
+}
+
+// firedCombatRule 是某一帧触发的规则及其解析后的动作序列。
+type firedCombatRule struct {
+	Name    string
+	Actions []ActionType
+}
+
+// ParseCombatRules 解析并编译战斗规则脚本 JSON。条件表达式与动作在加载时即完成校验，
+// 避免战斗中途才发现脚本错误。
+func ParseCombatRules(raw string) (*CombatRules, error) {
+	var script CombatRuleScript
+	if err := json.Unmarshal([]byte(raw), &script); err != nil {
+		return nil, fmt.Errorf("parse combat rules: %w", err)
+	}
+	if len(script.Rules) == 0 {
+		return nil, fmt.Errorf("combat rules must contain at least one rule")
+	}
+	if script.Name == "" {
+		script.Name = "unnamed"
+	}
+
+	rules := make([]*compiledCombatRule, 0, len(script.Rules))
+	for i, rule := range script.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule%d", i+1)
+		}
+		compiled, err := compileCombatRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		rules = append(rules, compiled)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority > rules[j].Priority
+	})
+	return &CombatRules{Name: script.Name, rules: rules, skillCycleIndex: 1}, nil
+}
+
+func compileCombatRule(rule CombatRule) (*compiledCombatRule, error) {
+	if len(rule.Do) == 0 {
+		return nil, fmt.Errorf("do must contain at least one action")
+	}
+	if rule.Cooldown < 0 {
+		return nil, fmt.Errorf("cooldown must be non-negative")
+	}
+
+	when, err := compileCombatCondition(rule.When)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := make([]combatRuleAction, 0, len(rule.Do))
+	for _, spec := range rule.Do {
+		action, err := parseCombatRuleAction(spec)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+	return &compiledCombatRule{CombatRule: rule, when: when, actions: actions}, nil
+}
+
+// compileCombatCondition 解析条件表达式，并按 combatConditionSchema 做类型检查，校验变量/函数名且结果为布尔值。
+func compileCombatCondition(expr string) (ast.Expr, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, fmt.Errorf("when is required")
+	}
+	when, err := expression.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("parse when: %w", err)
+	}
+	t, err := expression.Check(when, combatConditionSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid when: %w", err)
+	}
+	if t != expression.TypeBool {
+		return nil, fmt.Errorf("when must be a boolean expression, got %s", t)
+	}
+	return when, nil
+}
+
+// evaluateCombatCondition 对已编译的条件求值。求值出错（如除零）时视为不满足。
+func evaluateCombatCondition(when ast.Expr, state *combatState) bool {
+	result, err := expression.Evaluate(when, state)
+	if err != nil {
+		return false
+	}
+	matched, _ := result.(bool)
+	return matched
+}
+
+// parseCombatRuleAction 解析 "combo"、"skill:2"、"end_skill:ready"、"switch:healthy" 形式的动作。
+func parseCombatRuleAction(spec string) (combatRuleAction, error) {
+	spec = strings.TrimSpace(spec)
+	if action, ok := combatRuleActionNames[spec]; ok {
+		return combatRuleAction{action: action}, nil
+	}
+
+	kind, target, ok := strings.Cut(spec, ":")
+	targets, known := combatRuleActionTargets[kind]
+	if !ok || !known {
+		return combatRuleAction{}, fmt.Errorf("unknown action %q", spec)
+	}
+	if slices.Contains(targets, target) {
+		return combatRuleAction{kind: kind, target: target}, nil
+	}
+	op, err := strconv.Atoi(target)
+	if err != nil || op < 1 || op > 4 {
+		return combatRuleAction{}, fmt.Errorf("action %q must target an operator 1-4 or one of %v", spec, targets)
+	}
+	return combatRuleAction{kind: kind, op: op}, nil
+}
+
+// Decide 按优先级求值所有规则，返回本帧触发的规则。
+// 规则的动作需要按干员解析时（如 end_skill:ready 而没有就绪的终结技），视为本帧不满足条件。
+func (r *CombatRules) Decide(state *combatState, now time.Time) []firedCombatRule {
+	var fired []firedCombatRule
+	for _, rule := range r.rules {
+		if !rule.lastFired.IsZero() && now.Sub(rule.lastFired) < time.Duration(rule.Cooldown*float64(time.Second)) {
+			continue
+		}
+		if !evaluateCombatCondition(rule.when, state) {
+			continue
+		}
+		actions, ok := r.resolveActions(rule.actions, state)
+		if !ok {
+			continue
+		}
+
+		rule.lastFired = now
+		fired = append(fired, firedCombatRule{Name: rule.Name, Actions: actions})
+		if rule.Stop {
+			break
+		}
+	}
+	return fired
+}
+
+func (r *CombatRules) resolveActions(actions []combatRuleAction, state *combatState) ([]ActionType, bool) {
+	resolved := make([]ActionType, 0, len(actions))
+	usesSkillCycle := false
+	for _, a := range actions {
+		if a.kind == "" {
+			resolved = append(resolved, a.action)
+			continue
+		}
+
+		op := a.op
+		switch a.target {
+		case "next":
+			op = r.skillCycleIndex
+			if state.CharacterCount > 0 {
+				op = ((op - 1) % state.CharacterCount) + 1
+			}
+			usesSkillCycle = true
+		case "selected":
+			op = state.Selected
+		case "ready":
+			if len(state.EndSkillFull) == 0 {
+				return nil, false
+			}
+			op = state.EndSkillFull[0]
+		case "healthy":
+			if len(state.HealthNormal) == 0 {
+				return nil, false
+			}
+			op = state.HealthNormal[0]
+		}
+		if op < 1 || op > 4 || (state.CharacterCount > 0 && op > state.CharacterCount) {
+			return nil, false
+		}
+
+		switch a.kind {
+		case "skill":
+			resolved = append(resolved, skillAction(op))
+		case "end_skill":
+			resolved = append(resolved, endSkillAction(op))
+		case "switch":
+			resolved = append(resolved, switchCharacterAction(op))
+		}
+	}
+	if usesSkillCycle {
+		r.skillCycleIndex++
+	}
+	return resolved, true
+}
+
+// attachJSONSource 取出 attach 中内嵌的 JSON 配置（combat_rules、end_axis_timeline_control）：
+// 既可直接写 JSON 对象，也可写成 JSON 字符串（便于 option 输入覆盖）。
+func attachJSONSource(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	if raw[0] != '"' {
+		return string(raw), nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(s), nil
+}
@@ -0,0 +1,175 @@
+package autofight
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseCombatRulesRejectsInvalidScripts(t *testing.T) {
+	testCases := []struct {
+		name string
+		raw  string
+	}{
+		{name: "invalid json", raw: `{"rules": [`},
+		{name: "no rules", raw: `{"rules": []}`},
+		{name: "missing when", raw: `{"rules": [{"do": ["combo"]}]}`},
+		{name: "missing do", raw: `{"rules": [{"when": "combo_active"}]}`},
+		{name: "unknown variable", raw: `{"rules": [{"when": "mana > 1", "do": ["combo"]}]}`},
+		{name: "unknown function", raw: `{"rules": [{"when": "skill_ready(1)", "do": ["combo"]}]}`},
+		{name: "non boolean when", raw: `{"rules": [{"when": "energy_level + 1", "do": ["combo"]}]}`},
+		{name: "type mismatch", raw: `{"rules": [{"when": "energy_level && combo_active", "do": ["combo"]}]}`},
+		{name: "function argument type", raw: `{"rules": [{"when": "combo_full(enemy_boss)", "do": ["combo"]}]}`},
+		{name: "unknown action", raw: `{"rules": [{"when": "true", "do": ["jump"]}]}`},
+		{name: "operator out of range", raw: `{"rules": [{"when": "true", "do": ["skill:5"]}]}`},
+		{name: "unknown target", raw: `{"rules": [{"when": "true", "do": ["switch:next"]}]}`},
+		{name: "negative cooldown", raw: `{"rules": [{"when": "true", "do": ["combo"], "cooldown": -1}]}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseCombatRules(tc.raw); err == nil {
+				t.Fatalf("ParseCombatRules(%s) expected error", tc.raw)
+			}
+		})
+	}
+}
+
+func TestEvaluateCombatExpression(t *testing.T) {
+	state := &combatState{
+		CharacterCount: 4,
+		Selected:       2,
+		EnergyLevel:    2,
+		ComboFull:      []int{1, 3},
+		EndSkillFull:   []int{4},
+		EnemyBoss:      true,
+	}
+	testCases := []struct {
+		when string
+		want bool
+	}{
+		{when: "energy_level >= 2 && enemy_boss", want: true},
+		{when: "combo_full(3) && !combo_full(2)", want: true},
+		{when: "end_skill_full(selected + 2)", want: true},
+		{when: "combo_full_count == 2 || died_count > 0", want: true},
+		{when: "enemy_accumulating_power == enemy_boss", want: false},
+		{when: "(energy_level - 3) * -1 == 1", want: true},
+	}
+
+	for _, tc := range testCases {
+		rules, err := ParseCombatRules(`{"rules": [{"when": ` + quoteJSON(t, tc.when) + `, "do": ["combo"]}]}`)
+		if err != nil {
+			t.Fatalf("ParseCombatRules(%q) error = %v", tc.when, err)
+		}
+		fired := rules.Decide(state, time.Now())
+		if got := len(fired) == 1; got != tc.want {
+			t.Fatalf("when %q fired = %v, want %v", tc.when, got, tc.want)
+		}
+	}
+}
+
+func TestCombatRulesRuntimeErrorDoesNotFire(t *testing.T) {
+	// 除零只在运行时出现，不应在加载时拒绝规则
+	rules, err := ParseCombatRules(`{"rules": [{"when": "energy_level / character_count > 0", "do": ["combo"]}]}`)
+	if err != nil {
+		t.Fatalf("ParseCombatRules() error = %v", err)
+	}
+	if fired := rules.Decide(&combatState{EnergyLevel: 3}, time.Now()); len(fired) != 0 {
+		t.Fatalf("rule with division by zero fired: %+v", fired)
+	}
+	if fired := rules.Decide(&combatState{EnergyLevel: 3, CharacterCount: 2}, time.Now()); len(fired) != 1 {
+		t.Fatalf("fired = %+v, want 1 rule", fired)
+	}
+}
+
+func TestCombatRulesDecidePriorityStopAndCooldown(t *testing.T) {
+	rules, err := ParseCombatRules(`{
+		"name": "test",
+		"rules": [
+			{"name": "combo", "when": "combo_active", "do": ["combo"]},
+			{"name": "end", "when": "end_skill_full_count > 0", "do": ["end_skill:ready"], "priority": 10, "stop": true},
+			{"name": "skill", "when": "energy_level >= 1", "do": ["switch:2", "skill:selected"], "priority": 5, "cooldown": 2}
+		]
+	}`)
+	if err != nil {
+		t.Fatalf("ParseCombatRules() error = %v", err)
+	}
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	state := &combatState{CharacterCount: 4, Selected: 3, EnergyLevel: 1, ComboActive: true, EndSkillFull: []int{2}}
+	fired := rules.Decide(state, now)
+	if len(fired) != 1 || fired[0].Name != "end" || !slices.Equal(fired[0].Actions, []ActionType{ActionEndSkill2}) {
+		t.Fatalf("stop rule should block lower priorities, got %+v", fired)
+	}
+
+	state.EndSkillFull = nil
+	fired = rules.Decide(state, now)
+	if len(fired) != 2 || fired[0].Name != "skill" || fired[1].Name != "combo" {
+		t.Fatalf("rules should fire in priority order, got %+v", fired)
+	}
+	if !slices.Equal(fired[0].Actions, []ActionType{ActionSwitchCharacter2, ActionSkill3}) {
+		t.Fatalf("unexpected skill actions %v", fired[0].Actions)
+	}
+
+	fired = rules.Decide(state, now.Add(time.Second))
+	if len(fired) != 1 || fired[0].Name != "combo" {
+		t.Fatalf("skill rule should be cooling down, got %+v", fired)
+	}
+	fired = rules.Decide(state, now.Add(2*time.Second))
+	if len(fired) != 2 {
+		t.Fatalf("skill rule should fire after cooldown, got %+v", fired)
+	}
+}
+
+func TestCombatRulesResolveDynamicTargets(t *testing.T) {
+	rules, err := ParseCombatRules(`{"rules": [
+		{"name": "cycle", "when": "energy_level >= 1", "do": ["skill:next"]},
+		{"name": "rescue", "when": "health_dangerous(selected)", "do": ["switch:healthy"]}
+	]}`)
+	if err != nil {
+		t.Fatalf("ParseCombatRules() error = %v", err)
+	}
+	state := &combatState{CharacterCount: 2, Selected: 1, EnergyLevel: 1, HealthDangerous: []int{1}}
+
+	var skills []ActionType
+	for range 3 {
+		fired := rules.Decide(state, time.Now())
+		if len(fired) != 1 || fired[0].Name != "cycle" {
+			t.Fatalf("rescue should not fire without a healthy operator, got %+v", fired)
+		}
+		skills = append(skills, fired[0].Actions...)
+	}
+	if !slices.Equal(skills, []ActionType{ActionSkill1, ActionSkill2, ActionSkill1}) {
+		t.Fatalf("skill:next should cycle through operators, got %v", skills)
+	}
+
+	state.EnergyLevel = 0
+	state.HealthNormal = []int{2}
+	fired := rules.Decide(state, time.Now())
+	if len(fired) != 1 || !slices.Equal(fired[0].Actions, []ActionType{ActionSwitchCharacter2}) {
+		t.Fatalf("switch:healthy should pick the healthy operator, got %+v", fired)
+	}
+}
+
+func TestAttachJSONSource(t *testing.T) {
+	object := json.RawMessage(`{"rules": []}`)
+	if got, err := attachJSONSource(object); err != nil || got != string(object) {
+		t.Fatalf("attachJSONSource(object) = %q, %v", got, err)
+	}
+	if got, err := attachJSONSource(json.RawMessage(`" {\"rules\": []} "`)); err != nil || got != `{"rules": []}` {
+		t.Fatalf("attachJSONSource(string) = %q, %v", got, err)
+	}
+	if got, err := attachJSONSource(json.RawMessage(`""`)); err != nil || got != "" {
+		t.Fatalf("attachJSONSource(empty) = %q, %v", got, err)
+	}
+}
+
+func quoteJSON(t *testing.T, s string) string {
+	t.Helper()
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	return string(b)
+}
@@ -0,0 +1,658 @@
+package autofight
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/i18n"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/maafocus"
+	"github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// fightStepWindow 是每帧取出到期动作时额外放宽的时间窗口：本帧以 +1ms 入队的动作（闪避、锁定、切人等）
+// 在本帧执行，且排在同一帧的连携 / 技能之后。
+const fightStepWindow = time.Millisecond
+
+// fightNotifier 输出战斗中的提示。实战时通过 maafocus 发送给客户端，离线回放时丢弃。
+type fightNotifier interface {
+	Print(content string)
+	PrintThrottle(interval time.Duration, content string)
+}
+
+type focusNotifier struct {
+	ctx *maa.Context
+}
+
+func (n focusNotifier) Print(content string) {
+	maafocus.Print(n.ctx, content)
+}
+
+func (n focusNotifier) PrintThrottle(interval time.Duration, content string) {
+	maafocus.PrintThrottle(n.ctx, interval, content)
+}
+
+type discardNotifier struct{}
+
+func (discardNotifier) Print(string) {}
+
+func (discardNotifier) PrintThrottle(time.Duration, string) {}
+
+// fightDecision 是决策核心处理一帧后的输出。
+type fightDecision struct {
+	// Actions 是本帧到期、按执行顺序排列的动作。
+	Actions []fightAction
+	// Paused 为 true 表示当前不在战斗空间内，本帧没有做决策。
+	Paused bool
+	// Exit 为 true 表示应退出战斗，ExitReason 为退出原因。
+	Exit       bool
+	ExitReason string
+}
+
+// fightDecisionCore 是 AutoFight 的决策核心：按时间顺序输入带时间戳的识别帧，输出动作事件。
+// 实现不得调用 maa.Context，所有计时都以帧时间戳为准，以便用录制的帧离线、确定性地重放。
+type fightDecisionCore interface {
+	Step(frame screenFrame) fightDecision
+}
+
+// fightDecider 是 fightDecisionCore 的默认实现，包含闪避、锁定目标、危险血量切人，
+// 以及时间轴 / 战斗规则 / 内置逻辑三选一的技能决策；时间轴开启回退时，停滞期间改用后两者。
+type fightDecider struct {
+	params   autoFightAttach
+	sa       *ScreenAnalyzer
+	notify   fightNotifier
+	timeline *EndAxisTimeline
+	rules    *CombatRules
+
+	now         time.Time
+	started     bool
+	queue       []fightAction
+	lastDodgeAt time.Time // 最近一次入队闪避/移动动作的时间（含移动动作内置的闪避）
+
+	pauseStart           time.Time
+	noLockStart          time.Time
+	lockTargetStage      lockStage
+	firstNoLockIteration bool
+	characterCount       int
+	skillCycleIndex      int
+
+	timelineIdleSince time.Time     // 时间轴没有运行中的 scenario 的起始时间
+	fallbackSince     time.Time     // 本次时间轴回退的起始时间，未回退时为零值
+	fallbackTotal     time.Duration // 已结束的回退累计时长
+}
+
+var _ fightDecisionCore = &fightDecider{}
+
+// newFightDecider 创建决策核心。timeline 与 rules 可为 nil，两者都为 nil 时使用内置技能逻辑。
+func newFightDecider(params autoFightAttach, sa *ScreenAnalyzer, notify fightNotifier, timeline *EndAxisTimeline, rules *CombatRules) *fightDecider {
+	d := &fightDecider{
+		params:               params,
+		sa:                   sa,
+		notify:               notify,
+		timeline:             timeline,
+		rules:                rules,
+		firstNoLockIteration: true,
+		characterCount:       -1,
+		skillCycleIndex:      1,
+	}
+	if timeline != nil {
+		timeline.clock = func() time.Time { return d.now }
+	}
+	return d
+}
+
+// loadFightStrategies 按 attach 加载 EndAxis 时间轴与战斗规则：时间轴优先，其次战斗规则；
+// 时间轴开启回退时同时加载战斗规则作为回退策略。加载失败时输出提示并回退到内置技能逻辑。
+func loadFightStrategies(params autoFightAttach, notify fightNotifier) (*EndAxisTimeline, *CombatRules) {
+	timeline := loadEndAxisTimeline(params, notify)
+	if timeline != nil && !timeline.fallback {
+		return timeline, nil
+	}
+	return timeline, loadCombatRules(params, notify)
+}
+
+// loadEndAxisTimeline 加载 attach 中的数据码，或按名称从本地排轴库加载，并在战斗开始前校验。
+// 存在错误级问题时不使用该时间轴。
+func loadEndAxisTimeline(params autoFightAttach, notify fightNotifier) *EndAxisTimeline {
+	code := params.EndAxisTimelineCode
+	if code == "" && params.EndAxisTimelineName != "" {
+		entry, ok := lookupEndAxisTimeline(params.EndAxisTimelineName)
+		if !ok {
+			notify.Print(i18n.T("autofight.endaxis.timeline_not_found", params.EndAxisTimelineName))
+			return nil
+		}
+		code = entry.Code
+	}
+	if code == "" {
+		return nil
+	}
+	tl := NewEndAxisTimeline()
+	if !tl.SetTimelineCode(code) {
+		log.Info().Str("component", "AutoFight").Msg("endaxis timeline code invalid, fallback to default skill logic")
+		notify.Print(i18n.T("autofight.endaxis.timeline_invalid_fallback"))
+		return nil
+	}
+	issues := tl.Validate()
+	for _, issue := range issues {
+		log.Warn().Str("component", "AutoFight").Str("issue", issue.String()).Msg("endaxis timeline validation issue")
+	}
+	for _, issue := range issues {
+		if issue.Severity == EndAxisIssueSeverityError {
+			notify.Print(i18n.T("autofight.endaxis.timeline_rejected", issue.Message))
+			return nil
+		}
+	}
+	if len(issues) > 0 {
+		notify.Print(i18n.T("autofight.endaxis.timeline_warnings", len(issues)))
+	}
+	log.Info().Str("component", "AutoFight").Msg("endaxis timeline enabled")
+	notify.Print(i18n.T("autofight.endaxis.timeline_enabled"))
+
+	source, err := attachJSONSource(params.EndAxisTimelineControl)
+	if err == nil && source != "" {
+		var control *EndAxisTimelineControl
+		control, err = ParseEndAxisTimelineControl(source)
+		if err == nil {
+			err = tl.SetTimelineControl(control)
+		}
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("component", "AutoFight").Msg("endaxis timeline control invalid, ignored")
+		notify.Print(i18n.T("autofight.endaxis.control_invalid"))
+	}
+	return tl
+}
+
+// lookupEndAxisTimeline 从本地排轴库中按名称查找排轴。
+func lookupEndAxisTimeline(name string) (EndAxisTimelineEntry, bool) {
+	library, err := OpenEndAxisTimelineLibrary(endAxisTimelineLibraryPath)
+	if err != nil {
+		log.Warn().Err(err).Str("component", "AutoFight").Str("path", endAxisTimelineLibraryPath).Msg("failed to open timeline library")
+		return EndAxisTimelineEntry{}, false
+	}
+	entry, ok := library.Get(name)
+	if !ok {
+		log.Warn().Str("component", "AutoFight").Str("name", name).Msg("timeline not found in library")
+	}
+	return entry, ok
+}
+
+func loadCombatRules(params autoFightAttach, notify fightNotifier) *CombatRules {
+	source, err := attachJSONSource(params.CombatRules)
+	if err != nil || source == "" {
+		if err != nil {
+			log.Warn().Err(err).Str("component", "AutoFight").Msg("combat rules invalid, fallback to default skill logic")
+			notify.Print(i18n.T("autofight.rules_invalid_fallback"))
+		}
+		return nil
+	}
+	rules, err := ParseCombatRules(source)
+	if err != nil {
+		log.Warn().Err(err).Str("component", "AutoFight").Msg("combat rules invalid, fallback to default skill logic")
+		notify.Print(i18n.T("autofight.rules_invalid_fallback"))
+		return nil
+	}
+	log.Info().Str("component", "AutoFight").Str("rules", rules.Name).Msg("combat rules enabled")
+	notify.Print(i18n.T("autofight.rules_enabled", rules.Name))
+	return rules
+}
+
+func (d *fightDecider) enqueue(a fightAction) {
+	d.queue = append(d.queue, a)
+	// 稳定排序，保证同一时刻入队的动作序列按入队顺序执行
+	sort.SliceStable(d.queue, func(i, j int) bool {
+		return d.queue[i].executeAt.Before(d.queue[j].executeAt)
+	})
+	switch a.action {
+	case ActionDodge, ActionMoveBack, ActionMoveForward, ActionMoveLeft, ActionMoveRight:
+		d.lastDodgeAt = d.now
+	}
+}
+
+// drain 取出截至 now（含 fightStepWindow）到期的动作。
+func (d *fightDecider) drain(now time.Time) []fightAction {
+	deadline := now.Add(fightStepWindow)
+	n := 0
+	for n < len(d.queue) && !d.queue[n].executeAt.After(deadline) {
+		n++
+	}
+	due := slices.Clone(d.queue[:n])
+	d.queue = d.queue[n:]
+	return due
+}
+
+// enqueueCombatRuleActions 将触发规则的动作按书写顺序入队，并标记已消耗的技能点 / 终结技标签，
+// 避免下一帧在识别结果更新前重复释放。
+func (d *fightDecider) enqueueCombatRuleActions(fired []firedCombatRule) {
+	for _, rule := range fired {
+		for _, action := range rule.Actions {
+			d.enqueue(fightAction{executeAt: d.now, action: action})
+			switch {
+			case action >= ActionSkill1 && action <= ActionSkill4:
+				d.sa.MarkLabelUsed(LabelEnergyLevelFull)
+			case action >= ActionEndSkill1 && action <= ActionEndSkill4:
+				d.sa.MarkLabelUsed(LabelEndSkillFull)
+			}
+		}
+	}
+}
+
+// Step 处理一帧识别结果。
+func (d *fightDecider) Step(frame screenFrame) fightDecision {
+	now := frame.Timestamp
+	d.now = now
+	d.sa.AddFrame(frame)
+	if !d.started {
+		d.started = true
+		d.lastDodgeAt = now
+	}
+	sa := d.sa
+	params := d.params
+
+	// 暂停判定：检查是否在战斗空间内
+	charSelect := sa.GetCharacterSelect()
+	inFightSpace := charSelect > 0
+	if inFightSpace {
+		d.pauseStart = time.Time{}
+	} else {
+		if d.pauseStart.IsZero() {
+			d.pauseStart = now
+			log.Info().Str("component", "AutoFight").Msg("not in fight space, start pause timer")
+		}
+		if now.Sub(d.pauseStart) >= 10*time.Second {
+			log.Info().Str("component", "AutoFight").Dur("elapsed", now.Sub(d.pauseStart)).Msg("pause timeout, exiting fight")
+			d.notify.Print(i18n.T("autofight.exit_fight"))
+			return fightDecision{Exit: true, ExitReason: "pause_timeout"}
+		}
+		return fightDecision{Paused: true}
+	}
+
+	// 退出判定
+	comboFull := sa.GetCharacterComboFull()
+	// comboEmpty := sa.GetCharacterComboEmpty()
+	if sa.GetCharacterLevel() &&
+		!sa.GetEnemyTarget() &&
+		!sa.GetEnemyFacingLeft() &&
+		!sa.GetEnemyFacingRight() &&
+		!sa.GetEnemyFacingBack() &&
+		len(comboFull) == 0 {
+		log.Info().Str("component", "AutoFight").Msg("exiting fight")
+		d.notify.Print(i18n.T("autofight.exit_fight"))
+		return fightDecision{Exit: true, ExitReason: "character_level"}
+	}
+
+	// CharacterLevel小概率识别不到，comboEmpty大概率不显示了依然命中，双重保险
+	// if len(comboFull) == 0 && len(comboEmpty) == 0 {
+	// 	log.Info().Str("component", "AutoFight").Msg("no combo detected, exiting fight")
+	// 	d.notify.Print(i18n.T("autofight.exit_fight"))
+	// 	return fightDecision{Exit: true, ExitReason: "combo_empty"}
+	// }
+	healthNormal := sa.GetCharacterHealthNormal()
+	healthDangerous := sa.GetCharacterHealthDangerous()
+
+	// 按第一帧
+	if d.characterCount == -1 {
+		d.characterCount = max(len(healthNormal)+len(healthDangerous), len(comboFull))
+		log.Info().
+			Str("component", "AutoFight").
+			Int("characterCount", d.characterCount).
+			Any("healthNormal", healthNormal).
+			Any("comboFull", comboFull).
+			Msg("initial character count detected")
+		d.notify.Print(i18n.T("autofight.character_count", d.characterCount))
+	}
+	characterCount := d.characterCount
+
+	if params.EnableDodge {
+		// 地面攻击必闪；否则按是否启用兼容模式选择普通/兼容闪避检测
+		dodgeCheck := sa.GetEnemyDodge
+		if params.EnableDodgeCompat {
+			dodgeCheck = sa.GetEnemyDodgeCompat
+		}
+		if sa.GetEnemyAttackGroundDodge() || dodgeCheck() {
+			d.enqueue(fightAction{
+				executeAt: now.Add(time.Millisecond),
+				action:    ActionDodge,
+			})
+		}
+	}
+
+	if params.EnableLockTarget {
+		d.stepLockTarget(now)
+	}
+
+	hasEnemyTarget := false
+	if params.EnableLockTarget && sa.GetEnemyLocked() {
+		hasEnemyTarget = true
+	} else if !params.EnableLockTarget {
+		hasEnemyTarget = true
+	}
+
+	if params.EnableHealthDangerousSwitch {
+		if charSelect > 0 && slices.Contains(healthDangerous, charSelect) && len(healthNormal) > 0 {
+			switchTo := healthNormal[0]
+			d.notify.Print(i18n.T("autofight.health_dangerous_switch", charSelect, switchTo))
+			d.enqueue(fightAction{
+				executeAt: now.Add(time.Millisecond),
+				action:    switchCharacterAction(switchTo),
+			})
+		}
+	}
+
+	endSkillFull := sa.GetEndSkillFull(true)
+	energyLevel := sa.GetEnergyLevel(true)
+	var state *combatState
+	if d.timeline != nil || d.rules != nil {
+		state = newCombatState(sa, characterCount, hasEnemyTarget, energyLevel, endSkillFull)
+	}
+	switch {
+	case d.timeline != nil:
+		d.stepTimeline(now, hasEnemyTarget, comboFull, endSkillFull, energyLevel, state)
+	case d.rules != nil:
+		d.stepCombatRules(now, state)
+	default:
+		d.stepDefaultSkills(now, hasEnemyTarget, endSkillFull, energyLevel)
+	}
+
+	return fightDecision{Actions: d.drain(now)}
+}
+
+// stepLockTarget 锁定目标时序状态机（按距上次检测到 EnemyLocked 的累计时长划分）：
+//
+//	首次未锁定的那一帧               -> 直接 continue，过滤瞬时识别抖动
+//	阶段 0 [0, 3s)    -> 宽限期，不特殊处理，正常进入战斗决策
+//	阶段 1 [3s, 6s)   -> 进入时发一次 ActionLockTarget
+//	阶段 2 [6s, 9s)   -> 进入时根据 EnemyFacing 方向升级动作：
+//	                     左/右/后 -> 对应方向移动 + Sleep + Sleep + ActionLockTarget
+//	                     无 facing -> 前进 + ActionLockTarget
+//	阶段 3 [9s, ∞)    -> 重置 noLockStart 重新进入阶段 0（含首帧 continue），循环重试
+//	任意时刻检测到 EnemyLocked     -> 把 noLockStart 推回当前时刻、回到阶段 0，并重置首帧标记
+func (d *fightDecider) stepLockTarget(now time.Time) {
+	sa := d.sa
+	if sa.GetEnemyLocked() {
+		d.noLockStart = now
+		d.lockTargetStage = lockStageLocked
+		d.firstNoLockIteration = false
+		// 5秒内没有闪避/冲刺则向前冲刺一次，防止怪跑远
+		if now.Sub(d.lastDodgeAt) >= 5*time.Second {
+			d.notify.Print(i18n.T("autofight.approach_enemy"))
+			d.enqueue(fightAction{
+				executeAt: now.Add(time.Millisecond),
+				action:    ActionMoveForward,
+			})
+		}
+		return
+	}
+
+	if d.noLockStart.IsZero() {
+		d.noLockStart = now
+		d.lockTargetStage = lockStageLocked
+	}
+	if now.Sub(d.noLockStart) >= 9*time.Second {
+		d.noLockStart = now
+		d.lockTargetStage = lockStageLocked
+	}
+	elapsed := now.Sub(d.noLockStart)
+
+	switch {
+	case elapsed < 3*time.Second:
+		if d.firstNoLockIteration {
+			if d.lockTargetStage < lockStageInitial {
+				d.notify.Print(i18n.T("autofight.start_combat_lock_target"))
+				d.enqueue(fightAction{
+					executeAt: now.Add(time.Millisecond),
+					action:    ActionLockTarget,
+				})
+				d.lockTargetStage = lockStageInitial
+			}
+		}
+	case elapsed < 6*time.Second:
+		if d.lockTargetStage < lockStageRetry {
+			d.notify.Print(i18n.T("autofight.lock_target"))
+			d.enqueue(fightAction{
+				executeAt: now.Add(time.Millisecond),
+				action:    ActionLockTarget,
+			})
+			d.lockTargetStage = lockStageRetry
+		}
+	default:
+		if d.lockTargetStage < lockStageRecover {
+			facingBack := sa.GetEnemyFacingBack()
+			facingLeft := sa.GetEnemyFacingLeft()
+			facingRight := sa.GetEnemyFacingRight()
+			move := ActionMoveForward
+			switch {
+			case facingBack:
+				d.notify.Print(i18n.T("autofight.move_back"))
+				move = ActionMoveBack
+			case facingLeft:
+				d.notify.Print(i18n.T("autofight.move_left"))
+				move = ActionMoveLeft
+			case facingRight:
+				d.notify.Print(i18n.T("autofight.move_right"))
+				move = ActionMoveRight
+			default:
+				d.notify.Print(i18n.T("autofight.move_forward"))
+			}
+			d.enqueue(fightAction{
+				executeAt: now.Add(time.Millisecond),
+				action:    move,
+			})
+			if facingBack || facingLeft || facingRight {
+				d.enqueue(fightAction{
+					executeAt: now.Add(time.Millisecond),
+					action:    ActionSleepSecond,
+				})
+				d.enqueue(fightAction{
+					executeAt: now.Add(time.Millisecond),
+					action:    ActionSleepSecond,
+				})
+			}
+			d.enqueue(fightAction{
+				executeAt: now.Add(time.Millisecond),
+				action:    ActionLockTarget,
+			})
+			d.lockTargetStage = lockStageRecover
+		}
+	}
+}
+
+// stepCombatRules 按战斗规则脚本派发动作。
+func (d *fightDecider) stepCombatRules(now time.Time, state *combatState) {
+	fired := d.rules.Decide(state, now)
+	for _, rule := range fired {
+		log.Debug().Str("component", "AutoFight").Str("rule", rule.Name).Int("actions", len(rule.Actions)).Msg("combat rule fired")
+	}
+	d.enqueueCombatRuleActions(fired)
+}
+
+// stepDefaultSkills 是未配置时间轴与战斗规则时的内置连携 / 终结技 / 技能逻辑。
+func (d *fightDecider) stepDefaultSkills(now time.Time, hasEnemyTarget bool, endSkillFull []int, energyLevel int) {
+	sa := d.sa
+	params := d.params
+	characterCount := d.characterCount
+
+	if params.EnableCombo && sa.GetCharacterComboActive() {
+		d.enqueue(fightAction{
+			executeAt: now,
+			action:    ActionCombo,
+		})
+	}
+
+	if params.EnableEndSkill && hasEnemyTarget {
+		if len(endSkillFull) > 0 {
+			sa.MarkLabelUsed(LabelEndSkillFull)
+			for _, idx := range endSkillFull {
+				if idx >= 5-characterCount {
+					op := idx + characterCount - 4
+					d.enqueue(fightAction{
+						executeAt: now,
+						action:    endSkillAction(op),
+					})
+				}
+				break
+			}
+		}
+	}
+	if params.EnableSkill && energyLevel >= 1 {
+		if params.EnableBreakAccumulatingPower && sa.GetEnemyAccumulatingPower(true) {
+			d.notify.Print(i18n.T("autofight.enemy_accumulating_power"))
+			op := d.skillCycleIndex
+			if characterCount > 0 {
+				op = ((op - 1) % characterCount) + 1
+			}
+			d.enqueue(fightAction{
+				executeAt: now,
+				action:    skillAction(op),
+			})
+			d.skillCycleIndex++
+		} else if energyLevel > params.ReserveSkillLevel && hasEnemyTarget {
+			log.Debug().
+				Str("component", "AutoFight").
+				Int("energyLevel", energyLevel).
+				Int("reserveLevel", params.ReserveSkillLevel).
+				Msg("energy level above reserve, using skill")
+			op := d.skillCycleIndex
+			if characterCount > 0 {
+				op = ((op - 1) % characterCount) + 1
+			}
+			d.enqueue(fightAction{
+				executeAt: now,
+				action:    skillAction(op),
+			})
+			d.skillCycleIndex++
+		}
+		sa.MarkLabelUsed(LabelEnergyLevelFull)
+	}
+}
+
+// stepTimeline 按 EndAxis 时间轴派发终结技 / 技能。
+//
+// 队首动作的前置条件（终结技充能、技能点、锁定目标）不满足时时间轴停滞：停滞超过 max_wait 时跳过该动作；
+// 开启回退时，停滞或没有运行中的 scenario 超过 fallback_after 后，改用战斗规则（未配置时为内置逻辑）决策。
+func (d *fightDecider) stepTimeline(now time.Time, hasEnemyTarget bool, comboFull, endSkillFull []int, energyLevel int, state *combatState) {
+	sa := d.sa
+	timeline := d.timeline
+	characterCount := d.characterCount
+
+	timeline.Advance(state)
+	if hasEnemyTarget && timeline.ActionFinish() {
+		d.notify.PrintThrottle(3*time.Second, i18n.T("autofight.endaxis.retry_timeline"))
+		timeline.SelectScenario(d.notify, characterCount, comboFull, endSkillFull, energyLevel)
+	}
+	comboActive := sa.GetCharacterComboActive() && !timeline.ActionFinish()
+
+	action := timeline.FrontAction()
+	if action != nil {
+		op := action.TrackIdx + 1
+		if op < 1 || op > characterCount {
+			// timeline 设计的 track 在当前队伍里没有对应角色，直接丢弃这个动作
+			log.Warn().
+				Str("component", "AutoFight").
+				Str("step", "timelineDecision").
+				Int("trackIdx", action.TrackIdx).
+				Int("characterCount", characterCount).
+				Msg("timeline action targets non-existent character, skip")
+			timeline.SkipFrontAction()
+			if comboActive {
+				d.enqueue(fightAction{executeAt: now, action: ActionCombo})
+			}
+			return
+		}
+		screenSlot := op + 4 - characterCount
+
+		var next ActionType
+		ready := false
+		switch action.Type {
+		case "ultimate":
+			next, ready = endSkillAction(op), slices.Contains(endSkillFull, screenSlot) && hasEnemyTarget
+		case "skill":
+			next, ready = skillAction(op), energyLevel >= 1 && hasEnemyTarget
+		}
+		if ready {
+			d.endTimelineFallback(now)
+			if comboActive {
+				d.enqueue(fightAction{executeAt: now, action: ActionCombo})
+			}
+			d.enqueue(fightAction{executeAt: now, action: next})
+			if action.Type == "ultimate" {
+				sa.MarkLabelUsed(LabelEndSkillFull)
+			} else {
+				sa.MarkLabelUsed(LabelEnergyLevelFull)
+			}
+			timeline.PopFrontAction()
+			return
+		}
+		if timeline.maxWait > 0 && timeline.Stalled() >= timeline.maxWait {
+			log.Info().
+				Str("component", "AutoFight").
+				Str("step", "timelineDecision").
+				Str("type", action.Type).
+				Int("trackIdx", action.TrackIdx).
+				Int("plannedFrame", action.PlannedFrame).
+				Msg("timeline action preconditions not met in time, skip")
+			d.notify.Print(i18n.T("autofight.endaxis.action_skipped", action.Name))
+			timeline.SkipFrontAction()
+		}
+	}
+
+	if timeline.fallback {
+		stalled := timeline.Stalled()
+		if timeline.Running() {
+			d.timelineIdleSince = time.Time{}
+		} else {
+			if d.timelineIdleSince.IsZero() {
+				d.timelineIdleSince = now
+			}
+			stalled = now.Sub(d.timelineIdleSince)
+		}
+		if stalled >= timeline.fallbackAfter {
+			if d.fallbackSince.IsZero() {
+				d.fallbackSince = now
+				log.Info().Str("component", "AutoFight").Dur("stalled", stalled).Msg("timeline stalled, fallback to rule-based decisions")
+				d.notify.Print(i18n.T("autofight.endaxis.fallback"))
+			}
+			if d.rules != nil {
+				d.stepCombatRules(now, state)
+			} else {
+				d.stepDefaultSkills(now, hasEnemyTarget, endSkillFull, energyLevel)
+			}
+			return
+		}
+		d.endTimelineFallback(now)
+	}
+
+	if when, waiting := timeline.WaitingSync(); waiting {
+		d.notify.PrintThrottle(3*time.Second, i18n.T("autofight.endaxis.waiting_sync", when))
+	}
+	if comboActive {
+		d.enqueue(fightAction{
+			executeAt: now,
+			action:    ActionCombo,
+		})
+	}
+}
+
+// endTimelineFallback 结束当前的时间轴回退并累计回退时长。
+func (d *fightDecider) endTimelineFallback(now time.Time) {
+	if d.fallbackSince.IsZero() {
+		return
+	}
+	d.fallbackTotal += now.Sub(d.fallbackSince)
+	d.fallbackSince = time.Time{}
+}
+
+// timelineReport 返回时间轴漂移报告（含回退时长），未启用时间轴时返回 nil。
+func (d *fightDecider) timelineReport() *EndAxisDriftReport {
+	if d.timeline == nil {
+		return nil
+	}
+	report := d.timeline.DriftReport()
+	fallback := d.fallbackTotal
+	if !d.fallbackSince.IsZero() {
+		fallback += d.now.Sub(d.fallbackSince)
+	}
+	report.FallbackMs = fallback.Milliseconds()
+	return report
+}
@@ -0,0 +1,121 @@
+package autofight
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MaaXYZ/maa-framework-go/v4"
+)
+
+var testFightStart = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+func testDetection(label string, region maa.Rect) screenDetection {
+	return screenDetection{Box: maa.Rect{region[0] + 1, region[1] + 1, 10, 10}, Label: label, Score: 0.9}
+}
+
+// testFightFrame 返回一帧四人队伍在战斗空间内（1 号干员在场）的画面，并附加 extra 中的检测结果。
+func testFightFrame(ms int, extra ...screenDetection) screenFrame {
+	frame := screenFrame{Timestamp: testFightStart.Add(time.Duration(ms) * time.Millisecond)}
+	frame.Detections = append(frame.Detections, testDetection(LabelCharacterSelect, characterRegions[0]))
+	for _, region := range characterRegions {
+		frame.Detections = append(frame.Detections,
+			testDetection(LabelCharacterComboFull, region),
+			testDetection(LabelCharacterHealthNormal, region),
+		)
+	}
+	frame.Detections = append(frame.Detections, extra...)
+	return frame
+}
+
+func actionNames(actions []fightAction) []string {
+	names := make([]string, 0, len(actions))
+	for _, a := range actions {
+		names = append(names, a.action.String())
+	}
+	return names
+}
+
+func TestFightDeciderDodgeAndCombo(t *testing.T) {
+	params := autoFightAttach{EnableDodge: true, EnableCombo: true}
+	d := newFightDecider(params, NewScreenAnalyzer(), discardNotifier{}, nil, nil)
+
+	if got := d.Step(testFightFrame(0)); len(got.Actions) != 0 || got.Exit || got.Paused {
+		t.Fatalf("idle frame decision = %+v", got)
+	}
+	if d.characterCount != 4 {
+		t.Fatalf("characterCount = %d, want 4", d.characterCount)
+	}
+	got := d.Step(testFightFrame(100,
+		testDetection(LabelEnemyDodge, dodgeCompatRegion),
+		testDetection(LabelCharacterComboActive, characterRegions[1]),
+	))
+	// 同一帧内连携先于 +1ms 入队的闪避执行
+	if names := actionNames(got.Actions); !slices.Equal(names, []string{"combo", "dodge"}) {
+		t.Fatalf("actions = %v, want [combo dodge]", names)
+	}
+
+	// 兼容模式忽略画面中央的闪避提示
+	params.EnableDodgeCompat = true
+	d = newFightDecider(params, NewScreenAnalyzer(), discardNotifier{}, nil, nil)
+	got = d.Step(testFightFrame(0, testDetection(LabelEnemyDodge, dodgeCompatRegion)))
+	if len(got.Actions) != 0 {
+		t.Fatalf("compat dodge should ignore centered warning, got %v", actionNames(got.Actions))
+	}
+}
+
+func TestFightDeciderLockTargetStages(t *testing.T) {
+	d := newFightDecider(autoFightAttach{EnableLockTarget: true}, NewScreenAnalyzer(), discardNotifier{}, nil, nil)
+
+	steps := []struct {
+		ms   int
+		want []string
+	}{
+		{ms: 0, want: []string{"lock_target"}},
+		{ms: 1000, want: nil},
+		{ms: 3000, want: []string{"lock_target"}},
+		{ms: 4000, want: nil},
+		{ms: 6000, want: []string{"move_forward", "lock_target"}},
+		{ms: 7000, want: nil},
+	}
+	for _, step := range steps {
+		got := actionNames(d.Step(testFightFrame(step.ms)).Actions)
+		if !slices.Equal(got, step.want) {
+			t.Fatalf("at %dms actions = %v, want %v", step.ms, got, step.want)
+		}
+	}
+}
+
+func TestFightDeciderPauseTimeout(t *testing.T) {
+	d := newFightDecider(autoFightAttach{}, NewScreenAnalyzer(), discardNotifier{}, nil, nil)
+	outside := func(ms int) screenFrame {
+		return screenFrame{Timestamp: testFightStart.Add(time.Duration(ms) * time.Millisecond)}
+	}
+
+	if got := d.Step(outside(0)); !got.Paused || got.Exit {
+		t.Fatalf("first frame outside fight space = %+v", got)
+	}
+	if got := d.Step(outside(9000)); !got.Paused || got.Exit {
+		t.Fatalf("frame before timeout = %+v", got)
+	}
+	if got := d.Step(outside(10000)); !got.Exit || got.ExitReason != "pause_timeout" {
+		t.Fatalf("frame at timeout = %+v", got)
+	}
+}
+
+func TestFightDeciderCombatRules(t *testing.T) {
+	rules, err := ParseCombatRules(`{"rules": [{"when": "energy_level >= 1", "do": ["switch:2", "skill:selected"], "cooldown": 1}]}`)
+	if err != nil {
+		t.Fatalf("ParseCombatRules() error = %v", err)
+	}
+	d := newFightDecider(autoFightAttach{}, NewScreenAnalyzer(), discardNotifier{}, nil, rules)
+	energy := testDetection(LabelEnergyLevelFull, energyRegions[0])
+
+	if got := actionNames(d.Step(testFightFrame(0, energy)).Actions); !slices.Equal(got, []string{"switch:2", "skill:1"}) {
+		t.Fatalf("actions = %v, want [switch:2 skill:1]", got)
+	}
+	// 技能点已被标记使用，冷却结束前后都不会重复释放
+	if got := d.Step(testFightFrame(1500, energy)); len(got.Actions) != 0 {
+		t.Fatalf("used energy should not fire again, got %v", actionNames(got.Actions))
+	}
+}
@@ -0,0 +1,200 @@
+package autofight
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"time"
+)
+
+// defaultTimelineFallbackAfter 是开启回退但未配置 fallback_after 时，时间轴停滞多久后回退到规则决策。
+const defaultTimelineFallbackAfter = 2 * time.Second
+
+// EndAxisTimelineControl 是 Endaxis 数据码之外的时间轴控制配置（attach 字段 end_axis_timeline_control）。
+// Endaxis 只描述一次性的排轴，这里补充循环、同步点、scenario 串联与回退策略。
+type EndAxisTimelineControl struct {
+	// Fallback 为 true 时，时间轴未运行或停滞超过 FallbackAfter 秒，改用战斗规则（未配置时为内置逻辑）决策。
+	Fallback      bool    `json:"fallback,omitempty"`
+	FallbackAfter float64 `json:"fallback_after,omitempty"`
+	// MaxWait 为队首动作条件不满足时最多等待的秒数，超时后跳过该动作；0 表示一直等待。
+	MaxWait float64 `json:"max_wait,omitempty"`
+	// Scenarios 以 scenario 的 id 或名称为键。
+	Scenarios map[string]EndAxisScenarioControl `json:"scenarios,omitempty"`
+}
+
+// EndAxisScenarioControl 是单个 scenario 的控制配置。
+type EndAxisScenarioControl struct {
+	Loop *EndAxisLoop      `json:"loop,omitempty"`
+	Sync []EndAxisSyncSpec `json:"sync,omitempty"`
+	// Next 限定该 scenario 结束后可选的下一个 scenario（id 或名称，按顺序尝试）；为空时不限。
+	Next []string `json:"next,omitempty"`
+}
+
+// EndAxisLoop 表示将 [From, To) 帧区间重复 Count 次（0 表示一直重复到战斗结束）。
+type EndAxisLoop struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Count int `json:"count,omitempty"`
+}
+
+// EndAxisSyncSpec 是同步点：时间轴走到 Frame 时暂停，直到 When 条件成立或等待超过 Timeout 秒。
+// When 的语法与战斗规则脚本的条件相同，例如 "end_skill_full(2)"、"enemy_boss"。
+type EndAxisSyncSpec struct {
+	Frame   int     `json:"frame"`
+	When    string  `json:"when"`
+	Timeout float64 `json:"timeout,omitempty"`
+}
+
+// endAxisScenarioControl 是按 scenario ID 解析、编译后的控制配置。
+type endAxisScenarioControl struct {
+	loop *EndAxisLoop
+	sync []endAxisSync
+	next []string
+}
+
+type endAxisSync struct {
+	EndAxisSyncSpec
+	when ast.Expr
+}
+
+// ParseEndAxisTimelineControl 解析时间轴控制配置 JSON。scenario 的解析与校验在 EndAxisTimeline.SetTimelineControl 中进行。
+func ParseEndAxisTimelineControl(raw string) (*EndAxisTimelineControl, error) {
+	var control EndAxisTimelineControl
+	if err := json.Unmarshal([]byte(raw), &control); err != nil {
+		return nil, fmt.Errorf("parse timeline control: %w", err)
+	}
+	if control.FallbackAfter < 0 || control.MaxWait < 0 {
+		return nil, fmt.Errorf("fallback_after and max_wait must be non-negative")
+	}
+	return &control, nil
+}
+
+// SetTimelineControl 应用时间轴控制配置，需在 SetTimelineCode 成功后调用。
+// 配置中的 scenario 引用必须能在已加载的时间轴中找到，循环区间与同步点必须合法；校验失败时不修改任何状态。
+func (t *EndAxisTimeline) SetTimelineControl(control *EndAxisTimelineControl) error {
+	if t.root == nil {
+		return fmt.Errorf("timeline code is not loaded")
+	}
+	controls := make(map[string]*endAxisScenarioControl, len(control.Scenarios))
+	for key, sc := range control.Scenarios {
+		scenario, err := t.findScenario(key)
+		if err != nil {
+			return err
+		}
+		if _, dup := controls[scenario.ID]; dup {
+			return fmt.Errorf("scenario %q is configured more than once", key)
+		}
+
+		compiled := &endAxisScenarioControl{loop: sc.Loop}
+		if loop := sc.Loop; loop != nil {
+			if loop.From < timelineFrameBase || loop.To <= loop.From || loop.Count < 0 {
+				return fmt.Errorf("scenario %q: invalid loop [%d, %d) x %d", key, loop.From, loop.To, loop.Count)
+			}
+		}
+		for i, spec := range sc.Sync {
+			if spec.Frame < timelineFrameBase || spec.Timeout < 0 {
+				return fmt.Errorf("scenario %q: invalid sync point %d", key, i)
+			}
+			when, err := compileCombatCondition(spec.When)
+			if err != nil {
+				return fmt.Errorf("scenario %q: sync point %d: %w", key, i, err)
+			}
+			compiled.sync = append(compiled.sync, endAxisSync{EndAxisSyncSpec: spec, when: when})
+		}
+		for _, next := range sc.Next {
+			nextScenario, err := t.findScenario(next)
+			if err != nil {
+				return fmt.Errorf("scenario %q: next: %w", key, err)
+			}
+			compiled.next = append(compiled.next, nextScenario.ID)
+		}
+		controls[scenario.ID] = compiled
+	}
+	t.controls = controls
+	t.fallback = control.Fallback
+	t.fallbackAfter = time.Duration(control.FallbackAfter * float64(time.Second))
+	if t.fallback && t.fallbackAfter == 0 {
+		t.fallbackAfter = defaultTimelineFallbackAfter
+	}
+	t.maxWait = time.Duration(control.MaxWait * float64(time.Second))
+	return nil
+}
+
+// findScenario 按 id 或名称查找 scenario，id 优先。
+func (t *EndAxisTimeline) findScenario(key string) (*timelineScenarioRaw, error) {
+	for i := range t.root.ScenarioList {
+		if t.root.ScenarioList[i].ID == key {
+			return &t.root.ScenarioList[i], nil
+		}
+	}
+	var found *timelineScenarioRaw
+	for i := range t.root.ScenarioList {
+		if t.root.ScenarioList[i].Name == key {
+			if found != nil {
+				return nil, fmt.Errorf("scenario name %q is ambiguous", key)
+			}
+			found = &t.root.ScenarioList[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("scenario %q not found", key)
+	}
+	return found, nil
+}
+
+// EndAxisDriftEntry 记录一个时间轴动作的计划帧与实际执行帧。
+// 计划帧计入了循环带来的偏移；实际帧按 scenario 启动后的真实流逝时间换算，包含所有暂停与等待。
+type EndAxisDriftEntry struct {
+	Scenario      string `json:"scenario"`
+	Type          string `json:"type"`
+	TrackIdx      int    `json:"track_idx"`
+	Name          string `json:"name,omitempty"`
+	PlannedFrame  int    `json:"planned_frame"`
+	ExecutedFrame int    `json:"executed_frame"`
+	Skipped       bool   `json:"skipped,omitempty"`
+}
+
+// EndAxisSyncEntry 记录一次同步点等待。
+type EndAxisSyncEntry struct {
+	Scenario string `json:"scenario"`
+	Frame    int    `json:"frame"`
+	When     string `json:"when"`
+	WaitMs   int64  `json:"wait_ms"`
+	TimedOut bool   `json:"timed_out,omitempty"`
+}
+
+// EndAxisDriftReport 汇总整场战斗中时间轴的计划与实际执行情况。
+type EndAxisDriftReport struct {
+	Executed       int                 `json:"executed"`
+	Skipped        int                 `json:"skipped"`
+	Loops          int                 `json:"loops"`
+	AvgDriftFrames float64             `json:"avg_drift_frames"`
+	MaxDriftFrames int                 `json:"max_drift_frames"`
+	FallbackMs     int64               `json:"fallback_ms"`
+	Actions        []EndAxisDriftEntry `json:"actions"`
+	Syncs          []EndAxisSyncEntry  `json:"syncs,omitempty"`
+}
+
+// DriftReport 返回截至目前的时间轴漂移报告。
+func (t *EndAxisTimeline) DriftReport() *EndAxisDriftReport {
+	report := &EndAxisDriftReport{
+		Loops:   t.loopsTotal,
+		Actions: append([]EndAxisDriftEntry{}, t.driftActions...),
+		Syncs:   append([]EndAxisSyncEntry{}, t.driftSyncs...),
+	}
+	total := 0
+	for _, entry := range report.Actions {
+		if entry.Skipped {
+			report.Skipped++
+			continue
+		}
+		report.Executed++
+		drift := entry.ExecutedFrame - entry.PlannedFrame
+		total += drift
+		report.MaxDriftFrames = max(report.MaxDriftFrames, drift)
+	}
+	if report.Executed > 0 {
+		report.AvgDriftFrames = float64(total) / float64(report.Executed)
+	}
+	return report
+}
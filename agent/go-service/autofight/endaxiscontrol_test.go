@@ -0,0 +1,249 @@
+package autofight
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testTimelineCode 按 Endaxis "复制数据码" 的格式编码 scenario 列表。
+func testTimelineCode(t *testing.T, scenarios ...timelineScenarioRaw) string {
+	t.Helper()
+	content, err := json.Marshal(timelineRootRaw{ScenarioList: scenarios})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	code, err := encodeEndAxisShareCode(content)
+	if err != nil {
+		t.Fatalf("encodeEndAxisShareCode() error = %v", err)
+	}
+	return code
+}
+
+// testScenario 返回只有 1 号 track 的 scenario，每个动作持续 30 帧。
+func testScenario(id string, actions ...timelineActionRaw) timelineScenarioRaw {
+	for i := range actions {
+		actions[i].Duration = 30
+	}
+	return timelineScenarioRaw{ID: id, Name: id, Data: timelineDataRaw{Tracks: []timelineTrackRaw{{ID: "op1", Actions: actions}}}}
+}
+
+// newTestTimeline 加载时间轴与控制配置，并以 *now 作为时钟。
+func newTestTimeline(t *testing.T, now *time.Time, control string, scenarios ...timelineScenarioRaw) *EndAxisTimeline {
+	t.Helper()
+	tl := NewEndAxisTimeline()
+	tl.clock = func() time.Time { return *now }
+	if !tl.SetTimelineCode(testTimelineCode(t, scenarios...)) {
+		t.Fatal("SetTimelineCode() failed")
+	}
+	if control != "" {
+		parsed, err := ParseEndAxisTimelineControl(control)
+		if err == nil {
+			err = tl.SetTimelineControl(parsed)
+		}
+		if err != nil {
+			t.Fatalf("SetTimelineControl() error = %v", err)
+		}
+	}
+	return tl
+}
+
+func TestSetTimelineControlRejectsInvalidConfig(t *testing.T) {
+	testCases := []struct {
+		name    string
+		control string
+	}{
+		{name: "unknown scenario", control: `{"scenarios": {"missing": {}}}`},
+		{name: "empty loop", control: `{"scenarios": {"a": {"loop": {"from": 600, "to": 600}}}}`},
+		{name: "loop before base", control: `{"scenarios": {"a": {"loop": {"from": 0, "to": 600}}}}`},
+		{name: "negative loop count", control: `{"scenarios": {"a": {"loop": {"from": 300, "to": 600, "count": -1}}}}`},
+		{name: "invalid sync condition", control: `{"scenarios": {"a": {"sync": [{"frame": 600, "when": "boss_hp > 1"}]}}}`},
+		{name: "missing sync condition", control: `{"scenarios": {"a": {"sync": [{"frame": 600}]}}}`},
+		{name: "unknown next", control: `{"scenarios": {"a": {"next": ["missing"]}}}`},
+		{name: "duplicate scenario", control: `{"scenarios": {"a": {}, "A": {}}}`},
+	}
+
+	now := testFightStart
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scenario := testScenario("a", timelineActionRaw{Type: "skill", StartTime: 300})
+			scenario.Name = "A"
+			tl := newTestTimeline(t, &now, "", scenario)
+			control, err := ParseEndAxisTimelineControl(tc.control)
+			if err != nil {
+				t.Fatalf("ParseEndAxisTimelineControl() error = %v", err)
+			}
+			if err := tl.SetTimelineControl(control); err == nil {
+				t.Fatalf("SetTimelineControl(%s) expected error", tc.control)
+			}
+		})
+	}
+
+	if _, err := ParseEndAxisTimelineControl(`{"max_wait": -1}`); err == nil {
+		t.Fatal("negative max_wait expected error")
+	}
+}
+
+func TestEndAxisTimelineLoopAndDrift(t *testing.T) {
+	now := testFightStart
+	tl := newTestTimeline(t, &now, `{"scenarios": {"a": {"loop": {"from": 300, "to": 420, "count": 2}}}}`,
+		testScenario("a",
+			timelineActionRaw{Type: "skill", StartTime: 300},
+			timelineActionRaw{Type: "battleSkill", StartTime: 360},
+		))
+	if !tl.SelectScenario(discardNotifier{}, 1, []int{1}, nil, 0) {
+		t.Fatal("SelectScenario() failed")
+	}
+
+	// 每一步先 Advance 再取队首动作；pop 为 false 时模拟动作条件暂不满足
+	steps := []struct {
+		ms  int
+		pop bool
+	}{
+		{0, true}, {1000, false}, {1500, true}, {2500, true}, {3500, true}, {4500, true}, {5500, true}, {6500, true},
+	}
+	for _, step := range steps {
+		now = testFightStart.Add(time.Duration(step.ms) * time.Millisecond)
+		tl.Advance(&combatState{})
+		if tl.FrontAction() != nil && step.pop {
+			tl.PopFrontAction()
+		}
+	}
+	if !tl.ActionFinish() {
+		t.Fatal("timeline should finish after the loop has been repeated twice")
+	}
+
+	report := tl.DriftReport()
+	var planned []int
+	for _, a := range report.Actions {
+		planned = append(planned, a.PlannedFrame)
+	}
+	if want := []int{300, 360, 420, 480, 540, 600}; !slices.Equal(planned, want) {
+		t.Fatalf("planned frames = %v, want %v", planned, want)
+	}
+	// 第二个动作等待了 500ms（30 帧），之后的动作都整体后移 30 帧
+	if report.Loops != 2 || report.Executed != 6 || report.MaxDriftFrames != 30 || report.AvgDriftFrames != 25 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestEndAxisTimelineSyncPoint(t *testing.T) {
+	control := `{"scenarios": {"a": {"sync": [{"frame": 360, "when": "end_skill_full(1)", "timeout": 3}]}}}`
+	scenario := testScenario("a",
+		timelineActionRaw{Type: "skill", StartTime: 300},
+		timelineActionRaw{Type: "skill", StartTime: 420},
+	)
+
+	run := func(t *testing.T, readyAt int) *EndAxisDriftReport {
+		now := testFightStart
+		tl := newTestTimeline(t, &now, control, scenario)
+		tl.SelectScenario(discardNotifier{}, 1, []int{1}, nil, 0)
+		for ms := 0; ms <= 6000; ms += 500 {
+			now = testFightStart.Add(time.Duration(ms) * time.Millisecond)
+			state := &combatState{}
+			if readyAt >= 0 && ms >= readyAt {
+				state.EndSkillFull = []int{1}
+			}
+			tl.Advance(state)
+			if _, waiting := tl.WaitingSync(); waiting && tl.FrontAction() != nil {
+				t.Fatalf("at %dms FrontAction should be blocked by the sync point", ms)
+			}
+			if tl.FrontAction() != nil {
+				tl.PopFrontAction()
+			}
+		}
+		return tl.DriftReport()
+	}
+
+	// 1000ms 到达同步点，2500ms 条件成立，逻辑帧共暂停 1.5s（90 帧）
+	report := run(t, 2500)
+	if len(report.Syncs) != 1 || report.Syncs[0].WaitMs != 1500 || report.Syncs[0].TimedOut {
+		t.Fatalf("Syncs = %+v", report.Syncs)
+	}
+	if report.Executed != 2 || report.Actions[1].ExecutedFrame-report.Actions[1].PlannedFrame != 90 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	// 条件始终不成立时，等待 3s 后超时继续
+	report = run(t, -1)
+	if len(report.Syncs) != 1 || report.Syncs[0].WaitMs != 3000 || !report.Syncs[0].TimedOut || report.Executed != 2 {
+		t.Fatalf("unexpected report with timeout: %+v", report)
+	}
+}
+
+func TestEndAxisTimelineNextScenario(t *testing.T) {
+	now := testFightStart
+	tl := newTestTimeline(t, &now, `{"scenarios": {"a": {"next": ["c"]}}}`,
+		testScenario("a", timelineActionRaw{Type: "skill", StartTime: 300}),
+		testScenario("b", timelineActionRaw{Type: "skill", StartTime: 300}),
+		testScenario("c", timelineActionRaw{Type: "skill", StartTime: 300}),
+	)
+
+	var selected []string
+	for i := 0; i < 3; i++ {
+		if !tl.SelectScenario(discardNotifier{}, 1, []int{1}, nil, 0) {
+			t.Fatal("SelectScenario() failed")
+		}
+		selected = append(selected, tl.selectedID)
+		tl.FrontAction()
+		tl.PopFrontAction()
+		now = now.Add(time.Second)
+	}
+	// a 之后只能选 c；c 没有配置 next，随后回到按顺序挑选
+	if want := []string{"a", "c", "a"}; !slices.Equal(selected, want) {
+		t.Fatalf("selected = %v, want %v", selected, want)
+	}
+}
+
+func TestFightDeciderTimelineFallback(t *testing.T) {
+	rules, err := ParseCombatRules(`{"rules": [{"when": "end_skill_full(1)", "do": ["end_skill:1"], "cooldown": 10}]}`)
+	if err != nil {
+		t.Fatalf("ParseCombatRules() error = %v", err)
+	}
+	now := testFightStart
+	tl := newTestTimeline(t, &now, `{"fallback": true, "fallback_after": 1, "max_wait": 3}`,
+		testScenario("a", timelineActionRaw{Type: "skill", StartTime: 300}))
+	d := newFightDecider(autoFightAttach{}, NewScreenAnalyzer(), discardNotifier{}, tl, rules)
+	endSkill := testDetection(LabelEndSkillFull, endSkillRegions[0])
+
+	// 没有技能点，时间轴的战技一直无法释放
+	steps := []struct {
+		ms   int
+		want []string
+	}{
+		{ms: 0, want: nil},
+		{ms: 1000, want: []string{"end_skill:1"}},
+		{ms: 2000, want: nil},
+		{ms: 3000, want: nil},
+	}
+	for _, step := range steps {
+		got := actionNames(d.Step(testFightFrame(step.ms, endSkill)).Actions)
+		if !slices.Equal(got, step.want) {
+			t.Fatalf("at %dms actions = %v, want %v", step.ms, got, step.want)
+		}
+	}
+
+	report := d.timelineReport()
+	if report.Skipped != 1 || report.Executed != 0 || report.FallbackMs != 2000 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestFightDeciderTimelineSkipsMissingTrackKeepsCombo(t *testing.T) {
+	now := testFightStart
+	scenario := testScenario("a")
+	scenario.Data.Tracks = make([]timelineTrackRaw, 5)
+	scenario.Data.Tracks[4] = timelineTrackRaw{ID: "op5", Actions: []timelineActionRaw{{Type: "skill", StartTime: 300, Duration: 30}}}
+	tl := newTestTimeline(t, &now, "", scenario)
+	d := newFightDecider(autoFightAttach{}, NewScreenAnalyzer(), discardNotifier{}, tl, nil)
+
+	// 四人队伍没有 5 号 track 对应的角色，跳过该动作的同一帧仍需释放连携
+	got := actionNames(d.Step(testFightFrame(0, testDetection(LabelCharacterComboActive, characterRegions[1]))).Actions)
+	if !slices.Equal(got, []string{"combo"}) {
+		t.Fatalf("actions = %v, want [combo]", got)
+	}
+	if report := d.timelineReport(); report.Skipped != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,326 @@
+package autofight
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/atomicfile"
+)
+
+const (
+	EndAxisIssueSeverityError   = "error"
+	EndAxisIssueSeverityWarning = "warning"
+
+	// EndAxisIssueNoScenarios 表示时间轴中没有任何 scenario。
+	EndAxisIssueNoScenarios = "no_scenarios"
+	// EndAxisIssueNoActions 表示 scenario 内没有可派发的 ultimate / skill 动作，选择时总会被跳过；
+	// 所有 scenario 都没有时为错误。
+	EndAxisIssueNoActions = "no_actions"
+	// EndAxisIssueTrackOutOfRange 表示 scenario 的 track 超过 4 条，多出的 track 没有对应干员。
+	EndAxisIssueTrackOutOfRange = "track_out_of_range"
+	// EndAxisIssueUnsupportedAction 表示 action 的 type 不会被派发。
+	EndAxisIssueUnsupportedAction = "unsupported_action"
+	// EndAxisIssueMissingUltimate 表示有动作的 track 中没有 ultimate，该干员的终结技在此 scenario 中不会释放。
+	EndAxisIssueMissingUltimate = "missing_ultimate"
+
+	endAxisTimelineLibrarySchemaVersion = 1
+	endAxisMaxTracks                    = 4
+)
+
+// endAxisTimelineLibraryPath 是本地排轴库文件的路径。
+var endAxisTimelineLibraryPath = filepath.Join("debug", "record", "AutoFightTimelines.json")
+
+// EndAxisTimelineIssue 是排轴校验发现的问题。
+type EndAxisTimelineIssue struct {
+	Severity string `json:"severity"`
+	Kind     string `json:"kind"`
+	Scenario string `json:"scenario,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (i EndAxisTimelineIssue) String() string {
+	if i.Scenario == "" {
+		return fmt.Sprintf("%s: %s: %s", i.Severity, i.Kind, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: scenario %q: %s", i.Severity, i.Kind, i.Scenario, i.Message)
+}
+
+// hasEndAxisIssueErrors 返回 issues 中是否有错误级问题。
+func hasEndAxisIssueErrors(issues []EndAxisTimelineIssue) bool {
+	return slices.ContainsFunc(issues, func(i EndAxisTimelineIssue) bool {
+		return i.Severity == EndAxisIssueSeverityError
+	})
+}
+
+// ValidateEndAxisTimelineSource 解析并校验导出的 JSON 或数据码。
+func ValidateEndAxisTimelineSource(source string) ([]EndAxisTimelineIssue, error) {
+	root, _, err := parseEndAxisTimelineSource(source)
+	if err != nil {
+		return nil, err
+	}
+	return validateEndAxisTimeline(root), nil
+}
+
+// validateEndAxisTimeline 检查时间轴在 AutoFight 中能否按预期派发，按 scenario 顺序返回所有问题：
+// 没有 scenario、所有 scenario 都没有可派发动作、track 超过 4 条为错误；
+// 单个 scenario 没有可派发动作、不会派发的 action 类型、有动作但没有 ultimate 的 track 为警告。
+func validateEndAxisTimeline(root *timelineRootRaw) []EndAxisTimelineIssue {
+	issues := make([]EndAxisTimelineIssue, 0)
+	if len(root.ScenarioList) == 0 {
+		return append(issues, EndAxisTimelineIssue{
+			Severity: EndAxisIssueSeverityError,
+			Kind:     EndAxisIssueNoScenarios,
+			Message:  "timeline has no scenario",
+		})
+	}
+
+	dispatchable := false
+	for i := range root.ScenarioList {
+		sc := &root.ScenarioList[i]
+		name := sc.Name
+		if name == "" {
+			name = sc.ID
+		}
+		if len(collectTimelineActions(sc)) > 0 {
+			dispatchable = true
+		} else {
+			issues = append(issues, EndAxisTimelineIssue{
+				Severity: EndAxisIssueSeverityWarning,
+				Kind:     EndAxisIssueNoActions,
+				Scenario: name,
+				Message:  "no ultimate / skill / battleSkill action to dispatch",
+			})
+		}
+		if len(sc.Data.Tracks) > endAxisMaxTracks {
+			issues = append(issues, EndAxisTimelineIssue{
+				Severity: EndAxisIssueSeverityError,
+				Kind:     EndAxisIssueTrackOutOfRange,
+				Scenario: name,
+				Message:  fmt.Sprintf("%d tracks, at most %d operators are supported", len(sc.Data.Tracks), endAxisMaxTracks),
+			})
+		}
+
+		unsupported := map[string]int{}
+		var missingUltimate []int
+		for trackIdx := range sc.Data.Tracks {
+			track := &sc.Data.Tracks[trackIdx]
+			for _, a := range track.Actions {
+				switch a.Type {
+				case "ultimate", "skill", "battleSkill":
+				default:
+					unsupported[a.Type]++
+				}
+			}
+			if trackIdx < endAxisMaxTracks && len(track.Actions) > 0 && !trackHasUltimate(track) {
+				missingUltimate = append(missingUltimate, trackIdx+1)
+			}
+		}
+		types := make([]string, 0, len(unsupported))
+		for t := range unsupported {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		for _, t := range types {
+			issues = append(issues, EndAxisTimelineIssue{
+				Severity: EndAxisIssueSeverityWarning,
+				Kind:     EndAxisIssueUnsupportedAction,
+				Scenario: name,
+				Message:  fmt.Sprintf("%d %q action(s) will not be dispatched", unsupported[t], t),
+			})
+		}
+		if len(missingUltimate) > 0 {
+			issues = append(issues, EndAxisTimelineIssue{
+				Severity: EndAxisIssueSeverityWarning,
+				Kind:     EndAxisIssueMissingUltimate,
+				Scenario: name,
+				Message:  fmt.Sprintf("tracks %v have no ultimate, their end skills are not used in this scenario", missingUltimate),
+			})
+		}
+	}
+	if !dispatchable {
+		issues = append(issues, EndAxisTimelineIssue{
+			Severity: EndAxisIssueSeverityError,
+			Kind:     EndAxisIssueNoActions,
+			Message:  "no scenario has an action to dispatch",
+		})
+	}
+	return issues
+}
+
+// parseEndAxisTimelineSource 解析 Endaxis 时间轴：既可是"导出 JSON"的文件内容，也可是"复制数据码"生成的数据码。
+// 返回解析结果与其 JSON 原文。
+func parseEndAxisTimelineSource(source string) (*timelineRootRaw, []byte, error) {
+	source = strings.TrimSpace(source)
+	plain := []byte(source)
+	if !strings.HasPrefix(source, "{") {
+		var err error
+		if plain, err = decodeEndAxisShareCode(source); err != nil {
+			return nil, nil, err
+		}
+	}
+	var root timelineRootRaw
+	if err := json.Unmarshal(plain, &root); err != nil {
+		return nil, nil, fmt.Errorf("parse timeline json: %w", err)
+	}
+	return &root, plain, nil
+}
+
+// encodeEndAxisShareCode 是 decodeEndAxisShareCode 的逆操作，生成可粘贴到 Endaxis 网站或 attach 中的数据码。
+func encodeEndAxisShareCode(plain []byte) (string, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(plain); err != nil {
+		return "", fmt.Errorf("gzip write: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("gzip close: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeEndAxisTimelineCode 将数据码还原为缩进后的项目 JSON。
+func DecodeEndAxisTimelineCode(code string) ([]byte, error) {
+	plain, err := decodeEndAxisShareCode(code)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, plain, "", "    "); err != nil {
+		return nil, fmt.Errorf("parse timeline json: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// endAxisTimelineSquad 返回时间轴首个 scenario 各 track 的 id，即 Endaxis 中的干员标识。
+func endAxisTimelineSquad(root *timelineRootRaw) []string {
+	if len(root.ScenarioList) == 0 {
+		return nil
+	}
+	var squad []string
+	for _, track := range root.ScenarioList[0].Data.Tracks {
+		if track.ID != "" {
+			squad = append(squad, track.ID)
+		}
+	}
+	return squad
+}
+
+// EndAxisTimelineEntry 是排轴库中的一条排轴。
+type EndAxisTimelineEntry struct {
+	Name string `json:"name"`
+	// Squad 是该排轴适用的干员，默认取首个 scenario 各 track 的 id。
+	Squad      []string  `json:"squad,omitempty"`
+	Code       string    `json:"code"`
+	ImportedAt time.Time `json:"imported_at"`
+}
+
+// EndAxisTimelineLibraryPath 返回本地排轴库文件的路径。
+func EndAxisTimelineLibraryPath() string {
+	return endAxisTimelineLibraryPath
+}
+
+// EndAxisTimelineLibrary 是保存在本地的具名排轴库，供 attach 的 end_axis_timeline_name 与命令行使用。
+type EndAxisTimelineLibrary struct {
+	path    string
+	entries []EndAxisTimelineEntry
+}
+
+type endAxisTimelineLibraryFile struct {
+	SchemaVersion int                    `json:"schema_version"`
+	Timelines     []EndAxisTimelineEntry `json:"timelines"`
+}
+
+// OpenEndAxisTimelineLibrary 读取排轴库，文件不存在时视为空库。
+func OpenEndAxisTimelineLibrary(path string) (*EndAxisTimelineLibrary, error) {
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read timeline library: %w", err)
+	}
+	var file endAxisTimelineLibraryFile
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &file); err != nil {
+			return nil, fmt.Errorf("parse timeline library: %w", err)
+		}
+		if file.SchemaVersion > endAxisTimelineLibrarySchemaVersion {
+			return nil, fmt.Errorf("unsupported timeline library schema version: %d", file.SchemaVersion)
+		}
+	}
+	return &EndAxisTimelineLibrary{path: path, entries: file.Timelines}, nil
+}
+
+// Get 按名称查找排轴。
+func (l *EndAxisTimelineLibrary) Get(name string) (EndAxisTimelineEntry, bool) {
+	for _, e := range l.entries {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return EndAxisTimelineEntry{}, false
+}
+
+// List 按名称顺序返回排轴；squad 非空时只返回适用于该干员的排轴。
+func (l *EndAxisTimelineLibrary) List(squad string) []EndAxisTimelineEntry {
+	out := make([]EndAxisTimelineEntry, 0, len(l.entries))
+	for _, e := range l.entries {
+		if squad == "" || slices.Contains(e.Squad, squad) {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Import 校验并以 name 保存一条排轴，同名排轴会被覆盖。source 为导出的 JSON 或数据码；squad 为空时从 track id 推断。
+// 存在错误级问题时不保存，返回的 issues 包含所有问题。
+func (l *EndAxisTimelineLibrary) Import(name, source string, squad []string, now time.Time) (EndAxisTimelineEntry, []EndAxisTimelineIssue, error) {
+	if strings.TrimSpace(name) == "" {
+		return EndAxisTimelineEntry{}, nil, fmt.Errorf("timeline name is required")
+	}
+	root, plain, err := parseEndAxisTimelineSource(source)
+	if err != nil {
+		return EndAxisTimelineEntry{}, nil, err
+	}
+	issues := validateEndAxisTimeline(root)
+	if hasEndAxisIssueErrors(issues) {
+		return EndAxisTimelineEntry{}, issues, fmt.Errorf("timeline %q has validation errors", name)
+	}
+	code, err := encodeEndAxisShareCode(plain)
+	if err != nil {
+		return EndAxisTimelineEntry{}, issues, err
+	}
+	if len(squad) == 0 {
+		squad = endAxisTimelineSquad(root)
+	}
+
+	entry := EndAxisTimelineEntry{Name: name, Squad: squad, Code: code, ImportedAt: now}
+	l.Remove(name)
+	l.entries = append(l.entries, entry)
+	return entry, issues, nil
+}
+
+// Remove 删除同名排轴，返回是否存在。
+func (l *EndAxisTimelineLibrary) Remove(name string) bool {
+	n := len(l.entries)
+	l.entries = slices.DeleteFunc(l.entries, func(e EndAxisTimelineEntry) bool { return e.Name == name })
+	return len(l.entries) != n
+}
+
+// Save 将排轴库原子写回磁盘。
+func (l *EndAxisTimelineLibrary) Save() error {
+	if err := atomicfile.WriteJSON(l.path, endAxisTimelineLibraryFile{
+		SchemaVersion: endAxisTimelineLibrarySchemaVersion,
+		Timelines:     l.List(""),
+	}); err != nil {
+		return fmt.Errorf("write timeline library: %w", err)
+	}
+	return nil
+}
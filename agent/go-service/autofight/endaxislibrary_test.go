@@ -0,0 +1,156 @@
+package autofight
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestValidateEndAxisTimeline(t *testing.T) {
+	five := testScenario("wide", timelineActionRaw{Type: "ultimate", StartTime: 300})
+	for i := 0; i < 4; i++ {
+		five.Data.Tracks = append(five.Data.Tracks, timelineTrackRaw{ID: "extra"})
+	}
+	root := &timelineRootRaw{ScenarioList: []timelineScenarioRaw{
+		testScenario("opener",
+			timelineActionRaw{Type: "skill", StartTime: 300},
+			timelineActionRaw{Type: "link", StartTime: 320},
+			timelineActionRaw{Type: "attack", StartTime: 340},
+			timelineActionRaw{Type: "link", StartTime: 360},
+		),
+		testScenario("empty", timelineActionRaw{Type: "attack", StartTime: 300}),
+		five,
+	}}
+
+	var got []string
+	for _, issue := range validateEndAxisTimeline(root) {
+		got = append(got, issue.Severity+" "+issue.Kind+" "+issue.Scenario)
+	}
+	want := []string{
+		"warning unsupported_action opener",
+		"warning unsupported_action opener",
+		"warning missing_ultimate opener",
+		"warning no_actions empty",
+		"warning unsupported_action empty",
+		"warning missing_ultimate empty",
+		"error track_out_of_range wide",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("issues = %v, want %v", got, want)
+	}
+
+	onlyEmpty := &timelineRootRaw{ScenarioList: root.ScenarioList[1:2]}
+	if issues := validateEndAxisTimeline(onlyEmpty); !hasEndAxisIssueErrors(issues) || issues[len(issues)-1].Kind != EndAxisIssueNoActions {
+		t.Fatalf("timeline without dispatchable actions should be an error, got %v", issues)
+	}
+	if issues := validateEndAxisTimeline(&timelineRootRaw{}); len(issues) != 1 || issues[0].Kind != EndAxisIssueNoScenarios {
+		t.Fatalf("empty timeline issues = %v", issues)
+	}
+}
+
+func TestParseEndAxisTimelineSourceAcceptsJSONAndShareCode(t *testing.T) {
+	scenario := testScenario("a", timelineActionRaw{Type: "ultimate", StartTime: 300})
+	code := testTimelineCode(t, scenario)
+	plain, err := DecodeEndAxisTimelineCode(code)
+	if err != nil {
+		t.Fatalf("DecodeEndAxisTimelineCode() error = %v", err)
+	}
+
+	for _, source := range []string{code, string(plain), "  " + code + "==\n"} {
+		root, _, err := parseEndAxisTimelineSource(source)
+		if err != nil {
+			t.Fatalf("parseEndAxisTimelineSource() error = %v", err)
+		}
+		if len(root.ScenarioList) != 1 || root.ScenarioList[0].ID != "a" {
+			t.Fatalf("unexpected timeline: %+v", root)
+		}
+	}
+	if _, _, err := parseEndAxisTimelineSource("{not json"); err == nil {
+		t.Fatal("invalid JSON expected error")
+	}
+}
+
+func TestEndAxisTimelineLibrary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "AutoFightTimelines.json")
+	library, err := OpenEndAxisTimelineLibrary(path)
+	if err != nil {
+		t.Fatalf("OpenEndAxisTimelineLibrary() error = %v", err)
+	}
+
+	content, _ := json.Marshal(timelineRootRaw{ScenarioList: []timelineScenarioRaw{
+		testScenario("a", timelineActionRaw{Type: "ultimate", StartTime: 300}),
+	}})
+	entry, issues, err := library.Import("boss", string(content), nil, testFightStart)
+	if err != nil || len(issues) != 0 {
+		t.Fatalf("Import() = %v, %v", issues, err)
+	}
+	if !slices.Equal(entry.Squad, []string{"op1"}) {
+		t.Fatalf("Squad = %v, want squad inferred from track ids", entry.Squad)
+	}
+	// 保存的数据码可直接用于 attach
+	if tl := NewEndAxisTimeline(); !tl.SetTimelineCode(entry.Code) {
+		t.Fatal("exported share code cannot be loaded")
+	}
+
+	if _, _, err := library.Import("broken", `{"scenarioList": []}`, nil, testFightStart); err == nil {
+		t.Fatal("Import() of a timeline with errors should fail")
+	}
+	if _, _, err := library.Import("daily", testTimelineCode(t, testScenario("b", timelineActionRaw{Type: "skill", StartTime: 300})), []string{"op2"}, testFightStart); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if err := library.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reopened, err := OpenEndAxisTimelineLibrary(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	names := func(entries []EndAxisTimelineEntry) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Name)
+		}
+		return out
+	}
+	if got := names(reopened.List("")); !slices.Equal(got, []string{"boss", "daily"}) {
+		t.Fatalf("List() = %v", got)
+	}
+	if got := names(reopened.List("op2")); !slices.Equal(got, []string{"daily"}) {
+		t.Fatalf("List(op2) = %v", got)
+	}
+	if !reopened.Remove("boss") || reopened.Remove("boss") {
+		t.Fatal("Remove() should delete the entry exactly once")
+	}
+	if _, ok := reopened.Get("boss"); ok {
+		t.Fatal("removed entry is still returned")
+	}
+}
+
+func TestLoadEndAxisTimelineFromLibrary(t *testing.T) {
+	oldPath := endAxisTimelineLibraryPath
+	endAxisTimelineLibraryPath = filepath.Join(t.TempDir(), "AutoFightTimelines.json")
+	defer func() { endAxisTimelineLibraryPath = oldPath }()
+
+	library, _ := OpenEndAxisTimelineLibrary(endAxisTimelineLibraryPath)
+	if _, _, err := library.Import("boss", testTimelineCode(t, testScenario("a", timelineActionRaw{Type: "ultimate", StartTime: 300})), nil, testFightStart); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if err := library.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if tl := loadEndAxisTimeline(autoFightAttach{EndAxisTimelineName: "boss"}, discardNotifier{}); tl == nil {
+		t.Fatal("timeline from library was not loaded")
+	}
+	if tl := loadEndAxisTimeline(autoFightAttach{EndAxisTimelineName: "missing"}, discardNotifier{}); tl != nil {
+		t.Fatal("unknown library name should not load a timeline")
+	}
+	// 校验存在错误时不使用时间轴
+	wide := testScenario("a", timelineActionRaw{Type: "ultimate", StartTime: 300})
+	wide.Data.Tracks = append(wide.Data.Tracks, make([]timelineTrackRaw, 4)...)
+	if tl := loadEndAxisTimeline(autoFightAttach{EndAxisTimelineCode: testTimelineCode(t, wide)}, discardNotifier{}); tl != nil {
+		t.Fatal("timeline with validation errors should be rejected")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"io"
 	"slices"
@@ -13,8 +12,6 @@ import (
 	"time"
 
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/i18n"
-	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/maafocus"
-	"github.com/MaaXYZ/maa-framework-go/v4"
 	"github.com/rs/zerolog/log"
 )
 
@@ -34,6 +31,8 @@ type EndAxisAction struct {
 	Duration  int    // 帧
 	Name      string
 	ID        string
+	// PlannedFrame 是计入循环偏移后，该动作在整场时间轴上的计划帧；未循环时等于 StartTime。
+	PlannedFrame int
 }
 
 // 以下是仅用于反序列化 JSON 的精简结构，业务上只关心这几个字段，
@@ -85,12 +84,16 @@ type timelineRootRaw struct {
 //	}
 //
 // 时序：SelectScenario 成功后内部计时即开始；当 FrontAction 返回一个动作时，
-// 计时被自动暂停，直到 PopFrontAction 调用后再恢复。
+// 计时被自动暂停，直到 PopFrontAction / SkipFrontAction 调用后再恢复。
+//
+// 通过 SetTimelineControl 还可为 scenario 配置循环区间、同步点与后继 scenario，
+// 此时需在每帧调用 FrontAction 之前调用 Advance。
 type EndAxisTimeline struct {
-	root        *timelineRootRaw
-	selectedID  string
-	queue       []EndAxisAction
-	energyLevel int
+	root         *timelineRootRaw
+	selectedID   string
+	selectedName string
+	queue        []EndAxisAction
+	energyLevel  int
 
 	started        bool          // SelectScenario 是否成功启动了时间轴
 	paused         bool          // 当前是否处于暂停（等待 PopFrontAction）
@@ -98,41 +101,57 @@ type EndAxisTimeline struct {
 	pausedAt       time.Time     // 当前这一段暂停的起始真实时间，仅在 paused=true 时有意义
 	pausedDuration time.Duration // 截至上次 resume，已累计的暂停总时长
 	endFrame       int           // 当前 scenario 内所有 action 的最晚结束帧 max(startTime + duration)
+
+	clock func() time.Time // 计时使用的时钟，为 nil 时使用 time.Now；回放时替换为识别帧的时间戳
+
+	// 以下由 SetTimelineControl 设置，跨 scenario 保留
+	controls      map[string]*endAxisScenarioControl // 按 scenario ID 索引
+	fallback      bool
+	fallbackAfter time.Duration
+	maxWait       time.Duration
+
+	// 当前 scenario 的控制状态
+	control    *endAxisScenarioControl
+	actions    []EndAxisAction // 当前 scenario 的全部动作，循环时从中重新入队
+	loopShift  int             // 循环回退累计的帧数
+	loopsDone  int
+	syncPassed []bool
+	syncIndex  int // 正在等待的同步点下标，-1 表示没有
+
+	// nextCandidates 是下一次 SelectScenario 允许选择的 scenario ID，为空时不限
+	nextCandidates []string
+
+	// 整场战斗的漂移记录
+	driftActions []EndAxisDriftEntry
+	driftSyncs   []EndAxisSyncEntry
+	loopsTotal   int
 }
 
 // NewEndAxisTimeline 返回一个空的时间轴对象，使用前需先调用 SetTimelineCode。
 func NewEndAxisTimeline() *EndAxisTimeline {
-	return &EndAxisTimeline{}
+	t := &EndAxisTimeline{}
+	t.reset()
+	return t
 }
 
-// SetTimelineCode 解析传入的 Endaxis 数据码（base64url(gzip(JSON))）。
+// SetTimelineCode 解析传入的 Endaxis 数据码（base64url(gzip(JSON))），也接受"导出 JSON"的原文。
 // 成功返回 true，失败返回 false。失败时会清空已有的时间轴数据。
 func (t *EndAxisTimeline) SetTimelineCode(code string) bool {
-	jsonBytes, err := decodeEndAxisShareCode(code)
+	root, _, err := parseEndAxisTimelineSource(code)
 	if err != nil {
 		log.Error().
 			Err(err).
 			Str("component", "EndAxisTimeline").
 			Str("step", "SetTimelineCode").
-			Msg("failed to decode timeline share code")
-		t.root = nil
-		t.reset()
-		return false
-	}
-
-	var root timelineRootRaw
-	if err := json.Unmarshal(jsonBytes, &root); err != nil {
-		log.Error().
-			Err(err).
-			Str("component", "EndAxisTimeline").
-			Str("step", "SetTimelineCode").
-			Msg("failed to parse timeline json")
+			Msg("failed to parse timeline code")
 		t.root = nil
 		t.reset()
 		return false
 	}
 
-	t.root = &root
+	t.root = root
+	t.controls = nil
+	t.nextCandidates = nil
 	t.reset()
 	log.Info().
 		Str("component", "EndAxisTimeline").
@@ -141,6 +160,14 @@ func (t *EndAxisTimeline) SetTimelineCode(code string) bool {
 	return true
 }
 
+// Validate 校验已加载的时间轴，未加载时返回 nil。
+func (t *EndAxisTimeline) Validate() []EndAxisTimelineIssue {
+	if t.root == nil {
+		return nil
+	}
+	return validateEndAxisTimeline(t.root)
+}
+
 // decodeEndAxisShareCode 把 Endaxis "复制数据码" 生成的字符串还原成项目 JSON。
 // 网站侧的生成流程见 src/utils/gzipUtils.js：JSON.stringify → gzip → base64
 // 后再做 '+'→'-'、'/'→'_'、去掉尾随 '=' 三项替换；这里执行其逆操作。
@@ -185,16 +212,18 @@ func decodeEndAxisShareCode(code string) ([]byte, error) {
 //
 // 匹配规则：
 //  1. 1..characterCount 任一角色不在 characterComboFull 中（即有人连携没满），直接返回
-//     false，不进入 scenario 匹配，并通过 maafocus.PrintThrottle（3s）输出"等待连携技冷却完成"的提示；
+//     false，不进入 scenario 匹配，并通过 notify.PrintThrottle（3s）输出"等待连携技冷却完成"的提示；
 //  2. 对每个 scenario，逐个 track i ∈ [0, characterCount) 检查：若该 track 含 type==ultimate
 //     的 action，则对应角色编号 i+1 必须在 endSkillFull 列表中；任一项不满足则跳过该
-//     scenario，并通过 maafocus.PrintThrottle（3s）输出"终结技未充能完毕"的提示；
+//     scenario，并通过 notify.PrintThrottle（3s）输出"终结技未充能完毕"的提示；
 //  3. scenario 内若没有任何 type==ultimate / skill / battleSkill 的 action（即没有可派发的动作），
-//     也跳过该 scenario，并通过 maafocus.PrintThrottle（3s）输出"没有战技或终结技"的提示；
+//     也跳过该 scenario，并通过 notify.PrintThrottle（3s）输出"没有战技或终结技"的提示；
 //  4. 所有 scenario 都不满足时返回 false。
 //
-// 选中 scenario 时通过 maafocus.Print 输出多语言提示；跳过提示限频，ctx 为 nil 时仅记录日志。
-func (t *EndAxisTimeline) SelectScenario(ctx *maa.Context, characterCount int, characterComboFull, endSkillFull []int, energyLevel int) bool {
+// 上一个 scenario 配置了 next 时，只按 next 的顺序在其中挑选。
+//
+// 选中 scenario 时通过 notify.Print 输出多语言提示；跳过提示限频。
+func (t *EndAxisTimeline) SelectScenario(notify fightNotifier, characterCount int, characterComboFull, endSkillFull []int, energyLevel int) bool {
 	t.reset()
 
 	if t.root == nil {
@@ -208,13 +237,12 @@ func (t *EndAxisTimeline) SelectScenario(ctx *maa.Context, characterCount int, c
 				Str("step", "SelectScenario").
 				Int("waitingOperator", op).
 				Msg("combo not ready for all operators")
-			maafocus.PrintThrottle(ctx, 3*time.Second, i18n.T("autofight.endaxis.waiting_combo_cooldown"))
+			notify.PrintThrottle(3*time.Second, i18n.T("autofight.endaxis.waiting_combo_cooldown"))
 			return false
 		}
 	}
 
-	for i := range t.root.ScenarioList {
-		sc := &t.root.ScenarioList[i]
+	for _, sc := range t.candidateScenarios() {
 		if !scenarioMatchesEndSkill(sc, endSkillFull, characterCount) {
 			log.Debug().
 				Str("component", "EndAxisTimeline").
@@ -222,7 +250,7 @@ func (t *EndAxisTimeline) SelectScenario(ctx *maa.Context, characterCount int, c
 				Str("scenarioId", sc.ID).
 				Str("scenarioName", sc.Name).
 				Msg("scenario skipped: ultimate gauge not full")
-			maafocus.PrintThrottle(ctx, 3*time.Second, i18n.T("autofight.endaxis.scenario_skipped_endskill", sc.Name))
+			notify.PrintThrottle(3*time.Second, i18n.T("autofight.endaxis.scenario_skipped_endskill", sc.Name))
 			continue
 		}
 
@@ -234,18 +262,26 @@ func (t *EndAxisTimeline) SelectScenario(ctx *maa.Context, characterCount int, c
 				Str("scenarioId", sc.ID).
 				Str("scenarioName", sc.Name).
 				Msg("scenario skipped: no skill/ultimate actions")
-			maafocus.PrintThrottle(ctx, 3*time.Second, i18n.T("autofight.endaxis.scenario_skipped_no_action", sc.Name))
+			notify.PrintThrottle(3*time.Second, i18n.T("autofight.endaxis.scenario_skipped_no_action", sc.Name))
 			continue
 		}
 
 		t.selectedID = sc.ID
+		t.selectedName = sc.Name
 		t.queue = actions
+		t.actions = slices.Clone(actions)
 		t.energyLevel = energyLevel
 		t.started = true
 		t.paused = false
-		t.startReal = time.Now()
+		t.startReal = t.now()
 		t.pausedDuration = 0
 		t.endFrame = computeTimelineEndFrame(sc)
+		t.control = t.controls[sc.ID]
+		t.nextCandidates = nil
+		if t.control != nil {
+			t.syncPassed = make([]bool, len(t.control.sync))
+			t.nextCandidates = t.control.next
+		}
 
 		log.Info().
 			Str("component", "EndAxisTimeline").
@@ -256,7 +292,7 @@ func (t *EndAxisTimeline) SelectScenario(ctx *maa.Context, characterCount int, c
 			Int("endFrame", t.endFrame).
 			Int("energyLevel", energyLevel).
 			Msg("scenario selected")
-		maafocus.Print(ctx, i18n.T("autofight.endaxis.scenario_selected", sc.Name))
+		notify.Print(i18n.T("autofight.endaxis.scenario_selected", sc.Name))
 		return true
 	}
 
@@ -265,15 +301,145 @@ func (t *EndAxisTimeline) SelectScenario(ctx *maa.Context, characterCount int, c
 		Str("step", "SelectScenario").
 		Int("scenarioCount", len(t.root.ScenarioList)).
 		Msg("no matching scenario")
-	maafocus.PrintThrottle(ctx, 3*time.Second, i18n.T("autofight.endaxis.no_matching_scenario"))
+	notify.PrintThrottle(3*time.Second, i18n.T("autofight.endaxis.no_matching_scenario"))
+	return false
+}
+
+// candidateScenarios 返回本次 SelectScenario 可选的 scenario。
+func (t *EndAxisTimeline) candidateScenarios() []*timelineScenarioRaw {
+	var out []*timelineScenarioRaw
+	if len(t.nextCandidates) > 0 {
+		for _, id := range t.nextCandidates {
+			if sc, err := t.findScenario(id); err == nil {
+				out = append(out, sc)
+			}
+		}
+		return out
+	}
+	for i := range t.root.ScenarioList {
+		out = append(out, &t.root.ScenarioList[i])
+	}
+	return out
+}
+
+// Advance 处理当前 scenario 的同步点与循环区间，需在每帧调用 FrontAction 之前调用。
+// state 用于求值同步点条件；未配置控制时为空操作。
+func (t *EndAxisTimeline) Advance(state *combatState) {
+	if !t.started || t.control == nil {
+		return
+	}
+	if t.advanceSync(state) {
+		return
+	}
+	t.advanceLoop()
+}
+
+// advanceSync 在时间轴走到同步点时暂停计时，直到条件成立或等待超时。返回是否仍在等待。
+// 同步点之前的动作全部派发后才会进入等待，保证同步点不会越过更早的动作。
+func (t *EndAxisTimeline) advanceSync(state *combatState) bool {
+	syncs := t.control.sync
+	if t.syncIndex < 0 {
+		if t.paused {
+			return false
+		}
+		frame := t.currentFrame()
+		for i := range syncs {
+			if t.syncPassed[i] || syncs[i].Frame > frame {
+				continue
+			}
+			if len(t.queue) > 0 && t.queue[0].StartTime < syncs[i].Frame {
+				continue
+			}
+			t.syncIndex = i
+			t.pause()
+			log.Debug().
+				Str("component", "EndAxisTimeline").
+				Str("step", "Advance").
+				Int("frame", syncs[i].Frame).
+				Str("when", syncs[i].When).
+				Msg("waiting at sync point")
+			break
+		}
+		if t.syncIndex < 0 {
+			return false
+		}
+	}
+
+	s := &syncs[t.syncIndex]
+	waited := t.now().Sub(t.pausedAt)
+	met := evaluateCombatCondition(s.when, state)
+	timedOut := !met && s.Timeout > 0 && waited >= time.Duration(s.Timeout*float64(time.Second))
+	if !met && !timedOut {
+		return true
+	}
+
+	t.syncPassed[t.syncIndex] = true
+	t.syncIndex = -1
+	t.resume()
+	t.driftSyncs = append(t.driftSyncs, EndAxisSyncEntry{
+		Scenario: t.selectedName,
+		Frame:    s.Frame,
+		When:     s.When,
+		WaitMs:   waited.Milliseconds(),
+		TimedOut: timedOut,
+	})
+	log.Debug().
+		Str("component", "EndAxisTimeline").
+		Str("step", "Advance").
+		Int("frame", s.Frame).
+		Dur("waited", waited).
+		Bool("timedOut", timedOut).
+		Msg("sync point passed")
 	return false
 }
 
+// advanceLoop 在循环区间 [From, To) 内的动作全部派发、且逻辑帧走到 To 后，把逻辑帧回退到 From，
+// 重新入队区间内的动作并重置区间内的同步点。
+func (t *EndAxisTimeline) advanceLoop() {
+	loop := t.control.loop
+	if loop == nil || t.paused || (loop.Count > 0 && t.loopsDone >= loop.Count) {
+		return
+	}
+	if t.currentFrame() < loop.To || (len(t.queue) > 0 && t.queue[0].StartTime < loop.To) {
+		return
+	}
+
+	span := loop.To - loop.From
+	t.loopShift += span
+	t.loopsDone++
+	t.loopsTotal++
+	for i := range t.queue {
+		t.queue[i].PlannedFrame += span
+	}
+	var replay []EndAxisAction
+	for _, a := range t.actions {
+		if a.StartTime >= loop.From && a.StartTime < loop.To {
+			a.PlannedFrame = a.StartTime + t.loopShift
+			replay = append(replay, a)
+		}
+	}
+	t.queue = append(replay, t.queue...)
+	for i, s := range t.control.sync {
+		if s.Frame >= loop.From && s.Frame < loop.To {
+			t.syncPassed[i] = false
+		}
+	}
+
+	log.Debug().
+		Str("component", "EndAxisTimeline").
+		Str("step", "Advance").
+		Int("from", loop.From).
+		Int("to", loop.To).
+		Int("iteration", t.loopsDone).
+		Int("actionCount", len(replay)).
+		Msg("timeline loop restarted")
+}
+
 // FrontAction 返回当前帧应触发的队首 ultimate/skill 动作。
 // 命中时会自动暂停内部计时，直到 PopFrontAction 调用后再恢复。
-// 未到时间或队列为空时返回 nil, false。
+// 未到时间、队列为空或正在等待同步点时返回 nil。
 func (t *EndAxisTimeline) FrontAction() *EndAxisAction {
-	if !t.started || len(t.queue) == 0 {
+	if !t.started || len(t.queue) == 0 || t.syncIndex >= 0 {
 		return nil
 	}
 	if t.queue[0].StartTime > t.currentFrame() {
@@ -285,8 +451,18 @@ func (t *EndAxisTimeline) FrontAction() *EndAxisAction {
 	return &head
 }
 
-// PopFrontAction 删除当前队首动作并恢复计时。队列为空或时间轴未启动时为空操作。
+// PopFrontAction 删除当前队首动作并恢复计时，同时记录其计划帧与实际执行帧。
+// 队列为空或时间轴未启动时为空操作。
 func (t *EndAxisTimeline) PopFrontAction() {
+	t.popFront(false)
+}
+
+// SkipFrontAction 放弃当前队首动作（前置条件长时间不满足）并恢复计时，在漂移报告中记为跳过。
+func (t *EndAxisTimeline) SkipFrontAction() {
+	t.popFront(true)
+}
+
+func (t *EndAxisTimeline) popFront(skipped bool) {
 	if !t.started || len(t.queue) == 0 {
 		return
 	}
@@ -294,21 +470,55 @@ func (t *EndAxisTimeline) PopFrontAction() {
 	popped := t.queue[0]
 	t.queue = t.queue[1:]
 	t.resume()
+	executed := timelineFrameBase + int(t.now().Sub(t.startReal).Seconds()*timelineFPS)
+	t.driftActions = append(t.driftActions, EndAxisDriftEntry{
+		Scenario:      t.selectedName,
+		Type:          popped.Type,
+		TrackIdx:      popped.TrackIdx,
+		Name:          popped.Name,
+		PlannedFrame:  popped.PlannedFrame,
+		ExecutedFrame: executed,
+		Skipped:       skipped,
+	})
 
 	log.Debug().
 		Str("component", "EndAxisTimeline").
 		Str("step", "PopFrontAction").
 		Str("type", popped.Type).
 		Int("trackIdx", popped.TrackIdx).
-		Int("startTime", popped.StartTime).
+		Int("plannedFrame", popped.PlannedFrame).
+		Int("executedFrame", executed).
+		Bool("skipped", skipped).
 		Int("remaining", len(t.queue)).
 		Msg("action popped")
 }
 
+// WaitingSync 返回是否正在同步点等待，以及等待的条件。
+func (t *EndAxisTimeline) WaitingSync() (string, bool) {
+	if !t.started || t.syncIndex < 0 {
+		return "", false
+	}
+	return t.control.sync[t.syncIndex].When, true
+}
+
+// Running 返回是否有 scenario 正在运行。
+func (t *EndAxisTimeline) Running() bool {
+	return t.started && !t.ActionFinish()
+}
+
+// Stalled 返回时间轴当前停滞（队首动作条件不满足，或在同步点等待）的时长，未停滞时为 0。
+func (t *EndAxisTimeline) Stalled() time.Duration {
+	if !t.started || !t.paused {
+		return 0
+	}
+	return t.now().Sub(t.pausedAt)
+}
+
 // ActionFinish 返回当前 scenario 的时间轴是否已经结束。
-// 同时满足两个条件才视为结束：
+// 同时满足以下条件才视为结束：
 //  1. 派发队列已空（所有 ultimate/skill 都已 Pop）；
-//  2. 当前逻辑帧已经走到该 scenario 内所有 action 的最晚结束帧。
+//  2. 没有正在等待的同步点，循环区间也已重复完指定次数；
+//  3. 当前逻辑帧已经走到该 scenario 内所有 action 的最晚结束帧。
 //
 // 这样可以避免最后一个 ultimate/skill Pop 完后立刻进入下一次 SelectScenario，
 // 留出 scenario 末尾普攻/位移等动作所占用的时间窗口。
@@ -316,15 +526,19 @@ func (t *EndAxisTimeline) ActionFinish() bool {
 	if !t.started {
 		return true
 	}
-	if len(t.queue) > 0 {
+	if len(t.queue) > 0 || t.syncIndex >= 0 {
+		return false
+	}
+	if t.control != nil && t.control.loop != nil && (t.control.loop.Count == 0 || t.loopsDone < t.control.loop.Count) {
 		return false
 	}
 	return t.currentFrame() >= t.endFrame
 }
 
-// reset 清空运行时状态，但不清空 root（已加载的 JSON）。
+// reset 清空当前 scenario 的运行时状态，但不清空 root（已加载的 JSON）、控制配置与漂移记录。
 func (t *EndAxisTimeline) reset() {
 	t.selectedID = ""
+	t.selectedName = ""
 	t.queue = nil
 	t.energyLevel = 0
 	t.started = false
@@ -333,26 +547,40 @@ func (t *EndAxisTimeline) reset() {
 	t.pausedAt = time.Time{}
 	t.pausedDuration = 0
 	t.endFrame = 0
+	t.control = nil
+	t.actions = nil
+	t.loopShift = 0
+	t.loopsDone = 0
+	t.syncPassed = nil
+	t.syncIndex = -1
 }
 
 // currentFrame 返回当前逻辑帧。
-// 逻辑帧 = 基准帧(300) + (真实流逝时间 - 累计暂停时长) 换算成的帧数。
+// 逻辑帧 = 基准帧(300) + (真实流逝时间 - 累计暂停时长) 换算成的帧数 - 循环回退的帧数。
 func (t *EndAxisTimeline) currentFrame() int {
 	if !t.started {
 		return 0
 	}
-	elapsed := time.Since(t.startReal) - t.pausedDuration
+	now := t.now()
+	elapsed := now.Sub(t.startReal) - t.pausedDuration
 	if t.paused {
-		elapsed -= time.Since(t.pausedAt)
+		elapsed -= now.Sub(t.pausedAt)
+	}
+	return timelineFrameBase + int(elapsed.Seconds()*timelineFPS) - t.loopShift
+}
+
+func (t *EndAxisTimeline) now() time.Time {
+	if t.clock == nil {
+		return time.Now()
 	}
-	return timelineFrameBase + int(elapsed.Seconds()*timelineFPS)
+	return t.clock()
 }
 
 func (t *EndAxisTimeline) pause() {
 	if t.paused {
 		return
 	}
-	t.pausedAt = time.Now()
+	t.pausedAt = t.now()
 	t.paused = true
 }
 
@@ -360,7 +588,7 @@ func (t *EndAxisTimeline) resume() {
 	if !t.paused {
 		return
 	}
-	t.pausedDuration += time.Since(t.pausedAt)
+	t.pausedDuration += t.now().Sub(t.pausedAt)
 	t.paused = false
 }
 
@@ -422,12 +650,13 @@ func collectTimelineActions(sc *timelineScenarioRaw) []EndAxisAction {
 				continue
 			}
 			out = append(out, EndAxisAction{
-				Type:      actionType,
-				TrackIdx:  trackIdx,
-				StartTime: a.StartTime,
-				Duration:  a.Duration,
-				Name:      a.Name,
-				ID:        a.ID,
+				Type:         actionType,
+				TrackIdx:     trackIdx,
+				StartTime:    a.StartTime,
+				Duration:     a.Duration,
+				Name:         a.Name,
+				ID:           a.ID,
+				PlannedFrame: a.StartTime,
 			})
 		}
 	}
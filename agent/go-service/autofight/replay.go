@@ -0,0 +1,241 @@
+package autofight
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const fightRecordSchemaVersion = 1
+
+// fightRecordDir 是战斗录制文件的输出目录。
+var fightRecordDir = filepath.Join("debug", "autofight_record")
+
+// fightRecording 是一场战斗的录制：attach 参数、逐帧识别结果，以及录制时决策核心输出的动作。
+// 动作的 offset_ms 是所在帧相对第一帧的时间，与回放输出一致，便于直接比较。
+type fightRecording struct {
+	SchemaVersion int                `json:"schema_version"`
+	StartedAt     time.Time          `json:"started_at"`
+	ExitReason    string             `json:"exit_reason,omitempty"`
+	Attach        autoFightAttach    `json:"attach"`
+	Frames        []screenFrame      `json:"frames"`
+	Actions       []FightActionEvent `json:"actions"`
+}
+
+// fightRecorder 在实战中录制输入决策核心的帧与其输出。所有方法对 nil 接收者安全。
+type fightRecorder struct {
+	rec fightRecording
+}
+
+func newFightRecorder(params autoFightAttach) *fightRecorder {
+	// 按名称引用的排轴在录制时换成数据码，回放时不再依赖本地排轴库
+	if params.EndAxisTimelineCode == "" && params.EndAxisTimelineName != "" {
+		if entry, ok := lookupEndAxisTimeline(params.EndAxisTimelineName); ok {
+			params.EndAxisTimelineCode = entry.Code
+		}
+	}
+	return &fightRecorder{rec: fightRecording{SchemaVersion: fightRecordSchemaVersion, Attach: params}}
+}
+
+// recordStep 记录一帧及决策核心对它的输出。
+func (r *fightRecorder) recordStep(frame screenFrame, decision fightDecision) {
+	if r == nil {
+		return
+	}
+	if len(r.rec.Frames) == 0 {
+		r.rec.StartedAt = frame.Timestamp
+	}
+	frame.Detections = slices.Clone(frame.Detections)
+	r.rec.Frames = append(r.rec.Frames, frame)
+	offset := frame.Timestamp.Sub(r.rec.StartedAt).Milliseconds()
+	for _, a := range decision.Actions {
+		r.rec.Actions = append(r.rec.Actions, FightActionEvent{OffsetMs: offset, Action: a.action.String()})
+	}
+}
+
+// finish 将录制写入 fightRecordDir。
+func (r *fightRecorder) finish(exitReason string) {
+	if r == nil || len(r.rec.Frames) == 0 {
+		return
+	}
+	r.rec.ExitReason = exitReason
+	content, err := json.Marshal(&r.rec)
+	if err == nil {
+		err = os.MkdirAll(fightRecordDir, 0755)
+	}
+	path := filepath.Join(fightRecordDir, fmt.Sprintf("fight_%s.json", r.rec.StartedAt.Format("20060102_150405")))
+	if err == nil {
+		err = os.WriteFile(path, content, 0644)
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("component", "AutoFight").Str("path", path).Msg("failed to write fight recording")
+		return
+	}
+	log.Info().Str("component", "AutoFight").Str("path", path).Int("frames", len(r.rec.Frames)).Msg("saved fight recording to disk")
+}
+
+func loadFightRecording(path string) (*fightRecording, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec fightRecording
+	if err := json.Unmarshal(content, &rec); err != nil {
+		return nil, fmt.Errorf("parse fight recording %s: %w", path, err)
+	}
+	if rec.SchemaVersion != fightRecordSchemaVersion {
+		return nil, fmt.Errorf("unsupported fight recording schema version %d", rec.SchemaVersion)
+	}
+	if len(rec.Frames) == 0 {
+		return nil, fmt.Errorf("fight recording %s has no frames", path)
+	}
+	return &rec, nil
+}
+
+// FightActionShift 是两次运行中匹配上、但所在时间不同的动作。
+type FightActionShift struct {
+	Action     string `json:"action"`
+	BaselineMs int64  `json:"baseline_ms"`
+	CurrentMs  int64  `json:"current_ms"`
+}
+
+// FightActionDiff 是两次运行输出动作序列的差异，按动作名的最长公共子序列对齐。
+type FightActionDiff struct {
+	Matched int                `json:"matched"`
+	Added   []FightActionEvent `json:"added,omitempty"`
+	Removed []FightActionEvent `json:"removed,omitempty"`
+	Shifted []FightActionShift `json:"shifted,omitempty"`
+}
+
+// Identical 返回两次运行的动作及其时间是否完全一致。
+func (d *FightActionDiff) Identical() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Shifted) == 0
+}
+
+// diffFightActions 比较 baseline 与 current 两个动作序列。
+func diffFightActions(baseline, current []FightActionEvent) FightActionDiff {
+	n, m := len(baseline), len(current)
+	// lcs[i*(m+1)+j] 是 baseline[i:] 与 current[j:] 的最长公共子序列长度
+	lcs := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if baseline[i].Action == current[j].Action {
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+			} else {
+				lcs[i*(m+1)+j] = max(lcs[(i+1)*(m+1)+j], lcs[i*(m+1)+j+1])
+			}
+		}
+	}
+
+	var diff FightActionDiff
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case baseline[i].Action == current[j].Action:
+			diff.Matched++
+			if baseline[i].OffsetMs != current[j].OffsetMs {
+				diff.Shifted = append(diff.Shifted, FightActionShift{
+					Action:     current[j].Action,
+					BaselineMs: baseline[i].OffsetMs,
+					CurrentMs:  current[j].OffsetMs,
+				})
+			}
+			i++
+			j++
+		case lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+			diff.Removed = append(diff.Removed, baseline[i])
+			i++
+		default:
+			diff.Added = append(diff.Added, current[j])
+			j++
+		}
+	}
+	diff.Removed = append(diff.Removed, baseline[i:]...)
+	diff.Added = append(diff.Added, current[j:]...)
+	return diff
+}
+
+// FightReplayReport 是一次离线回放的结果。
+type FightReplayReport struct {
+	Frames     int                `json:"frames"`
+	ExitReason string             `json:"exit_reason,omitempty"`
+	Actions    []FightActionEvent `json:"actions"`
+	Diff       FightActionDiff    `json:"diff"`
+	// Timeline 是回放中 EndAxis 时间轴的漂移报告，未启用时间轴时为空。
+	Timeline *EndAxisDriftReport `json:"timeline,omitempty"`
+}
+
+// WriteJSON 以 JSON 格式输出回放结果。
+func (r *FightReplayReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(r)
+}
+
+// replayFightRecording 用 params 创建新的决策核心，依次输入录制的帧并收集输出的动作。
+// 决策核心只依赖帧时间戳，因此同一份录制的回放结果是确定的。
+func replayFightRecording(rec *fightRecording, params autoFightAttach) *FightReplayReport {
+	notify := discardNotifier{}
+	timeline, rules := loadFightStrategies(params, notify)
+	decider := newFightDecider(params, NewScreenAnalyzer(), notify, timeline, rules)
+
+	report := &FightReplayReport{Actions: []FightActionEvent{}}
+	start := rec.Frames[0].Timestamp
+	for _, frame := range rec.Frames {
+		// 决策核心会标记已使用的检测结果，复制一份避免影响录制数据
+		frame.Detections = slices.Clone(frame.Detections)
+		decision := decider.Step(frame)
+		report.Frames++
+		offset := frame.Timestamp.Sub(start).Milliseconds()
+		for _, a := range decision.Actions {
+			report.Actions = append(report.Actions, FightActionEvent{OffsetMs: offset, Action: a.action.String()})
+		}
+		if decision.Exit {
+			report.ExitReason = decision.ExitReason
+			break
+		}
+	}
+	report.Timeline = decider.timelineReport()
+	return report
+}
+
+// RunFightReplay 离线回放录制的战斗，并将输出的动作与基线比较。
+// attachOverride 为 JSON 对象，覆盖录制时的 attach 参数；baselinePath 为空时与录制时输出的动作比较，
+// 否则读取该文件（录制文件或之前的回放结果）的 actions 字段作为基线。
+func RunFightReplay(recordPath, attachOverride, baselinePath string) (*FightReplayReport, error) {
+	rec, err := loadFightRecording(recordPath)
+	if err != nil {
+		return nil, err
+	}
+	params := rec.Attach
+	if attachOverride != "" {
+		if err := json.Unmarshal([]byte(attachOverride), &params); err != nil {
+			return nil, fmt.Errorf("parse attach override: %w", err)
+		}
+	}
+
+	baseline := rec.Actions
+	if baselinePath != "" {
+		content, err := os.ReadFile(baselinePath)
+		if err != nil {
+			return nil, err
+		}
+		var other struct {
+			Actions []FightActionEvent `json:"actions"`
+		}
+		if err := json.Unmarshal(content, &other); err != nil {
+			return nil, fmt.Errorf("parse baseline %s: %w", baselinePath, err)
+		}
+		baseline = other.Actions
+	}
+
+	report := replayFightRecording(rec, params)
+	report.Diff = diffFightActions(baseline, report.Actions)
+	return report, nil
+}
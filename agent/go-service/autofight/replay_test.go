@@ -0,0 +1,134 @@
+package autofight
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffFightActions(t *testing.T) {
+	baseline := []FightActionEvent{{0, "combo"}, {100, "dodge"}, {200, "skill:1"}}
+	current := []FightActionEvent{{0, "combo"}, {250, "skill:1"}, {300, "skill:2"}}
+
+	diff := diffFightActions(baseline, current)
+	if diff.Matched != 2 || diff.Identical() {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Action != "dodge" {
+		t.Fatalf("Removed = %+v", diff.Removed)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Action != "skill:2" {
+		t.Fatalf("Added = %+v", diff.Added)
+	}
+	if len(diff.Shifted) != 1 || diff.Shifted[0] != (FightActionShift{Action: "skill:1", BaselineMs: 200, CurrentMs: 250}) {
+		t.Fatalf("Shifted = %+v", diff.Shifted)
+	}
+
+	if same := diffFightActions(baseline, baseline); !same.Identical() || same.Matched != 3 {
+		t.Fatalf("diff with itself = %+v", same)
+	}
+}
+
+func TestFightRecordingReplayIsDeterministic(t *testing.T) {
+	oldDir := fightRecordDir
+	fightRecordDir = t.TempDir()
+	defer func() { fightRecordDir = oldDir }()
+
+	params := autoFightAttach{EnableDodge: true, EnableCombo: true, EnableLockTarget: true}
+	frames := []screenFrame{
+		testFightFrame(0),
+		testFightFrame(100, testDetection(LabelEnemyDodge, characterRegions[0])),
+		testFightFrame(200, testDetection(LabelEnemyLocked, enemyTargetCenterRegion)),
+		testFightFrame(300, testDetection(LabelCharacterComboActive, characterRegions[2])),
+		testFightFrame(3500),
+		testFightFrame(6500),
+	}
+
+	// 模拟实战：决策核心与录制器同时处理每一帧
+	recorder := newFightRecorder(params)
+	decider := newFightDecider(params, NewScreenAnalyzer(), discardNotifier{}, nil, nil)
+	for _, frame := range frames {
+		recorder.recordStep(frame, decider.Step(frame))
+	}
+	recorder.finish("stopped")
+
+	entries, err := os.ReadDir(fightRecordDir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("ReadDir() = %v, %v", entries, err)
+	}
+	path := filepath.Join(fightRecordDir, entries[0].Name())
+
+	report, err := RunFightReplay(path, "", "")
+	if err != nil {
+		t.Fatalf("RunFightReplay() error = %v", err)
+	}
+	if report.Frames != len(frames) || len(report.Actions) == 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if !report.Diff.Identical() || report.Diff.Matched != len(report.Actions) {
+		t.Fatalf("replay should reproduce the recorded actions, diff = %+v", report.Diff)
+	}
+
+	// 关闭闪避后与录制相比应少一次闪避
+	report, err = RunFightReplay(path, `{"enable_dodge": false}`, "")
+	if err != nil {
+		t.Fatalf("RunFightReplay() with override error = %v", err)
+	}
+	if len(report.Diff.Removed) != 1 || report.Diff.Removed[0].Action != "dodge" || len(report.Diff.Added) != 0 {
+		t.Fatalf("unexpected diff with dodge disabled: %+v", report.Diff)
+	}
+
+	// 以上一次回放结果作为基线
+	output := filepath.Join(t.TempDir(), "replay.json")
+	f, err := os.Create(output)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := report.WriteJSON(f); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	f.Close()
+	report, err = RunFightReplay(path, `{"enable_dodge": false}`, output)
+	if err != nil || !report.Diff.Identical() {
+		t.Fatalf("replay against previous output = %+v, %v", report, err)
+	}
+}
+
+func TestFightRecordingStoresLibraryTimelineCode(t *testing.T) {
+	oldDir, oldPath := fightRecordDir, endAxisTimelineLibraryPath
+	fightRecordDir = t.TempDir()
+	endAxisTimelineLibraryPath = filepath.Join(t.TempDir(), "AutoFightTimelines.json")
+	defer func() { fightRecordDir, endAxisTimelineLibraryPath = oldDir, oldPath }()
+
+	library, _ := OpenEndAxisTimelineLibrary(endAxisTimelineLibraryPath)
+	code := testTimelineCode(t, testScenario("a", timelineActionRaw{Type: "ultimate", StartTime: 300}))
+	if _, _, err := library.Import("boss", code, nil, testFightStart); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if err := library.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	recorder := newFightRecorder(autoFightAttach{EndAxisTimelineName: "boss"})
+	if recorder.rec.Attach.EndAxisTimelineCode != code {
+		t.Fatal("recording attach does not contain the library timeline code")
+	}
+	recorder.recordStep(testFightFrame(0), fightDecision{})
+	recorder.finish("stopped")
+
+	// 回放环境中没有本地排轴库
+	if err := os.Remove(endAxisTimelineLibraryPath); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	entries, err := os.ReadDir(fightRecordDir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("ReadDir() = %v, %v", entries, err)
+	}
+	report, err := RunFightReplay(filepath.Join(fightRecordDir, entries[0].Name()), "", "")
+	if err != nil {
+		t.Fatalf("RunFightReplay() error = %v", err)
+	}
+	if report.Timeline == nil {
+		t.Fatal("replay did not load the recorded timeline")
+	}
+}
@@ -36,24 +36,33 @@ const (
 )
 
 type screenDetection struct {
-	Box    maa.Rect
-	Label  string
-	Score  float64
-	IsUsed bool
+	Box    maa.Rect `json:"box"`
+	Label  string   `json:"label"`
+	Score  float64  `json:"score"`
+	IsUsed bool     `json:"-"`
 }
 
 type screenFrame struct {
-	Timestamp  time.Time
-	Detections []screenDetection
+	Timestamp  time.Time         `json:"timestamp"`
+	Detections []screenDetection `json:"detections,omitempty"`
 }
 
 type ScreenAnalyzer struct {
 	frames []screenFrame
 }
 
+// now returns the timestamp of the latest frame, so that time-based queries are
+// deterministic when frames are replayed. It falls back to the wall clock before the first frame.
+func (sa *ScreenAnalyzer) now() time.Time {
+	if len(sa.frames) == 0 {
+		return time.Now()
+	}
+	return sa.frames[len(sa.frames)-1].Timestamp
+}
+
 // FrameCount prunes expired frames (older than 30s) and returns the current count.
 func (sa *ScreenAnalyzer) FrameCount() int {
-	cutoff := time.Now().Add(-30 * time.Second)
+	cutoff := sa.now().Add(-30 * time.Second)
 	i := 0
 	for i < len(sa.frames) && sa.frames[i].Timestamp.Before(cutoff) {
 		i++
@@ -62,11 +71,35 @@ func (sa *ScreenAnalyzer) FrameCount() int {
 	return len(sa.frames)
 }
 
+// LatestLabels returns the labels detected in the latest frame.
+func (sa *ScreenAnalyzer) LatestLabels() []string {
+	if len(sa.frames) == 0 {
+		return nil
+	}
+	detections := sa.frames[len(sa.frames)-1].Detections
+	labels := make([]string, 0, len(detections))
+	for _, det := range detections {
+		labels = append(labels, det.Label)
+	}
+	return labels
+}
+
 func NewScreenAnalyzer() *ScreenAnalyzer {
 	return &ScreenAnalyzer{}
 }
 
 func (sa *ScreenAnalyzer) UpdateScreenDetail(ctx *maa.Context, arg image.Image) bool {
+	frame, ok := recognizeScreenFrame(ctx, arg)
+	if !ok {
+		return false
+	}
+	sa.AddFrame(frame)
+	return true
+}
+
+// recognizeScreenFrame runs the screen detection model on arg and returns the detections as a frame
+// stamped with the current time, without adding it to any analyzer.
+func recognizeScreenFrame(ctx *maa.Context, arg image.Image) (screenFrame, bool) {
 	detail_reco, err := ctx.RunRecognition("__AutoFightRecognitionScreen", arg)
 	if err != nil || detail_reco == nil {
 		log.Error().
@@ -74,12 +107,11 @@ func (sa *ScreenAnalyzer) UpdateScreenDetail(ctx *maa.Context, arg image.Image)
 			Str("component", "AutoFight").
 			Str("step", "run_recognition_screen").
 			Msg("run recognition failed")
-		return false
+		return screenFrame{}, false
 	}
 
 	if !detail_reco.Hit || detail_reco.Results.All == nil {
-		sa.frames = append(sa.frames, screenFrame{Timestamp: time.Now()})
-		return true
+		return screenFrame{Timestamp: time.Now()}, true
 	}
 
 	// debugLabel 为本地调试用：检测到该 label 时保存当前画面并打印位置，按需修改。
@@ -102,7 +134,6 @@ func (sa *ScreenAnalyzer) UpdateScreenDetail(ctx *maa.Context, arg image.Image)
 			debugBoxes = append(debugBoxes, detail.Box)
 		}
 	}
-	sa.frames = append(sa.frames, frame)
 
 	if len(debugBoxes) > 0 {
 		// saveLabelDebugImage(debugLabel, arg, debugBoxes)
@@ -121,8 +152,15 @@ func (sa *ScreenAnalyzer) UpdateScreenDetail(ctx *maa.Context, arg image.Image)
 	// 	Floats64("scores", scores).
 	// 	Msg("Screen frame updated")
 
+	return frame, true
+}
+
+// AddFrame appends a frame and drops frames older than 30s relative to it.
+func (sa *ScreenAnalyzer) AddFrame(frame screenFrame) {
+	sa.frames = append(sa.frames, frame)
+
 	// 删除时间过久的帧
-	cutoff := time.Now().Add(-30 * time.Second)
+	cutoff := frame.Timestamp.Add(-30 * time.Second)
 	newFrames := make([]screenFrame, 0, len(sa.frames))
 	for _, f := range sa.frames {
 		if f.Timestamp.After(cutoff) {
@@ -130,8 +168,6 @@ func (sa *ScreenAnalyzer) UpdateScreenDetail(ctx *maa.Context, arg image.Image)
 		}
 	}
 	sa.frames = newFrames
-
-	return true
 }
 
 // saveLabelDebugImage 用于调试：检测到指定 label 时把当前画面保存到 debug/autofight_label 目录，
@@ -212,7 +248,7 @@ func (sa *ScreenAnalyzer) MarkLabelUsed(label string) {
 }
 
 func (sa *ScreenAnalyzer) hasLabelInDuration(label string, duration time.Duration, region ...maa.Rect) bool {
-	cutoff := time.Now().Add(-duration)
+	cutoff := sa.now().Add(-duration)
 	hasRegion := len(region) > 0
 
 	for fi := len(sa.frames) - 1; fi >= 0; fi-- {
@@ -0,0 +1,295 @@
+package autofight
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/i18n"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/maafocus"
+	"github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// fightReportDir 是战斗报告 JSON 的输出目录。
+var fightReportDir = filepath.Join("debug", "autofight_report")
+
+// currentFightTelemetry 是当前战斗的记录器，随 fightDecider 一起在 AutoFightMainAction 开始时逐场重置。
+var currentFightTelemetry *fightTelemetry
+
+// fightTelemetryFrame 是时间轴上的一帧识别结果，只保留标签名以控制报告体积。
+type fightTelemetryFrame struct {
+	OffsetMs int64    `json:"offset_ms"`
+	Labels   []string `json:"labels,omitempty"`
+}
+
+// FightActionEvent 是时间轴上的一次动作。
+type FightActionEvent struct {
+	OffsetMs int64  `json:"offset_ms"`
+	Action   string `json:"action"`
+}
+
+// fightSummary 是一场战斗的统计汇总。
+type fightSummary struct {
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	ExitReason string    `json:"exit_reason"`
+	Frames     int       `json:"frames"`
+
+	Actions map[string]int `json:"actions"`
+
+	// DodgeSignals 是敌人攻击预警（含地面攻击）出现的次数，每次预警只计一次。
+	DodgeSignals int `json:"dodge_signals"`
+	// MissedDodges 是预警消失前没有执行闪避的次数。
+	MissedDodges int `json:"missed_dodges"`
+	// DodgeLatencyAvgMs / DodgeLatencyMaxMs 是从预警出现到闪避动作执行的耗时。
+	DodgeLatencyAvgMs int64 `json:"dodge_latency_avg_ms"`
+	DodgeLatencyMaxMs int64 `json:"dodge_latency_max_ms"`
+
+	LockedMs    int64   `json:"locked_ms"`
+	LockedRatio float64 `json:"locked_ratio"`
+
+	// Deaths 是干员倒下的次数，DiedOperators 是战斗中倒下过的干员编号。
+	Deaths        int   `json:"deaths"`
+	DiedOperators []int `json:"died_operators,omitempty"`
+
+	// WastedSkills 是执行时画面上没有可用技能点 / 终结技的技能与终结技动作数。
+	WastedSkills int `json:"wasted_skills"`
+
+	Captures     int   `json:"captures"`
+	CaptureAvgMs int64 `json:"capture_avg_ms"`
+	CaptureMaxMs int64 `json:"capture_max_ms"`
+}
+
+// fightReport 是写入磁盘的完整战斗报告。
+type fightReport struct {
+	Summary fightSummary          `json:"summary"`
+	Frames  []fightTelemetryFrame `json:"frames"`
+	Actions []FightActionEvent    `json:"actions"`
+	// Timeline 是 EndAxis 时间轴的漂移报告，未启用时间轴时为空。
+	Timeline *EndAxisDriftReport `json:"timeline,omitempty"`
+}
+
+// fightTelemetry 记录一场战斗内的识别与动作时间轴，并在退出时汇总。
+// 所有方法对 nil 接收者安全，便于在未开启记录时直接调用。
+type fightTelemetry struct {
+	start   time.Time
+	frames  []fightTelemetryFrame
+	actions []FightActionEvent
+
+	lastFrameAt time.Time
+	locked      time.Duration
+
+	dodgeSignalActive bool
+	dodgePendingAt    time.Time
+	dodgeSignals      int
+	missedDodges      int
+	dodgeLatencies    []time.Duration
+
+	died          map[int]bool
+	diedOperators []int
+	deaths        int
+
+	energyLevel   int
+	endSkillReady bool
+	wastedSkills  int
+}
+
+// fightTelemetryObservation 是从 ScreenAnalyzer 读取的、统计需要的一帧状态。
+type fightTelemetryObservation struct {
+	Labels        []string
+	Locked        bool
+	DodgeSignal   bool
+	Died          []int
+	EnergyLevel   int
+	EndSkillReady bool
+}
+
+// observeScreen 从 ScreenAnalyzer 读取一帧统计状态。技能点与终结技按全部识别结果判断，不受 MarkLabelUsed 影响。
+func observeScreen(sa *ScreenAnalyzer) fightTelemetryObservation {
+	return fightTelemetryObservation{
+		Labels:        sa.LatestLabels(),
+		Locked:        sa.GetEnemyLocked(),
+		DodgeSignal:   sa.GetEnemyAttackGroundDodge() || sa.GetEnemyDodge() || sa.GetEnemyDodgeCompat(),
+		Died:          sa.GetCharacterDied(),
+		EnergyLevel:   sa.GetEnergyLevel(false),
+		EndSkillReady: len(sa.GetEndSkillFull(false)) > 0,
+	}
+}
+
+func newFightTelemetry(start time.Time) *fightTelemetry {
+	return &fightTelemetry{start: start, died: map[int]bool{}}
+}
+
+func (t *fightTelemetry) offset(at time.Time) int64 {
+	return at.Sub(t.start).Milliseconds()
+}
+
+// recordFrame 记录一帧识别结果并更新锁定时长、闪避预警与干员倒下统计。
+func (t *fightTelemetry) recordFrame(obs fightTelemetryObservation, now time.Time) {
+	if t == nil {
+		return
+	}
+	t.frames = append(t.frames, fightTelemetryFrame{OffsetMs: t.offset(now), Labels: obs.Labels})
+
+	if obs.Locked && !t.lastFrameAt.IsZero() {
+		t.locked += now.Sub(t.lastFrameAt)
+	}
+	t.lastFrameAt = now
+
+	switch {
+	case obs.DodgeSignal && !t.dodgeSignalActive:
+		t.dodgeSignals++
+		t.dodgePendingAt = now
+	case !obs.DodgeSignal && t.dodgeSignalActive:
+		if !t.dodgePendingAt.IsZero() {
+			t.missedDodges++
+			t.dodgePendingAt = time.Time{}
+		}
+	}
+	t.dodgeSignalActive = obs.DodgeSignal
+
+	current := make(map[int]bool, len(obs.Died))
+	for _, op := range obs.Died {
+		current[op] = true
+		if !t.died[op] {
+			t.deaths++
+			if !slices.Contains(t.diedOperators, op) {
+				t.diedOperators = append(t.diedOperators, op)
+			}
+		}
+	}
+	t.died = current
+
+	t.energyLevel = obs.EnergyLevel
+	t.endSkillReady = obs.EndSkillReady
+}
+
+// recordAction 记录一次实际执行的动作。
+func (t *fightTelemetry) recordAction(action ActionType, now time.Time) {
+	if t == nil {
+		return
+	}
+	t.actions = append(t.actions, FightActionEvent{OffsetMs: t.offset(now), Action: action.String()})
+
+	switch {
+	case action == ActionDodge:
+		if !t.dodgePendingAt.IsZero() {
+			t.dodgeLatencies = append(t.dodgeLatencies, now.Sub(t.dodgePendingAt))
+			t.dodgePendingAt = time.Time{}
+		}
+	case action >= ActionSkill1 && action <= ActionSkill4:
+		if t.energyLevel < 1 {
+			t.wastedSkills++
+		}
+	case action >= ActionEndSkill1 && action <= ActionEndSkill4:
+		if !t.endSkillReady {
+			t.wastedSkills++
+		}
+	}
+}
+
+// report 汇总整场战斗。capture 为本场战斗的截图识别耗时统计。
+func (t *fightTelemetry) report(now time.Time, exitReason string, capture captureStats) *fightReport {
+	summary := fightSummary{
+		StartedAt:     t.start,
+		DurationMs:    t.offset(now),
+		ExitReason:    exitReason,
+		Frames:        len(t.frames),
+		Actions:       map[string]int{},
+		DodgeSignals:  t.dodgeSignals,
+		MissedDodges:  t.missedDodges,
+		LockedMs:      t.locked.Milliseconds(),
+		Deaths:        t.deaths,
+		DiedOperators: t.diedOperators,
+		WastedSkills:  t.wastedSkills,
+		Captures:      capture.Count,
+		CaptureAvgMs:  capture.Avg.Milliseconds(),
+		CaptureMaxMs:  capture.Max.Milliseconds(),
+	}
+	for _, a := range t.actions {
+		summary.Actions[a.Action]++
+	}
+	if len(t.dodgeLatencies) > 0 {
+		var total time.Duration
+		for _, d := range t.dodgeLatencies {
+			total += d
+			summary.DodgeLatencyMaxMs = max(summary.DodgeLatencyMaxMs, d.Milliseconds())
+		}
+		summary.DodgeLatencyAvgMs = (total / time.Duration(len(t.dodgeLatencies))).Milliseconds()
+	}
+	if summary.DurationMs > 0 {
+		summary.LockedRatio = float64(summary.LockedMs) / float64(summary.DurationMs)
+	}
+	return &fightReport{Summary: summary, Frames: t.frames, Actions: t.actions}
+}
+
+// writeFightReport 将战斗报告写入 dir，返回文件路径。
+func writeFightReport(dir string, report *fightReport) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	content, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("fight_%s.json", report.Summary.StartedAt.Format("20060102_150405"))
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// finishFightTelemetry 汇总当前战斗，写入 JSON 报告并通过 maafocus 输出 HTML 摘要。
+// timeline 为时间轴漂移报告，未启用时间轴时为 nil。
+func finishFightTelemetry(ctx *maa.Context, exitReason string, timeline *EndAxisDriftReport) {
+	t := currentFightTelemetry
+	currentFightTelemetry = nil
+	if t == nil {
+		return
+	}
+
+	report := t.report(time.Now(), exitReason, capturePerf.fightStats())
+	report.Timeline = timeline
+	summary := report.Summary
+	path, err := writeFightReport(fightReportDir, report)
+	if err != nil {
+		log.Warn().Err(err).Str("component", "AutoFight").Str("dir", fightReportDir).Msg("failed to write fight report")
+	} else {
+		log.Info().Str("component", "AutoFight").Str("path", path).Str("exitReason", exitReason).Msg("saved fight report to disk")
+	}
+
+	type actionCount struct {
+		Action string
+		Count  int
+	}
+	actions := make([]actionCount, 0, len(summary.Actions))
+	for action, count := range summary.Actions {
+		actions = append(actions, actionCount{Action: action, Count: count})
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].Count != actions[j].Count {
+			return actions[i].Count > actions[j].Count
+		}
+		return actions[i].Action < actions[j].Action
+	})
+
+	maafocus.Print(ctx, i18n.RenderHTML("autofight.fight_report", map[string]any{
+		"Duration":          float64(summary.DurationMs) / 1000,
+		"Actions":           actions,
+		"DodgeSignals":      summary.DodgeSignals,
+		"MissedDodges":      summary.MissedDodges,
+		"DodgeLatencyAvgMs": summary.DodgeLatencyAvgMs,
+		"LockedPercent":     summary.LockedRatio * 100,
+		"Deaths":            summary.Deaths,
+		"WastedSkills":      summary.WastedSkills,
+		"CaptureAvgMs":      summary.CaptureAvgMs,
+		"CaptureMaxMs":      summary.CaptureMaxMs,
+		"Path":              path,
+	}))
+}
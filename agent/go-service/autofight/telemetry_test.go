@@ -0,0 +1,94 @@
+package autofight
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFightTelemetryReport(t *testing.T) {
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	tel := newFightTelemetry(start)
+
+	tel.recordFrame(fightTelemetryObservation{Labels: []string{LabelEnemyLocked}, Locked: true, EnergyLevel: 1}, at(0))
+	tel.recordFrame(fightTelemetryObservation{Locked: true, DodgeSignal: true, EnergyLevel: 1}, at(100))
+	tel.recordAction(ActionDodge, at(160))
+	tel.recordAction(ActionSkill2, at(170))
+	// 同一次预警持续多帧只计一次
+	tel.recordFrame(fightTelemetryObservation{DodgeSignal: true, Died: []int{3}}, at(200))
+	tel.recordAction(ActionSkill1, at(210))
+	tel.recordAction(ActionEndSkill4, at(220))
+	tel.recordFrame(fightTelemetryObservation{Died: []int{3}}, at(300))
+	tel.recordFrame(fightTelemetryObservation{DodgeSignal: true}, at(400))
+	tel.recordFrame(fightTelemetryObservation{Died: []int{3}}, at(500))
+
+	report := tel.report(at(1000), "character_level", captureStats{Count: 6, Avg: 40 * time.Millisecond, Max: 90 * time.Millisecond})
+	s := report.Summary
+	if s.DurationMs != 1000 || s.Frames != 6 || len(report.Actions) != 4 || s.ExitReason != "character_level" {
+		t.Fatalf("unexpected timeline summary: %+v", s)
+	}
+	if s.DodgeSignals != 2 || s.MissedDodges != 1 || s.DodgeLatencyAvgMs != 60 || s.DodgeLatencyMaxMs != 60 {
+		t.Fatalf("unexpected dodge summary: %+v", s)
+	}
+	if s.LockedMs != 100 || s.LockedRatio != 0.1 {
+		t.Fatalf("unexpected locked summary: %+v", s)
+	}
+	if s.Deaths != 2 || !slices.Equal(s.DiedOperators, []int{3}) {
+		t.Fatalf("unexpected death summary: %+v", s)
+	}
+	if s.WastedSkills != 2 {
+		t.Fatalf("WastedSkills = %d, want 2", s.WastedSkills)
+	}
+	if s.Actions["dodge"] != 1 || s.Actions["skill:1"] != 1 || s.Actions["skill:2"] != 1 || s.Actions["end_skill:4"] != 1 {
+		t.Fatalf("unexpected action counts: %v", s.Actions)
+	}
+	if s.Captures != 6 || s.CaptureAvgMs != 40 || s.CaptureMaxMs != 90 {
+		t.Fatalf("unexpected capture summary: %+v", s)
+	}
+
+	path, err := writeFightReport(filepath.Join(t.TempDir(), "report"), report)
+	if err != nil {
+		t.Fatalf("writeFightReport() error = %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var decoded fightReport
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.Summary.Deaths != 2 || len(decoded.Frames) != 6 || decoded.Frames[0].Labels[0] != LabelEnemyLocked {
+		t.Fatalf("unexpected decoded report: %+v", decoded.Summary)
+	}
+}
+
+func TestFightTelemetryNilSafe(t *testing.T) {
+	var tel *fightTelemetry
+	tel.recordFrame(fightTelemetryObservation{Locked: true}, time.Now())
+	tel.recordAction(ActionDodge, time.Now())
+}
+
+func TestActionTypeString(t *testing.T) {
+	testCases := map[ActionType]string{
+		ActionCombo:            "combo",
+		ActionSkill3:           "skill:3",
+		ActionEndSkill1:        "end_skill:1",
+		ActionSwitchCharacter4: "switch:4",
+		ActionSleepSecond:      "sleep",
+		ActionMoveLeft:         "move_left",
+	}
+	for action, want := range testCases {
+		if got := action.String(); got != want {
+			t.Fatalf("ActionType(%d).String() = %q, want %q", int(action), got, want)
+		}
+		// 名称与战斗规则脚本中的动作一致
+		if _, err := parseCombatRuleAction(want); err != nil {
+			t.Fatalf("parseCombatRuleAction(%q) error = %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/autofight"
+	"github.com/rs/zerolog/log"
+)
+
+// runAutoFightReplay 处理 `--autofight-replay [-attach <json>] [-baseline <file>] [-o <file>] [-check] <record_file>` 入口。
+// 它离线重放 AutoFight 录制的识别帧，重新运行决策核心并输出动作序列，同时与基线（默认为录制时的动作）比较。
+// 不连接 MaaFramework，可在无头 Linux 环境运行。
+func runAutoFightReplay(args []string) {
+	fs := flag.NewFlagSet("autofight-replay", flag.ExitOnError)
+	attach := fs.String("attach", "", "AutoFight attach override in JSON")
+	baseline := fs.String("baseline", "", "file whose actions are compared against (default: actions in the recording)")
+	output := fs.String("o", "", "output file (default stdout)")
+	check := fs.Bool("check", false, "exit with status 1 if actions differ from the baseline")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		log.Fatal().
+			Msg("Usage: go-service --autofight-replay [-attach <json>] [-baseline <file>] [-o <file>] [-check] <record_file>")
+	}
+	path := fs.Arg(0)
+
+	log.Info().
+		Str("record", path).
+		Str("attach", *attach).
+		Str("baseline", *baseline).
+		Msg("AutoFight replay invoked")
+
+	report, err := autofight.RunFightReplay(path, *attach, *baseline)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("AutoFight replay failed")
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatal().
+				Err(err).
+				Str("output", *output).
+				Msg("Failed to create replay output")
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := report.WriteJSON(w); err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Failed to write replay output")
+	}
+
+	log.Info().
+		Int("frames", report.Frames).
+		Int("actions", len(report.Actions)).
+		Int("matched", report.Diff.Matched).
+		Int("added", len(report.Diff.Added)).
+		Int("removed", len(report.Diff.Removed)).
+		Int("shifted", len(report.Diff.Shifted)).
+		Msg("AutoFight replay finished")
+
+	if *check && !report.Diff.Identical() {
+		os.Exit(1)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"sort"
 	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/serverday"
 )
 
 const (
@@ -31,7 +33,7 @@ type dailyStorageRecord struct {
 }
 
 func serverDateInfo(now time.Time, loc *time.Location) (string, int) {
-	serverTime := adjustedServerTime(now, loc)
+	serverTime := serverday.AdjustedTime(now, loc)
 	return serverTime.Format(time.DateOnly), maaWeekday(serverTime.Weekday())
 }
 
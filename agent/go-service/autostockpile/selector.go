@@ -8,6 +8,7 @@ import (
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/captureuid"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/i18n"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/maafocus"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/serverday"
 	maa "github.com/MaaXYZ/maa-framework-go/v4"
 	"github.com/rs/zerolog/log"
 )
@@ -91,7 +92,7 @@ func (a *SelectItemAction) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool
 	}
 	serverTimeOffset := attach.ServerTime
 	applyWeekdayAdjustment := serverTimeOffset != nil
-	serverLocation := locationFromUTCOffset(serverTimeOffset)
+	serverLocation := serverday.LocationFromUTCOffset(serverTimeOffset)
 
 	log.Info().
 		Str("component", "autostockpile").
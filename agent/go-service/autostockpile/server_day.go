@@ -1,39 +1,11 @@
 package autostockpile
 
 import (
-	"fmt"
 	"time"
-)
 
-const (
-	serverDayBoundaryHour  = 4
-	defaultServerUTCOffset = 8 * 60 * 60
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/serverday"
 )
 
-var defaultServerLocation = time.FixedZone("UTC+8", defaultServerUTCOffset)
-
-func locationFromUTCOffset(offset *int) *time.Location {
-	if offset == nil {
-		return defaultServerLocation
-	}
-
-	name := fmt.Sprintf("UTC%+d", *offset)
-	return time.FixedZone(name, *offset*60*60)
-}
-
 func resolveServerWeekday(now time.Time, loc *time.Location) time.Weekday {
-	return adjustedServerTime(now, loc).Weekday()
-}
-
-func adjustedServerTime(now time.Time, loc *time.Location) time.Time {
-	if loc == nil {
-		loc = defaultServerLocation
-	}
-
-	serverTime := now.In(loc)
-	if serverTime.Hour() < serverDayBoundaryHour {
-		serverTime = serverTime.AddDate(0, 0, -1)
-	}
-
-	return serverTime
+	return serverday.AdjustedTime(now, loc).Weekday()
 }
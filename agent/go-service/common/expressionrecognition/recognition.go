@@ -2,16 +2,13 @@ package expressionrecognition
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
 	"math"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/expression"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/i18n"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/maafocus"
 	maa "github.com/MaaXYZ/maa-framework-go/v4"
@@ -51,8 +48,8 @@ var (
 	ocrNumericPattern     = regexp.MustCompile(`(?i)[+-]?(?:\d+(?:[.,]\d+)?|[.,]\d+)\s*(?:[a-z]+|万|亿)?`)
 	asciiLetterPattern    = regexp.MustCompile(`[A-Za-z]+$`)
 
-	expressionIntMax = int(^uint(0) >> 1)
-	expressionIntMin = -expressionIntMax - 1
+	expressionIntMax = expression.IntMax
+	expressionIntMin = expression.IntMin
 )
 
 // Run evaluates a boolean expression composed of numeric recognition nodes.
@@ -362,159 +359,13 @@ func extractRecognitionNumber(detail *maa.RecognitionDetail) (int, error) {
 	return 0, fmt.Errorf("no ocr result found")
 }
 
-func evaluateExpression(expression string) (any, error) {
-	parsedExpression, err := parser.ParseExpr(expression)
+func evaluateExpression(resolvedExpression string) (any, error) {
+	parsedExpression, err := expression.Parse(resolvedExpression)
 	if err != nil {
 		return nil, err
 	}
 
-	return evaluateASTExpression(parsedExpression)
-}
-
-func evaluateASTExpression(expr ast.Expr) (any, error) {
-	switch node := expr.(type) {
-	case *ast.BasicLit:
-		if node.Kind != token.INT {
-			return nil, fmt.Errorf("unsupported literal kind %s", node.Kind.String())
-		}
-		return parseExpressionIntLiteral(node.Value)
-	case *ast.ParenExpr:
-		return evaluateASTExpression(node.X)
-	case *ast.UnaryExpr:
-		value, err := evaluateASTExpression(node.X)
-		if err != nil {
-			return nil, err
-		}
-		switch node.Op {
-		case token.ADD:
-			intValue, ok := value.(int)
-			if !ok {
-				return nil, fmt.Errorf("operator + expects int, got %T", value)
-			}
-			return intValue, nil
-		case token.SUB:
-			intValue, ok := value.(int)
-			if !ok {
-				return nil, fmt.Errorf("operator - expects int, got %T", value)
-			}
-			return -intValue, nil
-		case token.NOT:
-			boolValue, ok := value.(bool)
-			if !ok {
-				return nil, fmt.Errorf("operator ! expects bool, got %T", value)
-			}
-			return !boolValue, nil
-		default:
-			return nil, fmt.Errorf("unsupported unary operator %s", node.Op.String())
-		}
-	case *ast.BinaryExpr:
-		left, err := evaluateASTExpression(node.X)
-		if err != nil {
-			return nil, err
-		}
-		right, err := evaluateASTExpression(node.Y)
-		if err != nil {
-			return nil, err
-		}
-		return evaluateBinaryExpression(left, right, node.Op)
-	default:
-		return nil, fmt.Errorf("unsupported expression type %T", expr)
-	}
-}
-
-func evaluateBinaryExpression(left any, right any, op token.Token) (any, error) {
-	switch op {
-	case token.ADD, token.SUB, token.MUL, token.QUO, token.REM,
-		token.LSS, token.LEQ, token.GTR, token.GEQ:
-		leftInt, rightInt, err := requireInts(left, right, op)
-		if err != nil {
-			return nil, err
-		}
-		switch op {
-		case token.ADD:
-			return leftInt + rightInt, nil
-		case token.SUB:
-			return leftInt - rightInt, nil
-		case token.MUL:
-			return leftInt * rightInt, nil
-		case token.QUO:
-			if rightInt == 0 {
-				return nil, fmt.Errorf("division by zero")
-			}
-			return leftInt / rightInt, nil
-		case token.REM:
-			if rightInt == 0 {
-				return nil, fmt.Errorf("division by zero")
-			}
-			return leftInt % rightInt, nil
-		case token.LSS:
-			return leftInt < rightInt, nil
-		case token.LEQ:
-			return leftInt <= rightInt, nil
-		case token.GTR:
-			return leftInt > rightInt, nil
-		case token.GEQ:
-			return leftInt >= rightInt, nil
-		}
-	case token.EQL, token.NEQ:
-		switch leftValue := left.(type) {
-		case int:
-			rightValue, ok := right.(int)
-			if !ok {
-				return nil, fmt.Errorf("operator %s expects same-type operands, got %T and %T", op.String(), left, right)
-			}
-			if op == token.EQL {
-				return leftValue == rightValue, nil
-			}
-			return leftValue != rightValue, nil
-		case bool:
-			rightValue, ok := right.(bool)
-			if !ok {
-				return nil, fmt.Errorf("operator %s expects same-type operands, got %T and %T", op.String(), left, right)
-			}
-			if op == token.EQL {
-				return leftValue == rightValue, nil
-			}
-			return leftValue != rightValue, nil
-		default:
-			return nil, fmt.Errorf("unsupported equality operand type %T", left)
-		}
-	case token.LAND, token.LOR:
-		leftBool, rightBool, err := requireBools(left, right, op)
-		if err != nil {
-			return nil, err
-		}
-		if op == token.LAND {
-			return leftBool && rightBool, nil
-		}
-		return leftBool || rightBool, nil
-	}
-
-	return nil, fmt.Errorf("unsupported binary operator %s", op.String())
-}
-
-func requireInts(left any, right any, op token.Token) (int, int, error) {
-	leftInt, ok := left.(int)
-	if !ok {
-		return 0, 0, fmt.Errorf("operator %s expects int operands, got %T and %T", op.String(), left, right)
-	}
-	rightInt, ok := right.(int)
-	if !ok {
-		return 0, 0, fmt.Errorf("operator %s expects int operands, got %T and %T", op.String(), left, right)
-	}
-	return leftInt, rightInt, nil
-}
-
-func requireBools(left any, right any, op token.Token) (bool, bool, error) {
-	leftBool, ok := left.(bool)
-	if !ok {
-		return false, false, fmt.Errorf("operator %s expects bool operands, got %T and %T", op.String(), left, right)
-	}
-	rightBool, ok := right.(bool)
-	if !ok {
-		return false, false, fmt.Errorf("operator %s expects bool operands, got %T and %T", op.String(), left, right)
-	}
-	return leftBool, rightBool, nil
+	return expression.Evaluate(parsedExpression, nil)
 }
 
 func parseOCRNumericValue(text string) (int, error) {
@@ -554,29 +405,6 @@ func parseOCRNumericValue(text string) (int, error) {
 	return int(scaled), nil
 }
 
-func parseExpressionIntLiteral(raw string) (int, error) {
-	value, err := strconv.Atoi(raw)
-	if err == nil {
-		return value, nil
-	}
-
-	var numErr *strconv.NumError
-	if errors.As(err, &numErr) && numErr.Err == strconv.ErrRange {
-		clamped := expressionIntMax
-		if strings.HasPrefix(strings.TrimSpace(raw), "-") {
-			clamped = expressionIntMin
-		}
-		log.Warn().
-			Str("component", "ExpressionRecognition").
-			Str("literal", raw).
-			Int("clamped_value", clamped).
-			Msg("expression integer literal out of int range, clamped")
-		return clamped, nil
-	}
-
-	return 0, err
-}
-
 func clampToExpressionInt(value float64) int {
 	if value > float64(expressionIntMax) {
 		return expressionIntMax
@@ -188,42 +188,6 @@ func TestParseParamsTrimsBoxNode(t *testing.T) {
 	}
 }
 
-func TestParseExpressionIntLiteral(t *testing.T) {
-	testCases := []struct {
-		name string
-		raw  string
-		want int
-	}{
-		{
-			name: "plain integer",
-			raw:  "300000000",
-			want: 300000000,
-		},
-		{
-			name: "positive overflow clamps to max int",
-			raw:  "99999999999999999999999999999",
-			want: expressionIntMax,
-		},
-		{
-			name: "negative overflow clamps to min int",
-			raw:  "-99999999999999999999999999999",
-			want: expressionIntMin,
-		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			got, err := parseExpressionIntLiteral(tc.raw)
-			if err != nil {
-				t.Fatalf("unexpected error: %v", err)
-			}
-			if got != tc.want {
-				t.Fatalf("parseExpressionIntLiteral(%q) = %d, want %d", tc.raw, got, tc.want)
-			}
-		})
-	}
-}
-
 func TestEvaluateExpressionOverflowLiteral(t *testing.T) {
 	result, err := evaluateExpression("99999999999999999999999999999 < 7920000")
 	if err != nil {
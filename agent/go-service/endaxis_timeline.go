@@ -0,0 +1,157 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/autofight"
+	"github.com/rs/zerolog/log"
+)
+
+const endAxisTimelineUsage = "Usage: go-service --endaxis-timeline import [-squad <id,...>] <name> <file|code> | export [-json] <name> | list [-squad <id>] | remove <name> | validate <file|code|name>..."
+
+// runEndAxisTimeline 处理 `--endaxis-timeline <command> [args...]` 入口，管理本地排轴库
+// （debug/record/AutoFightTimelines.json）：导入 Endaxis 导出的 JSON 文件或数据码、导出为数据码、列出、删除，
+// 以及在战斗前校验排轴。validate 将问题逐行输出到 stdout，存在错误级问题时以非零状态退出。
+func runEndAxisTimeline(args []string) {
+	if len(args) == 0 {
+		log.Fatal().Msg(endAxisTimelineUsage)
+	}
+	path := autofight.EndAxisTimelineLibraryPath()
+	library, err := autofight.OpenEndAxisTimelineLibrary(path)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("path", path).
+			Msg("Failed to open timeline library")
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "import":
+		fs := flag.NewFlagSet("endaxis-timeline import", flag.ExitOnError)
+		squad := fs.String("squad", "", "comma-separated operator ids (default: track ids of the first scenario)")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 2 {
+			log.Fatal().Msg(endAxisTimelineUsage)
+		}
+		var squadIDs []string
+		if *squad != "" {
+			squadIDs = strings.Split(*squad, ",")
+		}
+		entry, issues, err := library.Import(fs.Arg(0), readEndAxisTimelineSource(fs.Arg(1)), squadIDs, time.Now())
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		if err != nil {
+			log.Fatal().
+				Err(err).
+				Msg("Failed to import timeline")
+		}
+		if err := library.Save(); err != nil {
+			log.Fatal().
+				Err(err).
+				Msg("Failed to save timeline library")
+		}
+		log.Info().
+			Str("name", entry.Name).
+			Strs("squad", entry.Squad).
+			Str("path", path).
+			Msg("Timeline imported")
+	case "export":
+		fs := flag.NewFlagSet("endaxis-timeline export", flag.ExitOnError)
+		asJSON := fs.Bool("json", false, "print the project JSON instead of the share code")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+			log.Fatal().Msg(endAxisTimelineUsage)
+		}
+		entry, ok := library.Get(fs.Arg(0))
+		if !ok {
+			log.Fatal().
+				Str("name", fs.Arg(0)).
+				Msg("Timeline not found")
+		}
+		if !*asJSON {
+			fmt.Println(entry.Code)
+			return
+		}
+		content, err := autofight.DecodeEndAxisTimelineCode(entry.Code)
+		if err != nil {
+			log.Fatal().
+				Err(err).
+				Msg("Failed to decode timeline")
+		}
+		fmt.Println(string(content))
+	case "list":
+		fs := flag.NewFlagSet("endaxis-timeline list", flag.ExitOnError)
+		squad := fs.String("squad", "", "only list timelines for this operator id")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+			log.Fatal().Msg(endAxisTimelineUsage)
+		}
+		for _, entry := range library.List(*squad) {
+			fmt.Printf("%s\t%s\t%s\n", entry.Name, strings.Join(entry.Squad, ","), entry.ImportedAt.Format(time.DateTime))
+		}
+	case "remove":
+		if len(args) != 1 {
+			log.Fatal().Msg(endAxisTimelineUsage)
+		}
+		if !library.Remove(args[0]) {
+			log.Fatal().
+				Str("name", args[0]).
+				Msg("Timeline not found")
+		}
+		if err := library.Save(); err != nil {
+			log.Fatal().
+				Err(err).
+				Msg("Failed to save timeline library")
+		}
+		log.Info().
+			Str("name", args[0]).
+			Msg("Timeline removed")
+	case "validate":
+		if len(args) == 0 {
+			log.Fatal().Msg(endAxisTimelineUsage)
+		}
+		errorCount := 0
+		for _, arg := range args {
+			var source string
+			if entry, ok := library.Get(arg); ok {
+				source = entry.Code
+			} else {
+				source = readEndAxisTimelineSource(arg)
+			}
+			issues, err := autofight.ValidateEndAxisTimelineSource(source)
+			if err != nil {
+				errorCount++
+				fmt.Printf("%s: %s: parse: %v\n", arg, autofight.EndAxisIssueSeverityError, err)
+				continue
+			}
+			for _, issue := range issues {
+				if issue.Severity == autofight.EndAxisIssueSeverityError {
+					errorCount++
+				}
+				fmt.Printf("%s: %s\n", arg, issue)
+			}
+			fmt.Printf("%s: %d issue(s)\n", arg, len(issues))
+		}
+		if errorCount > 0 {
+			log.Fatal().
+				Int("errors", errorCount).
+				Msg("Timeline validation found errors")
+		}
+		log.Info().
+			Msg("Timeline validation passed")
+	default:
+		log.Fatal().Msg(endAxisTimelineUsage)
+	}
+}
+
+// readEndAxisTimelineSource 读取参数指向的文件内容；参数不是文件时按数据码原样返回。
+func readEndAxisTimelineSource(arg string) string {
+	content, err := os.ReadFile(arg)
+	if err != nil {
+		return arg
+	}
+	return string(content)
+}
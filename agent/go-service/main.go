@@ -12,7 +12,7 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-const usage = "Usage: go-service <identifier> | go-service --pretask <taskname> [args...]"
+const usage = "Usage: go-service <identifier> | go-service --pretask <taskname> [args...] | go-service --replay [flags] <frames_dir> | go-service --autofight-replay [flags] <record_file> | go-service --endaxis-timeline <command> [args...] | go-service --check-navmesh [-fmt] <file|dir>..."
 
 func main() {
 	if _, ok := os.LookupEnv("GOTRACEBACK"); !ok {
@@ -75,6 +75,14 @@ func main() {
 	switch os.Args[1] {
 	case "--pretask":
 		pretask.Run(os.Args[2:])
+	case "--replay":
+		runReplay(os.Args[2:])
+	case "--autofight-replay":
+		runAutoFightReplay(os.Args[2:])
+	case "--endaxis-timeline":
+		runEndAxisTimeline(os.Args[2:])
+	case "--check-navmesh":
+		runCheckNavMesh(os.Args[2:])
 	default:
 		runAgent(os.Args[1])
 	}
@@ -8,8 +8,6 @@ import (
 	"image/draw"
 	"math"
 	"math/rand"
-	"sort"
-	"sync"
 
 	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
@@ -50,6 +48,8 @@ type MapTrackerBigMapFindImageMatch struct {
 	MapY     float64 `json:"MapY"`
 	Conf     float64 `json:"Conf"`
 	Rotation float64 `json:"Rotation"`
+	// box is the hit box in map coordinates, used to merge matches across viewports.
+	box minicv.MultiHit
 }
 
 var mapTrackerBigMapFindImageDefaultParam = MapTrackerBigMapFindImageParam{
@@ -353,10 +353,7 @@ func (r *MapTrackerBigMapFindImage) runMultiViewportSearch(
 		log.Info().Int("remaining", len(remaining)).Int("totalMatches", len(allMatches)).Msg("Multi-viewport search progress")
 	}
 
-	sort.Slice(allMatches, func(i, j int) bool {
-		return allMatches[i].Conf > allMatches[j].Conf
-	})
-	allMatches = deduplicateMatches(allMatches)
+	allMatches = suppressFindImageMatches(allMatches)
 	if len(allMatches) > param.MaxMatches {
 		allMatches = allMatches[:param.MaxMatches]
 	}
@@ -464,26 +461,8 @@ func (r *MapTrackerBigMapFindImage) matchCurrentViewport(tpl *minicv.Template, p
 		return
 	}
 
-	matches := r.matchTemplate(cropped, tpl, param, viewport)
-
-	// Deduplicate by map coordinates against previously accumulated results.
-	var newMatches []MapTrackerBigMapFindImageMatch
-	for _, m := range matches {
-		isDup := false
-		for _, existing := range *result {
-			dx := m.MapX - existing.MapX
-			dy := m.MapY - existing.MapY
-			if dx*dx+dy*dy < NMS_MIN_DISTANCE*NMS_MIN_DISTANCE {
-				isDup = true
-				break
-			}
-		}
-		if !isDup {
-			newMatches = append(newMatches, m)
-		}
-	}
-
-	*result = append(*result, newMatches...)
+	// Overlapping matches from different viewports are merged once the search completes
+	*result = append(*result, r.matchTemplate(cropped, tpl, param, viewport)...)
 }
 
 func (r *MapTrackerBigMapFindImage) matchTemplate(
@@ -492,122 +471,28 @@ func (r *MapTrackerBigMapFindImage) matchTemplate(
 	param *MapTrackerBigMapFindImageParam,
 	viewport *BigMapViewport,
 ) []MapTrackerBigMapFindImageMatch {
-	tplImg := tpl.Image
-	tplStats := tpl.Stats
-	halfW := float64(tplImg.Rect.Dx()) / 2.0
-	halfH := float64(tplImg.Rect.Dy()) / 2.0
-
-	var allMatches []MapTrackerBigMapFindImageMatch
-	if param.WithRotation {
-		allMatches = matchFindImageWithRotation(cropped, tplImg, tplStats, param, viewport, halfW, halfH)
-	} else {
-		allMatches = collectFindImageMatches(cropped, tplImg, tplStats, param, func(mx, my, score float64) MapTrackerBigMapFindImageMatch {
-			vx := mx + halfW + viewport.Left
-			vy := my + halfH + viewport.Top
-			return newFindImageMatch(viewport, vx, vy, score, 0)
-		})
-	}
-
-	sort.Slice(allMatches, func(i, j int) bool {
-		return allMatches[i].Conf > allMatches[j].Conf
-	})
-
-	if param.WithRotation && len(allMatches) > 1 {
-		allMatches = deduplicateMatches(allMatches)
-	}
-	if len(allMatches) > param.MaxMatches {
-		allMatches = allMatches[:param.MaxMatches]
-	}
-
-	return allMatches
-}
-
-func matchFindImageWithRotation(
-	cropped *image.RGBA,
-	tplImg *image.RGBA,
-	tplStats minicv.StatsResult,
-	param *MapTrackerBigMapFindImageParam,
-	viewport *BigMapViewport,
-	halfW float64,
-	halfH float64,
-) []MapTrackerBigMapFindImageMatch {
-	type angleResult struct {
-		matches []MapTrackerBigMapFindImageMatch
+	opts := minicv.MultiHitOptions{
+		UseColorMask:    param.GreenMask,
+		MaskColorRGB888: GREEN_SCREEN_MASK_COLOR_RGB888,
+		Threshold:       param.Threshold,
+		MaxHits:         param.MaxMatches,
 	}
-
-	numAngles := int(360.0 / ROTATION_STEP_DEG)
-	resChan := make(chan angleResult, numAngles)
-	var wg sync.WaitGroup
-
-	cx := float64(cropped.Rect.Dx()) / 2.0
-	cy := float64(cropped.Rect.Dy()) / 2.0
-
-	for angle := 0.0; angle < 360.0; angle += ROTATION_STEP_DEG {
-		wg.Add(1)
-		go func(a float64) {
-			defer wg.Done()
-
-			rotatedSrc := minicv.ImageRotate(cropped, a)
-			rad := a * math.Pi / 180.0
-			cosA, sinA := math.Cos(rad), math.Sin(rad)
-
-			matches := collectFindImageMatches(rotatedSrc, tplImg, tplStats, param, func(mx, my, score float64) MapTrackerBigMapFindImageMatch {
-				dx := mx + halfW - cx
-				dy := my + halfH - cy
-				origX := dx*cosA + dy*sinA + cx
-				origY := -dx*sinA + dy*cosA + cy
-				return newFindImageMatch(viewport, origX+viewport.Left, origY+viewport.Top, score, a)
-			})
-
-			resChan <- angleResult{matches: matches}
-		}(angle)
+	if param.WithRotation {
+		for angle := 0.0; angle < 360.0; angle += ROTATION_STEP_DEG {
+			opts.Angles = append(opts.Angles, angle)
+		}
 	}
 
-	go func() {
-		wg.Wait()
-		close(resChan)
-	}()
-
-	var allMatches []MapTrackerBigMapFindImageMatch
-	for res := range resChan {
-		allMatches = append(allMatches, res.matches...)
-	}
-	return allMatches
-}
-
-func collectFindImageMatches(
-	img *image.RGBA,
-	tplImg *image.RGBA,
-	tplStats minicv.StatsResult,
-	param *MapTrackerBigMapFindImageParam,
-	buildMatch func(mx, my, score float64) MapTrackerBigMapFindImageMatch,
-) []MapTrackerBigMapFindImageMatch {
-	imgIntArr := minicv.GetIntegralArray(img)
-	var hits []minicv.MatchTemplateHit
-	if param.GreenMask {
-		hits = minicv.MatchTemplateMultiHitWithMask(
-			img,
-			imgIntArr,
-			tplImg,
-			tplStats,
-			GREEN_SCREEN_MASK_COLOR_RGB888,
-			param.Threshold,
-			param.MaxMatches,
-		)
-	} else {
-		hits = minicv.MatchTemplateMultiHit(
-			img,
-			imgIntArr,
-			tplImg,
-			tplStats,
-			param.Threshold,
-			param.MaxMatches,
-		)
-	}
+	hits := minicv.MatchTemplateMultiHitTransformed(cropped, minicv.GetIntegralArray(cropped), tpl.Image, opts)
 
+	// Hits are already suppressed and sorted by descending confidence
 	matches := make([]MapTrackerBigMapFindImageMatch, 0, len(hits))
 	for _, hit := range hits {
-		matches = append(matches, buildMatch(hit.X, hit.Y, hit.Val))
+		match := newFindImageMatch(viewport, hit.X+viewport.Left, hit.Y+viewport.Top, hit.Val, hit.Angle)
+		match.box = hit
+		match.box.X, match.box.Y = viewport.GetMapCoordOf(hit.X+viewport.Left, hit.Y+viewport.Top)
+		match.box.W, match.box.H = hit.W/viewport.Scale, hit.H/viewport.Scale
+		matches = append(matches, match)
 	}
 	return matches
 }
@@ -630,37 +515,23 @@ func newFindImageMatch(
 	}
 }
 
-func deduplicateMatches(matches []MapTrackerBigMapFindImageMatch) []MapTrackerBigMapFindImageMatch {
-	if len(matches) <= 1 {
-		return matches
-	}
-
-	keep := make([]bool, len(matches))
-	for i := range keep {
-		keep[i] = true
-	}
-
-	for i := range len(matches) {
-		if !keep[i] {
-			continue
-		}
-		for j := i + 1; j < len(matches); j++ {
-			if !keep[j] {
-				continue
-			}
-			dx := matches[i].ScreenX - matches[j].ScreenX
-			dy := matches[i].ScreenY - matches[j].ScreenY
-			if math.Sqrt(dx*dx+dy*dy) < NMS_MIN_DISTANCE {
-				// Keep the one with higher conf (list is sorted, so i has higher or equal conf)
-				keep[j] = false
-			}
-		}
+// suppressFindImageMatches merges matches found in several viewports by non-maximum suppression over their map-coordinate boxes.
+// The result is sorted by descending confidence.
+func suppressFindImageMatches(matches []MapTrackerBigMapFindImageMatch) []MapTrackerBigMapFindImageMatch {
+	boxes := make([]minicv.MultiHit, len(matches))
+	for i, m := range matches {
+		boxes[i] = m.box
 	}
 
+	used := make([]bool, len(matches))
 	result := make([]MapTrackerBigMapFindImageMatch, 0, len(matches))
-	for i, k := range keep {
-		if k {
-			result = append(result, matches[i])
+	for _, kept := range minicv.SuppressHitsByIoU(boxes, minicv.DEFAULT_NMS_IOU_THRESHOLD) {
+		for i, box := range boxes {
+			if !used[i] && box == kept {
+				used[i] = true
+				result = append(result, matches[i])
+				break
+			}
 		}
 	}
 	return result
@@ -0,0 +1,25 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerbigmap
+
+import (
+	"testing"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/minicv"
+)
+
+func TestSuppressFindImageMatchesMergesViewports(t *testing.T) {
+	// The same icon seen from two viewports, plus a separate icon next to it
+	matches := []MapTrackerBigMapFindImageMatch{
+		{MapX: 100, MapY: 100, Conf: 0.8, box: minicv.MultiHit{X: 100, Y: 100, W: 20, H: 20, Val: 0.8}},
+		{MapX: 130, MapY: 100, Conf: 0.7, box: minicv.MultiHit{X: 130, Y: 100, W: 20, H: 20, Val: 0.7}},
+		{MapX: 102, MapY: 101, Conf: 0.9, box: minicv.MultiHit{X: 102, Y: 101, W: 20, H: 20, Val: 0.9}},
+	}
+
+	merged := suppressFindImageMatches(matches)
+	if len(merged) != 2 {
+		t.Fatalf("len(merged) = %d, want 2: %+v", len(merged), merged)
+	}
+	if merged[0].MapX != 102 || merged[1].MapX != 130 {
+		t.Fatalf("merged = %+v, want the best duplicate followed by the separate icon", merged)
+	}
+}
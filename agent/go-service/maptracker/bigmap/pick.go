@@ -12,6 +12,7 @@ import (
 
 	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/i18n"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/resource"
 	maa "github.com/MaaXYZ/maa-framework-go/v4"
 	"github.com/rs/zerolog/log"
@@ -34,6 +35,9 @@ type MapTrackerBigMapPickParam struct {
 	MapName string `json:"map_name"`
 	// Target is the target coordinate in the specified map file's original coordinate space.
 	Target [2]float64 `json:"target"`
+	// Landmark is the ID, localized name or alias of a landmark in the landmark registry.
+	// If set, map_name and target are taken from the landmark, and map_name, if also given, narrows the lookup.
+	Landmark string `json:"landmark,omitempty"`
 	// OnFind controls behavior when target enters viewport. Valid values: "Click", "Teleport", "DoNothing".
 	OnFind string `json:"on_find,omitempty"`
 	// AutoOpenMapScene controls whether to automatically open the big map scene before picking.
@@ -179,8 +183,17 @@ func (a *MapTrackerBigMapPick) parseParam(paramStr string) (*MapTrackerBigMapPic
 		return nil, fmt.Errorf("failed to unmarshal parameters: %w", err)
 	}
 
+	if param.Landmark != "" {
+		landmark, err := internal.FindLandmark(param.Landmark, param.MapName, i18n.Lang())
+		if err != nil {
+			return nil, err
+		}
+		log.Info().Str("query", param.Landmark).Str("landmark", landmark.ID).Str("map", landmark.MapName).Msg("Landmark resolved for MapTrackerBigMapPick")
+		param.MapName = landmark.MapName
+		param.Target = landmark.Target
+	}
 	if param.MapName == "" {
-		return nil, fmt.Errorf("map_name must be provided")
+		return nil, fmt.Errorf("map_name or landmark must be provided")
 	}
 	if param.OnFind == "" {
 		param.OnFind = mapTrackerBigMapPickDefaultParam.OnFind
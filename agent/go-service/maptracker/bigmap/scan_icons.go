@@ -0,0 +1,367 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerbigmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"time"
+
+	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/minicv"
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// MapTrackerBigMapScanIcons sweeps the big map of a whole region and records an inventory of icons.
+type MapTrackerBigMapScanIcons struct{}
+
+// MapTrackerBigMapScanIconsParam represents the custom_action_param for MapTrackerBigMapScanIcons.
+type MapTrackerBigMapScanIconsParam struct {
+	// MapName is the map to scan. The big map must already show this map.
+	MapName string `json:"map_name"`
+	// Icons are the icon templates to match in every viewport.
+	Icons []MapTrackerBigMapScanIconSpec `json:"icons"`
+	// Region is the map area [x, y, w, h] to sweep. If omitted, the whole map is swept.
+	Region *[4]float64 `json:"region,omitempty"`
+	// ZoomValue is the zoom slider position during the sweep. If omitted, defaults to 0.6. Set to 0 to keep the current zoom.
+	ZoomValue *float64 `json:"zoom_value,omitempty"`
+	// Output is the inventory file path. If omitted, the inventory is written under ICON_INVENTORY_RECORD_DIR.
+	Output string `json:"output,omitempty"`
+}
+
+// MapTrackerBigMapScanIconSpec describes one kind of icon to scan for.
+type MapTrackerBigMapScanIconSpec struct {
+	// Kind is the icon kind recorded in the inventory, e.g. "Zipline".
+	Kind string `json:"kind"`
+	// Template is the path to the icon image, relative to the resource directory.
+	Template string `json:"template"`
+	// Threshold is the minimum confidence for a valid match.
+	Threshold float64 `json:"threshold,omitempty"`
+	// GreenMask indicates whether to apply a #00FF00 color mask to the template during matching.
+	GreenMask bool `json:"green_mask,omitempty"`
+	// WithRotation indicates whether to perform rotation-invariant matching.
+	WithRotation bool `json:"with_rotation,omitempty"`
+}
+
+const (
+	SCAN_ICONS_VIEW_OVERLAP         = 0.2 // Overlap ratio between adjacent viewports
+	SCAN_ICONS_PAN_RETRY            = 5   // Pan attempts per waypoint
+	SCAN_ICONS_MAX_MATCHES_PER_VIEW = 64
+	SCAN_ICONS_STALL_DISTANCE       = 1.0 // Map distance below which a pan is considered blocked by the map border
+)
+
+var mapTrackerBigMapScanIconsDefaultParam = MapTrackerBigMapScanIconsParam{
+	ZoomValue: func() *float64 { v := 0.6; return &v }(),
+}
+
+type scanIconTemplate struct {
+	spec  MapTrackerBigMapScanIconSpec
+	tpl   *minicv.Template
+	param *MapTrackerBigMapFindImageParam
+}
+
+var _ maa.CustomActionRunner = &MapTrackerBigMapScanIcons{}
+
+// Run implements maa.CustomActionRunner.
+func (a *MapTrackerBigMapScanIcons) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
+	param, err := a.parseParam(arg.CustomActionParam)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to parse parameters for MapTrackerBigMapScanIcons")
+		return false
+	}
+
+	finder := &MapTrackerBigMapFindImage{}
+	templates := make([]scanIconTemplate, 0, len(param.Icons))
+	for _, spec := range param.Icons {
+		tpl, err := finder.resolveTemplate(spec.Template)
+		if err != nil {
+			log.Error().Err(err).Str("kind", spec.Kind).Msg("Failed to resolve icon template for MapTrackerBigMapScanIcons")
+			return false
+		}
+		templates = append(templates, scanIconTemplate{
+			spec: spec,
+			tpl:  tpl,
+			param: &MapTrackerBigMapFindImageParam{
+				Threshold:    spec.Threshold,
+				GreenMask:    spec.GreenMask,
+				WithRotation: spec.WithRotation,
+				MaxMatches:   SCAN_ICONS_MAX_MATCHES_PER_VIEW,
+			},
+		})
+	}
+
+	region, err := a.resolveRegion(ctx, param)
+	if err != nil {
+		log.Error().Err(err).Str("map", param.MapName).Msg("Failed to resolve scan region")
+		return false
+	}
+
+	ctrl := ctx.GetTasker().GetController()
+	ca, err := control.NewControlAdaptor(ctx, ctrl, WORK_W, WORK_H)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create control adaptor")
+		return false
+	}
+
+	zoomValue := *param.ZoomValue // Not nil verified by parseParam
+	if zoomValue != 0 {
+		if err := doBigMapZoom(ctrl, ca, zoomValue); err != nil {
+			log.Error().Err(err).Float64("zoomValue", zoomValue).Msg("Failed to adjust big-map zoom for scan")
+			return false
+		}
+	}
+
+	inv := &internal.IconInventory{
+		MapName:   param.MapName,
+		Region:    region,
+		ZoomValue: zoomValue,
+	}
+	if !a.sweep(ctx, arg, ctrl, ca, param, region, templates, inv) {
+		return false
+	}
+	inv.ScannedAt = time.Now()
+
+	output := param.Output
+	if output == "" {
+		output = internal.IconInventoryPath(param.MapName)
+	}
+	if err := internal.SaveIconInventory(output, inv); err != nil {
+		log.Error().Err(err).Str("path", output).Msg("Failed to save icon inventory")
+		return false
+	}
+
+	event := log.Info().Str("map", param.MapName).Str("path", output).Int("icons", len(inv.Icons))
+	for _, t := range templates {
+		event = event.Int(t.spec.Kind, inv.Count(t.spec.Kind))
+	}
+	event.Msg("Big-map icon scan completed")
+	return true
+}
+
+func (a *MapTrackerBigMapScanIcons) parseParam(paramStr string) (*MapTrackerBigMapScanIconsParam, error) {
+	if paramStr == "" {
+		return nil, fmt.Errorf("custom_action_param is required for MapTrackerBigMapScanIcons")
+	}
+
+	var param MapTrackerBigMapScanIconsParam
+	if err := json.Unmarshal([]byte(paramStr), &param); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal parameters: %w", err)
+	}
+
+	if param.MapName == "" {
+		return nil, fmt.Errorf("map_name must not be empty")
+	}
+	if len(param.Icons) == 0 {
+		return nil, fmt.Errorf("icons must not be empty")
+	}
+	for i := range param.Icons {
+		icon := &param.Icons[i]
+		if icon.Kind == "" || icon.Template == "" {
+			return nil, fmt.Errorf("icons[%d]: kind and template must not be empty", i)
+		}
+		if icon.Threshold == 0.0 {
+			icon.Threshold = mapTrackerBigMapFindImageDefaultParam.Threshold
+		} else if icon.Threshold < 0.0 || icon.Threshold > 1.0 {
+			return nil, fmt.Errorf("icons[%d]: invalid threshold value: %f", i, icon.Threshold)
+		}
+	}
+	if param.Region != nil && (param.Region[2] <= 0 || param.Region[3] <= 0) {
+		return nil, fmt.Errorf("region width and height must be positive")
+	}
+	if param.ZoomValue == nil {
+		param.ZoomValue = mapTrackerBigMapScanIconsDefaultParam.ZoomValue
+	} else if !(0 <= *param.ZoomValue && *param.ZoomValue <= 1) {
+		return nil, fmt.Errorf("zoom_value must be in range [0, 1]")
+	}
+
+	return &param, nil
+}
+
+// resolveRegion returns the region to sweep, defaulting to the bounds of the map image.
+func (a *MapTrackerBigMapScanIcons) resolveRegion(ctx *maa.Context, param *MapTrackerBigMapScanIconsParam) ([4]float64, error) {
+	if param.Region != nil {
+		return *param.Region, nil
+	}
+	internal.Resource.InitRawMaps(ctx)
+	if internal.Resource.RawMapsErr != nil {
+		return [4]float64{}, internal.Resource.RawMapsErr
+	}
+	for _, m := range internal.Resource.RawMaps {
+		if m.Name == param.MapName {
+			b := m.Img.Bounds()
+			return [4]float64{float64(m.OffsetX), float64(m.OffsetY), float64(b.Dx()), float64(b.Dy())}, nil
+		}
+	}
+	return [4]float64{}, fmt.Errorf("map %s not found", param.MapName)
+}
+
+// sweep pans the big map over all waypoints of the region, matching all templates at each stop.
+//
+// Flow: screenshot + infer → match → drop covered waypoints → pan toward the next → loop
+func (a *MapTrackerBigMapScanIcons) sweep(
+	ctx *maa.Context,
+	arg *maa.CustomActionArg,
+	ctrl *maa.Controller,
+	ca control.ControlAdaptor,
+	param *MapTrackerBigMapScanIconsParam,
+	region [4]float64,
+	templates []scanIconTemplate,
+	inv *internal.IconInventory,
+) bool {
+	finder := &MapTrackerBigMapFindImage{}
+	recoArg := &maa.CustomRecognitionArg{
+		TaskID:          arg.TaskID,
+		CurrentTaskName: arg.CurrentTaskName,
+		Roi:             maa.Rect{0, 0, WORK_W, WORK_H},
+	}
+	inferParam := &MapTrackerBigMapInferParam{MapNameRegex: "^" + regexp.QuoteMeta(param.MapName) + "$"}
+	panFactor := BIG_MAP_PAN_FACTOR_NUMERATOR / control.GetScreenDiagonalSize(ctrl)
+
+	// observe takes a screenshot, infers the viewport and matches all templates in it
+	observe := func() (*BigMapViewport, error) {
+		time.Sleep(INFER_PRE_DELAY_MS * time.Millisecond)
+		ctrl.PostScreencap().Wait()
+		img, err := ctrl.CacheImage()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cached image: %w", err)
+		}
+		if img == nil {
+			return nil, fmt.Errorf("cached image is nil")
+		}
+		inferRes, err := finder.inferResultWithImg(ctx, recoArg, img, inferParam)
+		if err != nil {
+			return nil, err
+		}
+		cropped, _, _, ok := cropBigMapTemplate(minicv.ImageConvertRGBA(img))
+		if !ok {
+			return nil, fmt.Errorf("big-map crop area is invalid")
+		}
+		viewport := &inferRes.ViewPort
+		for _, t := range templates {
+			for _, m := range finder.matchTemplate(cropped, t.tpl, t.param, viewport) {
+				if !isMapCoordInRegion(m.MapX, m.MapY, region) {
+					continue
+				}
+				inv.Add(internal.IconInventoryItem{
+					Kind:     t.spec.Kind,
+					X:        m.MapX,
+					Y:        m.MapY,
+					Conf:     m.Conf,
+					Rotation: m.Rotation,
+				}, NMS_MIN_DISTANCE)
+			}
+		}
+		return viewport, nil
+	}
+
+	viewport, err := observe()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to observe the initial viewport for icon scan")
+		return false
+	}
+
+	viewW := (viewport.Right - viewport.Left) / viewport.Scale
+	viewH := (viewport.Bottom - viewport.Top) / viewport.Scale
+	stepW, stepH := viewW*(1-SCAN_ICONS_VIEW_OVERLAP), viewH*(1-SCAN_ICONS_VIEW_OVERLAP)
+	waypoints, cellW, cellH := buildScanWaypoints(region, stepW, stepH)
+	total := len(waypoints)
+	log.Info().Int("waypoints", total).Float64("viewW", viewW).Float64("viewH", viewH).Msg("Big-map icon scan started")
+
+	skipped := 0
+	for len(waypoints) > 0 {
+		waypoints = filterCoveredWaypoints(waypoints, viewport, cellW/2, cellH/2)
+		if len(waypoints) == 0 {
+			break
+		}
+		if ctx.GetTasker().Stopping() {
+			log.Warn().Msg("Task is stopping, exiting icon scan")
+			return false
+		}
+
+		// Pan toward the first uncovered waypoint, so that the sweep follows the serpentine order
+		target := waypoints[0]
+		reached := false
+		for attempt := 1; attempt <= SCAN_ICONS_PAN_RETRY && !reached; attempt++ {
+			targetInViewX, targetInViewY := viewport.GetScreenCoordOf(target[0], target[1])
+			centerX := (viewport.Left + viewport.Right) * 0.5
+			centerY := (viewport.Top + viewport.Bottom) * 0.5
+			if !doDragViewport(ca, viewport, targetInViewX-centerX, targetInViewY-centerY, panFactor, rand.Intn(3)+1) {
+				break
+			}
+			time.Sleep(PAN_POST_DELAY_MS * time.Millisecond)
+			ca.TouchMove(0, 1, 1, 0)
+
+			prevX, prevY := viewport.OriginMapX, viewport.OriginMapY
+			viewport, err = observe()
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to observe viewport during icon scan")
+				return false
+			}
+			reached = isWaypointCovered(target, viewport, cellW/2, cellH/2)
+			if math.Hypot(viewport.OriginMapX-prevX, viewport.OriginMapY-prevY) < SCAN_ICONS_STALL_DISTANCE {
+				// The big map refuses to pan further, usually at the map border
+				break
+			}
+		}
+		if !reached {
+			skipped++
+			log.Debug().Float64("x", target[0]).Float64("y", target[1]).Msg("Icon scan waypoint not fully covered, skipping")
+			waypoints = waypoints[1:]
+		}
+		log.Info().Int("remaining", len(waypoints)).Int("total", total).Int("icons", len(inv.Icons)).Msg("Big-map icon scan progress")
+	}
+
+	if skipped > 0 {
+		log.Warn().Int("skipped", skipped).Int("total", total).Msg("Some icon scan waypoints were not fully covered")
+	}
+	return true
+}
+
+// buildScanWaypoints returns the centers of a grid of cells covering the region in serpentine order,
+// and the cell size, which is at most the given step.
+func buildScanWaypoints(region [4]float64, stepW, stepH float64) (waypoints [][2]float64, cellW, cellH float64) {
+	if stepW <= 0 || stepH <= 0 {
+		return nil, 0, 0
+	}
+	cols := max(1, int(math.Ceil(region[2]/stepW)))
+	rows := max(1, int(math.Ceil(region[3]/stepH)))
+	// Spread the cells evenly, so that the last row and column are not thinner than the others
+	cellW, cellH = region[2]/float64(cols), region[3]/float64(rows)
+
+	waypoints = make([][2]float64, 0, cols*rows)
+	for row := range rows {
+		y := region[1] + (float64(row)+0.5)*cellH
+		for i := range cols {
+			col := i
+			if row%2 == 1 {
+				col = cols - 1 - i
+			}
+			waypoints = append(waypoints, [2]float64{region[0] + (float64(col)+0.5)*cellW, y})
+		}
+	}
+	return waypoints, cellW, cellH
+}
+
+// isWaypointCovered reports whether the cell around the waypoint lies entirely in the viewport.
+func isWaypointCovered(wp [2]float64, viewport *BigMapViewport, halfW, halfH float64) bool {
+	return viewport.IsMapCoordInView(wp[0]-halfW, wp[1]-halfH) && viewport.IsMapCoordInView(wp[0]+halfW, wp[1]+halfH)
+}
+
+// filterCoveredWaypoints removes waypoints whose cells are covered by the viewport.
+func filterCoveredWaypoints(waypoints [][2]float64, viewport *BigMapViewport, halfW, halfH float64) [][2]float64 {
+	remaining := waypoints[:0]
+	for _, wp := range waypoints {
+		if !isWaypointCovered(wp, viewport, halfW, halfH) {
+			remaining = append(remaining, wp)
+		}
+	}
+	return remaining
+}
+
+func isMapCoordInRegion(x, y float64, region [4]float64) bool {
+	return x >= region[0] && x < region[0]+region[2] && y >= region[1] && y < region[1]+region[3]
+}
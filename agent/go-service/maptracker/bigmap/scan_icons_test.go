@@ -0,0 +1,38 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerbigmap
+
+import "testing"
+
+func TestBuildScanWaypointsCoversRegionInSerpentineOrder(t *testing.T) {
+	waypoints, cellW, cellH := buildScanWaypoints([4]float64{100, 50, 250, 100}, 100, 60)
+	if cellW != 250.0/3 || cellH != 50 {
+		t.Fatalf("cell size = (%v, %v), want (%v, 50)", cellW, cellH, 250.0/3)
+	}
+	want := [][2]float64{
+		{100 + cellW*0.5, 75}, {100 + cellW*1.5, 75}, {100 + cellW*2.5, 75},
+		{100 + cellW*2.5, 125}, {100 + cellW*1.5, 125}, {100 + cellW*0.5, 125},
+	}
+	if len(waypoints) != len(want) {
+		t.Fatalf("len(waypoints) = %d, want %d", len(waypoints), len(want))
+	}
+	for i := range want {
+		if waypoints[i] != want[i] {
+			t.Fatalf("waypoints[%d] = %v, want %v", i, waypoints[i], want[i])
+		}
+	}
+
+	if waypoints, _, _ := buildScanWaypoints([4]float64{0, 0, 10, 10}, 0, 10); waypoints != nil {
+		t.Fatalf("expected no waypoints for zero step, got %v", waypoints)
+	}
+}
+
+func TestFilterCoveredWaypoints(t *testing.T) {
+	// The viewport shows map area [0, 200) x [0, 100) at scale 2
+	viewport := &BigMapViewport{Left: 100, Top: 100, Right: 500, Bottom: 300, Scale: 2}
+	waypoints := [][2]float64{{50, 50}, {190, 50}, {100, 120}, {150, 50}}
+
+	remaining := filterCoveredWaypoints(waypoints, viewport, 40, 40)
+	if len(remaining) != 2 || remaining[0] != [2]float64{190, 50} || remaining[1] != [2]float64{100, 120} {
+		t.Fatalf("unexpected remaining waypoints: %v", remaining)
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// MapTrackerCalibrate measures the movement speeds and the camera sensitivity of the current
+// controller, and saves them as the calibration profile applied by [control.NewControlAdaptor].
+// The player should stand in an open area, since it moves straight forward and backward.
+type MapTrackerCalibrate struct{}
+
+// MapTrackerCalibrateParam represents the custom_action_param for MapTrackerCalibrate.
+type MapTrackerCalibrateParam struct {
+	// MapName has the same definition as [MapTrackerMoveParam.MapName] (required).
+	MapName string `json:"map_name,omitempty"`
+	// MapNameMatchRule has the same definition as [MapTrackerMoveParam.MapNameMatchRule].
+	MapNameMatchRule string `json:"map_name_match_rule,omitempty"`
+	// MeasureDuration is the time in milliseconds to move in each movement mode.
+	MeasureDuration int64 `json:"measure_duration,omitempty"`
+	// CameraDelta is the RotateCamera delta in pixels used to measure the camera sensitivity.
+	// The resulting rotation must stay below 180 degrees, otherwise its direction is ambiguous.
+	CameraDelta int `json:"camera_delta,omitempty"`
+}
+
+// Calibration configuration
+const (
+	// CALIBRATE_WARMUP_MS is the time to wait for the player to reach a steady speed before sampling.
+	CALIBRATE_WARMUP_MS = 800
+	// CALIBRATE_MIN_SAMPLES is the minimum number of locations required to measure a speed.
+	CALIBRATE_MIN_SAMPLES = 5
+	// CALIBRATE_MIN_LOC_CONF is the minimum location confidence of a speed sample.
+	CALIBRATE_MIN_LOC_CONF = 0.5
+	// CALIBRATE_MIN_SPEED is the minimum plausible speed (px/s), below which the player is considered blocked.
+	CALIBRATE_MIN_SPEED = 0.5
+	// CALIBRATE_MIN_ROTATION is the minimum plausible rotation (degrees) caused by the camera delta.
+	CALIBRATE_MIN_ROTATION = 5
+)
+
+var mapTrackerCalibrateDefaultParam = MapTrackerCalibrateParam{
+	MeasureDuration: 2500,
+	CameraDelta:     120,
+}
+
+var _ maa.CustomActionRunner = &MapTrackerCalibrate{}
+
+// calibrateSample is a location sampled during a speed measurement.
+type calibrateSample struct {
+	t    time.Time
+	x, y float64
+}
+
+// Run implements maa.CustomActionRunner.
+func (a *MapTrackerCalibrate) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
+	param, err := a.parseParam(arg.CustomActionParam)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to parse parameters for MapTrackerCalibrate")
+		return false
+	}
+
+	ctrl := ctx.GetTasker().GetController()
+	ctrlType, err := control.GetControlType(ctrl)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get control type for MapTrackerCalibrate")
+		return false
+	}
+	w, h, err := ctrl.GetResolution()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get resolution for MapTrackerCalibrate")
+		return false
+	}
+	ca, err := control.NewControlAdaptor(ctx, ctrl, WORK_W, WORK_H)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create control adaptor for MapTrackerCalibrate")
+		return false
+	}
+	// The camera is measured through the current scale, so the new scale is derived from it.
+	oldCameraScale := control.CalibrationOf(ca).GetCameraScale()
+	inferParam := &MapTrackerMoveParam{MapName: param.MapName, MapNameMatchRule: param.MapNameMatchRule}
+
+	ca.ResetCursor(control.CursorResetActive)
+	doPlayerStop(ca)
+
+	degreesPerPixel, err := a.measureCamera(ctx, ctrl, ca, ctrlType, param.CameraDelta)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to measure camera sensitivity")
+		doPlayerStop(ca)
+		return false
+	}
+
+	profile := control.CalibrationProfile{
+		ControlType:  ctrlType,
+		Width:        int(w),
+		Height:       int(h),
+		CameraScale:  oldCameraScale * control.CAMERA_REFERENCE_DEGREES_PER_PIXEL / degreesPerPixel,
+		CalibratedAt: time.Now(),
+	}
+	// Alternate the direction so that the player ends up near where it started.
+	modes := []struct {
+		name      string
+		movement  control.PlayerMovement
+		direction control.PlayerDirection
+		speed     *float64
+	}{
+		{"walk", control.MovementWalk, control.DirectionF, &profile.WalkSpeed},
+		{"run", control.MovementRun, control.DirectionB, &profile.RunSpeed},
+		{"sprint", control.MovementSprint, control.DirectionF, &profile.SprintSpeed},
+	}
+	duration := time.Duration(param.MeasureDuration) * time.Millisecond
+	for _, mode := range modes {
+		if ctx.GetTasker().Stopping() {
+			log.Warn().Msg("Task is stopping, exiting calibration")
+			doPlayerStop(ca)
+			return false
+		}
+		speed, err := a.measureSpeed(ctx, ctrl, ca, inferParam, mode.movement, mode.direction, duration)
+		if err != nil {
+			log.Error().Err(err).Str("mode", mode.name).Msg("Failed to measure movement speed")
+			doPlayerStop(ca)
+			return false
+		}
+		*mode.speed = speed
+		log.Info().Str("mode", mode.name).Float64("speed", speed).Msg("Movement speed measured")
+	}
+	doPlayerStop(ca)
+
+	if err := control.SaveCalibrationProfile(control.CALIBRATION_PROFILE_PATH, profile); err != nil {
+		log.Error().Err(err).Msg("Failed to save calibration profile")
+		return false
+	}
+	log.Info().
+		Str("controlType", profile.ControlType).
+		Int("width", profile.Width).
+		Int("height", profile.Height).
+		Float64("walkSpeed", profile.WalkSpeed).
+		Float64("runSpeed", profile.RunSpeed).
+		Float64("sprintSpeed", profile.SprintSpeed).
+		Float64("cameraScale", profile.CameraScale).
+		Msg("Calibration profile saved")
+	return true
+}
+
+// measureCamera rotates the camera forth and back by delta pixels and returns the mean
+// rotation per pixel observed on the minimap pointer.
+func (a *MapTrackerCalibrate) measureCamera(ctx *maa.Context, ctrl *maa.Controller, ca control.ControlAdaptor, ctrlType string, delta int) (float64, error) {
+	rotInfer := &MapTrackerInfer{}
+	settleWait := control.MovementWalk.EtaOfRotation(180)
+	readRotation := func() (int, error) {
+		screenImg, err := captureFullScreen(ctrl)
+		if err != nil {
+			return 0, fmt.Errorf("failed to capture screen: %w", err)
+		}
+		rot := rotInfer.inferRotation(ctrlType, screenImg, TOWARD_ROT_STEP)
+		if rot == nil {
+			return 0, fmt.Errorf("rotation inference failed")
+		}
+		return rot.Rot, nil
+	}
+
+	prevRot, err := readRotation()
+	if err != nil {
+		return 0, err
+	}
+	totalRot := 0.0
+	for _, d := range []int{delta, -delta} {
+		if ctx.GetTasker().Stopping() {
+			return 0, fmt.Errorf("task is stopping")
+		}
+		ca.RotateCamera(d, 0)
+		ca.ResetCursor(control.CursorResetLazy)
+		snapBodyOrientation(ca)
+		time.Sleep(settleWait)
+
+		curRot, err := readRotation()
+		if err != nil {
+			return 0, err
+		}
+		deltaRot := math.Abs(float64(calcDeltaRotation(prevRot, curRot)))
+		if deltaRot < CALIBRATE_MIN_ROTATION {
+			return 0, fmt.Errorf("camera delta %d rotated only %.0f degrees", d, deltaRot)
+		}
+		log.Debug().Int("delta", d).Int("fromRot", prevRot).Int("toRot", curRot).Msg("Camera rotation measured")
+		totalRot += deltaRot
+		prevRot = curRot
+	}
+	return totalRot / float64(2*delta), nil
+}
+
+// measureSpeed moves the player in the given movement mode and direction, and returns the
+// straight-line speed between the first and the last confident location after warming up.
+func (a *MapTrackerCalibrate) measureSpeed(
+	ctx *maa.Context, ctrl *maa.Controller, ca control.ControlAdaptor, inferParam *MapTrackerMoveParam,
+	movement control.PlayerMovement, direction control.PlayerDirection, duration time.Duration,
+) (float64, error) {
+	ca.SetPlayerDirection(direction)
+	ca.SetPlayerMovement(movement, control.PolicyDefault)
+	time.Sleep(CALIBRATE_WARMUP_MS * time.Millisecond)
+
+	samples := make([]calibrateSample, 0)
+	mapName := ""
+	loopInterval := time.Duration(INFER_INTERVAL_MS) * time.Millisecond
+	deadline := time.Now().Add(duration)
+	for time.Now().Before(deadline) {
+		if ctx.GetTasker().Stopping() {
+			break
+		}
+		t := time.Now()
+		result, err := doInfer(ctx, ctrl, inferParam)
+		if err == nil && result.LocConf >= CALIBRATE_MIN_LOC_CONF {
+			if mapName == "" {
+				mapName = result.MapName
+			}
+			if result.MapName == mapName {
+				samples = append(samples, calibrateSample{t: t, x: result.X, y: result.Y})
+			}
+		}
+		if elapsed := time.Since(t); elapsed < loopInterval {
+			time.Sleep(loopInterval - elapsed)
+		}
+	}
+	ca.SetPlayerMovement(control.MovementStop, control.PolicyDefault)
+	ca.SetPlayerDirection(control.DirectionF)
+
+	if len(samples) < CALIBRATE_MIN_SAMPLES {
+		return 0, fmt.Errorf("only %d confident locations sampled", len(samples))
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	dt := last.t.Sub(first.t).Seconds()
+	if dt <= 0 {
+		return 0, fmt.Errorf("sampling time span is empty")
+	}
+	speed := math.Hypot(last.x-first.x, last.y-first.y) / dt
+	if speed < CALIBRATE_MIN_SPEED {
+		return 0, fmt.Errorf("player barely moved (%.2f px/s), calibration requires an open area", speed)
+	}
+	return speed, nil
+}
+
+func (a *MapTrackerCalibrate) parseParam(paramStr string) (*MapTrackerCalibrateParam, error) {
+	var param MapTrackerCalibrateParam
+	if err := json.Unmarshal([]byte(paramStr), &param); err != nil {
+		return nil, fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	if param.MapName == "" {
+		return nil, fmt.Errorf("map_name is required, got empty")
+	}
+	if param.MeasureDuration == 0 {
+		param.MeasureDuration = mapTrackerCalibrateDefaultParam.MeasureDuration
+	} else if param.MeasureDuration < 1000 {
+		return nil, fmt.Errorf("measure_duration must be at least 1000 ms")
+	}
+	if param.CameraDelta == 0 {
+		param.CameraDelta = mapTrackerCalibrateDefaultParam.CameraDelta
+	} else if param.CameraDelta < 20 || param.CameraDelta > 360 {
+		return nil, fmt.Errorf("camera_delta must be between 20 and 360 pixels")
+	}
+	if param.MapNameMatchRule == "" {
+		param.MapNameMatchRule = mapTrackerMoveDefaultParam.MapNameMatchRule
+	}
+	mapNameRegex := buildMapNameRegex(param.MapNameMatchRule, param.MapName)
+	if _, err := regexp.Compile(mapNameRegex); err != nil {
+		return nil, fmt.Errorf("map_name_match_rule produced invalid regex %q: %w", mapNameRegex, err)
+	}
+	return &param, nil
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/captureuid"
+	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/i18n"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/maafocus"
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// MapTrackerCollectRoute visits every NavMesh vertex matching the given flags along a planned tour.
+type MapTrackerCollectRoute struct{}
+
+var _ maa.CustomActionRunner = &MapTrackerCollectRoute{}
+
+const collectRouteDefaultFlags = "C"
+
+// MapTrackerCollectRouteParam represents the custom_action_param for MapTrackerCollectRoute.
+type MapTrackerCollectRouteParam struct {
+	MapTrackerMoveParam
+	// Flags are the vertex flag letters a stop must carry, e.g. "C" for collectables or "RC" for rare collectables.
+	Flags string `json:"flags,omitempty"`
+	// Region limits the stops to the [x, y, w, h] rectangle of the map.
+	Region *[4]float64 `json:"region,omitempty"`
+	// MaxStops limits the number of stops visited in one run. Zero means unlimited.
+	MaxStops int `json:"max_stops,omitempty"`
+	// OnArrive is an inline pipeline node object executed at each stop.
+	OnArrive map[string]any `json:"on_arrive,omitempty"`
+	// NoSkipCollected visits stops again even if their entities are still on harvest cooldown.
+	NoSkipCollected bool `json:"no_skip_collected,omitempty"`
+	// RespawnInterval is the respawn interval of the collected entities in seconds. Zero means they respawn at the next game day.
+	RespawnInterval int64 `json:"respawn_interval,omitempty"`
+	// ZiplinePolicy is passed to MapTrackerGoal when moving between stops.
+	ZiplinePolicy string `json:"zipline_policy,omitempty"`
+}
+
+// Run implements maa.CustomActionRunner.
+func (a *MapTrackerCollectRoute) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
+	param, err := a.parseParam(arg.CustomActionParam)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to parse parameters for MapTrackerCollectRoute")
+		return false
+	}
+	flagMask, _ := internal.ParseNavMeshVertexFlags(param.Flags)
+
+	ctrl := ctx.GetTasker().GetController()
+	inferParam := &MapTrackerMoveParam{MapName: param.MapName, MapNameMatchRule: param.MapNameMatchRule}
+	if inferParam.MapNameMatchRule == "" {
+		inferParam.MapNameMatchRule = mapTrackerMoveDefaultParam.MapNameMatchRule
+	}
+	current, err := doInfer(ctx, ctrl, inferParam)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to infer current location for MapTrackerCollectRoute")
+		return false
+	}
+	if !isMapNameCoreMatch(current.MapName, param.MapName) {
+		log.Error().Str("current", current.MapName).Str("expected", param.MapName).Msg("Current map does not match MapTrackerCollectRoute map")
+		return false
+	}
+
+	mapName := getMapCoreName(param.MapName)
+	mesh, err := internal.LoadNavMesh(mapName)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load NavMesh for MapTrackerCollectRoute")
+		return false
+	}
+
+	store, err := openHarvestStore(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to open harvest store, collected entities will not be remembered")
+	}
+	collected := map[int64]bool{}
+	if !param.NoSkipCollected {
+		collected = store.CooldownEntityIDs(mapName, time.Now())
+	}
+
+	stopIDs := make([]int, 0)
+	skipped := 0
+	for _, vertex := range mesh.SelectVertices(flagMask, param.Region) {
+		if vertex.EntityID > 0 && collected[vertex.EntityID] {
+			skipped++
+			continue
+		}
+		stopIDs = append(stopIDs, vertex.ID)
+	}
+
+	tour, unreachable, err := mesh.PlanVisitTour([2]float64{current.X, current.Y}, stopIDs)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to plan MapTrackerCollectRoute tour")
+		return false
+	}
+	if param.MaxStops > 0 && len(tour) > param.MaxStops {
+		tour = tour[:param.MaxStops]
+	}
+	log.Info().
+		Str("map", mapName).
+		Str("flags", param.Flags).
+		Int("stopCount", len(tour)).
+		Int("skippedCount", skipped).
+		Ints("unreachable", unreachable).
+		Msg("MapTrackerCollectRoute tour planned")
+	if len(tour) == 0 {
+		if !param.NoPrint {
+			maafocus.Print(ctx, i18n.T("maptracker.collect_route_empty", skipped))
+		}
+		return true
+	}
+
+	failed := 0
+	for i, id := range tour {
+		if ctx.GetTasker().Stopping() {
+			log.Warn().Msg("Task is stopping, exiting collect route")
+			return false
+		}
+		vertex := mesh.Vertices[id]
+		if !param.NoPrint {
+			maafocus.Print(ctx, i18n.T("maptracker.collect_route_stop", i+1, len(tour), vertex.X, vertex.Y))
+		}
+		if !a.runStop(ctx, arg, param, vertex) {
+			failed++
+			log.Warn().Int("vertex", id).Int64("entity", vertex.EntityID).Msg("Failed to collect at stop, skipping it")
+			continue
+		}
+		if vertex.EntityID > 0 && store != nil {
+			if err := store.Record(mapName, vertex.EntityID, time.Now(), time.Duration(param.RespawnInterval)*time.Second); err != nil {
+				log.Warn().Err(err).Int64("entity", vertex.EntityID).Msg("Failed to persist collected entity")
+			}
+		}
+	}
+
+	log.Info().Int("stopCount", len(tour)).Int("failedCount", failed).Msg("MapTrackerCollectRoute finished")
+	return failed < len(tour)
+}
+
+// runStop navigates to a stop through MapTrackerGoal and runs on_arrive there.
+func (a *MapTrackerCollectRoute) runStop(ctx *maa.Context, arg *maa.CustomActionArg, param *MapTrackerCollectRouteParam, vertex internal.NavMeshVertex) bool {
+	goalParam := MapTrackerGoalParam{
+		MapTrackerMoveParam: param.MapTrackerMoveParam,
+		Target:              &[2]float64{vertex.X, vertex.Y},
+		ZiplinePolicy:       param.ZiplinePolicy,
+		TeleportPolicy:      TELEPORT_POLICY_NEVER,
+	}
+	goalParam.OnFinish = param.OnArrive
+	goalParam.NoPrint = true
+	paramBytes, err := json.Marshal(goalParam)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal MapTrackerGoal parameters for MapTrackerCollectRoute")
+		return false
+	}
+	return (&MapTrackerGoal{}).Run(ctx, &maa.CustomActionArg{
+		TaskID:            arg.TaskID,
+		CurrentTaskName:   arg.CurrentTaskName,
+		CustomActionName:  "MapTrackerGoal",
+		CustomActionParam: string(paramBytes),
+		RecognitionDetail: arg.RecognitionDetail,
+		Box:               arg.Box,
+	})
+}
+
+func (a *MapTrackerCollectRoute) parseParam(paramStr string) (*MapTrackerCollectRouteParam, error) {
+	var param MapTrackerCollectRouteParam
+	if err := json.Unmarshal([]byte(paramStr), &param); err != nil {
+		return nil, fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	if param.MapName == "" {
+		return nil, fmt.Errorf("map_name is required in parameters, got empty")
+	}
+	if param.Flags == "" {
+		param.Flags = collectRouteDefaultFlags
+	}
+	if _, err := internal.ParseNavMeshVertexFlags(param.Flags); err != nil {
+		return nil, fmt.Errorf("invalid flags: %w", err)
+	}
+	if param.Region != nil {
+		for _, value := range param.Region {
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				return nil, fmt.Errorf("region contains invalid value")
+			}
+		}
+		if param.Region[2] <= 0 || param.Region[3] <= 0 {
+			return nil, fmt.Errorf("region width and height must be positive")
+		}
+	}
+	if param.MaxStops < 0 {
+		return nil, fmt.Errorf("max_stops must be non-negative")
+	}
+	if param.RespawnInterval < 0 {
+		return nil, fmt.Errorf("respawn_interval must be non-negative")
+	}
+	if param.ZiplinePolicy == "" {
+		param.ZiplinePolicy = ZIPLINE_POLICY_NEVER
+	}
+	if _, ok := mapTrackerGoalZiplinePolicies[param.ZiplinePolicy]; !ok {
+		return nil, fmt.Errorf("zipline_policy must be one of %q, %q, %q, %q", ZIPLINE_POLICY_NEVER, ZIPLINE_POLICY_LAZY, ZIPLINE_POLICY_ACTIVE, ZIPLINE_POLICY_AGGRESSIVE)
+	}
+	return &param, nil
+}
+
+// openHarvestStore opens the harvest store of the current player, keyed by the hashed UID.
+func openHarvestStore(ctx *maa.Context) (*internal.HarvestStore, error) {
+	uid, err := captureuid.Capture(ctx, ctx.GetTasker().GetController(), true, true, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture uid: %w", err)
+	}
+	return internal.OpenHarvestStore(internal.HARVEST_RECORD_PATH, uid)
+}
@@ -1,6 +1,8 @@
 // Copyright (c) 2026 Harry Huang
 package maptrackerdefault
 
+import "github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+
 const (
 	WORK_W = 1280
 	WORK_H = 720
@@ -10,8 +12,10 @@ const (
 const (
 	INFER_INTERVAL_MS      = 100
 	ROTATION_MAX_SPEED     = 4.0
-	ROTATION_DEFAULT_SPEED = 2.0
+	ROTATION_DEFAULT_SPEED = 1.0 / control.CAMERA_REFERENCE_DEGREES_PER_PIXEL // RotateCamera pixels per degree
 	ROTATION_MIN_SPEED     = 1.0
+	// MOVE_MIN_TRACK_CONF is the minimum tracking confidence required to adjust rotation.
+	MOVE_MIN_TRACK_CONF = 0.5
 )
 
 // Misc
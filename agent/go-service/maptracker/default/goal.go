@@ -8,9 +8,12 @@ import (
 	"math"
 	"time"
 
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/captureuid"
 	maptrackerbigmap "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/bigmap"
 	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/i18n"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/maafocus"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/minicv"
 	maa "github.com/MaaXYZ/maa-framework-go/v4"
 	"github.com/rs/zerolog/log"
@@ -32,6 +35,12 @@ const (
 	ZIPLINE_POLICY_ACTIVE     = "Active"
 	ZIPLINE_POLICY_AGGRESSIVE = "Aggressive"
 
+	TELEPORT_POLICY_NEVER  = "Never"
+	TELEPORT_POLICY_AUTO   = "Auto"
+	TELEPORT_POLICY_ALWAYS = "Always"
+
+	TELEPORT_DEFAULT_OVERHEAD = 20.0
+
 	ZIPLINE_EXPECTED_DISTANCE = 9.0
 	ZIPLINE_MAX_REPLAN        = 16
 	ZIPLINE_FIRST_EDGE_ID     = -1000000
@@ -49,6 +58,27 @@ type MapTrackerGoalParam struct {
 	Target        *[2]float64 `json:"target,omitempty"`
 	EntityID      *int64      `json:"entity_id,omitempty"`
 	ZiplinePolicy string      `json:"zipline_policy,omitempty"`
+	// Landmark is the ID, localized name or alias of a landmark in the landmark registry, used as the target.
+	// If set, map_name is taken from the landmark, and map_name, if also given, narrows the lookup.
+	Landmark string `json:"landmark,omitempty"`
+	// RefreshZiplines rediscovers ziplines on the big map instead of reusing the zipline cache.
+	RefreshZiplines bool `json:"refresh_ziplines,omitempty"`
+	// UseIconInventory plans with the ziplines of the icon inventory saved by MapTrackerBigMapScanIcons, if any.
+	UseIconInventory bool `json:"use_icon_inventory,omitempty"`
+	// TeleportPolicy controls whether to teleport to a teleport anchor near the target instead of walking the whole way.
+	TeleportPolicy string `json:"teleport_policy,omitempty"`
+	// TeleportOverhead is the estimated time cost of a big-map teleport in seconds, including loading.
+	TeleportOverhead *float64 `json:"teleport_overhead,omitempty"`
+	// CrossMap allows planning a multi-leg route through teleports when the player is on another map.
+	CrossMap bool `json:"cross_map,omitempty"`
+	// SkipHarvested succeeds without moving when the entity_id target is still on harvest cooldown.
+	SkipHarvested bool `json:"skip_harvested,omitempty"`
+	// RecordHarvest records the entity_id target as harvested after the goal succeeds.
+	RecordHarvest bool `json:"record_harvest,omitempty"`
+	// RespawnInterval is the respawn interval of the recorded entity in seconds. Zero means it respawns at the next game day.
+	RespawnInterval int64 `json:"respawn_interval,omitempty"`
+	// ExportStuckSpots records the NavMesh edges where the player got stuck, so that the NavMesh data can be fixed.
+	ExportStuckSpots bool `json:"export_stuck_spots,omitempty"`
 }
 
 type goalContext struct {
@@ -58,6 +88,16 @@ type goalContext struct {
 	ctrl   *maa.Controller
 	mesh   *internal.NavMesh
 	target [2]float64
+	// blockedEdge is the edge disabled by the last fatal stuck event, if any.
+	blockedEdge *internal.NavMeshEdge
+	// ziplineCache is the zipline cache of the current player, or nil if unavailable.
+	ziplineCache *internal.ZiplineCache
+	// ziplineIDs are the runtime zipline vertices, and ziplineMustSeePoints are the points the big-map discovery must show.
+	ziplineIDs           []int
+	ziplineMustSeePoints [][2]int
+	// ziplinesFromCache reports whether the runtime ziplines were loaded from the cache or the icon inventory
+	// without revalidation.
+	ziplinesFromCache bool
 }
 
 type ziplinePolicy struct {
@@ -108,6 +148,42 @@ func (a *MapTrackerGoal) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 		return false
 	}
 
+	var store *internal.HarvestStore
+	if param.EntityID != nil && (param.SkipHarvested || param.RecordHarvest) {
+		if store, err = openHarvestStore(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to open harvest store for MapTrackerGoal")
+		}
+	}
+	if isGoalHarvestOnCooldown(param, store, time.Now()) {
+		log.Info().Int64("entity", *param.EntityID).Msg("MapTrackerGoal target is on harvest cooldown, skipping")
+		if !param.NoPrint {
+			maafocus.Print(ctx, i18n.T("maptracker.goal_harvested", *param.EntityID))
+		}
+		return true
+	}
+
+	handled, success := false, false
+	if param.CrossMap {
+		handled, success = a.runCrossMapGoal(ctx, arg, param)
+	}
+	if !handled {
+		success = a.runSingleMapGoal(ctx, arg, param)
+	}
+
+	if success && param.RecordHarvest && store != nil {
+		if err := store.Record(param.MapName, *param.EntityID, time.Now(), time.Duration(param.RespawnInterval)*time.Second); err != nil {
+			log.Warn().Err(err).Int64("entity", *param.EntityID).Msg("Failed to record harvested entity for MapTrackerGoal")
+		}
+	}
+	return success
+}
+
+// isGoalHarvestOnCooldown reports whether skip_harvested applies and the entity_id target has not respawned yet.
+func isGoalHarvestOnCooldown(param *MapTrackerGoalParam, store *internal.HarvestStore, now time.Time) bool {
+	return param.SkipHarvested && param.EntityID != nil && store.IsOnCooldown(param.MapName, *param.EntityID, now)
+}
+
+func (a *MapTrackerGoal) runSingleMapGoal(ctx *maa.Context, arg *maa.CustomActionArg, param *MapTrackerGoalParam) bool {
 	ctrl := ctx.GetTasker().GetController()
 	inferResult, mesh, target, err := a.prepare(ctx, ctrl, param)
 	if err != nil {
@@ -116,6 +192,14 @@ func (a *MapTrackerGoal) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 	}
 
 	goalCtx := &goalContext{ctx: ctx, arg: arg, param: param, ctrl: ctrl, mesh: mesh, target: target}
+	if param.TeleportPolicy != TELEPORT_POLICY_NEVER && a.runTeleportShortcut(goalCtx, inferResult) {
+		inferResult, mesh, target, err = a.prepare(ctx, ctrl, param)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to prepare MapTrackerGoal after teleport")
+			return false
+		}
+		goalCtx = &goalContext{ctx: ctx, arg: arg, param: param, ctrl: ctrl, mesh: mesh, target: target}
+	}
 	if mapTrackerGoalZiplinePolicies[param.ZiplinePolicy].MinNeedZiplineDistance >= 0 {
 		if a.runZiplineGoal(goalCtx, inferResult) {
 			return true
@@ -137,11 +221,21 @@ func (a *MapTrackerGoal) parseParam(paramStr string) (*MapTrackerGoalParam, erro
 	if err := json.Unmarshal([]byte(paramStr), &param); err != nil {
 		return nil, fmt.Errorf("failed to parse parameters: %w", err)
 	}
+	if param.Landmark != "" {
+		landmark, err := internal.FindLandmark(param.Landmark, param.MapName, i18n.Lang())
+		if err != nil {
+			return nil, err
+		}
+		log.Info().Str("query", param.Landmark).Str("landmark", landmark.ID).Str("map", landmark.MapName).Msg("Landmark resolved for MapTrackerGoal")
+		target := landmark.Target
+		param.MapName = landmark.MapName
+		param.Target = &target
+	}
 	if param.MapName == "" {
 		return nil, fmt.Errorf("map_name is required in parameters, got empty")
 	}
 	if param.Target == nil && param.EntityID == nil {
-		return nil, fmt.Errorf("target or entity_id is required in parameters")
+		return nil, fmt.Errorf("target, entity_id or landmark is required in parameters")
 	}
 	if param.Target == nil && param.EntityID != nil && *param.EntityID <= 0 {
 		return nil, fmt.Errorf("entity_id must be positive")
@@ -157,6 +251,21 @@ func (a *MapTrackerGoal) parseParam(paramStr string) (*MapTrackerGoalParam, erro
 	if _, ok := mapTrackerGoalZiplinePolicies[param.ZiplinePolicy]; !ok {
 		return nil, fmt.Errorf("zipline_policy must be one of %q, %q, %q, %q", ZIPLINE_POLICY_NEVER, ZIPLINE_POLICY_LAZY, ZIPLINE_POLICY_ACTIVE, ZIPLINE_POLICY_AGGRESSIVE)
 	}
+	if param.TeleportPolicy == "" {
+		param.TeleportPolicy = TELEPORT_POLICY_NEVER
+	}
+	if param.TeleportPolicy != TELEPORT_POLICY_NEVER && param.TeleportPolicy != TELEPORT_POLICY_AUTO && param.TeleportPolicy != TELEPORT_POLICY_ALWAYS {
+		return nil, fmt.Errorf("teleport_policy must be one of %q, %q, %q", TELEPORT_POLICY_NEVER, TELEPORT_POLICY_AUTO, TELEPORT_POLICY_ALWAYS)
+	}
+	if param.RespawnInterval < 0 {
+		return nil, fmt.Errorf("respawn_interval must be non-negative")
+	}
+	if param.TeleportOverhead == nil {
+		overhead := TELEPORT_DEFAULT_OVERHEAD
+		param.TeleportOverhead = &overhead
+	} else if *param.TeleportOverhead < 0 || math.IsNaN(*param.TeleportOverhead) || math.IsInf(*param.TeleportOverhead, 0) {
+		return nil, fmt.Errorf("teleport_overhead must be a non-negative number")
+	}
 	return &param, nil
 }
 
@@ -177,10 +286,11 @@ func (a *MapTrackerGoal) prepare(ctx *maa.Context, ctrl *maa.Controller, param *
 		return nil, nil, [2]float64{}, fmt.Errorf("current map %q does not match target map %q", inferResult.MapName, param.MapName)
 	}
 
-	mesh, err := internal.LoadNavMesh(param.MapName)
+	mesh, err := internal.LoadNavMesh(getMapCoreName(param.MapName))
 	if err != nil {
 		return nil, nil, [2]float64{}, fmt.Errorf("failed to load NavMesh for MapTrackerGoal: %w", err)
 	}
+	applyLearnedStuckEdges(mesh, param.MapName, time.Now())
 
 	target, err := a.resolveTarget(mesh, param)
 	if err != nil {
@@ -201,26 +311,33 @@ func (a *MapTrackerGoal) resolveTarget(mesh *internal.NavMesh, param *MapTracker
 }
 
 func (a *MapTrackerGoal) runOrdinaryGoal(goalCtx *goalContext, inferResult *MapTrackerInferResult) bool {
-	mesh := goalCtx.mesh
-	mesh.ClearTemporaryVertex()
-	defer mesh.ClearTemporaryVertex()
-	startID, _ := mesh.AddTemporaryVertex(inferResult.X, inferResult.Y, startPointCostFactor, startPointMCD)
-	targetID, _ := mesh.AddTemporaryVertex(goalCtx.target[0], goalCtx.target[1], endPointCostFactor, endPointMCD)
-	path, err := mesh.FindPath(startID, targetID)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to find NavMesh path for MapTrackerGoal")
-		return false
-	}
-
-	log.Info().Str("map", goalCtx.param.MapName).
-		Float64("startX", inferResult.X).
-		Float64("startY", inferResult.Y).
-		Float64("targetX", goalCtx.target[0]).
-		Float64("targetY", goalCtx.target[1]).
-		Int("pathCount", len(path)).
-		Msg("MapTrackerGoal path generated")
+	current := inferResult
+	for replan := 0; ; replan++ {
+		path, err := a.findOrdinaryPathFromLocation(goalCtx, current.X, current.Y)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to find NavMesh path for MapTrackerGoal")
+			return false
+		}
 
-	return a.runFinalGoalMove(goalCtx, path)
+		log.Info().Str("map", goalCtx.param.MapName).
+			Float64("startX", current.X).
+			Float64("startY", current.Y).
+			Float64("targetX", goalCtx.target[0]).
+			Float64("targetY", goalCtx.target[1]).
+			Int("pathCount", len(path)).
+			Int("replan", replan).
+			Msg("MapTrackerGoal path generated")
+
+		goalCtx.blockedEdge = nil
+		if a.runFinalGoalMove(goalCtx, path) {
+			return true
+		}
+		if goalCtx.blockedEdge == nil || replan >= STUCK_MAX_REPLAN {
+			return false
+		}
+		log.Warn().Int("edge", goalCtx.blockedEdge.ID).Int("replan", replan).Msg("Replanning MapTrackerGoal around stuck edge")
+		current = a.inferOrFallback(goalCtx, current)
+	}
 }
 
 func (a *MapTrackerGoal) runZiplineGoal(goalCtx *goalContext, inferResult *MapTrackerInferResult) bool {
@@ -242,7 +359,13 @@ func (a *MapTrackerGoal) runZiplineGoal(goalCtx *goalContext, inferResult *MapTr
 		mustSeePoints = pathToMustSeePoints(ordinaryPath)
 	}
 
-	ziplineIDs, err := a.loadRuntimeZiplines(goalCtx, mustSeePoints)
+	if cache, err := openZiplineCache(goalCtx.ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to open zipline cache for MapTrackerGoal")
+	} else {
+		goalCtx.ziplineCache = cache
+	}
+	goalCtx.ziplineMustSeePoints = mustSeePoints
+	ziplineIDs, err := a.loadRuntimeZiplines(goalCtx)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to load runtime ziplines")
 		return false
@@ -251,6 +374,7 @@ func (a *MapTrackerGoal) runZiplineGoal(goalCtx *goalContext, inferResult *MapTr
 		log.Warn().Msg("No runtime ziplines found")
 		return false
 	}
+	goalCtx.ziplineIDs = ziplineIDs
 
 	current := inferResult
 	onZipline := false
@@ -266,7 +390,7 @@ func (a *MapTrackerGoal) runZiplineGoal(goalCtx *goalContext, inferResult *MapTr
 			log.Warn().Err(err).Int("replan", replan).Msg("Zipline-aware path search failed")
 			return false
 		}
-		a.saveDebugImage(goalCtx, pathIDs, path, ziplineIDs, current, replan)
+		a.saveDebugImage(goalCtx, pathIDs, path, goalCtx.ziplineIDs, current, replan)
 
 		if len(pathIDs) < 2 {
 			log.Warn().Int("pathLen", len(pathIDs)).Msg("Zipline-aware path is too short")
@@ -288,6 +412,12 @@ func (a *MapTrackerGoal) runZiplineGoal(goalCtx *goalContext, inferResult *MapTr
 
 		destPoint, _ := goalCtx.mesh.VertexPoint(destID)
 		if !a.runZipline(goalCtx, destPoint) {
+			sourcePoint, _ := goalCtx.mesh.VertexPoint(sourceID)
+			if a.revalidateZiplines(goalCtx, sourcePoint, destPoint) {
+				log.Warn().Int("source", sourceID).Int("destination", destID).Msg("Zipline fast travel failed, ziplines revalidated")
+				current = a.inferOrFallback(goalCtx, current)
+				continue
+			}
 			if edge, ok := goalCtx.mesh.IsZiplineEdge(sourceID, destID); ok {
 				goalCtx.mesh.DisableEdge(edge.ID)
 			}
@@ -366,12 +496,16 @@ func (a *MapTrackerGoal) approachAndMountZipline(
 	if !*onZipline {
 		detail, err := goalCtx.ctx.RunTask("MapTrackerOpenWorld_GetOnZipline")
 		if err != nil || detail == nil || !detail.Status.Success() {
-			goalCtx.mesh.DisableVertex(sourceID)
 			event := log.Warn().Err(err).Int("vertex", sourceID)
 			if detail != nil {
 				event = event.Int64("subtaskID", detail.ID).Str("subtaskStatus", detail.Status.String())
 			}
-			event.Msg("Cannot get on zipline, disabling source point")
+			if a.revalidateZiplines(goalCtx, sourcePoint) {
+				event.Msg("Cannot get on zipline, ziplines revalidated")
+			} else {
+				goalCtx.mesh.DisableVertex(sourceID)
+				event.Msg("Cannot get on zipline, disabling source point")
+			}
 			*current = a.inferOrFallback(goalCtx, *current)
 			return false
 		}
@@ -471,7 +605,7 @@ func (a *MapTrackerGoal) runFinalGoalMove(goalCtx *goalContext, path [][2]float6
 }
 
 func (a *MapTrackerGoal) runMoveToZiplineSource(goalCtx *goalContext, pathIDs []int, ziplineIndex int, sourceID int) bool {
-	movePath, err := goalCtx.mesh.PathIDsToPoints(pathIDs[:ziplineIndex+1])
+	movePath, err := goalCtx.mesh.PathIDsToPoints(goalCtx.mesh.SmoothPathIDs(pathIDs[:ziplineIndex+1]))
 	if err != nil {
 		log.Warn().Err(err).Int("vertex", sourceID).Msg("Failed to convert path to zipline point")
 		return false
@@ -497,7 +631,8 @@ func (a *MapTrackerGoal) runMoveWithParam(goalCtx *goalContext, moveParam MapTra
 		log.Error().Err(err).Msg("Failed to marshal MapTrackerMove parameters for MapTrackerGoal")
 		return false
 	}
-	return (&MapTrackerMove{}).Run(goalCtx.ctx, &maa.CustomActionArg{
+	move := &MapTrackerMove{onStuck: func(event moveStuckEvent) { a.learnStuck(goalCtx, event) }}
+	return move.Run(goalCtx.ctx, &maa.CustomActionArg{
 		TaskID:            goalCtx.arg.TaskID,
 		CurrentTaskName:   goalCtx.arg.CurrentTaskName,
 		CustomActionName:  "MapTrackerMove",
@@ -507,6 +642,25 @@ func (a *MapTrackerGoal) runMoveWithParam(goalCtx *goalContext, moveParam MapTra
 	})
 }
 
+// learnStuck learns an obstacle from a stuck event reported by MapTrackerMove.
+func (a *MapTrackerGoal) learnStuck(goalCtx *goalContext, event moveStuckEvent) {
+	edge, ok := learnStuckEdge(goalCtx.mesh, event, time.Now())
+	if !ok {
+		return
+	}
+	if event.Fatal {
+		goalCtx.blockedEdge = &edge
+	}
+	if goalCtx.param.ExportStuckSpots && edge.ID > 0 {
+		spot, err := internal.RecordStuckSpot(internal.STUCK_SPOT_RECORD_PATH, event.MapName, edge, event.Location, time.Now())
+		if err != nil {
+			log.Warn().Err(err).Int("edge", edge.ID).Msg("Failed to export stuck spot")
+			return
+		}
+		log.Info().Int("edge", edge.ID).Int("count", spot.Count).Msg("Stuck spot exported")
+	}
+}
+
 func (a *MapTrackerGoal) inferAndGetOffZipline(goalCtx *goalContext, fallback *MapTrackerInferResult) *MapTrackerInferResult {
 	result := a.inferOrFallback(goalCtx, fallback)
 	if _, err := goalCtx.ctx.RunTask("MapTrackerOpenWorld_GetOffZipline"); err != nil {
@@ -528,7 +682,38 @@ func (a *MapTrackerGoal) inferOrFallback(goalCtx *goalContext, fallback *MapTrac
 	return result
 }
 
-func (a *MapTrackerGoal) loadRuntimeZiplines(goalCtx *goalContext, mustSeePoints [][2]int) ([]int, error) {
+// loadRuntimeZiplines adds the ziplines around the must-see points as runtime vertices.
+// The icon inventory is used when requested, and cached ziplines are reused when the cache covers the must-see
+// points; otherwise they are discovered on the big map.
+func (a *MapTrackerGoal) loadRuntimeZiplines(goalCtx *goalContext) ([]int, error) {
+	if goalCtx.param.UseIconInventory && !goalCtx.param.RefreshZiplines {
+		ids, err := addInventoryZiplines(goalCtx, internal.IconInventoryPath(goalCtx.param.MapName))
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to load icon inventory, falling back to the zipline cache")
+		} else if len(ids) > 0 {
+			goalCtx.ziplinesFromCache = true
+			log.Info().Int("ziplineCount", len(ids)).Int("runtimeEdges", len(goalCtx.mesh.RuntimeEdges)).Msg("Runtime ziplines loaded from icon inventory")
+			return ids, nil
+		}
+	}
+	if !goalCtx.param.RefreshZiplines {
+		cached := goalCtx.ziplineCache.Ziplines(goalCtx.param.MapName)
+		if cachedZiplinesCover(cached, goalCtx.ziplineMustSeePoints) {
+			points := make([][2]float64, 0, len(cached))
+			for _, zipline := range cached {
+				points = append(points, [2]float64{zipline.X, zipline.Y})
+			}
+			ids := a.addRuntimeZiplines(goalCtx, points)
+			goalCtx.ziplinesFromCache = true
+			log.Info().Int("ziplineCount", len(ids)).Int("runtimeEdges", len(goalCtx.mesh.RuntimeEdges)).Msg("Runtime ziplines loaded from cache")
+			return ids, nil
+		}
+	}
+	return a.discoverRuntimeZiplines(goalCtx)
+}
+
+// discoverRuntimeZiplines finds the ziplines on the big map, records them in the zipline cache and adds them as runtime vertices.
+func (a *MapTrackerGoal) discoverRuntimeZiplines(goalCtx *goalContext) ([]int, error) {
 	ca, err := control.NewControlAdaptor(goalCtx.ctx, goalCtx.ctrl, WORK_W, WORK_H)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create control adaptor: %w", err)
@@ -546,16 +731,52 @@ func (a *MapTrackerGoal) loadRuntimeZiplines(goalCtx *goalContext, mustSeePoints
 		return nil, fmt.Errorf("failed to filter ziplines on big map: %w", err)
 	}
 
-	matches, err := a.findBigMapZiplines(goalCtx, mustSeePoints)
+	matches, err := a.findBigMapZiplines(goalCtx, goalCtx.ziplineMustSeePoints)
 	if err != nil {
 		return nil, err
 	}
 
-	ids := make([]int, 0, len(matches))
+	points := make([][2]float64, 0, len(matches))
+	sightings := make([]internal.ZiplineSighting, 0, len(matches))
 	for _, match := range matches {
-		id, _ := goalCtx.mesh.AddRuntimeVertex(match.MapX, match.MapY, 0, 0, internal.NavMeshVertexFlagZipline)
+		points = append(points, [2]float64{match.MapX, match.MapY})
+		sightings = append(sightings, internal.ZiplineSighting{X: match.MapX, Y: match.MapY, Conf: match.Conf})
+	}
+	if goalCtx.ziplineCache != nil && len(sightings) > 0 {
+		if err := goalCtx.ziplineCache.Record(goalCtx.param.MapName, sightings, time.Now()); err != nil {
+			log.Warn().Err(err).Msg("Failed to record discovered ziplines")
+		}
+	}
+	ids := a.addRuntimeZiplines(goalCtx, points)
+	goalCtx.ziplinesFromCache = false
+	log.Info().Int("ziplineCount", len(ids)).Int("runtimeEdges", len(goalCtx.mesh.RuntimeEdges)).Msg("Runtime ziplines loaded")
+	return ids, nil
+}
+
+// addInventoryZiplines imports the ziplines of the icon inventory at path as runtime vertices.
+// A missing inventory imports nothing.
+func addInventoryZiplines(goalCtx *goalContext, path string) ([]int, error) {
+	inv, err := internal.LoadIconInventory(path)
+	if err != nil || inv == nil {
+		return nil, err
+	}
+	ids := inv.AddRuntimeVertices(goalCtx.mesh, internal.ICON_KIND_ZIPLINE)
+	connectGoalZiplines(goalCtx, ids)
+	return ids, nil
+}
+
+func (a *MapTrackerGoal) addRuntimeZiplines(goalCtx *goalContext, points [][2]float64) []int {
+	ids := make([]int, 0, len(points))
+	for _, point := range points {
+		id, _ := goalCtx.mesh.AddRuntimeVertex(point[0], point[1], 0, 0, internal.NavMeshVertexFlagZipline)
 		ids = append(ids, id)
 	}
+	connectGoalZiplines(goalCtx, ids)
+	return ids
+}
+
+// connectGoalZiplines connects the runtime zipline vertices with the edge cost factors of the goal's zipline policy.
+func connectGoalZiplines(goalCtx *goalContext, ids []int) {
 	policy := mapTrackerGoalZiplinePolicies[goalCtx.param.ZiplinePolicy]
 	factors := ziplineEdgeCostFactors{
 		ToVertex:      policy.ToZiplineEdgeCostFactor,
@@ -563,8 +784,30 @@ func (a *MapTrackerGoal) loadRuntimeZiplines(goalCtx *goalContext, mustSeePoints
 		BetweenVertex: policy.BetweenZiplineEdgeCostFactor,
 	}
 	connectRuntimeZiplines(goalCtx.mesh, ids, ZIPLINE_FIRST_EDGE_ID, ZIPLINE_EDGE_ID_OFFSET, factors)
-	log.Info().Int("ziplineCount", len(ids)).Int("runtimeEdges", len(goalCtx.mesh.RuntimeEdges)).Msg("Runtime ziplines loaded")
-	return ids, nil
+}
+
+// revalidateZiplines counts a failed travel against the cached ziplines at the given points and rediscovers the
+// ziplines on the big map, at most once per goal. Returns false if the ziplines were not loaded from the cache
+// or could not be rediscovered, in which case the caller should disable the failed vertex or edge instead.
+func (a *MapTrackerGoal) revalidateZiplines(goalCtx *goalContext, points ...[2]float64) bool {
+	if !goalCtx.ziplinesFromCache {
+		return false
+	}
+	goalCtx.ziplinesFromCache = false
+	if dropped, err := goalCtx.ziplineCache.RecordFailure(goalCtx.param.MapName, points...); err != nil {
+		log.Warn().Err(err).Msg("Failed to record zipline failure")
+	} else if dropped > 0 {
+		log.Info().Int("dropped", dropped).Msg("Dropped cached ziplines after repeated failures")
+	}
+
+	goalCtx.mesh.ClearRuntime()
+	ids, err := a.discoverRuntimeZiplines(goalCtx)
+	goalCtx.ziplineIDs = ids
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to rediscover ziplines on big map")
+		return false
+	}
+	return len(ids) > 0
 }
 
 func (a *MapTrackerGoal) findBigMapZiplines(goalCtx *goalContext, mustSeePoints [][2]int) ([]maptrackerbigmap.MapTrackerBigMapFindImageMatch, error) {
@@ -696,6 +939,37 @@ func pathToMustSeePoints(path [][2]float64) [][2]int {
 	return points
 }
 
+// cachedZiplinesCover returns whether every must-see point has a cached zipline within ziplineMaxDistance,
+// the farthest a zipline links to the next one. A cache holding only part of the route, such as one
+// recorded by a shorter goal nearby, is not trusted, so a route through an area without ziplines is
+// always rediscovered on the big map.
+func cachedZiplinesCover(cached []internal.CachedZipline, mustSeePoints [][2]int) bool {
+	if len(cached) == 0 || len(mustSeePoints) == 0 {
+		return false
+	}
+	for _, point := range mustSeePoints {
+		covered := false
+		for _, zipline := range cached {
+			if math.Hypot(zipline.X-float64(point[0]), zipline.Y-float64(point[1])) <= ziplineMaxDistance {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func openZiplineCache(ctx *maa.Context) (*internal.ZiplineCache, error) {
+	uid, err := captureuid.Capture(ctx, ctx.GetTasker().GetController(), true, true, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture uid: %w", err)
+	}
+	return internal.OpenZiplineCache(internal.ZIPLINE_CACHE_RECORD_PATH, uid)
+}
+
 func findFirstZiplineEdgeIndex(mesh *internal.NavMesh, pathIDs []int) int {
 	for i := 0; i+1 < len(pathIDs); i++ {
 		if _, ok := mesh.IsZiplineEdge(pathIDs[i], pathIDs[i+1]); ok {
@@ -0,0 +1,249 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	maptrackerbigmap "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/bigmap"
+	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/i18n"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/maafocus"
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// teleportEtaMovement is the movement state assumed when comparing walking with teleporting.
+var teleportEtaMovement = control.MovementRun
+
+const (
+	crossMapAnyMapMatchRule = "^.*$"
+	crossMapArrivalTimeout  = 30 * time.Second
+	crossMapArrivalInterval = 1000 * time.Millisecond
+)
+
+// runCrossMapGoal plans and executes a multi-leg route when the player is not on the target map.
+// It returns handled=false when the player is already on the target map, so the single-map flow should be used.
+func (a *MapTrackerGoal) runCrossMapGoal(ctx *maa.Context, arg *maa.CustomActionArg, param *MapTrackerGoalParam) (handled bool, success bool) {
+	ctrl := ctx.GetTasker().GetController()
+	current, err := doInfer(ctx, ctrl, &MapTrackerMoveParam{MapNameMatchRule: crossMapAnyMapMatchRule})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to infer current location for cross-map MapTrackerGoal")
+		return true, false
+	}
+	if isMapNameCoreMatch(current.MapName, param.MapName) {
+		return false, false
+	}
+
+	movement := control.LoadControllerCalibration(ctrl).Calibrate(teleportEtaMovement)
+	world, err := internal.LoadWorldGraph(movement.DistanceDuring(teleportOverhead(param)))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load world graph for cross-map MapTrackerGoal")
+		return true, false
+	}
+	target, err := a.resolveTarget(world.Meshes[getMapCoreName(param.MapName)], param)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to resolve cross-map MapTrackerGoal target")
+		return true, false
+	}
+	legs, err := world.PlanRoute(current.MapName, [2]float64{current.X, current.Y}, param.MapName, target)
+	if err != nil {
+		log.Error().Err(err).Str("from", current.MapName).Str("to", param.MapName).Msg("Failed to plan cross-map route")
+		return true, false
+	}
+	log.Info().
+		Str("from", current.MapName).
+		Str("to", param.MapName).
+		Int("legCount", len(legs)).
+		Msg("MapTrackerGoal cross-map route planned")
+
+	currentMap := current.MapName
+	for i, leg := range legs {
+		if ctx.GetTasker().Stopping() {
+			log.Warn().Msg("Task is stopping, exiting cross-map goal")
+			return true, false
+		}
+		isFinalLeg := i == len(legs)-1
+		if isFinalLeg && leg.Kind == internal.WORLD_LEG_MOVE {
+			// The final move is done by the single-map flow below, which also honors the original target and on_finish.
+			break
+		}
+
+		log.Info().
+			Int("leg", i+1).
+			Int("legCount", len(legs)).
+			Str("kind", leg.Kind).
+			Str("map", leg.MapName).
+			Float64("targetX", leg.To[0]).
+			Float64("targetY", leg.To[1]).
+			Msg("Running cross-map route leg")
+		if !param.NoPrint {
+			maafocus.Print(ctx, i18n.T("maptracker.goal_leg", i+1, len(legs), i18n.T(crossMapLegLabelKey(leg.Kind)), leg.MapName))
+		}
+
+		var ok bool
+		switch leg.Kind {
+		case internal.WORLD_LEG_MOVE:
+			ok = a.runCrossMapMoveLeg(ctx, arg, param, leg)
+		case internal.WORLD_LEG_TELEPORT:
+			ok = a.runTeleport(ctx, arg, currentMap, leg.MapName, leg.To)
+		default:
+			log.Error().Str("kind", leg.Kind).Msg("Unknown cross-map route leg kind")
+		}
+		if !ok {
+			log.Error().Int("leg", i+1).Str("kind", leg.Kind).Str("map", leg.MapName).Msg("Cross-map route leg failed")
+			return true, false
+		}
+		currentMap = leg.MapName
+	}
+
+	if !param.NoPrint {
+		maafocus.Print(ctx, i18n.T("maptracker.goal_leg", len(legs), len(legs), i18n.T(crossMapLegLabelKey(internal.WORLD_LEG_MOVE)), param.MapName))
+	}
+	// Teleports have already been considered by the route planner.
+	finalParam := *param
+	finalParam.TeleportPolicy = TELEPORT_POLICY_NEVER
+	return true, a.runSingleMapGoal(ctx, arg, &finalParam)
+}
+
+func (a *MapTrackerGoal) runCrossMapMoveLeg(ctx *maa.Context, arg *maa.CustomActionArg, param *MapTrackerGoalParam, leg internal.WorldLeg) bool {
+	legParam := *param
+	legParam.MapName = leg.MapName
+	legParam.Target = &leg.To
+	legParam.EntityID = nil
+	legParam.CrossMap = false
+	legParam.TeleportPolicy = TELEPORT_POLICY_NEVER
+	// Strip on_finish so it never fires on intermediate legs of a cross-map route.
+	legParam.OnFinish = nil
+	return a.runSingleMapGoal(ctx, arg, &legParam)
+}
+
+// runTeleportShortcut teleports to the teleport anchor nearest to the target when the teleport policy favors it.
+// It returns true only when the teleport was performed.
+func (a *MapTrackerGoal) runTeleportShortcut(goalCtx *goalContext, inferResult *MapTrackerInferResult) bool {
+	anchor, anchorDistance, err := goalCtx.mesh.FindNearestTeleportAnchor(goalCtx.target)
+	if err != nil {
+		log.Debug().Err(err).Msg("No teleport anchor available for MapTrackerGoal")
+		return false
+	}
+	walkDistance := math.Inf(1)
+	if path, err := a.findOrdinaryPathFromLocation(goalCtx, inferResult.X, inferResult.Y); err == nil {
+		walkDistance = internal.PathTotalDistance(path)
+	} else {
+		log.Warn().Err(err).Msg("Failed to find walking path, considering teleport only")
+	}
+	if anchorDistance >= walkDistance {
+		log.Debug().
+			Float64("walkDistance", walkDistance).
+			Float64("anchorDistance", anchorDistance).
+			Msg("Current location is closer to the target than any teleport anchor")
+		return false
+	}
+
+	movement := control.LoadControllerCalibration(goalCtx.ctrl).Calibrate(teleportEtaMovement)
+	walkEta := time.Duration(math.MaxInt64)
+	if !math.IsInf(walkDistance, 1) {
+		walkEta = movement.EtaOfDistance(walkDistance)
+	}
+	teleportEta := teleportOverhead(goalCtx.param) + movement.EtaOfDistance(anchorDistance)
+	shouldTeleport := goalCtx.param.TeleportPolicy == TELEPORT_POLICY_ALWAYS || teleportEta < walkEta
+	log.Info().
+		Str("policy", goalCtx.param.TeleportPolicy).
+		Int("anchor", anchor.ID).
+		Float64("walkDistance", walkDistance).
+		Float64("anchorDistance", anchorDistance).
+		Dur("walkEta", walkEta).
+		Dur("teleportEta", teleportEta).
+		Bool("teleport", shouldTeleport).
+		Msg("MapTrackerGoal teleport evaluated")
+	if !shouldTeleport {
+		return false
+	}
+
+	if !goalCtx.param.NoPrint {
+		maafocus.Print(goalCtx.ctx, i18n.T("maptracker.goal_teleport", int(walkEta.Seconds()), int(teleportEta.Seconds())))
+	}
+	if !a.runTeleport(goalCtx.ctx, goalCtx.arg, inferResult.MapName, getMapCoreName(goalCtx.param.MapName), [2]float64{anchor.X, anchor.Y}) {
+		log.Warn().Int("anchor", anchor.ID).Msg("Teleport failed, walking the whole way")
+		return false
+	}
+	return true
+}
+
+// teleportOverhead returns the estimated time cost of a big-map teleport.
+func teleportOverhead(param *MapTrackerGoalParam) time.Duration {
+	seconds := TELEPORT_DEFAULT_OVERHEAD
+	if param.TeleportOverhead != nil {
+		seconds = *param.TeleportOverhead
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// runTeleport teleports to the given point through the big map and waits for the arrival.
+func (a *MapTrackerGoal) runTeleport(ctx *maa.Context, arg *maa.CustomActionArg, currentMap string, mapName string, target [2]float64) bool {
+	sameMap := isMapNameCoreMatch(currentMap, mapName)
+	if sameMap {
+		if _, err := ctx.RunTask("MapTrackerBigMap_OpenBigMap"); err != nil {
+			log.Error().Err(err).Msg("Failed to open big map for teleport")
+			return false
+		}
+	}
+
+	param := maptrackerbigmap.MapTrackerBigMapPickParam{
+		MapName:          mapName,
+		Target:           target,
+		OnFind:           maptrackerbigmap.ON_FIND_TELEPORT,
+		AutoOpenMapScene: !sameMap,
+	}
+	paramBytes, err := json.Marshal(param)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal MapTrackerBigMapPick parameters for MapTrackerGoal")
+		return false
+	}
+	if !(&maptrackerbigmap.MapTrackerBigMapPick{}).Run(ctx, &maa.CustomActionArg{
+		TaskID:            arg.TaskID,
+		CurrentTaskName:   arg.CurrentTaskName,
+		CustomActionName:  "MapTrackerBigMapPick",
+		CustomActionParam: string(paramBytes),
+		RecognitionDetail: arg.RecognitionDetail,
+		Box:               arg.Box,
+	}) {
+		return false
+	}
+	_, err = waitForMapArrival(ctx, mapName)
+	if err != nil {
+		log.Error().Err(err).Str("map", mapName).Msg("Teleport did not arrive at expected map")
+		return false
+	}
+	return true
+}
+
+// waitForMapArrival polls location inference until the player is recognized on the given map.
+func waitForMapArrival(ctx *maa.Context, mapName string) (*MapTrackerInferResult, error) {
+	ctrl := ctx.GetTasker().GetController()
+	param := &MapTrackerMoveParam{MapName: mapName, MapNameMatchRule: mapTrackerMoveDefaultParam.MapNameMatchRule}
+	deadline := time.Now().Add(crossMapArrivalTimeout)
+	for time.Now().Before(deadline) {
+		if ctx.GetTasker().Stopping() {
+			return nil, fmt.Errorf("task is stopping")
+		}
+		time.Sleep(crossMapArrivalInterval)
+		result, err := doInfer(ctx, ctrl, param)
+		if err == nil && result != nil && isMapNameCoreMatch(result.MapName, mapName) {
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("timed out waiting to arrive at map %s", mapName)
+}
+
+func crossMapLegLabelKey(kind string) string {
+	switch kind {
+	case internal.WORLD_LEG_TELEPORT:
+		return "maptracker.goal_leg.teleport"
+	default:
+		return "maptracker.goal_leg.move"
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
+)
+
+const testLineNavMeshText = `[MapTrackerNavMesh.Meta]
+Version=1
+Encoding=UTF-8
+Name=map_line_lv001
+Description=Test navmesh
+MapRegionName=map_line
+MapLevelName=lv001
+GeoWidth=100.0
+GeoHeight=100.0
+
+[MapTrackerNavMesh.Vertices]
+V1=X0,Y0,T0,E0,F()
+V2=X100,Y0,T0,E0,F()
+
+[MapTrackerNavMesh.Edges]
+E1=S1,D2,B1,C100,F()
+`
+
+func TestAddInventoryZiplines(t *testing.T) {
+	mesh, err := internal.ParseNavMesh(strings.NewReader(testLineNavMeshText))
+	if err != nil {
+		t.Fatalf("ParseNavMesh() error = %v", err)
+	}
+	goalCtx := &goalContext{
+		param: &MapTrackerGoalParam{ZiplinePolicy: ZIPLINE_POLICY_AGGRESSIVE},
+		mesh:  mesh,
+	}
+
+	path := filepath.Join(t.TempDir(), "map_line_lv001.json")
+	if ids, err := addInventoryZiplines(goalCtx, path); err != nil || len(ids) != 0 {
+		t.Fatalf("addInventoryZiplines() on missing inventory = %v, %v", ids, err)
+	}
+
+	inv := &internal.IconInventory{MapName: "map_line_lv001"}
+	inv.Add(internal.IconInventoryItem{Kind: internal.ICON_KIND_ZIPLINE, X: 10, Y: 0, Conf: 0.9}, 5)
+	inv.Add(internal.IconInventoryItem{Kind: internal.ICON_KIND_ZIPLINE, X: 90, Y: 0, Conf: 0.9}, 5)
+	inv.Add(internal.IconInventoryItem{Kind: internal.ICON_KIND_TELEPORT, X: 50, Y: 0, Conf: 0.9}, 5)
+	if err := internal.SaveIconInventory(path, inv); err != nil {
+		t.Fatalf("SaveIconInventory() error = %v", err)
+	}
+
+	ids, err := addInventoryZiplines(goalCtx, path)
+	if err != nil {
+		t.Fatalf("addInventoryZiplines() error = %v", err)
+	}
+	if len(ids) != 2 || len(mesh.RuntimeVertices) != 2 {
+		t.Fatalf("imported %v, runtime vertices %+v, want the 2 ziplines only", ids, mesh.RuntimeVertices)
+	}
+
+	// Riding the imported zipline is cheaper than walking under the aggressive policy
+	pathIDs, err := mesh.FindPathIDs(1, 2)
+	if err != nil {
+		t.Fatalf("FindPathIDs() error = %v", err)
+	}
+	if findFirstZiplineEdgeIndex(mesh, pathIDs) < 0 {
+		t.Fatalf("path %v does not use the imported zipline", pathIDs)
+	}
+}
+
+func TestCachedZiplinesCover(t *testing.T) {
+	// A route eastward over 400px, as produced by pathToMustSeePoints
+	route := pathToMustSeePoints([][2]float64{{0, 0}, {100, 0}, {200, 10}, {300, 10}, {400, 0}})
+	zipline := func(x, y float64) internal.CachedZipline {
+		return internal.CachedZipline{MapName: "map_line_lv001", X: x, Y: y}
+	}
+	testCases := []struct {
+		name   string
+		cached []internal.CachedZipline
+		want   bool
+	}{
+		{
+			name:   "empty cache",
+			cached: nil,
+			want:   false,
+		},
+		{
+			// Recorded by an earlier short goal near the start, far from the rest of the route
+			name:   "partial cache around the start",
+			cached: []internal.CachedZipline{zipline(10, 20), zipline(80, -20)},
+			want:   false,
+		},
+		{
+			name:   "partial cache missing the end",
+			cached: []internal.CachedZipline{zipline(10, 20), zipline(90, 0), zipline(170, 10), zipline(250, 30)},
+			want:   false,
+		},
+		{
+			name:   "whole route",
+			cached: []internal.CachedZipline{zipline(10, 20), zipline(90, 0), zipline(170, 10), zipline(250, 30), zipline(330, 0)},
+			want:   true,
+		},
+	}
+	for _, tc := range testCases {
+		if got := cachedZiplinesCover(tc.cached, route); got != tc.want {
+			t.Fatalf("%s: cachedZiplinesCover() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if cachedZiplinesCover(testCases[3].cached, nil) {
+		t.Fatal("cachedZiplinesCover() without must-see points = true, want false")
+	}
+}
+
+func TestGoalHarvestCooldownWithoutEntityID(t *testing.T) {
+	store, err := internal.OpenHarvestStore(filepath.Join(t.TempDir(), "MapTrackerHarvest.json"), "uid")
+	if err != nil {
+		t.Fatalf("OpenHarvestStore() error = %v", err)
+	}
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	if err := store.Record("map_line_lv001", 1, now, time.Hour); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	goal := &MapTrackerGoal{}
+	param, err := goal.parseParam(`{"map_name": "map_line_lv001", "target": [1, 2], "skip_harvested": true, "record_harvest": true}`)
+	if err != nil {
+		t.Fatalf("parseParam() error = %v", err)
+	}
+	// skip_harvested only works with entity_id and is ignored for a coordinate target
+	if isGoalHarvestOnCooldown(param, store, now) {
+		t.Fatal("coordinate target reported on harvest cooldown")
+	}
+
+	param, err = goal.parseParam(`{"map_name": "map_line_lv001", "entity_id": 1, "skip_harvested": true}`)
+	if err != nil {
+		t.Fatalf("parseParam() error = %v", err)
+	}
+	if !isGoalHarvestOnCooldown(param, store, now) {
+		t.Fatal("harvested entity not reported on cooldown")
+	}
+	if isGoalHarvestOnCooldown(param, nil, now) {
+		t.Fatal("entity reported on cooldown without a harvest store")
+	}
+}
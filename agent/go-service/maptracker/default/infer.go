@@ -26,9 +26,10 @@ type MapTrackerInferResult struct {
 	Rot         int     `json:"rot"`         // Rotation angle (0-359 degrees)
 	LocConf     float64 `json:"locConf"`     // Location confidence
 	RotConf     float64 `json:"rotConf"`     // Rotation confidence
+	TrackConf   float64 `json:"trackConf"`   // Motion tracking confidence, 0 if the location is not tracked yet
 	LocTimeMs   int64   `json:"locTimeMs"`   // Location inference time in ms
 	RotTimeMs   int64   `json:"rotTimeMs"`   // Rotation inference time in ms
-	InferMode   string  `json:"inferMode"`   // Inference mode ("FullSearchHit", "FastSearchHit")
+	InferMode   string  `json:"inferMode"`   // Inference mode ("FullSearchHit", "FastSearchHit", "FeatureHit")
 	InferTimeMs int64   `json:"inferTimeMs"` // Total inference time in ms
 }
 
@@ -45,8 +46,9 @@ type MapTrackerInferParam struct {
 }
 
 const (
-	INFER_MODE_FULL_SEARCH = 1
-	INFER_MODE_FAST_SEARCH = 2
+	INFER_MODE_FULL_SEARCH      = 1
+	INFER_MODE_FAST_SEARCH      = 2
+	INFER_MODE_FEATURE_FALLBACK = 4
 )
 
 var mapTrackerInferDefaultParam = MapTrackerInferParam{
@@ -72,6 +74,7 @@ type InferLocationRawResult struct {
 	Conf          float64
 	Source        InferLocationHitMode
 	ElapsedTimeMs int64
+	TrackConf     float64 // Only set on results smoothed by the motion track
 }
 
 type InferRotationRawResult struct {
@@ -80,8 +83,6 @@ type InferRotationRawResult struct {
 	ElapsedTimeMs int64
 }
 
-var mapCoreNameRegexp = regexp.MustCompile(`^(.+?)(?:_tier_\w+)?$`)
-
 var MapTrackerInferRunner maa.CustomRecognitionRunner = &MapTrackerInfer{}
 
 // Run implements maa.CustomRecognitionRunner
@@ -102,8 +103,6 @@ func (i *MapTrackerInfer) Run(ctx *maa.Context, arg *maa.CustomRecognitionArg) (
 		return nil, false
 	}
 
-	rotStep := max(2, min(6, int(math.Round(6-param.Precision*4))))
-
 	// Initialize map resources
 	internal.Resource.InitRawMaps(ctx)
 	if internal.Resource.RawMapsErr != nil {
@@ -115,67 +114,8 @@ func (i *MapTrackerInfer) Run(ctx *maa.Context, arg *maa.CustomRecognitionArg) (
 	screenImg := minicv.ImageConvertRGBA(arg.Img)
 	t0 := time.Now()
 
-	ch := make(chan *InferLocationRawResult, 1)
-
-	go func() {
-		ch <- i.inferLocation(ctrlType, screenImg, mapNameRegex, param)
-	}()
-
-	rot := i.inferRotation(ctrlType, screenImg, rotStep)
-	loc := <-ch
-
-	// Determine if recognition hit natively
-	internalLocHit := loc != nil && loc.Conf > param.Threshold
-	internalRotHit := rot != nil && rot.Conf > param.Threshold
-
-	// Final results (nil for now)
-	var finalLoc *InferLocationRawResult
-	var finalRot *InferRotationRawResult
-
-	globalInferState.Lock()
-
-	// Process internal location hit
-	if internalLocHit {
-		if globalInferState.IsCloseToConvinced(loc) {
-			// This hit is close to the currently convinced location
-			globalInferState.SetConvinced(*loc)
-			finalLoc = loc
-
-		} else if globalInferState.IsCloseToPending(loc) {
-			// This hit is close to the pending location
-			globalInferState.UpdatePending(loc.X, loc.Y)
-
-			if globalInferState.ShouldTakeoverPending() {
-				// Do takeover (replace convinced with pending)
-				globalInferState.TakeoverPending()
-				finalLoc = loc
-			}
-		} else {
-			// This hit is far from both convinced and pending locations
-			if globalInferState.IsImmediateTrackLoss() {
-				// It's an immediate track loss, start a new pending
-				globalInferState.SetPending(*loc)
-
-				if param.AllowedModes&INFER_MODE_FAST_SEARCH == 0 {
-					// If fast search is not allowed, directly take this hit as final to avoid long wait for next hit
-					// Otherwise, don't set finalLoc here to wait for next fast search
-					finalLoc = loc
-				}
-			} else {
-				// It's a stale track loss, directly replace convinced with this new hit
-				globalInferState.SetConvinced(*loc)
-				globalInferState.ResetPending()
-				finalLoc = loc
-			}
-		}
-	}
-
-	// Process internal rotation hit
-	if internalRotHit {
-		finalRot = rot
-	}
-
-	globalInferState.Unlock()
+	loc, rot := i.inferRaw(&globalInferState, ctrlType, screenImg, mapNameRegex, param)
+	finalLoc, finalRot := i.resolveInferState(&globalInferState, loc, rot, param)
 
 	finalHit := finalLoc != nil && finalRot != nil
 	finalElapsedTimeMs := time.Since(t0).Milliseconds()
@@ -198,6 +138,7 @@ func (i *MapTrackerInfer) Run(ctx *maa.Context, arg *maa.CustomRecognitionArg) (
 		Rot:         finalRot.Rot,
 		LocConf:     finalLoc.Conf,
 		RotConf:     finalRot.Conf,
+		TrackConf:   finalLoc.TrackConf,
 		LocTimeMs:   finalLoc.ElapsedTimeMs,
 		RotTimeMs:   finalRot.ElapsedTimeMs,
 		InferMode:   string(finalLoc.Source),
@@ -218,6 +159,7 @@ func (i *MapTrackerInfer) Run(ctx *maa.Context, arg *maa.CustomRecognitionArg) (
 		Int("Rot", result.Rot).
 		Float64("LocConf", result.LocConf).
 		Float64("RotConf", result.RotConf).
+		Float64("TrackConf", result.TrackConf).
 		Msg("Map tracking inference completed")
 
 	// Return as hit
@@ -261,12 +203,83 @@ func (r *MapTrackerInfer) parseParam(paramStr string) (*MapTrackerInferParam, er
 	}
 }
 
-func getMapCoreName(mapName string) string {
-	matches := mapCoreNameRegexp.FindStringSubmatch(mapName)
-	if len(matches) < 2 {
-		return mapName
+// inferRaw runs location and rotation inference concurrently on the given screen image.
+func (i *MapTrackerInfer) inferRaw(state *InferState, ctrlType string, screenImg *image.RGBA, mapNameRegex *regexp.Regexp, param *MapTrackerInferParam) (*InferLocationRawResult, *InferRotationRawResult) {
+	rotStep := max(2, min(6, int(math.Round(6-param.Precision*4))))
+
+	ch := make(chan *InferLocationRawResult, 1)
+
+	go func() {
+		ch <- i.inferLocation(state, ctrlType, screenImg, mapNameRegex, param)
+	}()
+
+	rot := i.inferRotation(ctrlType, screenImg, rotStep)
+	loc := <-ch
+	return loc, rot
+}
+
+// resolveInferState feeds raw inference results into the time-series state,
+// and returns the final location and rotation (nil if not hit).
+func (i *MapTrackerInfer) resolveInferState(state *InferState, loc *InferLocationRawResult, rot *InferRotationRawResult, param *MapTrackerInferParam) (*InferLocationRawResult, *InferRotationRawResult) {
+	// Determine if recognition hit natively
+	// Feature fallback hits have passed their own acceptance gate, so threshold only applies to NCC values
+	internalLocHit := loc != nil && (loc.Source == FEATURE_HIT || loc.Conf > param.Threshold)
+	internalRotHit := rot != nil && rot.Conf > param.Threshold
+
+	// Final results (nil for now)
+	var finalLoc *InferLocationRawResult
+	var finalRot *InferRotationRawResult
+
+	state.Lock()
+	defer state.Unlock()
+
+	// Process internal rotation hit first, so that the heading is up to date for the motion track
+	if internalRotHit {
+		state.UpdateHeading(rot.Rot)
+		finalRot = rot
+	}
+
+	// Process internal location hit
+	if internalLocHit {
+		if state.IsCloseToConvinced(loc) {
+			// This hit is consistent with the currently convinced location
+			state.SetConvinced(*loc)
+			finalLoc = state.SmoothedResult(loc)
+
+		} else if state.IsCloseToPending(loc) {
+			// This hit is close to the pending location
+			state.UpdatePending(loc.X, loc.Y)
+
+			if state.ShouldTakeoverPending() {
+				// Do takeover (replace convinced with pending)
+				state.TakeoverPending()
+				finalLoc = state.SmoothedResult(loc)
+			}
+		} else {
+			// This hit is far from both convinced and pending locations
+			if state.IsImmediateTrackLoss() {
+				// It's an immediate track loss, start a new pending
+				state.SetPending(*loc)
+
+				if param.AllowedModes&INFER_MODE_FAST_SEARCH == 0 {
+					// If fast search is not allowed, directly take this hit as final to avoid long wait for next hit
+					// Otherwise, don't set finalLoc here to wait for next fast search
+					finalLoc = loc
+				}
+			} else {
+				// It's a stale track loss, directly replace convinced with this new hit
+				state.SetConvinced(*loc)
+				state.ResetPending()
+				finalLoc = state.SmoothedResult(loc)
+			}
+		}
 	}
-	return matches[1]
+
+	return finalLoc, finalRot
+}
+
+func getMapCoreName(mapName string) string {
+	return internal.MapCoreName(mapName)
 }
 
 func isMapNameCoreMatch(mapName1, mapName2 string) bool {
@@ -276,9 +289,14 @@ func isMapNameCoreMatch(mapName1, mapName2 string) bool {
 	return getMapCoreName(mapName1) == getMapCoreName(mapName2)
 }
 
+// matchMapPyramid searches the whole map coarse-to-fine for the minimap.
+func matchMapPyramid(m *internal.MapCache, miniMap *image.RGBA, miniStats minicv.StatsResult) (x, y, val float64) {
+	return minicv.MatchTemplatePyramid(m.GetPyramid(), miniMap, miniStats, [4]int{0, 0, m.Img.Bounds().Dx(), m.Img.Bounds().Dy()})
+}
+
 // inferLocation infers the player's location on the map.
 // Returns a raw result with mapName, x/y (map coordinates), conf, source, and elapsedTimeMs.
-func (i *MapTrackerInfer) inferLocation(ctrlType string, screenImg *image.RGBA, mapNameRegex *regexp.Regexp, param *MapTrackerInferParam) *InferLocationRawResult {
+func (i *MapTrackerInfer) inferLocation(state *InferState, ctrlType string, screenImg *image.RGBA, mapNameRegex *regexp.Regexp, param *MapTrackerInferParam) *InferLocationRawResult {
 	t0 := time.Now()
 
 	// Use cached scaled maps
@@ -290,16 +308,7 @@ func (i *MapTrackerInfer) inferLocation(ctrlType string, screenImg *image.RGBA,
 	}
 
 	// Crop and scale mini-map area from screen
-	var miniMap *image.RGBA
-	switch ctrlType {
-	case control.CONTROL_TYPE_ADB:
-		miniMap = minicv.ImageCropSquareByRadius(screenImg, 136, 131, 50)
-		miniMap = minicv.ImageScale(miniMap, 0.8)
-	default: // Win32 and others
-		miniMap = minicv.ImageCropSquareByRadius(screenImg, 108, 111, 40)
-	}
-
-	miniMap = minicv.ImageScale(miniMap, scale)
+	miniMap := minicv.ImageScale(cropMiniMap(ctrlType, screenImg), scale)
 	miniMapHalfW, miniMapHalfH := float64(miniMap.Bounds().Dx())/2.0, float64(miniMap.Bounds().Dy())/2.0
 
 	// Precompute needle (minimap) statistics for all matches
@@ -311,14 +320,13 @@ func (i *MapTrackerInfer) inferLocation(ctrlType string, screenImg *image.RGBA,
 	// Time-series empirical optimization
 	// If the user is in a stable state (convinced location updated recently, no pending drifts),
 	// try to match the convinced map around the convinced location first.
-	globalInferState.Lock()
+	state.Lock()
 
-	stableConvincedMapName := globalInferState.convinced.MapName
-	stableLocX := globalInferState.convinced.X
-	stableLocY := globalInferState.convinced.Y
-	isInTime := globalInferState.IsConvincedValid()
+	stableConvincedMapName := state.convinced.MapName
+	stableLocX, stableLocY := state.PredictConvinced()
+	isInTime := state.IsConvincedValid()
 
-	globalInferState.Unlock()
+	state.Unlock()
 
 	isStable := func() bool {
 		if !isInTime {
@@ -413,7 +421,7 @@ func (i *MapTrackerInfer) inferLocation(ctrlType string, screenImg *image.RGBA,
 	}
 
 	if singleMapToTry != nil {
-		matchX, matchY, matchVal := minicv.MatchTemplate(singleMapToTry.Img, singleMapToTry.GetIntegralArray(), miniMap, miniStats)
+		matchX, matchY, matchVal := matchMapPyramid(singleMapToTry, miniMap, miniStats)
 		bestVal = matchVal
 		bestX = roundTo1Decimal((matchX+miniMapHalfW)/scale + float64(singleMapToTry.OffsetX))
 		bestY = roundTo1Decimal((matchY+miniMapHalfH)/scale + float64(singleMapToTry.OffsetY))
@@ -431,7 +439,7 @@ func (i *MapTrackerInfer) inferLocation(ctrlType string, screenImg *image.RGBA,
 			wg.Add(1)
 			go func(m *internal.MapCache) {
 				defer wg.Done()
-				matchX, matchY, matchVal := minicv.MatchTemplate(m.Img, m.GetIntegralArray(), miniMap, miniStats)
+				matchX, matchY, matchVal := matchMapPyramid(m, miniMap, miniStats)
 				mx := roundTo1Decimal((matchX+miniMapHalfW)/scale + float64(m.OffsetX))
 				my := roundTo1Decimal((matchY+miniMapHalfH)/scale + float64(m.OffsetY))
 				resChan <- mapResult{matchVal, mx, my, m.Name}
@@ -456,6 +464,15 @@ func (i *MapTrackerInfer) inferLocation(ctrlType string, screenImg *image.RGBA,
 	if triedCount == 0 {
 		log.Warn().Str("regex", mapNameRegex.String()).Msg("No maps matched the regex")
 	}
+
+	// Fall back to keypoint matching, which tolerates partially covered minimaps
+	if param.AllowedModes&INFER_MODE_FEATURE_FALLBACK != 0 && triedCount > 0 && bestVal <= param.Threshold {
+		// The fallback has its own acceptance gate, and its confidence is not comparable with NCC values
+		if res := i.inferLocationByFeatures(ctrlType, screenImg, mapNameRegex); res != nil {
+			res.ElapsedTimeMs = time.Since(t0).Milliseconds()
+			return res
+		}
+	}
 	elapsedTimeMs := time.Since(t0).Milliseconds()
 
 	log.Debug().Int("triedMaps", triedCount).
@@ -476,6 +493,16 @@ func (i *MapTrackerInfer) inferLocation(ctrlType string, screenImg *image.RGBA,
 	}
 }
 
+// cropMiniMap crops the mini-map area from screen, normalized to the raw map scale.
+func cropMiniMap(ctrlType string, screenImg *image.RGBA) *image.RGBA {
+	switch ctrlType {
+	case control.CONTROL_TYPE_ADB:
+		return minicv.ImageScale(minicv.ImageCropSquareByRadius(screenImg, 136, 131, 50), 0.8)
+	default: // Win32 and others
+		return minicv.ImageCropSquareByRadius(screenImg, 108, 111, 40)
+	}
+}
+
 // inferRotation infers the player's rotation angle
 // Returns (angle, confidence)
 func (i *MapTrackerInfer) inferRotation(ctrlType string, screenImg *image.RGBA, rotStep int) *InferRotationRawResult {
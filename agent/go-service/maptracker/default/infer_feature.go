@@ -0,0 +1,169 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"image"
+	"math"
+	"regexp"
+	"sync"
+	"time"
+
+	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/minicv"
+	"github.com/rs/zerolog/log"
+)
+
+// Feature fallback configuration
+const (
+	FEATURE_MAX_HAMMING_DISTANCE = 64   // Maximum descriptor distance of a match (of 256 bits)
+	FEATURE_RATIO_TEST           = 0.8  // Nearest / second-nearest distance ratio
+	FEATURE_RANSAC_ITERATIONS    = 400  // Random minimal samples per map
+	FEATURE_INLIER_THRESHOLD_PX  = 3.0  // Maximum reprojection error of an inlier
+	FEATURE_MIN_INLIERS          = 8    // Minimum inliers to accept a transform
+	FEATURE_CONF_FULL_INLIERS    = 24   // Inliers at which the confidence reaches 1
+	FEATURE_MIN_SCALE            = 0.8  // The minimap has the raw map scale, so only small deviations
+	FEATURE_MAX_SCALE            = 1.25 // of the transform scale are allowed
+	FEATURE_MAX_ANGLE_DEG        = 10.0 // The minimap is north-up, so a rotated transform is a false match
+	FEATURE_MIN_INLIER_RATIO     = 0.5  // Minimum fraction of the ratio-test matches that are inliers
+	FEATURE_MAX_MEAN_ERROR_PX    = 1.5  // Maximum mean reprojection error of the inliers
+)
+
+// inferLocationByFeatures infers the player's location by keypoint matching between the minimap and raw maps.
+// Unlike template matching, it can recover the location when part of the minimap is covered.
+// Returns nil if no map yields an acceptable transform (see acceptFeatureTransform).
+// The confidence of the result is inlier-based and not comparable with NCC values.
+// Rotation is not recovered here: the minimap is always north-up, so the transform angle only
+// measures the match error, and the player's heading still comes from the pointer in inferRotation.
+func (i *MapTrackerInfer) inferLocationByFeatures(ctrlType string, screenImg *image.RGBA, mapNameRegex *regexp.Regexp) *InferLocationRawResult {
+	t0 := time.Now()
+
+	miniMap := cropMiniMap(ctrlType, screenImg)
+	w, h := miniMap.Rect.Dx(), miniMap.Rect.Dy()
+	circle := minicv.Circle{X: w / 2, Y: h / 2, Radius: min(w, h) / 2}
+	query := minicv.DetectORB(miniMap, minicv.ORBOptions{Circle: &circle})
+	if len(query.Keypoints) < FEATURE_MIN_INLIERS {
+		log.Debug().Int("keypoints", len(query.Keypoints)).Msg("Feature fallback skipped, too few minimap keypoints")
+		return nil
+	}
+
+	type mapResult struct {
+		inliers   int
+		transform minicv.SimilarityTransform
+		m         *internal.MapCache
+	}
+
+	// Match each map with plain goroutines, since detection and matching use the worker pool
+	maps := internal.Resource.RawMaps
+	results := make([]mapResult, len(maps))
+	var wg sync.WaitGroup
+	for idx := range maps {
+		m := &maps[idx]
+		if !mapNameRegex.MatchString(m.Name) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			train := m.GetFeatures()
+			matches := minicv.MatchDescriptors(query, train, FEATURE_MAX_HAMMING_DISTANCE, FEATURE_RATIO_TEST)
+			if len(matches) < FEATURE_MIN_INLIERS {
+				return
+			}
+			src := make([][2]float64, len(matches))
+			dst := make([][2]float64, len(matches))
+			for k, match := range matches {
+				q, t := query.Keypoints[match.QueryIdx], train.Keypoints[match.TrainIdx]
+				src[k] = [2]float64{float64(q.X), float64(q.Y)}
+				dst[k] = [2]float64{float64(t.X), float64(t.Y)}
+			}
+			transform, inliers, ok := minicv.EstimateSimilarityRANSAC(src, dst, minicv.RANSACOptions{
+				Iterations:      FEATURE_RANSAC_ITERATIONS,
+				InlierThreshold: FEATURE_INLIER_THRESHOLD_PX,
+				MinInliers:      FEATURE_MIN_INLIERS,
+				MinScale:        FEATURE_MIN_SCALE,
+				MaxScale:        FEATURE_MAX_SCALE,
+				Seed:            uint64(idx),
+			})
+			if !ok || math.Abs(transform.Angle) > FEATURE_MAX_ANGLE_DEG {
+				return
+			}
+			count, meanErr := featureInlierStats(src, dst, inliers, transform)
+			if !acceptFeatureTransform(len(matches), count, meanErr) {
+				log.Debug().Str("map", m.Name).
+					Int("matches", len(matches)).
+					Int("inliers", count).
+					Float64("meanError", meanErr).
+					Msg("Feature fallback transform rejected")
+				return
+			}
+			results[idx] = mapResult{count, transform, m}
+		}()
+	}
+	wg.Wait()
+
+	var best mapResult
+	for _, res := range results {
+		if res.inliers > best.inliers {
+			best = res
+		}
+	}
+	elapsedTimeMs := time.Since(t0).Milliseconds()
+	if best.m == nil {
+		log.Debug().Int("keypoints", len(query.Keypoints)).
+			Int64("elapsedTimeMs", elapsedTimeMs).
+			Msg("Feature fallback found no consistent transform")
+		return nil
+	}
+
+	cx, cy := best.transform.Apply(float64(w)/2.0, float64(h)/2.0)
+	conf := min(1.0, float64(best.inliers)/FEATURE_CONF_FULL_INLIERS)
+	x := roundTo1Decimal(cx + float64(best.m.OffsetX))
+	y := roundTo1Decimal(cy + float64(best.m.OffsetY))
+
+	log.Debug().Float64("conf", conf).
+		Int("inliers", best.inliers).
+		Float64("scale", best.transform.Scale).
+		Float64("angle", best.transform.Angle).
+		Str("map", best.m.Name).
+		Float64("X", x).
+		Float64("Y", y).
+		Int64("elapsedTimeMs", elapsedTimeMs).
+		Msg("Internal feature fallback location inference completed")
+
+	return &InferLocationRawResult{
+		MapName:       best.m.Name,
+		X:             x,
+		Y:             y,
+		Conf:          conf,
+		Source:        FEATURE_HIT,
+		ElapsedTimeMs: elapsedTimeMs,
+	}
+}
+
+// featureInlierStats returns the inlier count and the mean reprojection error of the inliers under the transform.
+func featureInlierStats(src, dst [][2]float64, inliers []bool, transform minicv.SimilarityTransform) (int, float64) {
+	count := 0
+	sumErr := 0.0
+	for k, in := range inliers {
+		if !in {
+			continue
+		}
+		x, y := transform.Apply(src[k][0], src[k][1])
+		sumErr += math.Hypot(x-dst[k][0], y-dst[k][1])
+		count++
+	}
+	if count == 0 {
+		return 0, math.Inf(1)
+	}
+	return count, sumErr / float64(count)
+}
+
+// acceptFeatureTransform is the acceptance gate of the feature fallback.
+// A transform is accepted only if it has enough inliers, explains a large enough share of the matches,
+// and fits its inliers tightly. A false match on repetitive terrain usually fails the latter two.
+func acceptFeatureTransform(matches, inliers int, meanErr float64) bool {
+	if inliers < FEATURE_MIN_INLIERS || matches == 0 {
+		return false
+	}
+	return float64(inliers)/float64(matches) >= FEATURE_MIN_INLIER_RATIO && meanErr <= FEATURE_MAX_MEAN_ERROR_PX
+}
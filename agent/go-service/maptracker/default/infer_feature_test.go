@@ -0,0 +1,140 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"regexp"
+	"testing"
+
+	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/minicv"
+)
+
+// buildFeatureTestScreen pastes the map area around (x, y) into the Win32 minimap area of a blank screen,
+// covering the right part of the minimap as an overlay would.
+func buildFeatureTestScreen(m *internal.MapCache, x, y, coverFromX int) *image.RGBA {
+	screen := image.NewRGBA(image.Rect(0, 0, 1280, 720))
+	for dy := -40; dy < 40; dy++ {
+		for dx := -40; dx < 40; dx++ {
+			c := m.Img.RGBAAt(x-m.OffsetX+dx, y-m.OffsetY+dy)
+			if dx >= coverFromX {
+				c = color.RGBA{R: 20, G: 20, B: 20, A: 255}
+			}
+			screen.SetRGBA(108+dx, 111+dy, c)
+		}
+	}
+	return screen
+}
+
+func TestInferLocationByFeatures(t *testing.T) {
+	chdirInferTestRepoRoot(t)
+	maps, err := internal.Resource.LoadMaps()
+	if err != nil {
+		t.Skipf("map resources unavailable: %v", err)
+	}
+	internal.Resource.RawMaps = maps
+	t.Cleanup(func() { internal.Resource.RawMaps = nil })
+
+	regex := regexp.MustCompile(mapTrackerInferDefaultParam.MapNameRegex)
+	infer := &MapTrackerInfer{}
+	cases := []struct {
+		mapName    string
+		x, y       int
+		coverFromX int
+	}{
+		{"map02_lv002", 320, 403, 40},
+		{"map02_lv002", 320, 403, 0},
+		{"map02_lv003", 332, 408, 15},
+		{"map02_lv005", 287, 294, -10},
+	}
+	for _, c := range cases {
+		var m *internal.MapCache
+		for idx := range maps {
+			if maps[idx].Name == c.mapName {
+				m = &maps[idx]
+			}
+		}
+		if m == nil {
+			t.Fatalf("map %s not found", c.mapName)
+		}
+
+		screen := buildFeatureTestScreen(m, c.x, c.y, c.coverFromX)
+		res := infer.inferLocationByFeatures(control.CONTROL_TYPE_WIN32, screen, regex)
+		if res == nil {
+			t.Fatalf("%s (%d, %d) cover=%d: no result", c.mapName, c.x, c.y, c.coverFromX)
+		}
+		if res.MapName != c.mapName || math.Hypot(res.X-float64(c.x), res.Y-float64(c.y)) > 1.5 {
+			t.Fatalf("%s (%d, %d) cover=%d: unexpected result %+v", c.mapName, c.x, c.y, c.coverFromX, res)
+		}
+		if res.Source != FEATURE_HIT || res.Conf <= 0 {
+			t.Fatalf("%s (%d, %d) cover=%d: unexpected source or confidence %+v", c.mapName, c.x, c.y, c.coverFromX, res)
+		}
+	}
+
+	blank := image.NewRGBA(image.Rect(0, 0, 1280, 720))
+	if res := infer.inferLocationByFeatures(control.CONTROL_TYPE_WIN32, blank, regex); res != nil {
+		t.Fatalf("expected no result on a blank screen, got %+v", res)
+	}
+}
+
+func TestAcceptFeatureTransform(t *testing.T) {
+	// 20 correspondences related by a pure translation; the last 4 are outliers
+	transform := minicv.SimilarityTransform{Scale: 1, TX: 100, TY: 50}
+	src := make([][2]float64, 20)
+	dst := make([][2]float64, 20)
+	inliers := make([]bool, 20)
+	for k := range src {
+		src[k] = [2]float64{float64(k * 3), float64(k * 7 % 40)}
+		dst[k] = [2]float64{src[k][0] + 100, src[k][1] + 50}
+		inliers[k] = k < 16
+		if k%2 == 0 {
+			dst[k][0] += 1
+		}
+		if !inliers[k] {
+			dst[k][1] += 30
+		}
+	}
+
+	count, meanErr := featureInlierStats(src, dst, inliers, transform)
+	if count != 16 || math.Abs(meanErr-0.5) > 1e-9 {
+		t.Fatalf("featureInlierStats() = (%d, %f), want (16, 0.5)", count, meanErr)
+	}
+
+	cases := []struct {
+		name             string
+		matches, inliers int
+		meanErr          float64
+		want             bool
+	}{
+		{"consistent", 20, 16, 0.5, true},
+		{"too few inliers", 10, FEATURE_MIN_INLIERS - 1, 0.5, false},
+		// 8 inliers give a confidence of 0.33, which used to beat any failed NCC match
+		{"low inlier ratio", 40, FEATURE_MIN_INLIERS, 0.5, false},
+		{"loose fit", 20, 16, 2.5, false},
+		{"no matches", 0, 0, 0, false},
+	}
+	for _, c := range cases {
+		if got := acceptFeatureTransform(c.matches, c.inliers, c.meanErr); got != c.want {
+			t.Errorf("%s: acceptFeatureTransform(%d, %d, %f) = %v, want %v", c.name, c.matches, c.inliers, c.meanErr, got, c.want)
+		}
+	}
+}
+
+func TestResolveInferStateFeatureHitIgnoresThreshold(t *testing.T) {
+	infer := &MapTrackerInfer{}
+	param := mapTrackerInferParamForMove
+	param.AllowedModes &^= INFER_MODE_FAST_SEARCH
+	state := &InferState{}
+
+	ncc := &InferLocationRawResult{MapName: "map02_lv002", X: 100, Y: 100, Conf: 0.25, Source: FULL_SEARCH_HIT}
+	if loc, _ := infer.resolveInferState(state, ncc, nil, &param); loc != nil {
+		t.Fatalf("NCC result below threshold should not hit, got %+v", loc)
+	}
+	feature := &InferLocationRawResult{MapName: "map02_lv002", X: 100, Y: 100, Conf: 0.25, Source: FEATURE_HIT}
+	if loc, _ := infer.resolveInferState(state, feature, nil, &param); loc == nil {
+		t.Fatal("feature result that passed its own gate should hit")
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"math"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+)
+
+// Motion model configuration
+const (
+	// MOTION_ACCEL_NOISE is the spectral density of the white acceleration noise (px^2/s^3).
+	MOTION_ACCEL_NOISE = 64.0
+	// MOTION_MEASURE_STD is the standard deviation of a location measurement at full confidence (px).
+	MOTION_MEASURE_STD = 2.0
+	// MOTION_INIT_VELOCITY_STD is the initial velocity uncertainty of a new track (px/s).
+	MOTION_INIT_VELOCITY_STD = 12.0
+	// MOTION_GATE_CHI2 is the squared Mahalanobis distance gate, the 99% quantile of chi-square with 2 DoF.
+	// It only models the measurement noise and smooth acceleration, so a converged track gates at about
+	// 8px (100ms between hits) to 14px (500ms), which widens with longer gaps and lower confidence.
+	MOTION_GATE_CHI2 = 9.21
+	// MOTION_GATE_MIN_DISTANCE is the radius around the prediction that is always accepted.
+	// Dodges and sudden turns while sprinting move the player up to about 15px off the prediction,
+	// so the gate is never tighter than the plain distance threshold used without a track.
+	MOTION_GATE_MIN_DISTANCE = CONVINCED_DISTANCE_THRESHOLD
+	// MOTION_HINT_VALID_TIME_MS is how long a commanded movement is trusted after it was reported.
+	MOTION_HINT_VALID_TIME_MS = 1000
+	// MOTION_HINT_SPEED_STD_RATIO scales the commanded speed into the velocity pseudo-measurement noise.
+	MOTION_HINT_SPEED_STD_RATIO = 1.0
+	// MOTION_HEADING_ALPHA and MOTION_HEADING_BETA are the gains of the alpha-beta heading filter.
+	MOTION_HEADING_ALPHA = 0.7
+	MOTION_HEADING_BETA  = 0.1
+)
+
+// motionAxis is a constant-velocity Kalman filter along one map axis, with state [position, velocity].
+// X and Y are filtered independently, which is exact for the isotropic noise used here.
+type motionAxis struct {
+	pos, vel      float64
+	p00, p01, p11 float64
+}
+
+func newMotionAxis(pos, measureVar float64) motionAxis {
+	return motionAxis{pos: pos, p00: measureVar, p11: MOTION_INIT_VELOCITY_STD * MOTION_INIT_VELOCITY_STD}
+}
+
+// predicted returns the axis propagated by dt seconds without modifying it.
+func (a motionAxis) predicted(dt float64) motionAxis {
+	if dt <= 0 {
+		return a
+	}
+	q := MOTION_ACCEL_NOISE
+	return motionAxis{
+		pos: a.pos + a.vel*dt,
+		vel: a.vel,
+		p00: a.p00 + 2*dt*a.p01 + dt*dt*a.p11 + q*dt*dt*dt/3,
+		p01: a.p01 + dt*a.p11 + q*dt*dt/2,
+		p11: a.p11 + q*dt,
+	}
+}
+
+func (a *motionAxis) updatePosition(z, r float64) {
+	s := a.p00 + r
+	k0, k1 := a.p00/s, a.p01/s
+	innovation := z - a.pos
+	a.pos += k0 * innovation
+	a.vel += k1 * innovation
+	a.p11 -= k1 * a.p01
+	a.p01 -= k0 * a.p01
+	a.p00 -= k0 * a.p00
+}
+
+func (a *motionAxis) updateVelocity(z, r float64) {
+	s := a.p11 + r
+	k0, k1 := a.p01/s, a.p11/s
+	innovation := z - a.vel
+	a.pos += k0 * innovation
+	a.vel += k1 * innovation
+	a.p00 -= k0 * a.p01
+	a.p01 -= k0 * a.p11
+	a.p11 -= k1 * a.p11
+}
+
+// motionTrack fuses location, rotation and commanded movement into a smoothed player state.
+type motionTrack struct {
+	mapName    string
+	x, y       motionAxis
+	lastTimeMs int64
+
+	heading       float64
+	headingRate   float64
+	headingTimeMs int64
+	headingReady  bool
+
+	hint       control.PlayerMovement
+	hintTimeMs int64
+}
+
+func (t *motionTrack) ready() bool {
+	return t.mapName != ""
+}
+
+// reset starts a new track at the given location.
+// InferState resets the track whenever the convinced location moves to another map or is replaced after a stale track loss.
+func (t *motionTrack) reset(loc InferLocationRawResult, nowMs int64) {
+	r := motionMeasureVariance(loc.Conf)
+	t.mapName = loc.MapName
+	t.x = newMotionAxis(loc.X, r)
+	t.y = newMotionAxis(loc.Y, r)
+	t.lastTimeMs = nowMs
+}
+
+// predict returns the axes propagated to nowMs without modifying the track.
+func (t *motionTrack) predict(nowMs int64) (motionAxis, motionAxis) {
+	dt := float64(nowMs-t.lastTimeMs) / 1000.0
+	return t.x.predicted(dt), t.y.predicted(dt)
+}
+
+// gate checks whether a location measurement is consistent with the predicted state.
+// It accepts measurements within MOTION_GATE_MIN_DISTANCE of the prediction, or within the
+// Mahalanobis gate when the track is uncertain enough for the latter to be wider.
+func (t *motionTrack) gate(loc *InferLocationRawResult, nowMs int64) bool {
+	x, y := t.predict(nowMs)
+	r := motionMeasureVariance(loc.Conf)
+	dx, dy := loc.X-x.pos, loc.Y-y.pos
+	if math.Hypot(dx, dy) < MOTION_GATE_MIN_DISTANCE {
+		return true
+	}
+	return dx*dx/(x.p00+r)+dy*dy/(y.p00+r) < MOTION_GATE_CHI2
+}
+
+// update propagates the track to nowMs, applies the commanded movement if fresh and fuses the location.
+func (t *motionTrack) update(loc InferLocationRawResult, nowMs int64) {
+	t.x, t.y = t.predict(nowMs)
+	t.lastTimeMs = nowMs
+	if speed, ok := t.hintSpeed(nowMs); ok {
+		// Heading 0 points to -Y and increases clockwise, see calcTargetRotation.
+		rad := t.heading * math.Pi / 180.0
+		std := MOTION_HINT_SPEED_STD_RATIO*speed + 1.0
+		t.x.updateVelocity(speed*math.Sin(rad), std*std)
+		t.y.updateVelocity(-speed*math.Cos(rad), std*std)
+	}
+	r := motionMeasureVariance(loc.Conf)
+	t.x.updatePosition(loc.X, r)
+	t.y.updatePosition(loc.Y, r)
+}
+
+// hintSpeed returns the commanded speed when it is fresh and the heading is known.
+func (t *motionTrack) hintSpeed(nowMs int64) (float64, bool) {
+	if t.hintTimeMs == 0 || nowMs-t.hintTimeMs > MOTION_HINT_VALID_TIME_MS || !t.headingReady {
+		return 0, false
+	}
+	return t.hint.DistanceDuring(time.Second), true
+}
+
+// updateHeading runs the alpha-beta heading filter with a measured rotation in degrees.
+// A stale heading is restarted from the measurement instead of being extrapolated.
+func (t *motionTrack) updateHeading(rot int, nowMs int64) {
+	if !t.headingReady || nowMs-t.headingTimeMs > MOTION_HINT_VALID_TIME_MS {
+		t.heading, t.headingRate = float64(rot), 0
+		t.headingTimeMs, t.headingReady = nowMs, true
+		return
+	}
+	dt := float64(nowMs-t.headingTimeMs) / 1000.0
+	predicted := t.heading + t.headingRate*dt
+	residual := math.Remainder(float64(rot)-predicted, 360)
+	t.heading = math.Mod(predicted+MOTION_HEADING_ALPHA*residual, 360)
+	if t.heading < 0 {
+		t.heading += 360
+	}
+	if dt > 0 {
+		t.headingRate += MOTION_HEADING_BETA * residual / dt
+	}
+	t.headingTimeMs = nowMs
+}
+
+// confidence maps the position uncertainty into [0, 1], reaching 0.5 when the
+// combined standard deviation equals CONVINCED_DISTANCE_THRESHOLD.
+func (t *motionTrack) confidence(nowMs int64) float64 {
+	if !t.ready() {
+		return 0
+	}
+	x, y := t.predict(nowMs)
+	threshold2 := float64(CONVINCED_DISTANCE_THRESHOLD * CONVINCED_DISTANCE_THRESHOLD)
+	return threshold2 / (threshold2 + x.p00 + y.p00)
+}
+
+// motionMeasureVariance returns the location measurement variance, inflated for low confidence matches.
+func motionMeasureVariance(conf float64) float64 {
+	std := MOTION_MEASURE_STD / math.Max(conf, 0.1)
+	return std * std
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// motionTestState drives an InferState on a fake clock, feeding location hits the way resolveInferState does.
+type motionTestState struct {
+	state InferState
+	now   time.Time
+}
+
+func newMotionTestState() *motionTestState {
+	s := &motionTestState{now: time.UnixMilli(1_000_000)}
+	s.state.clock = func() time.Time { return s.now }
+	return s
+}
+
+// hit advances the clock by dt and reports whether the location passes the gate; accepted hits are fused.
+func (s *motionTestState) hit(dt time.Duration, x, y float64) bool {
+	s.now = s.now.Add(dt)
+	s.state.Lock()
+	defer s.state.Unlock()
+	loc := InferLocationRawResult{MapName: "map02_lv002", X: x, Y: y, Conf: 1.0, Source: FULL_SEARCH_HIT}
+	if s.state.convinced.MapName == "" {
+		s.state.SetConvinced(loc)
+		return true
+	}
+	if !s.state.IsCloseToConvinced(&loc) {
+		return false
+	}
+	s.state.SetConvinced(loc)
+	return true
+}
+
+func TestMotionTrackConstantVelocity(t *testing.T) {
+	s := newMotionTestState()
+	const speed = 8.0 // Running speed in px/s
+	for k := 0; k <= 20; k++ {
+		x := 100 + speed*0.2*float64(k)
+		if !s.hit(200*time.Millisecond, x, 200) {
+			t.Fatalf("step %d: measurement on a constant-velocity track rejected", k)
+		}
+	}
+	if v := s.state.track.x.vel; math.Abs(v-speed) > 1.0 {
+		t.Fatalf("estimated velocity = %.2f, want about %.1f", v, speed)
+	}
+	s.state.Lock()
+	x, y := s.state.PredictConvinced()
+	s.state.Unlock()
+	if want := 100 + speed*0.2*20; math.Abs(x-want) > 1.0 || math.Abs(y-200) > 1.0 {
+		t.Fatalf("smoothed location = (%.2f, %.2f), want about (%.1f, 200)", x, y, want)
+	}
+}
+
+func TestMotionTrackRejectsJump(t *testing.T) {
+	s := newMotionTestState()
+	for k := 0; k <= 10; k++ {
+		s.hit(200*time.Millisecond, 100, 200)
+	}
+	// A false match elsewhere on the map while standing still
+	if s.hit(200*time.Millisecond, 160, 230) {
+		t.Fatal("a 67px jump within 200ms was accepted")
+	}
+	if s.hit(200*time.Millisecond, 100+CONVINCED_DISTANCE_THRESHOLD+5, 200) {
+		t.Fatal("a jump beyond the convinced distance threshold was accepted")
+	}
+}
+
+func TestMotionTrackAcceptsFastMoves(t *testing.T) {
+	cases := []struct {
+		name string
+		// run feeds a converged track and returns whether the final maneuver was accepted
+		run func(s *motionTestState) bool
+	}{
+		{
+			name: "sprint start after a gap",
+			run: func(s *motionTestState) bool {
+				for k := 0; k <= 10; k++ {
+					s.hit(200*time.Millisecond, 100, 200)
+				}
+				// Sprinting east for 1.5s without a hit in between
+				return s.hit(1500*time.Millisecond, 100+12*1.5, 200)
+			},
+		},
+		{
+			name: "sprint after a long gap",
+			run: func(s *motionTestState) bool {
+				for k := 0; k <= 10; k++ {
+					s.hit(200*time.Millisecond, 100, 200)
+				}
+				// Beyond MOTION_GATE_MIN_DISTANCE, accepted by the widened Mahalanobis gate
+				return s.hit(1900*time.Millisecond, 100+12*1.9, 200)
+			},
+		},
+		{
+			name: "sprint reversal",
+			run: func(s *motionTestState) bool {
+				x := 100.0
+				for k := 0; k <= 10; k++ {
+					x += 12 * 0.2
+					s.hit(200*time.Millisecond, x, 200)
+				}
+				// Turning back: the prediction is 6px ahead, the player is 6px behind
+				return s.hit(500*time.Millisecond, x-12*0.5, 200)
+			},
+		},
+		{
+			name: "dodge while sprinting",
+			run: func(s *motionTestState) bool {
+				x := 100.0
+				for k := 0; k <= 20; k++ {
+					x += 12 * 0.1
+					s.hit(100*time.Millisecond, x, 200)
+				}
+				// A dodge covers about 15px at once, far beyond the measurement noise
+				return s.hit(100*time.Millisecond, x+1.2+15, 200)
+			},
+		},
+		{
+			name: "sharp turn while sprinting",
+			run: func(s *motionTestState) bool {
+				y := 200.0
+				for k := 0; k <= 10; k++ {
+					y -= 12 * 0.2
+					s.hit(200*time.Millisecond, 100, y)
+				}
+				return s.hit(500*time.Millisecond, 100+12*0.5, y)
+			},
+		},
+	}
+	for _, c := range cases {
+		if !c.run(newMotionTestState()) {
+			t.Fatalf("%s: measurement rejected", c.name)
+		}
+	}
+}
+
+func TestMotionTrackResetsOnMapChange(t *testing.T) {
+	s := newMotionTestState()
+	for k := 0; k <= 10; k++ {
+		s.hit(200*time.Millisecond, 100+12*0.2*float64(k), 200)
+	}
+
+	s.now = s.now.Add(200 * time.Millisecond)
+	s.state.Lock()
+	s.state.SetConvinced(InferLocationRawResult{MapName: "map01_lv001", X: 500, Y: 600, Conf: 1.0, Source: FULL_SEARCH_HIT})
+	x, y := s.state.PredictConvinced()
+	s.state.Unlock()
+	if s.state.track.mapName != "map01_lv001" || s.state.track.x.vel != 0 || s.state.track.y.vel != 0 {
+		t.Fatalf("track after map change = %+v, want a fresh track on map01_lv001", s.state.track)
+	}
+	if x != 500 || y != 600 {
+		t.Fatalf("location after map change = (%.2f, %.2f), want (500, 600)", x, y)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"math"
 	"sync"
 	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
 )
 
 // InferLocationHitMode represents the mode of location inference hit
@@ -13,6 +15,7 @@ type InferLocationHitMode string
 const (
 	FULL_SEARCH_HIT InferLocationHitMode = "FullSearchHit"
 	FAST_SEARCH_HIT InferLocationHitMode = "FastSearchHit"
+	FEATURE_HIT     InferLocationHitMode = "FeatureHit"
 )
 
 // Time-series empirical optimization configuration
@@ -34,8 +37,14 @@ type InferState struct {
 	pendingFirstHitTime int64
 	pendingHitCount     int
 
+	// track filters the convinced location over time, see motionTrack.
+	track motionTrack
+
 	mu       sync.Mutex
 	lockTime int64
+
+	// clock overrides the wall clock used by Lock, e.g. for replaying recorded frames.
+	clock func() time.Time
 }
 
 var globalInferState InferState
@@ -43,7 +52,11 @@ var globalInferState InferState
 // Lock acquires the state mutex and records the current time
 func (s *InferState) Lock() {
 	s.mu.Lock()
-	s.lockTime = time.Now().UnixMilli()
+	if s.clock != nil {
+		s.lockTime = s.clock().UnixMilli()
+	} else {
+		s.lockTime = time.Now().UnixMilli()
+	}
 }
 
 // Unlock releases the state mutex and clears the lock time
@@ -60,9 +73,17 @@ func (s *InferState) getLockTime() int64 {
 	return s.lockTime
 }
 
-// SetConvinced sets the convinced state and updates last hit time
+// SetConvinced fuses the location into the motion track, sets the smoothed result as the convinced state and updates last hit time
 func (s *InferState) SetConvinced(loc InferLocationRawResult) {
 	nowMs := s.getLockTime()
+	if s.track.ready() && isMapNameCoreMatch(s.track.mapName, loc.MapName) && nowMs-s.convincedLastHitTime < CONVINCED_VALID_TIME_MS {
+		s.track.update(loc, nowMs)
+		s.track.mapName = loc.MapName
+	} else {
+		s.track.reset(loc, nowMs)
+	}
+	loc.X, loc.Y = s.track.x.pos, s.track.y.pos
+	loc.TrackConf = s.track.confidence(nowMs)
 	s.convinced = loc
 	s.convincedLastHitTime = nowMs
 }
@@ -86,7 +107,9 @@ func (s *InferState) UpdatePending(x, y float64) {
 // TakeoverPending promotes pending to convinced state
 func (s *InferState) TakeoverPending() {
 	nowMs := s.getLockTime()
+	s.track.reset(s.pending, nowMs)
 	s.convinced = s.pending
+	s.convinced.TrackConf = s.track.confidence(nowMs)
 	s.convincedLastHitTime = nowMs
 	s.ResetPending()
 }
@@ -107,11 +130,16 @@ func (s *InferState) IsConvincedValid() bool {
 		s.pendingHitCount == 0
 }
 
-// IsCloseToConvinced checks if a location is close to the convinced location
+// IsCloseToConvinced checks if a location is close to the convinced location.
+// While the motion track is fresh, it gates the location against the predicted state instead, see motionTrack.gate.
 func (s *InferState) IsCloseToConvinced(loc *InferLocationRawResult) bool {
+	nowMs := s.getLockTime()
 	if !isMapNameCoreMatch(s.convinced.MapName, loc.MapName) {
 		return false
 	}
+	if s.track.ready() && nowMs-s.convincedLastHitTime < CONVINCED_VALID_TIME_MS {
+		return s.track.gate(loc, nowMs)
+	}
 	dx := s.convinced.X - loc.X
 	dy := s.convinced.Y - loc.Y
 	return math.Hypot(dx, dy) < CONVINCED_DISTANCE_THRESHOLD
@@ -140,3 +168,42 @@ func (s *InferState) IsImmediateTrackLoss() bool {
 	nowMs := s.getLockTime()
 	return nowMs-s.convincedLastHitTime < CONVINCED_VALID_TIME_MS
 }
+
+// PredictConvinced returns the convinced location extrapolated to the lock time by the motion track
+func (s *InferState) PredictConvinced() (float64, float64) {
+	nowMs := s.getLockTime()
+	if !s.track.ready() || !isMapNameCoreMatch(s.track.mapName, s.convinced.MapName) {
+		return s.convinced.X, s.convinced.Y
+	}
+	x, y := s.track.predict(nowMs)
+	return x.pos, y.pos
+}
+
+// UpdateHeading feeds a measured rotation into the heading filter of the motion track
+func (s *InferState) UpdateHeading(rot int) {
+	nowMs := s.getLockTime()
+	s.track.updateHeading(rot, nowMs)
+}
+
+// SetMotionHint records the movement currently commanded to the player, used as a velocity prior by the motion track
+func (s *InferState) SetMotionHint(movement control.PlayerMovement) {
+	nowMs := s.getLockTime()
+	s.track.hint = movement
+	s.track.hintTimeMs = nowMs
+}
+
+// SmoothedResult returns a copy of the raw location with the smoothed convinced position and the tracking confidence
+func (s *InferState) SmoothedResult(loc *InferLocationRawResult) *InferLocationRawResult {
+	_ = s.getLockTime()
+	result := *loc
+	result.X, result.Y = s.convinced.X, s.convinced.Y
+	result.TrackConf = s.convinced.TrackConf
+	return &result
+}
+
+// reportPlayerMovement records the commanded movement into the global inference state
+func reportPlayerMovement(movement control.PlayerMovement) {
+	globalInferState.Lock()
+	defer globalInferState.Unlock()
+	globalInferState.SetMotionHint(movement)
+}
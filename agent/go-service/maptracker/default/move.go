@@ -20,14 +20,27 @@ import (
 	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/i18n"
-	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/maafocus"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/minicv"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/resource"
 	"github.com/MaaXYZ/maa-framework-go/v4"
 	"github.com/rs/zerolog/log"
 )
 
-type MapTrackerMove struct{}
+type MapTrackerMove struct {
+	// onStuck is notified of stuck conditions, so that callers like MapTrackerGoal can learn obstacles.
+	onStuck func(event moveStuckEvent)
+}
+
+// moveStuckEvent describes a stuck condition detected by MapTrackerMove.
+type moveStuckEvent struct {
+	MapName  string
+	Location [2]float64
+	// From and To are the endpoints of the path segment being traversed.
+	From [2]float64
+	To   [2]float64
+	// Fatal means the stuck timeout was exceeded and the move was aborted.
+	Fatal bool
+}
 
 // MapTrackerMoveParam represents the custom_action_param for MapTrackerMove
 type MapTrackerMoveParam struct {
@@ -86,8 +99,9 @@ var mapTrackerMoveDefaultParam = MapTrackerMoveParam{
 }
 
 var mapTrackerInferParamForMove = MapTrackerInferParam{
-	Precision: 0.7,
-	Threshold: 0.3,
+	Precision:    0.7,
+	Threshold:    0.3,
+	AllowedModes: INFER_MODE_FULL_SEARCH | INFER_MODE_FAST_SEARCH | INFER_MODE_FEATURE_FALLBACK,
 }
 
 // PlayerRotationAdjustmentState keeps track of one rotation adjustment
@@ -123,10 +137,29 @@ func (a *MapTrackerMove) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 		return false
 	}
 
+	if !a.navigate(&maaMoveEnv{ctx: ctx, ctrl: ctrl}, ca, param) {
+		return false
+	}
+
+	// Run the on_finish pipeline node once if provided
+	if len(param.OnFinish) > 0 {
+		log.Info().Msg("Running on_finish node for MapTrackerMove")
+		if err := runOnFinishNode(ctx, param.OnFinish); err != nil {
+			log.Error().Err(err).Msg("Failed to run on_finish node for MapTrackerMove")
+			return false
+		}
+	}
+
+	return true
+}
+
+// navigate drives the player through all targets of the path.
+// It returns false if the navigation is stopped or fails.
+func (a *MapTrackerMove) navigate(env moveEnv, ca control.ControlAdaptor, param *MapTrackerMoveParam) bool {
 	loopInterval := time.Duration(INFER_INTERVAL_MS) * time.Millisecond
 
 	if param.PathTrim && len(param.Path) > 1 {
-		if initRes, err := doInfer(ctx, ctrl, param); err == nil && initRes != nil {
+		if initRes, err := env.Infer(param); err == nil && initRes != nil {
 			closestIdx := 0
 			minDist := math.MaxFloat64
 			for i, p := range param.Path {
@@ -154,6 +187,9 @@ func (a *MapTrackerMove) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 		ca.AggressivelyResetPlayerMovement()
 	}
 
+	// Walking speed for the fine approach and rotation measurement, measured if calibrated
+	walk := control.CalibrationOf(ca).Calibrate(control.MovementWalk)
+
 	// Adaptive rotation sensitivity local state
 	rotationSpeed := ROTATION_DEFAULT_SPEED
 	var rotAdjState, rotAdjStateCache *PlayerRotationAdjustmentState
@@ -167,10 +203,17 @@ func (a *MapTrackerMove) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 
 		// Show navigation UI
 		var initRot int
-		if initResult, err := doInfer(ctx, ctrl, param); err == nil && initResult != nil {
+		segmentFrom := target
+		if i > 0 {
+			segmentFrom = param.Path[i-1]
+		}
+		if initResult, err := env.Infer(param); err == nil && initResult != nil {
 			initRot = calcTargetRotation(initResult.X, initResult.Y, targetX, targetY)
+			if i == 0 {
+				segmentFrom = [2]float64{initResult.X, initResult.Y}
+			}
 			if !param.NoPrint {
-				maafocus.PrintLargeContentTrimNewline(
+				env.Print(
 					a.buildNavigationMovingHTML(param, i, initResult.X, initResult.Y, targetX, targetY),
 				)
 			}
@@ -180,25 +223,26 @@ func (a *MapTrackerMove) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 
 		var (
 			lastLoopTime                = time.Time{}
-			lastArrivalTime             = time.Now()
+			lastArrivalTime             = env.Now()
 			prevLocationTime            = time.Time{}
 			prevLocation                *[2]float64
 			fineApproachOngoing         = false
 			fineApproachExpectedEndTime = time.Time{}
 			stuckMitigatorIdx           = 0
+			stuckReported               = false
 		)
 
 		for {
 			// Calculate time since last check
-			loopElapsed := time.Since(lastLoopTime)
+			loopElapsed := env.Now().Sub(lastLoopTime)
 			if loopElapsed < loopInterval {
-				time.Sleep(loopInterval - loopElapsed)
+				env.Sleep(loopInterval - loopElapsed)
 			}
-			loopStartTime := time.Now()
+			loopStartTime := env.Now()
 			lastLoopTime = loopStartTime
 
 			// Check stopping signal
-			if ctx.GetTasker().Stopping() {
+			if env.Stopping() {
 				log.Warn().Msg("Task is stopping, exiting navigation loop")
 				doPlayerStop(ca)
 				return false
@@ -212,13 +256,14 @@ func (a *MapTrackerMove) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 					break
 				} else {
 					log.Error().Msg("Arrival timeout, stopping task")
-					doEmergencyStop(ca, param.NoPrint)
+					doEmergencyStop(env, ca, param.NoPrint)
 					return false
 				}
 			}
 
-			// Run inference to get current location and rotation
-			result, err := doInfer(ctx, ctrl, param)
+			// Run inference to get current location and rotation, with the commanded movement as motion prior
+			reportPlayerMovement(ca.GetPlayerMovement())
+			result, err := env.Infer(param)
 			if err != nil {
 				log.Error().Err(err).Msg("Inference failed during navigation")
 				ca.SetPlayerMovement(control.MovementStop, control.PolicyDefault)
@@ -226,6 +271,8 @@ func (a *MapTrackerMove) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 			}
 			curX, curY := result.X, result.Y
 			rot := result.Rot
+			// Steering decisions are only taken on a well tracked location
+			isTracked := result.TrackConf >= MOVE_MIN_TRACK_CONF
 
 			// Calculate rotation difference
 			targetRot := calcTargetRotation(curX, curY, targetX, targetY)
@@ -264,7 +311,7 @@ func (a *MapTrackerMove) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 				if dist < param.ArrivalThreshold {
 					if enableFineApproach {
 						fineApproachOngoing = true
-						fineApproachExpectedElapsed := control.MovementWalk.EtaOfDistance(dist)
+						fineApproachExpectedElapsed := walk.EtaOfDistance(dist)
 						fineApproachExpectedEndTime = loopStartTime.Add(fineApproachExpectedElapsed)
 						ca.SetPlayerMovement(control.MovementWalk, control.PolicyDefault)
 						log.Info().Int("index", i).Float64("dist", dist).Dur("expectedElapsed", fineApproachExpectedElapsed).Msg("Entering fine approach")
@@ -287,14 +334,19 @@ func (a *MapTrackerMove) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 				deltaLocationMs := loopStartTime.Sub(prevLocationTime).Milliseconds()
 				if deltaLocationMs > param.StuckTimeout {
 					log.Error().Msg("Stuck for too long, stopping task")
-					doEmergencyStop(ca, param.NoPrint)
+					a.reportStuck(param, curX, curY, segmentFrom, target, true)
+					doEmergencyStop(env, ca, param.NoPrint)
 					return false
 				}
 				if deltaLocationMs > param.StuckThreshold {
+					if !stuckReported {
+						stuckReported = true
+						a.reportStuck(param, curX, curY, segmentFrom, target, false)
+					}
 					if len(param.StuckMitigators) > 0 {
 						action := param.StuckMitigators[stuckMitigatorIdx%len(param.StuckMitigators)]
 						stuckMitigatorIdx++
-						executeStuckMitigator(ctx, ca, action)
+						executeStuckMitigator(env, ca, action)
 					} else {
 						log.Debug().Msg("Stuck but no mitigators configured, skipping mitigation")
 					}
@@ -302,15 +354,16 @@ func (a *MapTrackerMove) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 			} else {
 				prevLocation = &[2]float64{curX, curY}
 				prevLocationTime = loopStartTime
+				stuckReported = false
 			}
 
 			// Update adaptive rotation speed
-			if rotAdjState != nil && (rotAdjStateCache == nil || rotAdjState.startTime.After(rotAdjStateCache.startTime)) {
+			if isTracked && rotAdjState != nil && (rotAdjStateCache == nil || rotAdjState.startTime.After(rotAdjStateCache.startTime)) {
 				// Check if last rotation adjustment is completed
 				if loopStartTime.Sub(rotAdjState.startTime) > rotAdjState.expectedElapsed {
 					// Check if player is moving and rotating sufficiently to trust rotation measurement
 					distTravel := math.Hypot(curX-rotAdjState.fromPos[0], curY-rotAdjState.fromPos[1])
-					if distTravel > control.MovementWalk.DistanceDuring(rotAdjState.expectedElapsed) {
+					if distTravel > walk.DistanceDuring(rotAdjState.expectedElapsed) {
 						// Check if rotation difference is sufficient to consider adjusting rotation speed
 						actualDeltaRot := calcDeltaRotation(rotAdjState.fromRot, rot)
 						if math.Abs(float64(actualDeltaRot))+math.Abs(rotAdjState.deltaRot) > param.RotationLowerThreshold {
@@ -362,7 +415,7 @@ func (a *MapTrackerMove) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 				}
 
 				// Start a new rotation adjustment
-				if absRawDeltaRot > 1.0 && (!fineApproachOngoing || absRawDeltaRot > param.RotationLowerThreshold) {
+				if isTracked && absRawDeltaRot > 1.0 && (!fineApproachOngoing || absRawDeltaRot > param.RotationLowerThreshold) {
 					finalDeltaRot := float64(rawDeltaRot)
 					ca.RotateCamera(int(finalDeltaRot*rotationSpeed), 0)
 
@@ -371,7 +424,7 @@ func (a *MapTrackerMove) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 						fromPos:         [2]float64{curX, curY},
 						fromRot:         rot,
 						deltaRot:        finalDeltaRot,
-						startTime:       time.Now(),
+						startTime:       env.Now(),
 						expectedElapsed: ca.GetPlayerMovement().EtaOfRotation(math.Abs(finalDeltaRot)),
 					}
 					ca.ResetCursor(control.CursorResetLazy)
@@ -390,23 +443,14 @@ func (a *MapTrackerMove) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 		if len(param.Path) > 0 {
 			finishedX, finishedY = param.Path[len(param.Path)-1][0], param.Path[len(param.Path)-1][1]
 		}
-		if finalInfer, err := doInfer(ctx, ctrl, param); err == nil && finalInfer != nil {
+		if finalInfer, err := env.Infer(param); err == nil && finalInfer != nil {
 			finishedX, finishedY = finalInfer.X, finalInfer.Y
 		}
-		maafocus.PrintLargeContentTrimNewline(
+		env.Print(
 			a.buildNavigationFinishedHTML(param, finishedX, finishedY),
 		)
 	}
 
-	// Run the on_finish pipeline node once if provided
-	if len(param.OnFinish) > 0 {
-		log.Info().Msg("Running on_finish node for MapTrackerMove")
-		if err := runOnFinishNode(ctx, param.OnFinish); err != nil {
-			log.Error().Err(err).Msg("Failed to run on_finish node for MapTrackerMove")
-			return false
-		}
-	}
-
 	return true
 }
 
@@ -511,12 +555,13 @@ func doPlayerStop(ca control.ControlAdaptor) {
 	ca.SetPlayerMovement(control.MovementRun, control.PolicyLazy)
 	// Finally set to stop to ensure immediate response to stopping signal
 	ca.SetPlayerMovement(control.MovementStop, control.PolicyDefault)
+	reportPlayerMovement(control.MovementStop)
 }
 
-func doEmergencyStop(ca control.ControlAdaptor, noPrint bool) {
+func doEmergencyStop(env moveEnv, ca control.ControlAdaptor, noPrint bool) {
 	log.Warn().Msg("Emergency stop triggered")
 	if !noPrint {
-		maafocus.PrintLargeContentTrimNewline(i18n.RenderHTML("maptracker.emergency_stop", nil))
+		env.Print(i18n.RenderHTML("maptracker.emergency_stop", nil))
 	}
 	doPlayerStop(ca)
 }
@@ -540,6 +585,7 @@ func doInfer(ctx *maa.Context, ctrl *maa.Controller, param *MapTrackerMoveParam)
 		"map_name_regex": mapNameRegex,
 		"precision":      mapTrackerInferParamForMove.Precision,
 		"threshold":      mapTrackerInferParamForMove.Threshold,
+		"allowed_modes":  mapTrackerInferParamForMove.AllowedModes,
 	}
 
 	inferConfigBytes, err := json.Marshal(inferConfig)
@@ -600,14 +646,21 @@ func runOnFinishNode(ctx *maa.Context, node map[string]any) error {
 	return nil
 }
 
-func executeStuckMitigator(ctx *maa.Context, ca control.ControlAdaptor, action string) {
+func (a *MapTrackerMove) reportStuck(param *MapTrackerMoveParam, x, y float64, from, to [2]float64, fatal bool) {
+	if a.onStuck == nil {
+		return
+	}
+	a.onStuck(moveStuckEvent{MapName: param.MapName, Location: [2]float64{x, y}, From: from, To: to, Fatal: fatal})
+}
+
+func executeStuckMitigator(env moveEnv, ca control.ControlAdaptor, action string) {
 	log.Info().Str("mitigator", action).Msg("Executing stuck mitigator action")
 	switch action {
 	case "Jump":
 		ca.SetPlayerMovement(ca.GetPlayerMovement(), control.PolicyActive)
 		ca.PlayerJump()
 	case "MoveOrDeleteDevice":
-		if _, err := ctx.RunTask("MapTrackerStuckMitigator_MoveOrDeleteDevice"); err != nil {
+		if err := env.RunTask("MapTrackerStuckMitigator_MoveOrDeleteDevice"); err != nil {
 			log.Warn().Err(err).Msg("Stuck mitigator MoveOrDeleteDevice failed")
 		}
 	default:
@@ -0,0 +1,57 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/maafocus"
+	"github.com/MaaXYZ/maa-framework-go/v4"
+)
+
+// moveEnv abstracts the time, location inference and pipeline side effects of MapTrackerMove,
+// so that its navigation logic can run against a simulated environment in tests.
+type moveEnv interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	// Stopping reports whether the tasker is stopping.
+	Stopping() bool
+	// Infer captures the screen and infers the player location.
+	Infer(param *MapTrackerMoveParam) (*MapTrackerInferResult, error)
+	// RunTask runs the given pipeline entry.
+	RunTask(entry string) error
+	// Print shows the content in the UI.
+	Print(content string)
+}
+
+// maaMoveEnv is the moveEnv backed by the MaaFramework context and controller.
+type maaMoveEnv struct {
+	ctx  *maa.Context
+	ctrl *maa.Controller
+}
+
+var _ moveEnv = &maaMoveEnv{}
+
+func (e *maaMoveEnv) Now() time.Time {
+	return time.Now()
+}
+
+func (e *maaMoveEnv) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (e *maaMoveEnv) Stopping() bool {
+	return e.ctx.GetTasker().Stopping()
+}
+
+func (e *maaMoveEnv) Infer(param *MapTrackerMoveParam) (*MapTrackerInferResult, error) {
+	return doInfer(e.ctx, e.ctrl, param)
+}
+
+func (e *maaMoveEnv) RunTask(entry string) error {
+	_, err := e.ctx.RunTask(entry)
+	return err
+}
+
+func (e *maaMoveEnv) Print(content string) {
+	maafocus.PrintLargeContentTrimNewline(content)
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control/controltest"
+)
+
+// simMoveEnv runs MapTrackerMove against a simulated player on the fake clock.
+type simMoveEnv struct {
+	clock    *controltest.Clock
+	screen   *controltest.Screen
+	stopping func() bool
+	tasks    []string
+	prints   int
+}
+
+func (e *simMoveEnv) Now() time.Time {
+	return e.clock.Now()
+}
+
+func (e *simMoveEnv) Sleep(d time.Duration) {
+	e.clock.Sleep(d)
+}
+
+func (e *simMoveEnv) Stopping() bool {
+	return e.stopping != nil && e.stopping()
+}
+
+func (e *simMoveEnv) Infer(param *MapTrackerMoveParam) (*MapTrackerInferResult, error) {
+	frame := e.screen.Capture()
+	if frame.Err != nil {
+		return nil, frame.Err
+	}
+	return &MapTrackerInferResult{
+		MapName:   param.MapName,
+		X:         frame.X,
+		Y:         frame.Y,
+		Rot:       frame.Rot,
+		LocConf:   frame.Conf,
+		RotConf:   frame.Conf,
+		TrackConf: 1.0,
+	}, nil
+}
+
+func (e *simMoveEnv) RunTask(entry string) error {
+	e.tasks = append(e.tasks, entry)
+	return nil
+}
+
+func (e *simMoveEnv) Print(string) {
+	e.prints++
+}
+
+type moveSim struct {
+	env     *simMoveEnv
+	ca      *controltest.Adaptor
+	player  *controltest.Player
+	move    *MapTrackerMove
+	stucks  []moveStuckEvent
+	started time.Time
+}
+
+func newMoveSim(player *controltest.Player) *moveSim {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := controltest.NewClock(start)
+	sim := &moveSim{
+		env:     &simMoveEnv{clock: clock, screen: controltest.NewScreen(clock, player, 1)},
+		ca:      controltest.NewAdaptor(clock, player),
+		player:  player,
+		started: start,
+	}
+	sim.move = &MapTrackerMove{onStuck: func(event moveStuckEvent) { sim.stucks = append(sim.stucks, event) }}
+	return sim
+}
+
+func (s *moveSim) run(t *testing.T, paramStr string) bool {
+	t.Helper()
+	param, err := s.move.parseParam(paramStr)
+	if err != nil {
+		t.Fatalf("parseParam: %v", err)
+	}
+	return s.move.navigate(s.env, s.ca, param)
+}
+
+func (s *moveSim) assertNear(t *testing.T, x, y, tolerance float64) {
+	t.Helper()
+	if d := math.Hypot(s.player.X-x, s.player.Y-y); d > tolerance {
+		t.Fatalf("player at (%.2f, %.2f), want within %.1f of (%.1f, %.1f)", s.player.X, s.player.Y, tolerance, x, y)
+	}
+}
+
+func TestMapTrackerMoveStraightRoute(t *testing.T) {
+	sim := newMoveSim(controltest.NewPlayer(100, 100, 0))
+	ok := sim.run(t, `{"map_name":"map01_lv001","path":[[100,80],[100,60]],"no_print":true}`)
+	if !ok {
+		t.Fatal("navigation failed")
+	}
+	sim.assertNear(t, 100, 60, 3)
+	if len(sim.stucks) != 0 {
+		t.Fatalf("unexpected stuck events: %+v", sim.stucks)
+	}
+	if sim.env.prints != 0 {
+		t.Fatalf("printed %d times with no_print", sim.env.prints)
+	}
+	if !sim.player.Movement().Equals(control.MovementStop) {
+		t.Fatalf("player still moving: %v", sim.player.Movement())
+	}
+	if len(sim.ca.CallsOf("SetPlayerMovement")) == 0 {
+		t.Fatal("no movement was commanded")
+	}
+}
+
+func TestMapTrackerMoveRotatesTowardTarget(t *testing.T) {
+	sim := newMoveSim(controltest.NewPlayer(100, 100, 0))
+	ok := sim.run(t, `{"map_name":"map01_lv001","path":[[130,100]],"no_print":true}`)
+	if !ok {
+		t.Fatal("navigation failed")
+	}
+	sim.assertNear(t, 130, 100, 3)
+	if total := sim.ca.TotalRotateCamera(); total <= 0 {
+		t.Fatalf("total camera rotation %d, want clockwise", total)
+	}
+	first := sim.ca.CallsOf("RotateCamera")[0]
+	if dx := first.Args["dx"]; dx < 90 {
+		t.Fatalf("first camera rotation %d, want a large turn toward east", dx)
+	}
+}
+
+func TestMapTrackerMoveJumpsOverLowWall(t *testing.T) {
+	player := controltest.NewPlayer(100, 100, 0)
+	player.Obstacles = []controltest.Obstacle{{MinX: 90, MinY: 89, MaxX: 110, MaxY: 90, Jumpable: true}}
+	sim := newMoveSim(player)
+	ok := sim.run(t, `{"map_name":"map01_lv001","path":[[100,75]],"stuck_mitigators":["Jump"],"no_print":true}`)
+	if !ok {
+		t.Fatal("navigation failed")
+	}
+	sim.assertNear(t, 100, 75, 3)
+	if len(sim.ca.CallsOf("PlayerJump")) == 0 {
+		t.Fatal("player never jumped")
+	}
+	if len(sim.stucks) == 0 || sim.stucks[0].Fatal {
+		t.Fatalf("want a non-fatal stuck event, got %+v", sim.stucks)
+	}
+}
+
+func TestMapTrackerMoveGivesUpOnHighWall(t *testing.T) {
+	player := controltest.NewPlayer(100, 100, 0)
+	player.Obstacles = []controltest.Obstacle{{MinX: 90, MinY: 89, MaxX: 110, MaxY: 90}}
+	sim := newMoveSim(player)
+	ok := sim.run(t, `{"map_name":"map01_lv001","path":[[100,75]],"stuck_mitigators":["Jump","MoveOrDeleteDevice"],"stuck_timeout":6000,"no_print":true}`)
+	if ok {
+		t.Fatal("navigation succeeded through a wall")
+	}
+	if len(sim.stucks) < 2 || !sim.stucks[len(sim.stucks)-1].Fatal {
+		t.Fatalf("want a fatal stuck event last, got %+v", sim.stucks)
+	}
+	if len(sim.env.tasks) == 0 || sim.env.tasks[0] != "MapTrackerStuckMitigator_MoveOrDeleteDevice" {
+		t.Fatalf("MoveOrDeleteDevice mitigator not run, tasks %v", sim.env.tasks)
+	}
+	if elapsed := sim.env.Now().Sub(sim.started); elapsed < 6*time.Second {
+		t.Fatalf("gave up after %v, before the stuck timeout", elapsed)
+	}
+	if !sim.player.Movement().Equals(control.MovementStop) {
+		t.Fatalf("player still moving: %v", sim.player.Movement())
+	}
+}
+
+func TestMapTrackerMoveStopsOnTaskStopping(t *testing.T) {
+	sim := newMoveSim(controltest.NewPlayer(100, 100, 0))
+	deadline := sim.started.Add(time.Second)
+	sim.env.stopping = func() bool { return sim.env.Now().After(deadline) }
+	ok := sim.run(t, `{"map_name":"map01_lv001","path":[[100,0]],"no_print":true}`)
+	if ok {
+		t.Fatal("navigation succeeded after stopping")
+	}
+	if !sim.player.Movement().Equals(control.MovementStop) {
+		t.Fatalf("player still moving: %v", sim.player.Movement())
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/atomicfile"
+)
+
+// RunNavMeshCheck validates the given NavMesh files and writes the found issues to w, one per line.
+// Directories are expanded to the .mtnm files they contain. When rewrite is set,
+// each file without error-level issues is rewritten in the canonical text format.
+// It returns the number of error-level issues, including files that fail to parse.
+func RunNavMeshCheck(w io.Writer, paths []string, rewrite bool) (int, error) {
+	files, err := expandNavMeshPaths(paths)
+	if err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		return 0, fmt.Errorf("no NavMesh files found")
+	}
+
+	errorCount := 0
+	for _, path := range files {
+		mesh, err := readNavMeshFile(path)
+		if err != nil {
+			errorCount++
+			if _, err := fmt.Fprintf(w, "%s: %s: parse: %v\n", path, internal.NavMeshIssueSeverityError, err); err != nil {
+				return errorCount, err
+			}
+			continue
+		}
+		issues := mesh.Validate()
+		fileErrors := 0
+		for _, issue := range issues {
+			if issue.Severity == internal.NavMeshIssueSeverityError {
+				fileErrors++
+			}
+			if _, err := fmt.Fprintf(w, "%s: %s\n", path, issue); err != nil {
+				return errorCount + fileErrors, err
+			}
+		}
+		errorCount += fileErrors
+		if rewrite && fileErrors == 0 {
+			if err := writeNavMeshFile(path, mesh); err != nil {
+				return errorCount, err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s: %d vertices, %d edges, %d issue(s)\n", path, len(mesh.Vertices), len(mesh.Edges), len(issues)); err != nil {
+			return errorCount, err
+		}
+	}
+	return errorCount, nil
+}
+
+func expandNavMeshPaths(paths []string) ([]string, error) {
+	files := make([]string, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory %s: %w", path, err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".mtnm") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func readNavMeshFile(path string) (*internal.NavMesh, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return internal.ParseNavMesh(file)
+}
+
+// writeNavMeshFile writes the mesh atomically, so that a failed write never leaves a truncated NavMesh behind.
+func writeNavMeshFile(path string, mesh *internal.NavMesh) error {
+	var buf bytes.Buffer
+	if _, err := mesh.WriteTo(&buf); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := atomicfile.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testDuplicateEntityNavMeshText has two vertices sharing entity 100, an error-level issue.
+const testDuplicateEntityNavMeshText = `[MapTrackerNavMesh.Meta]
+Version=1
+Encoding=UTF-8
+Name=map_line_lv001
+Description=Test navmesh
+MapRegionName=map_line
+MapLevelName=lv001
+GeoWidth=100.0
+GeoHeight=100.0
+
+[MapTrackerNavMesh.Vertices]
+V1=X0,Y0,T0,E100,F(C)
+V2=X100,Y0,T0,E100,F(C)
+
+[MapTrackerNavMesh.Edges]
+E1=S1,D2,B1,C100,F()
+`
+
+func TestRunNavMeshCheckRewritesOnlyValidFiles(t *testing.T) {
+	dir := t.TempDir()
+	validPath := filepath.Join(dir, "valid.mtnm")
+	invalidPath := filepath.Join(dir, "invalid.mtnm")
+	// Extra blank lines are dropped by the canonical format
+	validText := strings.Replace(testLineNavMeshText, "[MapTrackerNavMesh.Edges]", "\n\n[MapTrackerNavMesh.Edges]", 1)
+	invalidText := strings.Replace(testDuplicateEntityNavMeshText, "[MapTrackerNavMesh.Edges]", "\n\n[MapTrackerNavMesh.Edges]", 1)
+	for path, text := range map[string]string{validPath: validText, invalidPath: invalidText} {
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	errorCount, err := RunNavMeshCheck(&out, []string{dir}, true)
+	if err != nil {
+		t.Fatalf("RunNavMeshCheck() error = %v", err)
+	}
+	if errorCount != 1 {
+		t.Fatalf("errorCount = %d, want 1\n%s", errorCount, out.String())
+	}
+
+	if raw, _ := os.ReadFile(invalidPath); string(raw) != invalidText {
+		t.Fatalf("file with errors was rewritten:\n%s", raw)
+	}
+	if raw, _ := os.ReadFile(validPath); string(raw) == validText {
+		t.Fatal("valid file was not rewritten")
+	}
+	if _, err := readNavMeshFile(validPath); err != nil {
+		t.Fatalf("rewritten file does not parse: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatalf("unexpected files left in %s: %v", dir, entries)
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/minicv"
+	"github.com/rs/zerolog/log"
+)
+
+// INFER_REPLAY_MANIFEST is the manifest file name expected in a replay directory.
+const INFER_REPLAY_MANIFEST = "frames.json"
+
+// InferReplayManifest describes a directory of recorded screenshots for offline MapTrackerInfer replay.
+type InferReplayManifest struct {
+	// ControlType is the controller type the frames were captured with (default "win32").
+	ControlType string `json:"control_type,omitempty"`
+	// Frames are the recorded frames in capture order.
+	Frames []InferReplayFrame `json:"frames"`
+}
+
+// InferReplayFrame represents one recorded screenshot with its ground-truth location.
+type InferReplayFrame struct {
+	// Image is the screenshot file path relative to the replay directory.
+	Image string `json:"image"`
+	// TimeMs is the capture timestamp in ms, used as the clock of the time-series state.
+	TimeMs int64 `json:"time_ms"`
+	// MapName is the ground-truth map name.
+	MapName string `json:"map_name"`
+	// X and Y are the ground-truth map coordinates.
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	// Rot is the optional ground-truth rotation angle.
+	Rot *int `json:"rot,omitempty"`
+}
+
+// InferReplayFrameResult represents the replay result of one frame.
+type InferReplayFrameResult struct {
+	Index       int     `json:"index"`
+	Image       string  `json:"image"`
+	TimeMs      int64   `json:"timeMs"`
+	Hit         bool    `json:"hit"`
+	MapName     string  `json:"mapName"`
+	X           float64 `json:"x"`
+	Y           float64 `json:"y"`
+	Rot         int     `json:"rot"`
+	LocConf     float64 `json:"locConf"`
+	RotConf     float64 `json:"rotConf"`
+	TrackConf   float64 `json:"trackConf"`
+	InferMode   string  `json:"inferMode"`
+	MapMatched  bool    `json:"mapMatched"`
+	LocError    float64 `json:"locError"`            // Euclidean distance to ground truth, -1 if not hit
+	RotError    int     `json:"rotError"`            // Absolute angular difference to ground truth, -1 if unknown
+	LocTimeMs   int64   `json:"locTimeMs"`           // Location inference time in ms
+	RotTimeMs   int64   `json:"rotTimeMs"`           // Rotation inference time in ms
+	InferTimeMs int64   `json:"inferTimeMs"`         // Total inference time in ms
+	Error       string  `json:"error,omitempty"`     // Frame level error such as unreadable image
+	RawMapName  string  `json:"rawMapName"`          // Raw location inference result before time-series filtering
+	RawLocConf  float64 `json:"rawLocConf"`          // Raw location confidence before time-series filtering
+	RawLocError float64 `json:"rawLocError"`         // Raw location error before time-series filtering, -1 if unavailable
+	RawSource   string  `json:"rawSource,omitempty"` // Raw location inference mode before time-series filtering
+}
+
+// InferReplaySummary aggregates the replay results of all frames.
+type InferReplaySummary struct {
+	FrameCount      int     `json:"frameCount"`
+	HitCount        int     `json:"hitCount"`
+	MapMatchedCount int     `json:"mapMatchedCount"`
+	FastSearchCount int     `json:"fastSearchCount"`
+	MeanLocError    float64 `json:"meanLocError"`
+	P95LocError     float64 `json:"p95LocError"`
+	MaxLocError     float64 `json:"maxLocError"`
+	MeanRotError    float64 `json:"meanRotError"`
+	MeanInferTimeMs float64 `json:"meanInferTimeMs"`
+	MaxInferTimeMs  int64   `json:"maxInferTimeMs"`
+}
+
+// InferReplayReport is the full output of an offline MapTrackerInfer replay.
+type InferReplayReport struct {
+	Param   MapTrackerInferParam     `json:"param"`
+	Frames  []InferReplayFrameResult `json:"frames"`
+	Summary InferReplaySummary       `json:"summary"`
+}
+
+// RunInferReplay replays the recorded frames in dir through the full MapTrackerInfer pipeline,
+// including Full/Fast search and the pending/convinced time-series state.
+// paramStr uses the same format as the custom_recognition_param of MapTrackerInfer.
+func RunInferReplay(dir string, paramStr string) (*InferReplayReport, error) {
+	manifest, err := loadInferReplayManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	infer := &MapTrackerInfer{}
+	param, err := infer.parseParam(paramStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	mapNameRegex, err := regexp.Compile(param.MapNameRegex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid map_name_regex %q: %w", param.MapNameRegex, err)
+	}
+
+	internal.Resource.InitRawMaps(nil)
+	if internal.Resource.RawMapsErr != nil {
+		return nil, fmt.Errorf("failed to initialize maps: %w", internal.Resource.RawMapsErr)
+	}
+
+	// Replay uses its own state driven by the recorded timestamps, so that the result is independent of wall clock.
+	// Timestamps are offset by the replay start time since a zero lock time is reserved by InferState.
+	replayBase := time.Now()
+	frameTime := replayBase
+	state := &InferState{clock: func() time.Time { return frameTime }}
+
+	report := &InferReplayReport{Param: *param, Frames: make([]InferReplayFrameResult, 0, len(manifest.Frames))}
+	for idx, frame := range manifest.Frames {
+		frameTime = replayBase.Add(time.Duration(frame.TimeMs) * time.Millisecond)
+		result := InferReplayFrameResult{Index: idx, Image: frame.Image, TimeMs: frame.TimeMs, LocError: -1, RotError: -1, RawLocError: -1}
+
+		screenImg, err := loadInferReplayImage(filepath.Join(dir, frame.Image))
+		if err != nil {
+			log.Warn().Err(err).Str("image", frame.Image).Msg("Failed to load replay frame")
+			result.Error = err.Error()
+			report.Frames = append(report.Frames, result)
+			continue
+		}
+
+		t0 := time.Now()
+		loc, rot := infer.inferRaw(state, manifest.ControlType, screenImg, mapNameRegex, param)
+		finalLoc, finalRot := infer.resolveInferState(state, loc, rot, param)
+		result.InferTimeMs = time.Since(t0).Milliseconds()
+
+		if loc != nil {
+			result.RawMapName = loc.MapName
+			result.RawLocConf = loc.Conf
+			result.RawSource = string(loc.Source)
+			result.LocTimeMs = loc.ElapsedTimeMs
+			if isMapNameCoreMatch(loc.MapName, frame.MapName) {
+				result.RawLocError = math.Hypot(loc.X-frame.X, loc.Y-frame.Y)
+			}
+		}
+		if rot != nil {
+			result.RotTimeMs = rot.ElapsedTimeMs
+		}
+		if finalLoc != nil && finalRot != nil {
+			result.Hit = true
+			result.MapName = finalLoc.MapName
+			result.X, result.Y = finalLoc.X, finalLoc.Y
+			result.Rot = finalRot.Rot
+			result.LocConf, result.RotConf = finalLoc.Conf, finalRot.Conf
+			result.TrackConf = finalLoc.TrackConf
+			result.InferMode = string(finalLoc.Source)
+			result.MapMatched = isMapNameCoreMatch(finalLoc.MapName, frame.MapName)
+			if result.MapMatched {
+				result.LocError = math.Hypot(finalLoc.X-frame.X, finalLoc.Y-frame.Y)
+			}
+			if frame.Rot != nil {
+				result.RotError = int(math.Abs(float64(calcDeltaRotation(*frame.Rot, finalRot.Rot))))
+			}
+		}
+
+		log.Debug().Int("index", idx).
+			Bool("hit", result.Hit).
+			Float64("locError", result.LocError).
+			Int64("inferTimeMs", result.InferTimeMs).
+			Msg("Replay frame inferred")
+		report.Frames = append(report.Frames, result)
+	}
+
+	report.Summary = summarizeInferReplay(report.Frames)
+	return report, nil
+}
+
+// WriteJSON writes the whole report as indented JSON.
+func (r *InferReplayReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes the per-frame results as CSV, one row per frame.
+func (r *InferReplayReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"index", "image", "time_ms", "hit", "map_name", "x", "y", "rot",
+		"loc_conf", "rot_conf", "track_conf", "infer_mode", "map_matched", "loc_error", "rot_error",
+		"loc_time_ms", "rot_time_ms", "infer_time_ms", "raw_map_name", "raw_loc_conf", "raw_loc_error", "raw_source", "error",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	formatFloat := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, f := range r.Frames {
+		row := []string{
+			strconv.Itoa(f.Index), f.Image, strconv.FormatInt(f.TimeMs, 10), strconv.FormatBool(f.Hit),
+			f.MapName, formatFloat(f.X), formatFloat(f.Y), strconv.Itoa(f.Rot),
+			formatFloat(f.LocConf), formatFloat(f.RotConf), formatFloat(f.TrackConf), f.InferMode, strconv.FormatBool(f.MapMatched),
+			formatFloat(f.LocError), strconv.Itoa(f.RotError),
+			strconv.FormatInt(f.LocTimeMs, 10), strconv.FormatInt(f.RotTimeMs, 10), strconv.FormatInt(f.InferTimeMs, 10),
+			f.RawMapName, formatFloat(f.RawLocConf), formatFloat(f.RawLocError), f.RawSource, f.Error,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func loadInferReplayManifest(dir string) (*InferReplayManifest, error) {
+	content, err := os.ReadFile(filepath.Join(dir, INFER_REPLAY_MANIFEST))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay manifest: %w", err)
+	}
+	var manifest InferReplayManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse replay manifest: %w", err)
+	}
+	if len(manifest.Frames) == 0 {
+		return nil, fmt.Errorf("replay manifest has no frames")
+	}
+	switch manifest.ControlType {
+	case "":
+		manifest.ControlType = control.CONTROL_TYPE_WIN32
+	case control.CONTROL_TYPE_WIN32, control.CONTROL_TYPE_WLROOTS, control.CONTROL_TYPE_ADB:
+		// valid
+	default:
+		return nil, fmt.Errorf("unsupported control_type %q in replay manifest", manifest.ControlType)
+	}
+	for i, frame := range manifest.Frames {
+		if frame.Image == "" {
+			return nil, fmt.Errorf("frames[%d].image is required", i)
+		}
+		if i > 0 && frame.TimeMs < manifest.Frames[i-1].TimeMs {
+			return nil, fmt.Errorf("frames[%d].time_ms is earlier than the previous frame", i)
+		}
+	}
+	return &manifest, nil
+}
+
+// loadInferReplayImage loads a screenshot and normalizes it to the working resolution.
+func loadInferReplayImage(path string) (*image.RGBA, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	rgba := minicv.ImageConvertRGBA(img)
+	if h := rgba.Bounds().Dy(); h != WORK_H && h > 0 {
+		rgba = minicv.ImageScale(rgba, float64(WORK_H)/float64(h))
+	}
+	return rgba, nil
+}
+
+func summarizeInferReplay(frames []InferReplayFrameResult) InferReplaySummary {
+	summary := InferReplaySummary{FrameCount: len(frames)}
+	locErrors := make([]float64, 0, len(frames))
+	rotErrorSum, rotErrorCount := 0, 0
+	inferTimeSum := int64(0)
+	for _, f := range frames {
+		inferTimeSum += f.InferTimeMs
+		summary.MaxInferTimeMs = max(summary.MaxInferTimeMs, f.InferTimeMs)
+		if !f.Hit {
+			continue
+		}
+		summary.HitCount++
+		if f.InferMode == string(FAST_SEARCH_HIT) {
+			summary.FastSearchCount++
+		}
+		if f.MapMatched {
+			summary.MapMatchedCount++
+			locErrors = append(locErrors, f.LocError)
+		}
+		if f.RotError >= 0 {
+			rotErrorSum += f.RotError
+			rotErrorCount++
+		}
+	}
+	if len(frames) > 0 {
+		summary.MeanInferTimeMs = float64(inferTimeSum) / float64(len(frames))
+	}
+	if len(locErrors) > 0 {
+		sort.Float64s(locErrors)
+		sum := 0.0
+		for _, e := range locErrors {
+			sum += e
+		}
+		summary.MeanLocError = sum / float64(len(locErrors))
+		summary.P95LocError = locErrors[min(len(locErrors)-1, int(math.Ceil(0.95*float64(len(locErrors))))-1)]
+		summary.MaxLocError = locErrors[len(locErrors)-1]
+	}
+	if rotErrorCount > 0 {
+		summary.MeanRotError = float64(rotErrorSum) / float64(rotErrorCount)
+	}
+	return summary
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
+)
+
+func chdirInferTestRepoRoot(t *testing.T) {
+	t.Helper()
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get test file path")
+	}
+	oldCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Clean(filepath.Join(filepath.Dir(file), "..", "..", "..", ".."))); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(oldCwd); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// buildReplayTestScreen pastes the map area around (x, y) into the Win32 minimap area of a blank screen.
+func buildReplayTestScreen(m *internal.MapCache, x, y int) *image.RGBA {
+	screen := image.NewRGBA(image.Rect(0, 0, 1280, 720))
+	for dy := -40; dy < 40; dy++ {
+		for dx := -40; dx < 40; dx++ {
+			screen.SetRGBA(108+dx, 111+dy, m.Img.RGBAAt(x-m.OffsetX+dx, y-m.OffsetY+dy))
+		}
+	}
+	return screen
+}
+
+// writeInferReplayFixture records the screens of a short walk on m into dir, in the format read by RunInferReplay.
+// A missing screenshot is appended as the last frame.
+func writeInferReplayFixture(t *testing.T, dir string, m *internal.MapCache, walk [][2]int) {
+	t.Helper()
+	manifest := InferReplayManifest{}
+	for k, pos := range walk {
+		name := fmt.Sprintf("frame_%02d.png", k)
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if err := png.Encode(file, buildReplayTestScreen(m, pos[0], pos[1])); err != nil {
+			t.Fatalf("png.Encode() error = %v", err)
+		}
+		_ = file.Close()
+		manifest.Frames = append(manifest.Frames, InferReplayFrame{
+			Image: name, TimeMs: int64(k * 200), MapName: m.Name, X: float64(pos[0]), Y: float64(pos[1]),
+		})
+	}
+	manifest.Frames = append(manifest.Frames, InferReplayFrame{Image: "missing.png", TimeMs: int64(len(walk) * 200), MapName: m.Name})
+
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, INFER_REPLAY_MANIFEST), content, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestRunInferReplay(t *testing.T) {
+	chdirInferTestRepoRoot(t)
+	maps, err := internal.Resource.LoadMaps()
+	if err != nil {
+		t.Skipf("map resources unavailable: %v", err)
+	}
+	internal.Resource.RawMaps = maps
+	t.Cleanup(func() { internal.Resource.RawMaps = nil })
+
+	var m *internal.MapCache
+	for idx := range maps {
+		if maps[idx].Name == "map02_lv002" {
+			m = &maps[idx]
+		}
+	}
+	if m == nil {
+		t.Fatal("map map02_lv002 not found")
+	}
+	walk := [][2]int{{320, 403}, {323, 403}, {326, 404}, {329, 405}}
+	dir := t.TempDir()
+	writeInferReplayFixture(t, dir, m, walk)
+
+	report, err := RunInferReplay(dir, "")
+	if err != nil {
+		t.Fatalf("RunInferReplay() error = %v", err)
+	}
+	if len(report.Frames) != len(walk)+1 {
+		t.Fatalf("len(report.Frames) = %d, want %d", len(report.Frames), len(walk)+1)
+	}
+	for idx, frame := range report.Frames[:len(walk)] {
+		// Every raw inference finds the recorded location
+		if frame.RawMapName != m.Name || frame.RawLocError < 0 || frame.RawLocError > 1.5 {
+			t.Fatalf("frame %d: unexpected raw result %+v", idx, frame)
+		}
+		// The first frames only make the time-series state pending
+		if frame.Hit != (idx >= 2) {
+			t.Fatalf("frame %d: hit = %v", idx, frame.Hit)
+		}
+		if frame.Hit && (!frame.MapMatched || frame.LocError > 1.5 || frame.InferMode != string(FAST_SEARCH_HIT)) {
+			t.Fatalf("frame %d: unexpected location %+v", idx, frame)
+		}
+	}
+	if report.Frames[0].RawSource != string(FULL_SEARCH_HIT) {
+		t.Fatalf("first frame source = %q, want a full search", report.Frames[0].RawSource)
+	}
+	if last := report.Frames[len(walk)]; last.Hit || last.Error == "" {
+		t.Fatalf("missing screenshot was not reported: %+v", last)
+	}
+
+	summary := report.Summary
+	if summary.FrameCount != len(walk)+1 || summary.HitCount != 2 || summary.MapMatchedCount != 2 || summary.FastSearchCount != 2 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if summary.MaxLocError > 1.5 || summary.MeanLocError > summary.MaxLocError {
+		t.Fatalf("unexpected location errors in summary %+v", summary)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"sync"
+	"time"
+
+	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	STUCK_MAX_REPLAN = 3
+
+	stuckEdgeMatchDistance = 8.0
+	// stuckEdgeSegmentTolerance is how far the endpoints of an edge may lie from the traversed path segment.
+	stuckEdgeSegmentTolerance = 1.0
+	stuckEdgePenaltyFactor    = 4.0
+	stuckEdgeMemoryDuration   = 10 * time.Minute
+)
+
+// learnedStuckEdge is the lesson learned from stuck events on one NavMesh edge.
+type learnedStuckEdge struct {
+	penalty   float64
+	disabled  bool
+	expiresAt time.Time
+}
+
+// stuckEdgeMemory keeps learned stuck edges by map core name and edge ID, so that later goals in the same run avoid them.
+var stuckEdgeMemory = struct {
+	mu    sync.Mutex
+	edges map[string]map[int]*learnedStuckEdge
+}{edges: map[string]map[int]*learnedStuckEdge{}}
+
+// learnStuckEdge attributes the stuck event to an edge and applies the lesson to the mesh.
+// The edge is taken along the path segment being traversed, so that a player pushed off the path does not
+// blame a nearby parallel edge, and falls back to the edge nearest to the stuck location.
+// A recoverable stuck penalizes the edge cost, while a fatal stuck disables the edge.
+func learnStuckEdge(mesh *internal.NavMesh, event moveStuckEvent, now time.Time) (internal.NavMeshEdge, bool) {
+	edge, distance, ok := mesh.NearestEdgeAlong(event.Location, event.From, event.To, stuckEdgeSegmentTolerance)
+	if !ok {
+		edge, distance, ok = mesh.NearestEdge(event.Location, stuckEdgeMatchDistance)
+	}
+	if !ok {
+		log.Debug().Float64("x", event.Location[0]).Float64("y", event.Location[1]).Msg("No NavMesh edge near stuck location")
+		return internal.NavMeshEdge{}, false
+	}
+
+	mapName := getMapCoreName(event.MapName)
+	stuckEdgeMemory.mu.Lock()
+	edges := stuckEdgeMemory.edges[mapName]
+	if edges == nil {
+		edges = map[int]*learnedStuckEdge{}
+		stuckEdgeMemory.edges[mapName] = edges
+	}
+	learned := edges[edge.ID]
+	if learned == nil {
+		learned = &learnedStuckEdge{penalty: 1}
+		edges[edge.ID] = learned
+	}
+	learned.expiresAt = now.Add(stuckEdgeMemoryDuration)
+	if event.Fatal {
+		learned.disabled = true
+	} else {
+		learned.penalty *= stuckEdgePenaltyFactor
+	}
+	stuckEdgeMemory.mu.Unlock()
+
+	if event.Fatal {
+		mesh.DisableEdge(edge.ID)
+	} else {
+		mesh.PenalizeEdge(edge.ID, stuckEdgePenaltyFactor)
+	}
+	log.Info().
+		Str("map", mapName).
+		Int("edge", edge.ID).
+		Float64("distance", distance).
+		Bool("fatal", event.Fatal).
+		Msg("Learned stuck edge")
+	return edge, true
+}
+
+// applyLearnedStuckEdges applies the unexpired stuck lessons of the map to a freshly loaded mesh.
+func applyLearnedStuckEdges(mesh *internal.NavMesh, mapName string, now time.Time) {
+	stuckEdgeMemory.mu.Lock()
+	defer stuckEdgeMemory.mu.Unlock()
+	edges := stuckEdgeMemory.edges[getMapCoreName(mapName)]
+	for id, learned := range edges {
+		if !now.Before(learned.expiresAt) {
+			delete(edges, id)
+			continue
+		}
+		if learned.disabled {
+			mesh.DisableEdge(id)
+		} else if learned.penalty > 1 {
+			mesh.PenalizeEdge(id, learned.penalty)
+		}
+	}
+}
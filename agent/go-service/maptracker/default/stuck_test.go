@@ -0,0 +1,93 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerdefault
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	internal "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/internal"
+)
+
+// testParallelNavMeshText has a lower path E1-E4 along y=0 and a parallel upper path E2 along y=6.
+const testParallelNavMeshText = `[MapTrackerNavMesh.Meta]
+Version=1
+Encoding=UTF-8
+Name=map_parallel_lv001
+Description=Test navmesh
+MapRegionName=map_parallel
+MapLevelName=lv001
+GeoWidth=200.0
+GeoHeight=100.0
+
+[MapTrackerNavMesh.Vertices]
+V1=X0,Y0,T0,E0,F()
+V2=X100,Y0,T0,E0,F()
+V3=X0,Y6,T0,E0,F()
+V4=X100,Y6,T0,E0,F()
+V5=X200,Y0,T0,E0,F()
+
+[MapTrackerNavMesh.Edges]
+E1=S1,D2,B1,C100,F()
+E2=S3,D4,B1,C100,F()
+E3=S1,D3,B1,C6,F()
+E4=S2,D5,B1,C100,F()
+`
+
+func TestLearnStuckEdgeAlongSegment(t *testing.T) {
+	testCases := []struct {
+		name     string
+		location [2]float64
+		from     [2]float64
+		to       [2]float64
+		wantEdge int
+	}{
+		{
+			// Pushed off the lower path, closer to the upper one
+			name:     "pushed toward a parallel edge",
+			location: [2]float64{50, 4},
+			from:     [2]float64{0, 0},
+			to:       [2]float64{100, 0},
+			wantEdge: 1,
+		},
+		{
+			name:     "smoothed segment spanning two edges",
+			location: [2]float64{150, 3},
+			from:     [2]float64{0, 0},
+			to:       [2]float64{200, 0},
+			wantEdge: 4,
+		},
+		{
+			// The segment starts at the player location instead of a vertex
+			name:     "no edge along the segment",
+			location: [2]float64{50, 4},
+			from:     [2]float64{50, 30},
+			to:       [2]float64{100, 6},
+			wantEdge: 2,
+		},
+	}
+
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mesh, err := internal.ParseNavMesh(strings.NewReader(testParallelNavMeshText))
+			if err != nil {
+				t.Fatalf("ParseNavMesh() error = %v", err)
+			}
+			t.Cleanup(func() {
+				stuckEdgeMemory.mu.Lock()
+				delete(stuckEdgeMemory.edges, getMapCoreName("map_parallel_lv001"))
+				stuckEdgeMemory.mu.Unlock()
+			})
+
+			event := moveStuckEvent{MapName: "map_parallel_lv001", Location: tc.location, From: tc.from, To: tc.to, Fatal: true}
+			edge, ok := learnStuckEdge(mesh, event, now)
+			if !ok || edge.ID != tc.wantEdge {
+				t.Fatalf("learnStuckEdge() = (E%d, %v), want E%d", edge.ID, ok, tc.wantEdge)
+			}
+			if !mesh.DisabledEdges[tc.wantEdge] || len(mesh.DisabledEdges) != 1 {
+				t.Fatalf("disabled edges = %v, want only E%d", mesh.DisabledEdges, tc.wantEdge)
+			}
+		})
+	}
+}
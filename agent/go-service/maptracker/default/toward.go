@@ -155,14 +155,7 @@ func (a *MapTrackerToward) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool
 		ca.RotateCamera(int(float64(deltaRot)*ROTATION_DEFAULT_SPEED), 0)
 		ca.ResetCursor(control.CursorResetLazy)
 
-		// Snap the body orientation to the new camera facing by briefly walking backward
-		// and then forward, so the player turns in place without drifting.
-		ca.SetPlayerDirection(control.DirectionB)
-		ca.SetPlayerMovement(control.MovementWalk, control.PolicyDefault)
-		time.Sleep(TOWARD_NUDGE_BACK_MS * time.Millisecond)
-		ca.SetPlayerDirection(control.DirectionF)
-		time.Sleep(TOWARD_NUDGE_FORWARD_MS * time.Millisecond)
-		ca.SetPlayerMovement(control.MovementStop, control.PolicyDefault)
+		snapBodyOrientation(ca)
 
 		// Wait a fixed time for the orientation to settle before the next measurement.
 		time.Sleep(settleWait)
@@ -173,6 +166,17 @@ func (a *MapTrackerToward) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool
 	return true
 }
 
+// snapBodyOrientation snaps the body orientation to the camera facing by briefly walking
+// backward and then forward, so the player turns in place without drifting.
+func snapBodyOrientation(ca control.ControlAdaptor) {
+	ca.SetPlayerDirection(control.DirectionB)
+	ca.SetPlayerMovement(control.MovementWalk, control.PolicyDefault)
+	time.Sleep(TOWARD_NUDGE_BACK_MS * time.Millisecond)
+	ca.SetPlayerDirection(control.DirectionF)
+	time.Sleep(TOWARD_NUDGE_FORWARD_MS * time.Millisecond)
+	ca.SetPlayerMovement(control.MovementStop, control.PolicyDefault)
+}
+
 func (a *MapTrackerToward) parseParam(paramStr string) (*MapTrackerTowardParam, error) {
 	var param MapTrackerTowardParam
 	if err := json.Unmarshal([]byte(paramStr), &param); err != nil {
@@ -30,6 +30,195 @@ type algoEdge struct {
 	cost float64
 }
 
+// astarPath searches the lowest-cost path with a Euclidean heuristic scaled by heuristicScale.
+// The heuristic stays admissible as long as heuristicScale does not exceed the lowest cost per unit distance of any edge.
+func astarPath(points map[int]algoPoint, adjacency map[int][]algoEdge, startID, targetID int, heuristicScale float64) ([]int, error) {
+	target, ok := points[targetID]
+	if !ok {
+		return nil, fmt.Errorf("a* target %d not found", targetID)
+	}
+	heuristic := func(id int) float64 {
+		point, ok := points[id]
+		if !ok || heuristicScale <= 0 {
+			return 0
+		}
+		return heuristicScale * math.Hypot(point.x-target.x, point.y-target.y)
+	}
+
+	open := &dijkstraPriorityQueue{}
+	heap.Init(open)
+	heap.Push(open, dijkstraQueueItem{id: startID, priority: heuristic(startID)})
+
+	cameFrom := map[int]int{}
+	gScore := map[int]float64{startID: 0}
+	closed := map[int]bool{}
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(dijkstraQueueItem).id
+		if closed[current] {
+			continue
+		}
+		if current == targetID {
+			return reconstructDijkstraPath(cameFrom, current), nil
+		}
+		closed[current] = true
+
+		for _, edge := range adjacency[current] {
+			if closed[edge.to] {
+				continue
+			}
+			tentativeG := gScore[current] + edge.cost
+			oldG, ok := gScore[edge.to]
+			if ok && tentativeG >= oldG {
+				continue
+			}
+			cameFrom[edge.to] = current
+			gScore[edge.to] = tentativeG
+			heap.Push(open, dijkstraQueueItem{id: edge.to, priority: tentativeG + heuristic(edge.to)})
+		}
+	}
+
+	return nil, fmt.Errorf("a* path not found")
+}
+
+// pathCostRatio returns the lowest edge cost per unit of Euclidean distance, which bounds an admissible heuristic.
+func pathCostRatio(points map[int]algoPoint, adjacency map[int][]algoEdge) float64 {
+	ratio := math.Inf(1)
+	for fromID, edges := range adjacency {
+		from, ok := points[fromID]
+		if !ok {
+			continue
+		}
+		for _, edge := range edges {
+			to, ok := points[edge.to]
+			if !ok {
+				continue
+			}
+			dist := math.Hypot(to.x-from.x, to.y-from.y)
+			if dist < 1e-6 {
+				continue
+			}
+			ratio = math.Min(ratio, edge.cost/dist)
+		}
+	}
+	if math.IsInf(ratio, 1) || ratio < 0 {
+		return 0
+	}
+	return ratio
+}
+
+// stringPullPath returns the indices of the path points kept after string-pulling: from each kept point,
+// the path goes straight to the farthest later point in line of sight, as reported by visible.
+// Pinned points are always kept.
+func stringPullPath(path []algoPoint, pinned []bool, visible func(from, to int) bool) []int {
+	if len(path) <= 2 {
+		indices := make([]int, len(path))
+		for i := range path {
+			indices[i] = i
+		}
+		return indices
+	}
+
+	indices := []int{0}
+	anchor := 0
+	for anchor < len(path)-1 {
+		next := anchor + 1
+		for candidate := anchor + 2; candidate < len(path); candidate++ {
+			if pinned[candidate-1] {
+				break
+			}
+			if visible(anchor, candidate) {
+				next = candidate
+			}
+		}
+		indices = append(indices, next)
+		anchor = next
+	}
+	return indices
+}
+
+// algoSegment is a straight segment between two points.
+type algoSegment struct {
+	a algoPoint
+	b algoPoint
+}
+
+// segmentInCorridors reports whether the segment from a to b stays within radius of the corridor segments.
+// The segment is checked at samples spaced radius/2 apart.
+func segmentInCorridors(a, b algoPoint, corridors []algoSegment, radius float64) bool {
+	minX, maxX := math.Min(a.x, b.x)-radius, math.Max(a.x, b.x)+radius
+	minY, maxY := math.Min(a.y, b.y)-radius, math.Max(a.y, b.y)+radius
+	nearby := make([]algoSegment, 0, len(corridors))
+	for _, corridor := range corridors {
+		if math.Max(corridor.a.x, corridor.b.x) < minX || math.Min(corridor.a.x, corridor.b.x) > maxX ||
+			math.Max(corridor.a.y, corridor.b.y) < minY || math.Min(corridor.a.y, corridor.b.y) > maxY {
+			continue
+		}
+		nearby = append(nearby, corridor)
+	}
+
+	samples := max(1, int(math.Ceil(math.Hypot(b.x-a.x, b.y-a.y)/(radius/2))))
+	for k := 0; k <= samples; k++ {
+		t := float64(k) / float64(samples)
+		p := algoPoint{x: a.x + t*(b.x-a.x), y: a.y + t*(b.y-a.y)}
+		covered := false
+		for _, corridor := range nearby {
+			if pointSegmentDistance(p, corridor.a, corridor.b) <= radius {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func pointSegmentDistance(p, a, b algoPoint) float64 {
+	dx, dy := b.x-a.x, b.y-a.y
+	lengthSq := dx*dx + dy*dy
+	if lengthSq < 1e-12 {
+		return math.Hypot(p.x-a.x, p.y-a.y)
+	}
+	t := ((p.x-a.x)*dx + (p.y-a.y)*dy) / lengthSq
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p.x-(a.x+t*dx), p.y-(a.y+t*dy))
+}
+
+// dijkstraCosts returns the lowest path cost from startID to every reachable vertex.
+func dijkstraCosts(adjacency map[int][]algoEdge, startID int) map[int]float64 {
+	open := &dijkstraPriorityQueue{}
+	heap.Init(open)
+	heap.Push(open, dijkstraQueueItem{id: startID, priority: 0})
+
+	gScore := map[int]float64{startID: 0}
+	closed := map[int]bool{}
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(dijkstraQueueItem).id
+		if closed[current] {
+			continue
+		}
+		closed[current] = true
+
+		for _, edge := range adjacency[current] {
+			if closed[edge.to] {
+				continue
+			}
+			tentativeG := gScore[current] + edge.cost
+			oldG, ok := gScore[edge.to]
+			if ok && tentativeG >= oldG {
+				continue
+			}
+			gScore[edge.to] = tentativeG
+			heap.Push(open, dijkstraQueueItem{id: edge.to, priority: tentativeG})
+		}
+	}
+
+	return gScore
+}
+
 func dijkstraPath(adjacency map[int][]algoEdge, startID, targetID int) ([]int, error) {
 	open := &dijkstraPriorityQueue{}
 	heap.Init(open)
@@ -111,3 +300,66 @@ func (q *dijkstraPriorityQueue) Pop() any {
 	*q = old[:len(old)-1]
 	return item
 }
+
+/* ******** Tour solving algorithms ******** */
+
+// tourMaxImprovePasses bounds the number of 2-opt improvement passes.
+const tourMaxImprovePasses = 64
+
+// solveOpenTour orders nodes 1..n-1 of a cost matrix into an open tour starting from node 0.
+// It builds the tour by nearest neighbour and then improves it with 2-opt. Costs may be asymmetric.
+func solveOpenTour(costs [][]float64) []int {
+	n := len(costs)
+	if n <= 1 {
+		return nil
+	}
+
+	tour := make([]int, 0, n-1)
+	visited := make([]bool, n)
+	visited[0] = true
+	current := 0
+	for len(tour) < n-1 {
+		next, nextCost := -1, math.Inf(1)
+		for candidate := 1; candidate < n; candidate++ {
+			if !visited[candidate] && (next < 0 || costs[current][candidate] < nextCost) {
+				next, nextCost = candidate, costs[current][candidate]
+			}
+		}
+		visited[next] = true
+		tour = append(tour, next)
+		current = next
+	}
+
+	bestCost := openTourCost(costs, tour)
+	candidate := make([]int, len(tour))
+	for pass := 0; pass < tourMaxImprovePasses; pass++ {
+		improved := false
+		for i := 0; i < len(tour)-1; i++ {
+			for j := i + 1; j < len(tour); j++ {
+				copy(candidate, tour)
+				for l, r := i, j; l < r; l, r = l+1, r-1 {
+					candidate[l], candidate[r] = candidate[r], candidate[l]
+				}
+				if cost := openTourCost(costs, candidate); cost < bestCost-1e-9 {
+					copy(tour, candidate)
+					bestCost = cost
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return tour
+}
+
+// openTourCost returns the total cost of visiting the tour in order starting from node 0.
+func openTourCost(costs [][]float64, tour []int) float64 {
+	cost, current := 0.0, 0
+	for _, next := range tour {
+		cost += costs[current][next]
+		current = next
+	}
+	return cost
+}
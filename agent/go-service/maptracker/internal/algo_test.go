@@ -2,6 +2,7 @@
 package maptrackerinternal
 
 import (
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
@@ -61,6 +62,105 @@ func BenchmarkMap02Lv002DijkstraPath(b *testing.B) {
 	benchmarkDijkstraPathPairs(b, adjacency, pairs)
 }
 
+func TestAStarPathChoosesLowerCostPath(t *testing.T) {
+	points := map[int]algoPoint{1: {x: 0, y: 0}, 2: {x: 1, y: 1}, 3: {x: 1, y: -1}, 4: {x: 2, y: 0}}
+	adjacency := map[int][]algoEdge{
+		1: {{to: 2, cost: 2}, {to: 3, cost: 1}},
+		2: {{to: 4, cost: 2}},
+		3: {{to: 4, cost: 10}},
+	}
+
+	path, err := astarPath(points, adjacency, 1, 4, pathCostRatio(points, adjacency))
+	if err != nil {
+		t.Fatalf("astarPath() error = %v", err)
+	}
+	assertDijkstraPath(t, path, []int{1, 2, 4})
+}
+
+func TestPathCostRatioRespectsCheapEdges(t *testing.T) {
+	points := map[int]algoPoint{1: {x: 0, y: 0}, 2: {x: 10, y: 0}, 3: {x: 20, y: 0}}
+	adjacency := map[int][]algoEdge{
+		1: {{to: 2, cost: 10}},
+		2: {{to: 3, cost: 2.5}},
+	}
+
+	if ratio := pathCostRatio(points, adjacency); math.Abs(ratio-0.25) > 1e-9 {
+		t.Fatalf("pathCostRatio() = %v", ratio)
+	}
+}
+
+func TestStringPullPathKeepsPinnedPoints(t *testing.T) {
+	path := []algoPoint{{x: 0, y: 0}, {x: 5, y: 1}, {x: 10, y: 0}, {x: 15, y: 0}, {x: 15, y: 10}}
+
+	corridors := make([]algoSegment, 0, len(path)-1)
+	for i := 0; i+1 < len(path); i++ {
+		corridors = append(corridors, algoSegment{a: path[i], b: path[i+1]})
+	}
+	visible := func(from, to int) bool {
+		return segmentInCorridors(path[from], path[to], corridors, 2)
+	}
+
+	assertDijkstraPath(t, stringPullPath(path, make([]bool, len(path)), visible), []int{0, 3, 4})
+	pinned := []bool{false, false, true, false, false}
+	assertDijkstraPath(t, stringPullPath(path, pinned, visible), []int{0, 2, 3, 4})
+}
+
+func TestDijkstraCostsReturnsLowestCosts(t *testing.T) {
+	adjacency := map[int][]algoEdge{
+		1: {{to: 2, cost: 1}, {to: 3, cost: 10}},
+		2: {{to: 3, cost: 2}},
+	}
+
+	costs := dijkstraCosts(adjacency, 1)
+	if len(costs) != 3 || costs[1] != 0 || costs[2] != 1 || costs[3] != 3 {
+		t.Fatalf("dijkstraCosts() = %+v", costs)
+	}
+	if _, ok := dijkstraCosts(adjacency, 3)[1]; ok {
+		t.Fatalf("dijkstraCosts() reached vertex against edge direction")
+	}
+}
+
+func TestSolveOpenTourImprovesNearestNeighbour(t *testing.T) {
+	xs := []float64{0, 1, -1.5, 4}
+	costs := make([][]float64, len(xs))
+	for i := range xs {
+		costs[i] = make([]float64, len(xs))
+		for j := range xs {
+			costs[i][j] = math.Abs(xs[i] - xs[j])
+		}
+	}
+
+	tour := solveOpenTour(costs)
+	assertDijkstraPath(t, tour, []int{2, 1, 3})
+	if cost := openTourCost(costs, tour); cost != 7 {
+		t.Fatalf("openTourCost() = %v", cost)
+	}
+	if tour := solveOpenTour(costs[:1]); len(tour) != 0 {
+		t.Fatalf("solveOpenTour() with start only = %+v", tour)
+	}
+}
+
+func BenchmarkMap02Lv002AStarPath(b *testing.B) {
+	points, adjacency := loadBenchmarkMap02Lv002Graph(b)
+	pairs := benchmarkReachablePathPairs(b, points, adjacency, benchmarkMap02Lv002PairCount)
+	costRatio := pathCostRatio(points, adjacency)
+	b.ReportAllocs()
+	b.ReportMetric(float64(len(pairs)), "paths/op")
+	b.ResetTimer()
+
+	totalPathLen := 0
+	for i := 0; i < b.N; i++ {
+		for _, pair := range pairs {
+			path, err := astarPath(points, adjacency, pair.startID, pair.targetID, costRatio)
+			if err != nil {
+				b.Fatalf("astarPath(%d, %d) error = %v", pair.startID, pair.targetID, err)
+			}
+			totalPathLen += len(path)
+		}
+	}
+	benchmarkPathLengthSink = totalPathLen
+}
+
 func assertDijkstraPath(t *testing.T, actual []int, expected []int) {
 	t.Helper()
 	if len(actual) != len(expected) {
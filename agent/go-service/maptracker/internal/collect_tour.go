@@ -0,0 +1,106 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SelectVertices returns the visible vertices that carry all flags of flagMask and lie within the region, sorted by ID.
+// The region is given as [x, y, w, h]; a nil region selects the whole map.
+func (m *NavMesh) SelectVertices(flagMask int, region *[4]float64) []NavMeshVertex {
+	if m == nil {
+		return nil
+	}
+	selected := make([]NavMeshVertex, 0)
+	for id, vertex := range m.Vertices {
+		if m.DisabledVertices[id] || vertex.Flags&NavMeshVertexFlagHidden != 0 || vertex.Flags&flagMask != flagMask {
+			continue
+		}
+		if region != nil && (vertex.X < region[0] || vertex.X > region[0]+region[2] || vertex.Y < region[1] || vertex.Y > region[1]+region[3]) {
+			continue
+		}
+		selected = append(selected, vertex)
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].ID < selected[j].ID })
+	return selected
+}
+
+// PlanVisitTour orders the vertices into a low-cost tour starting from the given point, using NavMesh path costs.
+// Vertices without edges are linked to their nearest connected neighbour, the same way MapTrackerGoal reaches a target point.
+// Vertices that cannot be reached from the start are returned separately.
+func (m *NavMesh) PlanVisitTour(start [2]float64, vertexIDs []int) (tour []int, unreachable []int, err error) {
+	if m == nil {
+		return nil, nil, fmt.Errorf("navmesh is nil")
+	}
+	graph := m.cachedPathGraph()
+	adjacency := make(map[int][]algoEdge, len(graph.adjacency)+1)
+	for id, edges := range graph.adjacency {
+		adjacency[id] = append([]algoEdge(nil), edges...)
+	}
+	startID := navMeshFirstTemporaryID
+	if !m.linkTourPoint(adjacency, startID, start) {
+		return nil, nil, fmt.Errorf("start point cannot connect to navmesh")
+	}
+	for _, id := range vertexIDs {
+		if len(graph.adjacency[id]) > 0 {
+			continue
+		}
+		if point, ok := m.VertexPoint(id); ok {
+			m.linkTourPoint(adjacency, id, point)
+		}
+	}
+
+	startCosts := dijkstraCosts(adjacency, startID)
+	nodes := []int{startID}
+	for _, id := range vertexIDs {
+		if _, ok := startCosts[id]; ok {
+			nodes = append(nodes, id)
+		} else {
+			unreachable = append(unreachable, id)
+		}
+	}
+
+	costs := make([][]float64, len(nodes))
+	for i, fromID := range nodes {
+		fromCosts := startCosts
+		if i > 0 {
+			fromCosts = dijkstraCosts(adjacency, fromID)
+		}
+		costs[i] = make([]float64, len(nodes))
+		for j, toID := range nodes {
+			cost, ok := fromCosts[toID]
+			if !ok {
+				cost = math.Inf(1)
+			}
+			costs[i][j] = cost
+		}
+	}
+
+	for _, index := range solveOpenTour(costs) {
+		tour = append(tour, nodes[index])
+	}
+	return tour, unreachable, nil
+}
+
+// linkTourPoint connects a tour point to the cheapest nearby vertex that has edges of its own.
+func (m *NavMesh) linkTourPoint(adjacency map[int][]algoEdge, id int, point [2]float64) bool {
+	graph := m.cachedPathGraph()
+	candidates := m.pathConnectCandidates(NavMeshTemporaryVertex{
+		ID:                 id,
+		X:                  point[0],
+		Y:                  point[1],
+		CostFactor:         worldConnectCostFactor,
+		MaxConnectDistance: worldConnectDistance,
+	})
+	for _, candidate := range candidates {
+		if candidate.id == id || len(graph.adjacency[candidate.id]) == 0 {
+			continue
+		}
+		adjacency[id] = append(adjacency[id], algoEdge{to: candidate.id, cost: candidate.cost})
+		adjacency[candidate.id] = append(adjacency[candidate.id], algoEdge{to: id, cost: candidate.cost})
+		return true
+	}
+	return false
+}
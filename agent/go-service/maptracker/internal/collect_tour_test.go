@@ -0,0 +1,65 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import "testing"
+
+const testCollectNavMeshText = `[MapTrackerNavMesh.Meta]
+Version=1
+Encoding=UTF-8
+Name=map_c_lv001
+Description=Test navmesh
+MapRegionName=map_c
+MapLevelName=lv001
+GeoWidth=100.0
+GeoHeight=100.0
+
+[MapTrackerNavMesh.Vertices]
+V1=X0,Y0,T0,E0,F()
+V2=X10,Y0,T0,E101,F(C)
+V3=X-15,Y0,T0,E102,F(C)
+V4=X40,Y0,T0,E103,F(RC)
+V5=X20,Y0,T0,E104,F(CH)
+V6=X90,Y90,T0,E105,F(C)
+V7=X45,Y5,T0,E106,F(C)
+
+[MapTrackerNavMesh.Edges]
+E1=S1,D2,B1,C10,F()
+E2=S1,D3,B1,C15,F()
+E3=S2,D4,B1,C30,F()
+E4=S2,D5,B1,C10,F()
+`
+
+func TestSelectVerticesByFlagsAndRegion(t *testing.T) {
+	mesh := mustParseNavMesh(t, testCollectNavMeshText)
+
+	assertVertexIDs(t, mesh.SelectVertices(NavMeshVertexFlagCollectable, nil), []int{2, 3, 4, 6, 7})
+	assertVertexIDs(t, mesh.SelectVertices(NavMeshVertexFlagCollectable|NavMeshVertexFlagRare, nil), []int{4})
+	assertVertexIDs(t, mesh.SelectVertices(NavMeshVertexFlagCollectable, &[4]float64{-20, -5, 35, 10}), []int{2, 3})
+
+	mesh.DisableVertex(3)
+	assertVertexIDs(t, mesh.SelectVertices(NavMeshVertexFlagCollectable, &[4]float64{-20, -5, 35, 10}), []int{2})
+}
+
+func TestPlanVisitTour(t *testing.T) {
+	mesh := mustParseNavMesh(t, testCollectNavMeshText)
+
+	tour, unreachable, err := mesh.PlanVisitTour([2]float64{1, 0}, []int{2, 3, 4, 6, 7})
+	if err != nil {
+		t.Fatalf("PlanVisitTour() error = %v", err)
+	}
+	assertDijkstraPath(t, tour, []int{3, 2, 4, 7})
+	assertDijkstraPath(t, unreachable, []int{6})
+
+	if _, _, err := mesh.PlanVisitTour([2]float64{500, 500}, []int{2}); err == nil {
+		t.Fatalf("PlanVisitTour() from disconnected start error = nil")
+	}
+}
+
+func assertVertexIDs(t *testing.T, vertices []NavMeshVertex, expected []int) {
+	t.Helper()
+	ids := make([]int, len(vertices))
+	for i, vertex := range vertices {
+		ids[i] = vertex.ID
+	}
+	assertDijkstraPath(t, ids, expected)
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/atomicfile"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/serverday"
+)
+
+const (
+	// HARVEST_RECORD_PATH stores harvested entities under the working directory.
+	HARVEST_RECORD_PATH = "debug/record/MapTrackerHarvest.json"
+
+	harvestRecordSchemaVersion = 1
+)
+
+// HarvestRecord stores the last harvest of an entity by a player.
+type HarvestRecord struct {
+	UID         string    `json:"uid"`
+	MapName     string    `json:"map_name"`
+	EntityID    int64     `json:"entity_id"`
+	GameDate    string    `json:"game_date"`
+	HarvestedAt time.Time `json:"harvested_at"`
+	// RespawnSeconds is the respawn interval of the entity. Zero means it respawns at the start of the next game day.
+	RespawnSeconds int64 `json:"respawn_seconds,omitempty"`
+}
+
+// HarvestStore remembers harvested entities of one player, so that they can be skipped until they respawn.
+type HarvestStore struct {
+	path    string
+	uid     string
+	records []HarvestRecord
+}
+
+type harvestRecordFile struct {
+	SchemaVersion int             `json:"schema_version"`
+	Records       []HarvestRecord `json:"records"`
+}
+
+// HarvestGameDate returns the game date on the server, where a game day starts at 04:00 server time (UTC+8).
+func HarvestGameDate(now time.Time) string {
+	return serverday.AdjustedTime(now, nil).Format(time.DateOnly)
+}
+
+// RespawnAt returns the time when the harvested entity is expected to respawn.
+func (r HarvestRecord) RespawnAt() time.Time {
+	if r.RespawnSeconds > 0 {
+		return r.HarvestedAt.Add(time.Duration(r.RespawnSeconds) * time.Second)
+	}
+	return serverday.DayStart(r.HarvestedAt, nil).AddDate(0, 0, 1)
+}
+
+// OpenHarvestStore loads the harvest records of the player with the given hashed UID. A missing file is treated as empty.
+func OpenHarvestStore(path, uid string) (*HarvestStore, error) {
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read harvest record file: %w", err)
+	}
+	var file harvestRecordFile
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &file); err != nil {
+			return nil, fmt.Errorf("parse harvest record file: %w", err)
+		}
+	}
+	return &HarvestStore{path: path, uid: uid, records: file.Records}, nil
+}
+
+// IsOnCooldown returns whether the entity was harvested by the player and has not respawned yet.
+func (s *HarvestStore) IsOnCooldown(mapName string, entityID int64, now time.Time) bool {
+	if s == nil || entityID <= 0 {
+		return false
+	}
+	mapName = MapCoreName(mapName)
+	for _, record := range s.records {
+		if record.UID == s.uid && record.MapName == mapName && record.EntityID == entityID {
+			return now.Before(record.RespawnAt())
+		}
+	}
+	return false
+}
+
+// CooldownEntityIDs returns all entities on the map that were harvested by the player and have not respawned yet.
+func (s *HarvestStore) CooldownEntityIDs(mapName string, now time.Time) map[int64]bool {
+	ids := map[int64]bool{}
+	if s == nil {
+		return ids
+	}
+	mapName = MapCoreName(mapName)
+	for _, record := range s.records {
+		if record.UID == s.uid && record.MapName == mapName && now.Before(record.RespawnAt()) {
+			ids[record.EntityID] = true
+		}
+	}
+	return ids
+}
+
+// Record marks the entity as harvested now and saves the store. Records that have respawned are dropped on save.
+// A zero respawn interval means the entity respawns at the start of the next game day.
+func (s *HarvestStore) Record(mapName string, entityID int64, now time.Time, respawn time.Duration) error {
+	if s == nil {
+		return fmt.Errorf("harvest store is nil")
+	}
+	if entityID <= 0 {
+		return fmt.Errorf("entity_id must be positive")
+	}
+	record := HarvestRecord{
+		UID:            s.uid,
+		MapName:        MapCoreName(mapName),
+		EntityID:       entityID,
+		GameDate:       HarvestGameDate(now),
+		HarvestedAt:    now,
+		RespawnSeconds: int64(respawn / time.Second),
+	}
+
+	records := make([]HarvestRecord, 0, len(s.records)+1)
+	for _, old := range s.records {
+		if old.UID == record.UID && old.MapName == record.MapName && old.EntityID == record.EntityID {
+			continue
+		}
+		if !now.Before(old.RespawnAt()) {
+			continue
+		}
+		records = append(records, old)
+	}
+	records = append(records, record)
+	sort.SliceStable(records, func(i, j int) bool { return records[i].HarvestedAt.Before(records[j].HarvestedAt) })
+	s.records = records
+	return s.save()
+}
+
+func (s *HarvestStore) save() error {
+	return atomicfile.WriteJSON(s.path, harvestRecordFile{SchemaVersion: harvestRecordSchemaVersion, Records: s.records})
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/serverday"
+)
+
+func TestHarvestGameDate(t *testing.T) {
+	if date := HarvestGameDate(time.Date(2026, 3, 2, 3, 59, 0, 0, serverday.DefaultLocation)); date != "2026-03-01" {
+		t.Fatalf("HarvestGameDate() before boundary = %q", date)
+	}
+	if date := HarvestGameDate(time.Date(2026, 3, 2, 4, 0, 0, 0, serverday.DefaultLocation)); date != "2026-03-02" {
+		t.Fatalf("HarvestGameDate() at boundary = %q", date)
+	}
+	// The boundary follows server time regardless of the local time zone
+	if date := HarvestGameDate(time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)); date != "2026-03-02" {
+		t.Fatalf("HarvestGameDate() at boundary in UTC = %q", date)
+	}
+	if date := HarvestGameDate(time.Date(2026, 3, 2, 3, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60))); date != "2026-03-02" {
+		t.Fatalf("HarvestGameDate() in UTC-5 = %q", date)
+	}
+}
+
+func TestHarvestStoreRespawn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "record", "harvest.json")
+	store, err := OpenHarvestStore(path, "uid-a")
+	if err != nil {
+		t.Fatalf("OpenHarvestStore() error = %v", err)
+	}
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, serverday.DefaultLocation)
+	if err := store.Record("map_c_lv001_tier_1", 101, now, 0); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := store.Record("map_c_lv001", 102, now, 2*time.Hour); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	if !store.IsOnCooldown("map_c_lv001", 101, now.Add(17*time.Hour)) {
+		t.Fatalf("entity 101 should stay on cooldown until the next game day")
+	}
+	if store.IsOnCooldown("map_c_lv001", 101, time.Date(2026, 3, 3, 4, 0, 0, 0, serverday.DefaultLocation)) {
+		t.Fatalf("entity 101 should respawn at the next game day")
+	}
+	if ids := store.CooldownEntityIDs("map_c_lv001", now.Add(3*time.Hour)); len(ids) != 1 || !ids[101] {
+		t.Fatalf("CooldownEntityIDs() = %+v", ids)
+	}
+
+	reopened, err := OpenHarvestStore(path, "uid-a")
+	if err != nil {
+		t.Fatalf("OpenHarvestStore() reopen error = %v", err)
+	}
+	if !reopened.IsOnCooldown("map_c_lv001", 102, now.Add(time.Hour)) {
+		t.Fatalf("reopened store lost entity 102")
+	}
+	other, err := OpenHarvestStore(path, "uid-b")
+	if err != nil {
+		t.Fatalf("OpenHarvestStore() other uid error = %v", err)
+	}
+	if other.IsOnCooldown("map_c_lv001", 101, now) {
+		t.Fatalf("records leaked across UIDs")
+	}
+}
+
+func TestHarvestStoreDropsRespawnedRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "harvest.json")
+	store, err := OpenHarvestStore(path, "uid-a")
+	if err != nil {
+		t.Fatalf("OpenHarvestStore() error = %v", err)
+	}
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, serverday.DefaultLocation)
+	if err := store.Record("map_c_lv001", 101, now, time.Minute); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := store.Record("map_c_lv001", 102, now.Add(time.Hour), 0); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if len(store.records) != 1 || store.records[0].EntityID != 102 {
+		t.Fatalf("records = %+v", store.records)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/atomicfile"
+)
+
+const (
+	// ICON_INVENTORY_RECORD_DIR stores big-map icon inventories, one file per map, under the working directory.
+	ICON_INVENTORY_RECORD_DIR = "debug/record/MapTrackerIconInventory"
+
+	iconInventorySchemaVersion = 1
+)
+
+// Well-known icon kinds. Other kinds are allowed, but are imported without vertex flags.
+const (
+	ICON_KIND_ZIPLINE       = "Zipline"
+	ICON_KIND_TELEPORT      = "Teleport"
+	ICON_KIND_RESOURCE_NODE = "ResourceNode"
+	ICON_KIND_DELIVERY      = "Delivery"
+)
+
+var iconKindVertexFlags = map[string]int{
+	ICON_KIND_ZIPLINE:       NavMeshVertexFlagZipline,
+	ICON_KIND_TELEPORT:      NavMeshVertexFlagTeleportAnchor,
+	ICON_KIND_RESOURCE_NODE: NavMeshVertexFlagCollectable,
+}
+
+// IconInventoryItem is an icon found on the big map, in map coordinates.
+type IconInventoryItem struct {
+	Kind     string  `json:"kind"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Conf     float64 `json:"conf"`
+	Rotation float64 `json:"rotation,omitempty"`
+	// Seen counts the viewports in which the icon was matched.
+	Seen int `json:"seen"`
+}
+
+// IconInventory is the result of a full big-map scan of one map.
+type IconInventory struct {
+	SchemaVersion int                 `json:"schema_version"`
+	MapName       string              `json:"map_name"`
+	Region        [4]float64          `json:"region"`
+	ZoomValue     float64             `json:"zoom_value,omitempty"`
+	ScannedAt     time.Time           `json:"scanned_at"`
+	Icons         []IconInventoryItem `json:"icons"`
+}
+
+// IconInventoryPath returns the default inventory file path of the map.
+func IconInventoryPath(mapName string) string {
+	return filepath.Join(ICON_INVENTORY_RECORD_DIR, mapName+".json")
+}
+
+// IconKindVertexFlags returns the NavMesh vertex flags that icons of the kind are imported with.
+func IconKindVertexFlags(kind string) int {
+	return iconKindVertexFlags[kind]
+}
+
+// LoadIconInventory reads an inventory file. A missing file returns nil without error.
+func LoadIconInventory(path string) (*IconInventory, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read icon inventory file: %w", err)
+	}
+	var inv IconInventory
+	if err := json.Unmarshal(b, &inv); err != nil {
+		return nil, fmt.Errorf("parse icon inventory file: %w", err)
+	}
+	if inv.SchemaVersion > iconInventorySchemaVersion {
+		return nil, fmt.Errorf("unsupported icon inventory schema version: %d", inv.SchemaVersion)
+	}
+	return &inv, nil
+}
+
+// SaveIconInventory writes the inventory with icons sorted by kind and position.
+func SaveIconInventory(path string, inv *IconInventory) error {
+	inv.SchemaVersion = iconInventorySchemaVersion
+	sort.SliceStable(inv.Icons, func(i, j int) bool {
+		a, b := inv.Icons[i], inv.Icons[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+	return atomicfile.WriteJSON(path, inv)
+}
+
+// Add merges the icon into the inventory. An icon of the same kind within minDistance counts as the same icon,
+// whose position follows the more confident match. Returns whether a new icon was added.
+func (inv *IconInventory) Add(item IconInventoryItem, minDistance float64) bool {
+	for i := range inv.Icons {
+		existing := &inv.Icons[i]
+		if existing.Kind != item.Kind || math.Hypot(existing.X-item.X, existing.Y-item.Y) >= minDistance {
+			continue
+		}
+		existing.Seen++
+		if item.Conf > existing.Conf {
+			existing.X, existing.Y = item.X, item.Y
+			existing.Conf = item.Conf
+			existing.Rotation = item.Rotation
+		}
+		return false
+	}
+	item.Seen = max(item.Seen, 1)
+	inv.Icons = append(inv.Icons, item)
+	return true
+}
+
+// Count returns the number of icons of the kind.
+func (inv *IconInventory) Count(kind string) int {
+	count := 0
+	for _, icon := range inv.Icons {
+		if icon.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// AddRuntimeVertices imports the icons of the given kinds (all kinds if empty) into the mesh as runtime vertices,
+// returning the new vertex IDs in inventory order.
+func (inv *IconInventory) AddRuntimeVertices(mesh *NavMesh, kinds ...string) []int {
+	wanted := make(map[string]bool, len(kinds))
+	for _, kind := range kinds {
+		wanted[kind] = true
+	}
+	ids := make([]int, 0, len(inv.Icons))
+	for _, icon := range inv.Icons {
+		if len(wanted) > 0 && !wanted[icon.Kind] {
+			continue
+		}
+		id, _ := mesh.AddRuntimeVertex(icon.X, icon.Y, 0, 0, IconKindVertexFlags(icon.Kind))
+		ids = append(ids, id)
+	}
+	return ids
+}
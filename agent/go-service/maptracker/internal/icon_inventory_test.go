@@ -0,0 +1,77 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIconInventoryAddMergesNearbyIcons(t *testing.T) {
+	inv := &IconInventory{MapName: "map_square_lv001"}
+	if !inv.Add(IconInventoryItem{Kind: ICON_KIND_ZIPLINE, X: 10, Y: 10, Conf: 0.7}, 5) {
+		t.Fatal("first icon should be added")
+	}
+	if inv.Add(IconInventoryItem{Kind: ICON_KIND_ZIPLINE, X: 12, Y: 11, Conf: 0.9}, 5) {
+		t.Fatal("nearby icon of the same kind should be merged")
+	}
+	if !inv.Add(IconInventoryItem{Kind: ICON_KIND_TELEPORT, X: 11, Y: 10, Conf: 0.8}, 5) {
+		t.Fatal("nearby icon of another kind should be added")
+	}
+	if inv.Add(IconInventoryItem{Kind: ICON_KIND_ZIPLINE, X: 9, Y: 10, Conf: 0.6}, 5) {
+		t.Fatal("less confident duplicate should be merged")
+	}
+
+	if len(inv.Icons) != 2 || inv.Count(ICON_KIND_ZIPLINE) != 1 || inv.Count(ICON_KIND_TELEPORT) != 1 {
+		t.Fatalf("unexpected icons: %+v", inv.Icons)
+	}
+	zipline := inv.Icons[0]
+	if zipline.X != 12 || zipline.Y != 11 || zipline.Conf != 0.9 || zipline.Seen != 3 {
+		t.Fatalf("unexpected merged icon: %+v", zipline)
+	}
+}
+
+func TestIconInventorySaveLoadAndImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory", "map_square_lv001.json")
+	if inv, err := LoadIconInventory(path); err != nil || inv != nil {
+		t.Fatalf("LoadIconInventory() on missing file = %+v, %v", inv, err)
+	}
+
+	inv := &IconInventory{
+		MapName:   "map_square_lv001",
+		Region:    [4]float64{0, 0, 20, 20},
+		ScannedAt: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC),
+	}
+	inv.Add(IconInventoryItem{Kind: ICON_KIND_ZIPLINE, X: 10, Y: 0, Conf: 0.8}, 5)
+	inv.Add(IconInventoryItem{Kind: ICON_KIND_DELIVERY, X: 5, Y: 5, Conf: 0.7}, 5)
+	inv.Add(IconInventoryItem{Kind: ICON_KIND_ZIPLINE, X: 0, Y: 10, Conf: 0.9}, 5)
+	if err := SaveIconInventory(path, inv); err != nil {
+		t.Fatalf("SaveIconInventory() error = %v", err)
+	}
+
+	loaded, err := LoadIconInventory(path)
+	if err != nil {
+		t.Fatalf("LoadIconInventory() error = %v", err)
+	}
+	if loaded.SchemaVersion != iconInventorySchemaVersion || loaded.MapName != inv.MapName || len(loaded.Icons) != 3 {
+		t.Fatalf("unexpected loaded inventory: %+v", loaded)
+	}
+	if loaded.Icons[0].Kind != ICON_KIND_DELIVERY || loaded.Icons[1].Y != 0 || loaded.Icons[2].Y != 10 {
+		t.Fatalf("icons are not sorted by kind and position: %+v", loaded.Icons)
+	}
+
+	mesh := mustParseNavMesh(t, testSquareNavMeshText)
+	ids := loaded.AddRuntimeVertices(mesh, ICON_KIND_ZIPLINE)
+	if len(ids) != 2 {
+		t.Fatalf("len(ids) = %d, want 2", len(ids))
+	}
+	for _, id := range ids {
+		vertex, ok := mesh.RuntimeVertices[id]
+		if !ok || id >= 0 || vertex.Flags != NavMeshVertexFlagZipline {
+			t.Fatalf("unexpected runtime vertex %d: %+v", id, vertex)
+		}
+	}
+	if ids := loaded.AddRuntimeVertices(mesh); len(ids) != 3 || mesh.RuntimeVertices[ids[0]].Flags != 0 {
+		t.Fatalf("unexpected import of all kinds: %v", ids)
+	}
+}
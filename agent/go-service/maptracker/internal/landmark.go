@@ -0,0 +1,173 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/levenshtein"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/resource"
+)
+
+const (
+	// MAP_LANDMARK_DATA_PATH stores named landmarks, such as teleport anchors, on all maps.
+	MAP_LANDMARK_DATA_PATH = "data/MapTracker/map_landmark_data.json"
+
+	// LANDMARK_MATCH_DISTANCE_DIVISOR allows one edit per this many runes of a landmark name when matching typos,
+	// and at least one edit for names of two or more runes.
+	LANDMARK_MATCH_DISTANCE_DIVISOR = 3
+)
+
+// Landmark is a named location on a map.
+type Landmark struct {
+	// ID is the unique, language-independent identifier of the landmark.
+	ID string `json:"id"`
+	// MapName is the map containing the landmark, including its tier suffix if any.
+	MapName string `json:"map_name"`
+	// Target is the landmark coordinate in the map file's original coordinate space.
+	Target [2]float64 `json:"target"`
+	// Names maps UI language codes (e.g. "zh_cn") to the localized display name.
+	Names map[string]string `json:"names"`
+	// Aliases are other names of the landmark in any language, e.g. names qualified by region.
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// LandmarkRegistry looks up landmarks by ID, name or alias.
+type LandmarkRegistry struct {
+	Landmarks []Landmark
+}
+
+var loadLandmarkRegistry = sync.OnceValues(func() (*LandmarkRegistry, error) {
+	var landmarks []Landmark
+	if err := resource.ReadJsonResource(MAP_LANDMARK_DATA_PATH, &landmarks); err != nil {
+		return nil, fmt.Errorf("failed to load map landmark data: %w", err)
+	}
+	return NewLandmarkRegistry(landmarks)
+})
+
+// GetLandmarkRegistry returns the landmark registry loaded from resources, loading it on first use.
+func GetLandmarkRegistry() (*LandmarkRegistry, error) {
+	return loadLandmarkRegistry()
+}
+
+// FindLandmark looks up a landmark in the registry loaded from resources. See LandmarkRegistry.Find.
+func FindLandmark(query, mapName, lang string) (*Landmark, error) {
+	registry, err := GetLandmarkRegistry()
+	if err != nil {
+		return nil, err
+	}
+	return registry.Find(query, mapName, lang)
+}
+
+// NewLandmarkRegistry validates the landmarks and creates a registry of them.
+func NewLandmarkRegistry(landmarks []Landmark) (*LandmarkRegistry, error) {
+	ids := make(map[string]bool, len(landmarks))
+	for i, landmark := range landmarks {
+		if landmark.ID == "" {
+			return nil, fmt.Errorf("landmark %d has empty id", i)
+		}
+		if ids[landmark.ID] {
+			return nil, fmt.Errorf("duplicate landmark id %q", landmark.ID)
+		}
+		ids[landmark.ID] = true
+		if landmark.MapName == "" {
+			return nil, fmt.Errorf("landmark %q has empty map_name", landmark.ID)
+		}
+		for _, v := range landmark.Target {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, fmt.Errorf("landmark %q has invalid target", landmark.ID)
+			}
+		}
+	}
+	return &LandmarkRegistry{Landmarks: landmarks}, nil
+}
+
+// Find returns the landmark matching the query, which may be an ID, a localized name or an alias.
+// Names are compared ignoring case, spaces and punctuation, and tolerate OCR-style typos, see landmarkMatchTolerance.
+// A typo that matches several landmarks equally well is reported as ambiguous. If mapName is not empty, only landmarks on that map
+// (regardless of tier) are considered. Names in lang are preferred when several landmarks match equally well.
+func (r *LandmarkRegistry) Find(query, mapName, lang string) (*Landmark, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("landmark query is empty")
+	}
+	candidates := make([]int, 0, len(r.Landmarks))
+	for i := range r.Landmarks {
+		if mapName == "" || MapCoreName(r.Landmarks[i].MapName) == MapCoreName(mapName) {
+			candidates = append(candidates, i)
+		}
+	}
+	for _, i := range candidates {
+		if r.Landmarks[i].ID == query {
+			return &r.Landmarks[i], nil
+		}
+	}
+
+	normalizedQuery := normalizeLandmarkName(query)
+	best, bestDistance, bestPreferred, ambiguous := -1, math.MaxInt, false, false
+	for _, i := range candidates {
+		landmark := &r.Landmarks[i]
+		distance, preferred := math.MaxInt, false
+		consider := func(name string, isPreferred bool) {
+			normalized := normalizeLandmarkName(name)
+			if normalized == "" {
+				return
+			}
+			d := levenshtein.Distance(normalizedQuery, normalized)
+			if d > landmarkMatchTolerance(len([]rune(normalized))) {
+				return
+			}
+			if d < distance || (d == distance && isPreferred) {
+				distance, preferred = d, isPreferred
+			}
+		}
+		for nameLang, name := range landmark.Names {
+			consider(name, nameLang == lang)
+		}
+		for _, alias := range landmark.Aliases {
+			consider(alias, false)
+		}
+		if distance == math.MaxInt {
+			continue
+		}
+
+		switch {
+		case distance < bestDistance || (distance == bestDistance && preferred && !bestPreferred):
+			best, bestDistance, bestPreferred, ambiguous = i, distance, preferred, false
+		case distance == bestDistance && preferred == bestPreferred:
+			ambiguous = true
+		}
+	}
+	if best < 0 {
+		return nil, fmt.Errorf("landmark %q not found", query)
+	}
+	if ambiguous {
+		return nil, fmt.Errorf("landmark %q is ambiguous", query)
+	}
+	return &r.Landmarks[best], nil
+}
+
+// landmarkMatchTolerance returns the maximal edit distance to a normalized name of n runes:
+// one edit per LANDMARK_MATCH_DISTANCE_DIVISOR runes, at least one so that two-rune names such as 南山
+// still tolerate a misread rune, and none for single-rune names.
+func landmarkMatchTolerance(n int) int {
+	if n < 2 {
+		return 0
+	}
+	return max(1, n/LANDMARK_MATCH_DISTANCE_DIVISOR)
+}
+
+// normalizeLandmarkName lowercases the name and drops spaces and punctuation, which OCR often gets wrong.
+func normalizeLandmarkName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
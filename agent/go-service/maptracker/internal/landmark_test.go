@@ -0,0 +1,176 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestLandmarkRegistry(t *testing.T) *LandmarkRegistry {
+	t.Helper()
+	registry, err := NewLandmarkRegistry([]Landmark{
+		{ID: "Observatory", MapName: "map02_lv002", Target: [2]float64{10, 20}, Names: map[string]string{"zh_cn": "观测站", "en_us": "Observatory Post"}, Aliases: []string{"武陵城-观测站"}},
+		{ID: "SouthHill", MapName: "map02_lv001", Target: [2]float64{30, 40}, Names: map[string]string{"zh_cn": "南山"}, Aliases: []string{"景玉谷-南山"}},
+		{ID: "NorthHill", MapName: "map02_lv003_tier_1", Target: [2]float64{50, 60}, Names: map[string]string{"zh_cn": "北山"}},
+	})
+	if err != nil {
+		t.Fatalf("NewLandmarkRegistry() error = %v", err)
+	}
+	return registry
+}
+
+func TestLandmarkRegistryFind(t *testing.T) {
+	registry := newTestLandmarkRegistry(t)
+	tests := []struct {
+		query, mapName, want string
+	}{
+		{query: "Observatory", want: "Observatory"},
+		{query: "观测站", want: "Observatory"},
+		{query: "观测占", want: "Observatory"},
+		{query: "observatory  post", want: "Observatory"},
+		{query: "Observatroy Post", want: "Observatory"},
+		{query: "武陵城 - 观测站", want: "Observatory"},
+		{query: "景玉谷·南山", want: "SouthHill"},
+		{query: "北山", mapName: "map02_lv003", want: "NorthHill"},
+		{query: "南仙", want: "SouthHill"},
+		{query: "北出", mapName: "map02_lv003", want: "NorthHill"},
+	}
+	for _, tt := range tests {
+		landmark, err := registry.Find(tt.query, tt.mapName, "zh_cn")
+		if err != nil {
+			t.Fatalf("Find(%q, %q) error = %v", tt.query, tt.mapName, err)
+		}
+		if landmark.ID != tt.want {
+			t.Fatalf("Find(%q, %q) = %q, want %q", tt.query, tt.mapName, landmark.ID, tt.want)
+		}
+	}
+
+	// A typo that is one edit away from several short names is ambiguous rather than a guess
+	for _, query := range []string{"西山", "山", "", "Unknown Place"} {
+		if landmark, err := registry.Find(query, "", "zh_cn"); err == nil {
+			t.Fatalf("Find(%q) = %q, want error", query, landmark.ID)
+		}
+	}
+	if _, err := registry.Find("观测站", "map02_lv001", "zh_cn"); err == nil {
+		t.Fatal("Find() should not match landmarks on other maps")
+	}
+}
+
+func TestLandmarkRegistryFindAmbiguous(t *testing.T) {
+	registry, err := NewLandmarkRegistry([]Landmark{
+		{ID: "A", MapName: "map01_lv001", Names: map[string]string{"zh_cn": "储备站左上"}},
+		{ID: "B", MapName: "map01_lv001", Names: map[string]string{"zh_cn": "储备站右上"}},
+	})
+	if err != nil {
+		t.Fatalf("NewLandmarkRegistry() error = %v", err)
+	}
+	if landmark, err := registry.Find("储备站上", "", "zh_cn"); err == nil {
+		t.Fatalf("Find() = %q, want ambiguous error", landmark.ID)
+	}
+	if landmark, err := registry.Find("储备站左上", "", "zh_cn"); err != nil || landmark.ID != "A" {
+		t.Fatalf("Find() exact name = %+v, %v", landmark, err)
+	}
+}
+
+func TestNewLandmarkRegistryRejectsInvalidData(t *testing.T) {
+	tests := [][]Landmark{
+		{{ID: "", MapName: "map01_lv001"}},
+		{{ID: "A", MapName: ""}},
+		{{ID: "A", MapName: "map01_lv001"}, {ID: "A", MapName: "map01_lv002"}},
+	}
+	for i, landmarks := range tests {
+		if _, err := NewLandmarkRegistry(landmarks); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestRealLandmarkData(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "assets", "data", "MapTracker", "map_landmark_data.json"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var landmarks []Landmark
+	if err := json.Unmarshal(b, &landmarks); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	registry, err := NewLandmarkRegistry(landmarks)
+	if err != nil {
+		t.Fatalf("NewLandmarkRegistry() error = %v", err)
+	}
+	// Names are taken from existing data: zh_cn from the SceneEnterWorld<ID> pipeline nodes,
+	// and en_us, where documented, from the English scene manager docs
+	sceneDescs := readSceneEnterWorldDescs(t)
+	sceneDocs, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "docs", "en_us", "developers", "scene-manager.md"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	sceneDocRows := map[string]string{}
+	for _, line := range strings.Split(string(sceneDocs), "\n") {
+		if fields := strings.Split(line, "|"); len(fields) > 2 {
+			sceneDocRows[strings.Trim(strings.TrimSpace(fields[1]), "`")] = fields[2]
+		}
+	}
+	for _, landmark := range registry.Landmarks {
+		node := "SceneEnterWorld" + landmark.ID
+		name := landmark.Names["zh_cn"]
+		if name == "" || !strings.Contains(sceneDescs[node], "-"+name) {
+			t.Fatalf("landmark %q zh_cn name %q does not match %s %q", landmark.ID, name, node, sceneDescs[node])
+		}
+		for lang, name := range landmark.Names {
+			switch lang {
+			case "zh_cn":
+			case "en_us":
+				if !strings.Contains(sceneDocRows[node], "("+name+")") {
+					t.Fatalf("landmark %q en_us name %q is not the documented name of %s", landmark.ID, name, node)
+				}
+			default:
+				t.Fatalf("landmark %q has unverified %s name %q", landmark.ID, lang, name)
+			}
+		}
+	}
+	// Every name and alias must resolve to its own landmark, in its language and across all maps
+	for _, landmark := range registry.Landmarks {
+		for _, alias := range landmark.Aliases {
+			found, err := registry.Find(alias, "", "zh_cn")
+			if err != nil || found.ID != landmark.ID {
+				t.Fatalf("Find(%q) = %+v, %v, want %q", alias, found, err, landmark.ID)
+			}
+		}
+		for lang, name := range landmark.Names {
+			found, err := registry.Find(name, "", lang)
+			if err != nil || found.ID != landmark.ID {
+				t.Fatalf("Find(%q, %s) = %+v, %v, want %q", name, lang, found, err, landmark.ID)
+			}
+		}
+	}
+}
+
+// readSceneEnterWorldDescs returns the desc of every SceneEnterWorld node in the region scene interface pipelines.
+func readSceneEnterWorldDescs(t *testing.T) map[string]string {
+	t.Helper()
+	dir := filepath.Join("..", "..", "..", "..", "assets", "resource", "pipeline", "Interface")
+	paths := []string{filepath.Join(dir, "SceneValleyIV.json"), filepath.Join(dir, "SceneWuling.json")}
+	descs := map[string]string{}
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		var nodes map[string]struct {
+			Desc string `json:"desc"`
+		}
+		if err := json.Unmarshal(b, &nodes); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", path, err)
+		}
+		for name, node := range nodes {
+			if strings.HasPrefix(name, "SceneEnterWorld") {
+				descs[name] = node.Desc
+			}
+		}
+	}
+	return descs
+}
@@ -36,6 +36,10 @@ const (
 	NavMeshVertexFlagZipline        = 64
 
 	NavMeshEdgeFlagZipline = 1
+
+	// navMeshWalkableRadius is the half-width of the walkable corridor around each walkable edge.
+	// A smoothed path may cut straight through any area covered by these corridors.
+	navMeshWalkableRadius = 5.0
 )
 
 var (
@@ -105,6 +109,16 @@ type NavMesh struct {
 	RuntimeEdges      map[int]NavMeshEdge
 	DisabledVertices  map[int]bool
 	DisabledEdges     map[int]bool
+	EdgeCostFactors   map[int]float64
+
+	pathGraph *navMeshPathGraph
+}
+
+// navMeshPathGraph caches the search graph built from persistent and runtime vertices and edges.
+type navMeshPathGraph struct {
+	points    map[int]algoPoint
+	adjacency map[int][]algoEdge
+	costRatio float64
 }
 
 type navMeshConnectCandidate struct {
@@ -276,6 +290,7 @@ func (m *NavMesh) AddRuntimeVertex(x, y float64, tierID int, entityID int64, fla
 	}
 	vertex := NavMeshVertex{ID: id, X: roundNavMeshCoord(x), Y: roundNavMeshCoord(y), TierID: tierID, EntityID: entityID, Flags: flags}
 	m.RuntimeVertices[id] = vertex
+	m.InvalidatePathGraph()
 	return id, vertex
 }
 
@@ -286,15 +301,32 @@ func (m *NavMesh) AddRuntimeEdge(id, sourceID, destinationID int, bidirectional
 	}
 	edge := NavMeshEdge{ID: id, SourceID: sourceID, DestinationID: destinationID, Bidirectional: bidirectional, Cost: cost, Flags: flags}
 	m.RuntimeEdges[id] = edge
+	m.InvalidatePathGraph()
 	return edge
 }
 
+// ClearRuntime removes all runtime vertices and edges, together with their disabled states and cost factors,
+// so that their IDs can be reused.
+func (m *NavMesh) ClearRuntime() {
+	for id := range m.RuntimeVertices {
+		delete(m.DisabledVertices, id)
+	}
+	for id := range m.RuntimeEdges {
+		delete(m.DisabledEdges, id)
+		delete(m.EdgeCostFactors, id)
+	}
+	m.RuntimeVertices = nil
+	m.RuntimeEdges = nil
+	m.InvalidatePathGraph()
+}
+
 // DisableVertex disables a vertex and all incident edges for future path searches.
 func (m *NavMesh) DisableVertex(id int) {
 	if m.DisabledVertices == nil {
 		m.DisabledVertices = map[int]bool{}
 	}
 	m.DisabledVertices[id] = true
+	m.InvalidatePathGraph()
 }
 
 // DisableEdge disables an edge for future path searches.
@@ -303,6 +335,72 @@ func (m *NavMesh) DisableEdge(id int) {
 		m.DisabledEdges = map[int]bool{}
 	}
 	m.DisabledEdges[id] = true
+	m.InvalidatePathGraph()
+}
+
+// PenalizeEdge multiplies the search cost of an edge by factor for future path searches.
+func (m *NavMesh) PenalizeEdge(id int, factor float64) {
+	if m.EdgeCostFactors == nil {
+		m.EdgeCostFactors = map[int]float64{}
+	}
+	if current, ok := m.EdgeCostFactors[id]; ok {
+		factor *= current
+	}
+	m.EdgeCostFactors[id] = factor
+	m.InvalidatePathGraph()
+}
+
+// NearestEdge returns the enabled non-zipline edge closest to the point within maxDistance.
+func (m *NavMesh) NearestEdge(point [2]float64, maxDistance float64) (NavMeshEdge, float64, bool) {
+	return m.nearestEdge(point, maxDistance, nil)
+}
+
+// NearestEdgeAlong returns the enabled non-zipline edge closest to the point among the edges lying along
+// the segment from-to, that is, whose both endpoints are within tolerance of the segment.
+// A path segment may span several edges after smoothing, so the point picks the one among them.
+func (m *NavMesh) NearestEdgeAlong(point, from, to [2]float64, tolerance float64) (NavMeshEdge, float64, bool) {
+	a, b := algoPoint{x: from[0], y: from[1]}, algoPoint{x: to[0], y: to[1]}
+	return m.nearestEdge(point, math.Inf(1), func(source, destination NavMeshVertex) bool {
+		return pointSegmentDistance(algoPoint{x: source.X, y: source.Y}, a, b) <= tolerance &&
+			pointSegmentDistance(algoPoint{x: destination.X, y: destination.Y}, a, b) <= tolerance
+	})
+}
+
+func (m *NavMesh) nearestEdge(point [2]float64, maxDistance float64, accept func(source, destination NavMeshVertex) bool) (NavMeshEdge, float64, bool) {
+	var (
+		best         NavMeshEdge
+		bestDistance = math.Inf(1)
+		found        bool
+	)
+	p := algoPoint{x: point[0], y: point[1]}
+	for _, edges := range []map[int]NavMeshEdge{m.Edges, m.RuntimeEdges} {
+		for id, edge := range edges {
+			if m.DisabledEdges[id] || edge.Flags&NavMeshEdgeFlagZipline != 0 {
+				continue
+			}
+			source, sourceOK := m.vertexByID(edge.SourceID)
+			destination, destinationOK := m.vertexByID(edge.DestinationID)
+			if !sourceOK || !destinationOK {
+				continue
+			}
+			if accept != nil && !accept(source, destination) {
+				continue
+			}
+			distance := pointSegmentDistance(p, algoPoint{x: source.X, y: source.Y}, algoPoint{x: destination.X, y: destination.Y})
+			if !found || distance < bestDistance || (distance == bestDistance && id < best.ID) {
+				best, bestDistance, found = edge, distance, true
+			}
+		}
+	}
+	if !found || bestDistance > maxDistance {
+		return NavMeshEdge{}, 0, false
+	}
+	return best, bestDistance, true
+}
+
+// InvalidatePathGraph drops the cached search graph. It must be called after mutating the exported maps directly.
+func (m *NavMesh) InvalidatePathGraph() {
+	m.pathGraph = nil
 }
 
 // IsRuntimeVertex returns whether the vertex ID belongs to a runtime graph vertex.
@@ -345,13 +443,13 @@ func (m *NavMesh) VertexPoint(id int) ([2]float64, bool) {
 	return [2]float64{}, false
 }
 
-// FindPath finds a coordinate path between vertices through this NavMesh.
+// FindPath finds a smoothed coordinate path between vertices through this NavMesh.
 func (m *NavMesh) FindPath(startID, targetID int) ([][2]float64, error) {
 	pathIDs, err := m.FindPathIDs(startID, targetID)
 	if err != nil {
 		return nil, err
 	}
-	return m.PathIDsToPoints(pathIDs)
+	return m.PathIDsToPoints(m.SmoothPathIDs(pathIDs))
 }
 
 // FindPathIDs finds a vertex ID path between vertices through this NavMesh.
@@ -360,7 +458,18 @@ func (m *NavMesh) FindPathIDs(startID, targetID int) ([]int, error) {
 		return nil, fmt.Errorf("navmesh is nil")
 	}
 
-	points, adjacency := m.buildPathGraph()
+	graph := m.cachedPathGraph()
+	points, costRatio := graph.points, graph.costRatio
+	if len(m.TemporaryVertices) > 0 {
+		points = make(map[int]algoPoint, len(graph.points)+len(m.TemporaryVertices))
+		for id, point := range graph.points {
+			points[id] = point
+		}
+		for id, vertex := range m.TemporaryVertices {
+			points[id] = algoPoint{x: vertex.X, y: vertex.Y}
+			costRatio = math.Min(costRatio, math.Max(vertex.CostFactor, 0))
+		}
+	}
 	if _, ok := points[startID]; !ok {
 		return nil, fmt.Errorf("start vertex %d not found", startID)
 	}
@@ -371,7 +480,7 @@ func (m *NavMesh) FindPathIDs(startID, targetID int) ([]int, error) {
 	connectPlans := m.pathConnectPlans()
 
 	if len(connectPlans) == 0 {
-		pathIDs, err := dijkstraPath(adjacency, startID, targetID)
+		pathIDs, err := astarPath(points, graph.adjacency, startID, targetID, costRatio)
 		if err != nil {
 			return nil, fmt.Errorf("navmesh path not found")
 		}
@@ -379,8 +488,8 @@ func (m *NavMesh) FindPathIDs(startID, targetID int) ([]int, error) {
 	}
 
 	for _, plan := range connectPlans {
-		connectedAdjacency := m.applyConnectPlan(adjacency, plan)
-		pathIDs, err := dijkstraPath(connectedAdjacency, startID, targetID)
+		connectedAdjacency := m.applyConnectPlan(graph.adjacency, plan)
+		pathIDs, err := astarPath(points, connectedAdjacency, startID, targetID, costRatio)
 		if err == nil {
 			return pathIDs, nil
 		}
@@ -388,6 +497,97 @@ func (m *NavMesh) FindPathIDs(startID, targetID int) ([]int, error) {
 	return nil, fmt.Errorf("navmesh path not found")
 }
 
+// SmoothPathIDs string-pulls the path: it removes intermediate vertices when the straight line between their neighbors
+// stays in the walkable area of the same tier, which is covered by corridors around the walkable edges and the path itself.
+// Zipline endpoints and tier transitions are always kept.
+func (m *NavMesh) SmoothPathIDs(pathIDs []int) []int {
+	if len(pathIDs) <= 2 {
+		return pathIDs
+	}
+	points := make([]algoPoint, len(pathIDs))
+	tiers := make([]int, len(pathIDs))
+	for i, id := range pathIDs {
+		point, ok := m.VertexPoint(id)
+		if !ok {
+			return pathIDs
+		}
+		points[i] = algoPoint{x: point[0], y: point[1]}
+		tiers[i] = -1
+		if vertex, ok := m.vertexByID(id); ok {
+			tiers[i] = vertex.TierID
+		}
+	}
+
+	pinned := make([]bool, len(pathIDs))
+	zipline := make([]bool, len(pathIDs))
+	for i := 0; i+1 < len(pathIDs); i++ {
+		if _, ok := m.IsZiplineEdge(pathIDs[i], pathIDs[i+1]); ok {
+			pinned[i], pinned[i+1], zipline[i] = true, true, true
+			continue
+		}
+		if tiers[i] >= 0 && tiers[i+1] >= 0 && tiers[i] != tiers[i+1] {
+			pinned[i], pinned[i+1] = true, true
+		}
+	}
+
+	// Temporary vertices have no tier and are walkable on any tier
+	corridorsByTier := map[int][]algoSegment{}
+	tierCorridors := func(tier int) []algoSegment {
+		if corridors, ok := corridorsByTier[tier]; ok {
+			return corridors
+		}
+		corridors := m.walkableCorridors(tier)
+		for i := 0; i+1 < len(points); i++ {
+			if !zipline[i] && navMeshTierMatches(tiers[i], tier) && navMeshTierMatches(tiers[i+1], tier) {
+				corridors = append(corridors, algoSegment{a: points[i], b: points[i+1]})
+			}
+		}
+		corridorsByTier[tier] = corridors
+		return corridors
+	}
+	visible := func(from, to int) bool {
+		tier := tiers[from]
+		if tier < 0 {
+			tier = tiers[to]
+		}
+		return segmentInCorridors(points[from], points[to], tierCorridors(tier), navMeshWalkableRadius)
+	}
+
+	indices := stringPullPath(points, pinned, visible)
+	smoothed := make([]int, len(indices))
+	for i, index := range indices {
+		smoothed[i] = pathIDs[index]
+	}
+	return smoothed
+}
+
+// walkableCorridors returns the enabled non-zipline edges between visible vertices of the tier, or of all tiers if tier is negative.
+func (m *NavMesh) walkableCorridors(tier int) []algoSegment {
+	corridors := make([]algoSegment, 0, len(m.Edges)+len(m.RuntimeEdges))
+	for _, edges := range []map[int]NavMeshEdge{m.Edges, m.RuntimeEdges} {
+		for _, edge := range edges {
+			if m.DisabledEdges[edge.ID] || edge.Flags&NavMeshEdgeFlagZipline != 0 {
+				continue
+			}
+			source, sourceOK := m.vertexByID(edge.SourceID)
+			destination, destinationOK := m.vertexByID(edge.DestinationID)
+			if !sourceOK || !destinationOK || (source.Flags|destination.Flags)&NavMeshVertexFlagHidden != 0 {
+				continue
+			}
+			if !navMeshTierMatches(source.TierID, tier) || !navMeshTierMatches(destination.TierID, tier) {
+				continue
+			}
+			corridors = append(corridors, algoSegment{a: algoPoint{x: source.X, y: source.Y}, b: algoPoint{x: destination.X, y: destination.Y}})
+		}
+	}
+	return corridors
+}
+
+// navMeshTierMatches reports whether tier is walkable on want. A negative tier matches any tier.
+func navMeshTierMatches(tier, want int) bool {
+	return tier < 0 || want < 0 || tier == want
+}
+
 // PathIDsToPoints converts a vertex ID path to a coordinate path.
 func (m *NavMesh) PathIDsToPoints(pathIDs []int) ([][2]float64, error) {
 	if len(pathIDs) == 0 {
@@ -549,7 +749,7 @@ func parseNavMeshVertex(line string) (NavMeshVertex, error) {
 	if err != nil {
 		return NavMeshVertex{}, fmt.Errorf("invalid vertex entity id: %w", err)
 	}
-	flags, err := parseNavMeshVertexFlags(namedRegexpValue(navMeshVertexRegexp, match, "flags"))
+	flags, err := ParseNavMeshVertexFlags(namedRegexpValue(navMeshVertexRegexp, match, "flags"))
 	if err != nil {
 		return NavMeshVertex{}, err
 	}
@@ -602,7 +802,8 @@ func namedRegexpValue(re *regexp.Regexp, match []string, name string) string {
 	return match[idx]
 }
 
-func parseNavMeshVertexFlags(text string) (int, error) {
+// ParseNavMeshVertexFlags parses vertex flag letters (e.g. "TC") into a flag mask.
+func ParseNavMeshVertexFlags(text string) (int, error) {
 	flags := 0
 	for _, ch := range text {
 		switch ch {
@@ -629,6 +830,14 @@ func roundNavMeshCoord(value float64) float64 {
 	return math.Round(value*1000) / 1000
 }
 
+func (m *NavMesh) cachedPathGraph() *navMeshPathGraph {
+	if m.pathGraph == nil {
+		points, adjacency := m.buildPathGraph()
+		m.pathGraph = &navMeshPathGraph{points: points, adjacency: adjacency, costRatio: pathCostRatio(points, adjacency)}
+	}
+	return m.pathGraph
+}
+
 func (m *NavMesh) buildPathGraph() (map[int]algoPoint, map[int][]algoEdge) {
 	points := map[int]algoPoint{}
 	adjacency := map[int][]algoEdge{}
@@ -644,9 +853,6 @@ func (m *NavMesh) buildPathGraph() (map[int]algoPoint, map[int][]algoEdge) {
 		}
 		points[id] = algoPoint{x: vertex.X, y: vertex.Y}
 	}
-	for id, vertex := range m.TemporaryVertices {
-		points[id] = algoPoint{x: vertex.X, y: vertex.Y}
-	}
 	m.appendPathGraphEdges(points, adjacency, m.Edges)
 	m.appendPathGraphEdges(points, adjacency, m.RuntimeEdges)
 	return points, adjacency
@@ -663,9 +869,13 @@ func (m *NavMesh) appendPathGraphEdges(points map[int]algoPoint, adjacency map[i
 		if _, ok := points[edge.DestinationID]; !ok {
 			continue
 		}
-		adjacency[edge.SourceID] = append(adjacency[edge.SourceID], algoEdge{to: edge.DestinationID, cost: edge.Cost})
+		cost := edge.Cost
+		if factor, ok := m.EdgeCostFactors[edge.ID]; ok {
+			cost *= factor
+		}
+		adjacency[edge.SourceID] = append(adjacency[edge.SourceID], algoEdge{to: edge.DestinationID, cost: cost})
 		if edge.Bidirectional {
-			adjacency[edge.DestinationID] = append(adjacency[edge.DestinationID], algoEdge{to: edge.SourceID, cost: edge.Cost})
+			adjacency[edge.DestinationID] = append(adjacency[edge.DestinationID], algoEdge{to: edge.SourceID, cost: cost})
 		}
 	}
 }
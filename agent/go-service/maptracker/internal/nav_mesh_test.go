@@ -2,6 +2,8 @@
 package maptrackerinternal
 
 import (
+	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -53,19 +55,169 @@ func TestFindPath(t *testing.T) {
 	if err != nil {
 		t.Fatalf("ParseNavMesh() error = %v", err)
 	}
-	path, err := findTemporaryPath(mesh, [2]float64{-1, 0}, [2]float64{11, 0})
+	startID, _ := mesh.AddTemporaryVertex(-1, 0, 1.05, 20)
+	targetID, _ := mesh.AddTemporaryVertex(11, 0, 1.05, 20)
+	pathIDs, err := mesh.FindPathIDs(startID, targetID)
+	if err != nil {
+		t.Fatalf("FindPathIDs() error = %v", err)
+	}
+	if len(pathIDs) < 3 {
+		t.Fatalf("len(pathIDs) = %d, pathIDs = %+v", len(pathIDs), pathIDs)
+	}
+	path, err := mesh.FindPath(startID, targetID)
 	if err != nil {
 		t.Fatalf("FindPath() error = %v", err)
 	}
-	if len(path) < 3 {
-		t.Fatalf("len(path) = %d, path = %+v", len(path), path)
+	assertNavMeshPath(t, path, [][2]float64{{-1, 0}, {11, 0}})
+}
+
+func TestFindPathMatchesDijkstraCost(t *testing.T) {
+	mesh := loadRealNavMesh(t)
+	graph := mesh.cachedPathGraph()
+	pairs := benchmarkReachablePathPairs(t, graph.points, graph.adjacency, benchmarkMap02Lv002PairCount)
+	for _, pair := range pairs {
+		expected, err := dijkstraPath(graph.adjacency, pair.startID, pair.targetID)
+		if err != nil {
+			t.Fatalf("dijkstraPath(%d, %d) error = %v", pair.startID, pair.targetID, err)
+		}
+		actual, err := mesh.FindPathIDs(pair.startID, pair.targetID)
+		if err != nil {
+			t.Fatalf("FindPathIDs(%d, %d) error = %v", pair.startID, pair.targetID, err)
+		}
+		if math.Abs(pathIDsCost(graph.adjacency, actual)-pathIDsCost(graph.adjacency, expected)) > 1e-6 {
+			t.Fatalf("FindPathIDs(%d, %d) cost = %v, dijkstra cost = %v", pair.startID, pair.targetID, pathIDsCost(graph.adjacency, actual), pathIDsCost(graph.adjacency, expected))
+		}
+	}
+}
+
+func TestPathGraphCacheInvalidatedByRuntimeMutation(t *testing.T) {
+	mesh, err := ParseNavMesh(strings.NewReader(testNavMeshText))
+	if err != nil {
+		t.Fatalf("ParseNavMesh() error = %v", err)
 	}
-	if path[0] != [2]float64{-1, 0} {
-		t.Fatalf("path[0] = %+v", path[0])
+	if _, err := mesh.FindPathIDs(1, 2); err != nil {
+		t.Fatalf("FindPathIDs() error = %v", err)
+	}
+	runtimeID, _ := mesh.AddRuntimeVertex(5, 5, 0, 0, 0)
+	mesh.AddRuntimeEdge(-100, 1, runtimeID, true, 1, 0)
+	pathIDs, err := mesh.FindPathIDs(2, runtimeID)
+	if err != nil {
+		t.Fatalf("FindPathIDs() after AddRuntimeEdge error = %v", err)
+	}
+	assertDijkstraPath(t, pathIDs, []int{2, 1, runtimeID})
+
+	mesh.DisableVertex(1)
+	if _, err := mesh.FindPathIDs(2, runtimeID); err == nil {
+		t.Fatalf("FindPathIDs() after DisableVertex error = nil")
+	}
+}
+
+func TestSmoothPathIDsKeepsCornersAndZiplines(t *testing.T) {
+	mesh, err := ParseNavMesh(strings.NewReader(`[MapTrackerNavMesh.Meta]
+Version=1
+Encoding=UTF-8
+Name=map_test_lv001
+Description=Test navmesh
+MapRegionName=map_test
+MapLevelName=lv001
+GeoWidth=100.0
+GeoHeight=100.0
+
+[MapTrackerNavMesh.Vertices]
+V1=X0,Y0,T0,E0,F()
+V2=X10,Y1,T0,E0,F()
+V3=X20,Y0,T0,E0,F()
+V4=X20,Y20,T0,E0,F()
+V5=X20,Y40,T0,E0,F()
+
+[MapTrackerNavMesh.Edges]
+E1=S1,D2,B1,C10,F()
+E2=S2,D3,B1,C10,F()
+E3=S3,D4,B1,C20,F()
+E4=S4,D5,B1,C20,F()
+`))
+	if err != nil {
+		t.Fatalf("ParseNavMesh() error = %v", err)
+	}
+	assertDijkstraPath(t, mesh.SmoothPathIDs([]int{1, 2, 3, 4, 5}), []int{1, 3, 5})
+
+	leftID, _ := mesh.AddRuntimeVertex(20, 10, 0, 0, NavMeshVertexFlagZipline)
+	rightID, _ := mesh.AddRuntimeVertex(20, 30, 0, 0, NavMeshVertexFlagZipline)
+	mesh.AddRuntimeEdge(-100, leftID, rightID, true, 1, NavMeshEdgeFlagZipline)
+	assertDijkstraPath(t, mesh.SmoothPathIDs([]int{3, leftID, rightID, 5}), []int{3, leftID, rightID, 5})
+}
+
+func TestSmoothPathIDsCollapsesZigZagInOpenArea(t *testing.T) {
+	mesh := mustParseNavMesh(t, testOpenAreaNavMeshText())
+	// Zig-zag between y=0 and y=40 across the open area, through vertices (0,20) ... (100,20)
+	zigZag := []int{
+		testOpenAreaVertexID(0, 2), testOpenAreaVertexID(1, 3), testOpenAreaVertexID(2, 4), testOpenAreaVertexID(3, 3),
+		testOpenAreaVertexID(4, 2), testOpenAreaVertexID(5, 1), testOpenAreaVertexID(6, 0), testOpenAreaVertexID(7, 1),
+		testOpenAreaVertexID(8, 2), testOpenAreaVertexID(9, 3), testOpenAreaVertexID(10, 2),
+	}
+	assertDijkstraPath(t, mesh.SmoothPathIDs(zigZag), []int{zigZag[0], zigZag[len(zigZag)-1]})
+
+	// An obstacle on the straight line keeps the path going around it
+	mesh.DisableVertex(testOpenAreaVertexID(5, 2))
+	mesh.DisableVertex(testOpenAreaVertexID(6, 2))
+	smoothed := mesh.SmoothPathIDs(zigZag)
+	if len(smoothed) <= 2 {
+		t.Fatalf("SmoothPathIDs() = %v, want a path around the obstacle", smoothed)
+	}
+	for _, id := range smoothed {
+		if id == testOpenAreaVertexID(5, 2) || id == testOpenAreaVertexID(6, 2) {
+			t.Fatalf("SmoothPathIDs() = %v, passes through the obstacle", smoothed)
+		}
+	}
+}
+
+// testOpenAreaVertexID returns the ID of the open area vertex at column col (X=10*col) and row row (Y=10*row).
+func testOpenAreaVertexID(col, row int) int {
+	return row*11 + col + 1
+}
+
+// testOpenAreaNavMeshText returns an 11x5 grid of vertices spaced 10 apart, connected to their neighbors and diagonals.
+func testOpenAreaNavMeshText() string {
+	var b strings.Builder
+	b.WriteString(`[MapTrackerNavMesh.Meta]
+Version=1
+Encoding=UTF-8
+Name=map_open_lv001
+Description=Test navmesh
+MapRegionName=map_open
+MapLevelName=lv001
+GeoWidth=100.0
+GeoHeight=100.0
+
+[MapTrackerNavMesh.Vertices]
+`)
+	for row := 0; row < 5; row++ {
+		for col := 0; col < 11; col++ {
+			fmt.Fprintf(&b, "V%d=X%d,Y%d,T0,E0,F()\n", testOpenAreaVertexID(col, row), col*10, row*10)
+		}
 	}
-	if path[len(path)-1] != [2]float64{11, 0} {
-		t.Fatalf("path last = %+v", path[len(path)-1])
+	b.WriteString("\n[MapTrackerNavMesh.Edges]\n")
+	edgeID := 0
+	addEdge := func(from, to int, cost float64) {
+		edgeID++
+		fmt.Fprintf(&b, "E%d=S%d,D%d,B1,C%g,F()\n", edgeID, from, to, cost)
+	}
+	for row := 0; row < 5; row++ {
+		for col := 0; col < 11; col++ {
+			id := testOpenAreaVertexID(col, row)
+			if col+1 < 11 {
+				addEdge(id, testOpenAreaVertexID(col+1, row), 10)
+			}
+			if row+1 < 5 {
+				addEdge(id, testOpenAreaVertexID(col, row+1), 10)
+			}
+			if col+1 < 11 && row+1 < 5 {
+				addEdge(id, testOpenAreaVertexID(col+1, row+1), 10*math.Sqrt2)
+				addEdge(testOpenAreaVertexID(col+1, row), testOpenAreaVertexID(col, row+1), 10*math.Sqrt2)
+			}
+		}
 	}
+	return b.String()
 }
 
 func TestFindPathUsesTemporaryVertexConnection(t *testing.T) {
@@ -202,6 +354,91 @@ func TestDisableRuntimeZiplineVertex(t *testing.T) {
 	}
 }
 
+func TestClearRuntimeResetsDisabledRuntimeIDs(t *testing.T) {
+	mesh, err := ParseNavMesh(strings.NewReader(testNavMeshText))
+	if err != nil {
+		t.Fatalf("ParseNavMesh() error = %v", err)
+	}
+	ziplineID, _ := mesh.AddRuntimeVertex(5, 0, 0, 0, NavMeshVertexFlagZipline)
+	mesh.AddRuntimeEdge(-100, 1, ziplineID, true, 1, 0)
+	mesh.DisableVertex(ziplineID)
+	mesh.DisableEdge(-100)
+	mesh.DisableEdge(1)
+	mesh.ClearRuntime()
+
+	if len(mesh.RuntimeVertices) != 0 || len(mesh.RuntimeEdges) != 0 {
+		t.Fatalf("runtime graph not cleared: %+v, %+v", mesh.RuntimeVertices, mesh.RuntimeEdges)
+	}
+	if reusedID, _ := mesh.AddRuntimeVertex(5, 0, 0, 0, NavMeshVertexFlagZipline); reusedID != ziplineID || mesh.DisabledVertices[reusedID] {
+		t.Fatalf("reused runtime vertex %d should not stay disabled", reusedID)
+	}
+	if mesh.DisabledEdges[-100] || !mesh.DisabledEdges[1] {
+		t.Fatalf("unexpected disabled edges: %+v", mesh.DisabledEdges)
+	}
+}
+
+const testSquareNavMeshText = `[MapTrackerNavMesh.Meta]
+Version=1
+Encoding=UTF-8
+Name=map_square_lv001
+Description=Test navmesh
+MapRegionName=map_square
+MapLevelName=lv001
+GeoWidth=100.0
+GeoHeight=100.0
+
+[MapTrackerNavMesh.Vertices]
+V1=X0,Y0,T0,E0,F()
+V2=X10,Y0,T0,E0,F()
+V3=X10,Y10,T0,E0,F()
+V4=X0,Y10,T0,E0,F()
+
+[MapTrackerNavMesh.Edges]
+E1=S1,D2,B1,C10,F()
+E2=S2,D3,B1,C10,F()
+E3=S1,D4,B1,C10,F()
+E4=S4,D3,B1,C10,F()
+`
+
+func TestPenalizeEdgeReroutesPath(t *testing.T) {
+	mesh := mustParseNavMesh(t, testSquareNavMeshText)
+	pathIDs, err := mesh.FindPathIDs(1, 3)
+	if err != nil {
+		t.Fatalf("FindPathIDs() error = %v", err)
+	}
+	assertDijkstraPath(t, pathIDs, []int{1, 2, 3})
+
+	mesh.PenalizeEdge(2, 2)
+	mesh.PenalizeEdge(2, 2)
+	if factor := mesh.EdgeCostFactors[2]; factor != 4 {
+		t.Fatalf("EdgeCostFactors[2] = %v", factor)
+	}
+	pathIDs, err = mesh.FindPathIDs(1, 3)
+	if err != nil {
+		t.Fatalf("FindPathIDs() after penalty error = %v", err)
+	}
+	assertDijkstraPath(t, pathIDs, []int{1, 4, 3})
+	if cost := mesh.PathIDsCost([]int{1, 2, 3}); cost != 50 {
+		t.Fatalf("PathIDsCost() with penalty = %v", cost)
+	}
+}
+
+func TestNearestEdge(t *testing.T) {
+	mesh := mustParseNavMesh(t, testSquareNavMeshText)
+	edge, distance, ok := mesh.NearestEdge([2]float64{9, 5}, 3)
+	if !ok || edge.ID != 2 || distance != 1 {
+		t.Fatalf("NearestEdge() = %+v, %v, %v", edge, distance, ok)
+	}
+	if _, _, ok := mesh.NearestEdge([2]float64{5, 5}, 3); ok {
+		t.Fatalf("NearestEdge() beyond max distance ok = true")
+	}
+
+	mesh.DisableEdge(2)
+	if edge, _, ok := mesh.NearestEdge([2]float64{9, 5}, 10); !ok || edge.ID == 2 {
+		t.Fatalf("NearestEdge() returned disabled edge: %+v", edge)
+	}
+}
+
 func TestParseNavMeshRejectsInvalidData(t *testing.T) {
 	tests := []struct {
 		name string
@@ -257,17 +494,37 @@ func containsID(ids []int, target int) bool {
 	return false
 }
 
-func TestParseRealNavMesh(t *testing.T) {
+func pathIDsCost(adjacency map[int][]algoEdge, pathIDs []int) float64 {
+	cost := 0.0
+	for i := 0; i+1 < len(pathIDs); i++ {
+		best := math.Inf(1)
+		for _, edge := range adjacency[pathIDs[i]] {
+			if edge.to == pathIDs[i+1] {
+				best = math.Min(best, edge.cost)
+			}
+		}
+		cost += best
+	}
+	return cost
+}
+
+func loadRealNavMesh(tb testing.TB) *NavMesh {
+	tb.Helper()
 	file, err := os.Open(filepath.Join("..", "..", "..", "..", "assets", "data", "MapTrackerNavMesh", "map02_lv002.mtnm"))
 	if err != nil {
-		t.Fatalf("Open real NavMesh file error = %v", err)
+		tb.Fatalf("Open real NavMesh file error = %v", err)
 	}
 	defer func() { _ = file.Close() }()
 
 	mesh, err := ParseNavMesh(file)
 	if err != nil {
-		t.Fatalf("ParseNavMesh() error = %v", err)
+		tb.Fatalf("ParseNavMesh() error = %v", err)
 	}
+	return mesh
+}
+
+func TestParseRealNavMesh(t *testing.T) {
+	mesh := loadRealNavMesh(t)
 	if mesh.Meta.Name != "map02_lv002" {
 		t.Fatalf("Meta.Name = %q", mesh.Meta.Name)
 	}
@@ -0,0 +1,184 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	NavMeshIssueSeverityError   = "error"
+	NavMeshIssueSeverityWarning = "warning"
+
+	// NavMeshIssueDanglingEdge reports an edge referencing a missing vertex.
+	NavMeshIssueDanglingEdge = "dangling_edge"
+	// NavMeshIssueUnreachableComponent reports a connected component that cannot be reached from the main one.
+	NavMeshIssueUnreachableComponent = "unreachable_component"
+	// NavMeshIssueDuplicateEntity reports several vertices sharing the same entity ID.
+	NavMeshIssueDuplicateEntity = "duplicate_entity"
+	// NavMeshIssueZiplineEdge reports a zipline edge whose endpoints are not both zipline vertices.
+	NavMeshIssueZiplineEdge = "zipline_edge"
+)
+
+// NavMeshIssue represents a problem found by NavMesh validation.
+type NavMeshIssue struct {
+	Severity  string `json:"severity"`
+	Kind      string `json:"kind"`
+	Message   string `json:"message"`
+	VertexIDs []int  `json:"vertex_ids,omitempty"`
+	EdgeIDs   []int  `json:"edge_ids,omitempty"`
+}
+
+func (i NavMeshIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Kind, i.Message)
+}
+
+// Validate checks both persistent and runtime data of the NavMesh and returns all issues in a stable order.
+// Vertices without any edge are treated as markers and never form unreachable components.
+// Components holding a teleport anchor are reachable by teleporting, so they are not reported either.
+func (m *NavMesh) Validate() []NavMeshIssue {
+	vertices := map[int]NavMeshVertex{}
+	for id, vertex := range m.Vertices {
+		vertices[id] = vertex
+	}
+	for id, vertex := range m.RuntimeVertices {
+		vertices[id] = vertex
+	}
+	edges := make([]NavMeshEdge, 0, len(m.Edges)+len(m.RuntimeEdges))
+	for _, edge := range m.Edges {
+		edges = append(edges, edge)
+	}
+	for _, edge := range m.RuntimeEdges {
+		edges = append(edges, edge)
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].ID < edges[j].ID })
+
+	issues := make([]NavMeshIssue, 0)
+	linkedEdges := make([]NavMeshEdge, 0, len(edges))
+	for _, edge := range edges {
+		source, sourceOK := vertices[edge.SourceID]
+		destination, destinationOK := vertices[edge.DestinationID]
+		if !sourceOK || !destinationOK {
+			missing := edge.SourceID
+			if sourceOK {
+				missing = edge.DestinationID
+			}
+			issues = append(issues, NavMeshIssue{
+				Severity: NavMeshIssueSeverityError,
+				Kind:     NavMeshIssueDanglingEdge,
+				Message:  fmt.Sprintf("edge %d references missing vertex %d", edge.ID, missing),
+				EdgeIDs:  []int{edge.ID},
+			})
+			continue
+		}
+		linkedEdges = append(linkedEdges, edge)
+		if edge.Flags&NavMeshEdgeFlagZipline != 0 &&
+			(source.Flags&NavMeshVertexFlagZipline == 0 || destination.Flags&NavMeshVertexFlagZipline == 0) {
+			issues = append(issues, NavMeshIssue{
+				Severity:  NavMeshIssueSeverityError,
+				Kind:      NavMeshIssueZiplineEdge,
+				Message:   fmt.Sprintf("zipline edge %d links vertex %d and %d which are not both zipline vertices", edge.ID, edge.SourceID, edge.DestinationID),
+				VertexIDs: []int{edge.SourceID, edge.DestinationID},
+				EdgeIDs:   []int{edge.ID},
+			})
+		}
+	}
+
+	issues = append(issues, validateNavMeshEntities(vertices)...)
+	issues = append(issues, validateNavMeshComponents(vertices, linkedEdges)...)
+	return issues
+}
+
+func validateNavMeshEntities(vertices map[int]NavMeshVertex) []NavMeshIssue {
+	entityVertices := map[int64][]int{}
+	for id, vertex := range vertices {
+		if vertex.EntityID != 0 {
+			entityVertices[vertex.EntityID] = append(entityVertices[vertex.EntityID], id)
+		}
+	}
+	entityIDs := make([]int64, 0)
+	for entityID, ids := range entityVertices {
+		if len(ids) > 1 {
+			entityIDs = append(entityIDs, entityID)
+		}
+	}
+	sort.Slice(entityIDs, func(i, j int) bool { return entityIDs[i] < entityIDs[j] })
+
+	issues := make([]NavMeshIssue, 0, len(entityIDs))
+	for _, entityID := range entityIDs {
+		ids := entityVertices[entityID]
+		sort.Ints(ids)
+		issues = append(issues, NavMeshIssue{
+			Severity:  NavMeshIssueSeverityError,
+			Kind:      NavMeshIssueDuplicateEntity,
+			Message:   fmt.Sprintf("entity %d is shared by vertices %v", entityID, ids),
+			VertexIDs: ids,
+		})
+	}
+	return issues
+}
+
+func validateNavMeshComponents(vertices map[int]NavMeshVertex, edges []NavMeshEdge) []NavMeshIssue {
+	parent := map[int]int{}
+	var find func(id int) int
+	find = func(id int) int {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+	for _, edge := range edges {
+		for _, id := range []int{edge.SourceID, edge.DestinationID} {
+			if _, ok := parent[id]; !ok {
+				parent[id] = id
+			}
+		}
+		sourceRoot, destinationRoot := find(edge.SourceID), find(edge.DestinationID)
+		if sourceRoot != destinationRoot {
+			parent[max(sourceRoot, destinationRoot)] = min(sourceRoot, destinationRoot)
+		}
+	}
+
+	components := map[int][]int{}
+	for id := range parent {
+		root := find(id)
+		components[root] = append(components[root], id)
+	}
+	if len(components) <= 1 {
+		return nil
+	}
+	roots := make([]int, 0, len(components))
+	for root, ids := range components {
+		sort.Ints(ids)
+		roots = append(roots, root)
+	}
+	// The largest component is the main one, ties are broken by the smallest vertex ID.
+	sort.Slice(roots, func(i, j int) bool {
+		if len(components[roots[i]]) != len(components[roots[j]]) {
+			return len(components[roots[i]]) > len(components[roots[j]])
+		}
+		return roots[i] < roots[j]
+	})
+
+	issues := make([]NavMeshIssue, 0)
+	for _, root := range roots[1:] {
+		ids := components[root]
+		teleportable := false
+		for _, id := range ids {
+			if vertices[id].Flags&NavMeshVertexFlagTeleportAnchor != 0 {
+				teleportable = true
+				break
+			}
+		}
+		if teleportable {
+			continue
+		}
+		issues = append(issues, NavMeshIssue{
+			Severity:  NavMeshIssueSeverityWarning,
+			Kind:      NavMeshIssueUnreachableComponent,
+			Message:   fmt.Sprintf("component of %d vertices starting at vertex %d is not connected to the main component", len(ids), ids[0]),
+			VertexIDs: ids,
+		})
+	}
+	return issues
+}
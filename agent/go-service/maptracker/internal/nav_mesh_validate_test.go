@@ -0,0 +1,108 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testValidateNavMeshText = `[MapTrackerNavMesh.Meta]
+Version=1
+Encoding=UTF-8
+Name=map_test_lv001
+Description=Test navmesh
+MapRegionName=map_test
+MapLevelName=lv001
+GeoWidth=100.0
+GeoHeight=100.0
+
+[MapTrackerNavMesh.Vertices]
+V1=X0,Y0,T0,E0,F()
+V2=X10,Y0,T0,E100,F(C)
+V3=X20,Y0,T0,E0,F()
+V4=X50,Y50,T0,E0,F()
+V5=X60,Y50,T0,E100,F(C)
+V6=X90,Y90,T0,E0,F()
+V7=X95,Y90,T0,E0,F(TS)
+V8=X70,Y70,T0,E300,F(C)
+
+[MapTrackerNavMesh.Edges]
+E1=S1,D2,B1,C10,F()
+E2=S2,D3,B1,C10,F()
+E3=S4,D5,B0,C10,F()
+E4=S6,D7,B1,C5,F()
+`
+
+func TestNavMeshValidate(t *testing.T) {
+	mesh := mustParseNavMesh(t, testValidateNavMeshText)
+	issues := mesh.Validate()
+	assertNavMeshIssueKinds(t, issues, []string{NavMeshIssueDuplicateEntity, NavMeshIssueUnreachableComponent})
+	if !reflect.DeepEqual(issues[0].VertexIDs, []int{2, 5}) {
+		t.Fatalf("duplicate entity issue = %+v", issues[0])
+	}
+	if issues[0].Severity != NavMeshIssueSeverityError {
+		t.Fatalf("duplicate entity severity = %q", issues[0].Severity)
+	}
+	// V6-V7 holds a teleport anchor and V8 is an edgeless marker, so only V4-V5 is unreachable.
+	if !reflect.DeepEqual(issues[1].VertexIDs, []int{4, 5}) || issues[1].Severity != NavMeshIssueSeverityWarning {
+		t.Fatalf("unreachable component issue = %+v", issues[1])
+	}
+}
+
+func TestNavMeshValidateRuntimeEdges(t *testing.T) {
+	mesh := mustParseNavMesh(t, testNavMeshText)
+	ziplineA, _ := mesh.AddRuntimeVertex(0, 10, 0, 0, NavMeshVertexFlagZipline)
+	ziplineB, _ := mesh.AddRuntimeVertex(10, 10, 0, 0, NavMeshVertexFlagZipline)
+	mesh.AddRuntimeEdge(-1, ziplineA, ziplineB, true, 10, NavMeshEdgeFlagZipline)
+	mesh.AddRuntimeEdge(-2, 1, ziplineA, false, 10, 0)
+	if issues := mesh.Validate(); len(issues) != 0 {
+		t.Fatalf("Validate() = %+v, want no issues", issues)
+	}
+
+	mesh.AddRuntimeEdge(-3, 1, ziplineB, true, 10, NavMeshEdgeFlagZipline)
+	mesh.AddRuntimeEdge(-4, 2, -999, true, 10, 0)
+	issues := mesh.Validate()
+	assertNavMeshIssueKinds(t, issues, []string{NavMeshIssueDanglingEdge, NavMeshIssueZiplineEdge})
+	if !reflect.DeepEqual(issues[0].EdgeIDs, []int{-4}) || !reflect.DeepEqual(issues[1].EdgeIDs, []int{-3}) {
+		t.Fatalf("Validate() = %+v", issues)
+	}
+}
+
+func TestNavMeshValidateAssetsHaveNoErrors(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "..", "..", "assets", "data", "MapTrackerNavMesh", "*.mtnm"))
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		mesh, err := ParseNavMesh(file)
+		_ = file.Close()
+		if err != nil {
+			t.Fatalf("ParseNavMesh(%s) error = %v", path, err)
+		}
+		for _, issue := range mesh.Validate() {
+			if issue.Severity == NavMeshIssueSeverityError {
+				t.Errorf("%s: %s", filepath.Base(path), issue)
+			} else {
+				t.Logf("%s: %s", filepath.Base(path), issue)
+			}
+		}
+	}
+}
+
+func assertNavMeshIssueKinds(t *testing.T, issues []NavMeshIssue, expected []string) {
+	t.Helper()
+	if len(issues) != len(expected) {
+		t.Fatalf("issues = %+v, expected kinds = %+v", issues, expected)
+	}
+	for i := range expected {
+		if issues[i].Kind != expected[i] {
+			t.Fatalf("issues = %+v, expected kinds = %+v", issues, expected)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteTo serializes the persistent part of the NavMesh in the canonical MapTracker NavMesh text format.
+// Vertices and edges are sorted by ID and numbers are formatted the same way as the NavMesh editor does,
+// so that writing a parsed file reproduces it byte by byte. Runtime and temporary data are never written.
+func (m *NavMesh) WriteTo(w io.Writer) (int64, error) {
+	text, err := m.formatText()
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.WriteString(w, text)
+	return int64(n), err
+}
+
+func (m *NavMesh) formatText() (string, error) {
+	meta := m.Meta
+	for key, value := range map[string]string{
+		"Name":          meta.Name,
+		"Description":   meta.Description,
+		"MapRegionName": meta.MapRegionName,
+		"MapLevelName":  meta.MapLevelName,
+	} {
+		if strings.ContainsAny(value, "\r\n") || value != strings.TrimSpace(value) {
+			return "", fmt.Errorf("navmesh Meta %s %q cannot be written", key, value)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s]\n", navMeshSectionMeta)
+	fmt.Fprintf(&b, "Version=%d\n", navMeshVersion)
+	fmt.Fprintf(&b, "Encoding=%s\n", navMeshEncoding)
+	fmt.Fprintf(&b, "Name=%s\n", meta.Name)
+	fmt.Fprintf(&b, "Description=%s\n", meta.Description)
+	fmt.Fprintf(&b, "MapRegionName=%s\n", meta.MapRegionName)
+	fmt.Fprintf(&b, "MapLevelName=%s\n", meta.MapLevelName)
+	fmt.Fprintf(&b, "GeoWidth=%s\n", formatNavMeshFloat(meta.GeoWidth))
+	fmt.Fprintf(&b, "GeoHeight=%s\n", formatNavMeshFloat(meta.GeoHeight))
+
+	fmt.Fprintf(&b, "\n[%s]\n", navMeshSectionVertices)
+	vertexIDs := make([]int, 0, len(m.Vertices))
+	for id := range m.Vertices {
+		vertexIDs = append(vertexIDs, id)
+	}
+	sort.Ints(vertexIDs)
+	for _, id := range vertexIDs {
+		vertex := m.Vertices[id]
+		if vertex.ID != id || id <= 0 {
+			return "", fmt.Errorf("vertex %d has invalid id %d", id, vertex.ID)
+		}
+		flags, err := FormatNavMeshVertexFlags(vertex.Flags)
+		if err != nil {
+			return "", fmt.Errorf("vertex %d: %w", id, err)
+		}
+		fmt.Fprintf(&b, "V%d=X%s,Y%s,T%d,E%d,F(%s)\n",
+			id, formatNavMeshFloat(roundNavMeshCoord(vertex.X)), formatNavMeshFloat(roundNavMeshCoord(vertex.Y)), vertex.TierID, vertex.EntityID, flags)
+	}
+
+	fmt.Fprintf(&b, "\n[%s]\n", navMeshSectionEdges)
+	edgeIDs := make([]int, 0, len(m.Edges))
+	for id := range m.Edges {
+		edgeIDs = append(edgeIDs, id)
+	}
+	sort.Ints(edgeIDs)
+	for _, id := range edgeIDs {
+		edge := m.Edges[id]
+		if edge.ID != id || id <= 0 {
+			return "", fmt.Errorf("edge %d has invalid id %d", id, edge.ID)
+		}
+		if edge.Flags != 0 {
+			return "", fmt.Errorf("edge %d has flag(s) %d unsupported by the text format", id, edge.Flags)
+		}
+		bidirectional := 0
+		if edge.Bidirectional {
+			bidirectional = 1
+		}
+		fmt.Fprintf(&b, "E%d=S%d,D%d,B%d,C%s,F()\n", id, edge.SourceID, edge.DestinationID, bidirectional, formatNavMeshCost(edge.Cost))
+	}
+	return b.String(), nil
+}
+
+// FormatNavMeshVertexFlags formats a vertex flag mask into flag letters (e.g. "TC").
+// It is the inverse of ParseNavMeshVertexFlags and fails on runtime-only flags.
+func FormatNavMeshVertexFlags(flags int) (string, error) {
+	letters := []struct {
+		flag   int
+		letter byte
+	}{
+		{NavMeshVertexFlagTeleportAnchor, 'T'},
+		{NavMeshVertexFlagHidden, 'H'},
+		{NavMeshVertexFlagSystem, 'S'},
+		{NavMeshVertexFlagRare, 'R'},
+		{NavMeshVertexFlagCollectable, 'C'},
+		{NavMeshVertexFlagDigable, 'D'},
+	}
+	var b strings.Builder
+	rest := flags
+	for _, item := range letters {
+		if flags&item.flag != 0 {
+			b.WriteByte(item.letter)
+			rest &^= item.flag
+		}
+	}
+	if rest != 0 {
+		return "", fmt.Errorf("unsupported vertex flag(s): %d", rest)
+	}
+	return b.String(), nil
+}
+
+// formatNavMeshFloat formats a float in its shortest round-trip form, keeping a fractional part like "878.0".
+func formatNavMeshFloat(value float64) string {
+	text := strconv.FormatFloat(value, 'f', -1, 64)
+	if !strings.ContainsAny(text, ".eEnN") {
+		text += ".0"
+	}
+	return text
+}
+
+// formatNavMeshCost formats an edge cost with at most three decimals and no trailing zeros.
+func formatNavMeshCost(value float64) string {
+	text := strings.TrimRight(strings.TrimRight(strconv.FormatFloat(value, 'f', 3, 64), "0"), ".")
+	if text == "" || text == "-0" {
+		return "0"
+	}
+	return text
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testMessyNavMeshText = `[MapTrackerNavMesh.Meta]
+Version = 1
+Encoding=UTF-8
+Name=map_test_lv001
+Description=Test navmesh
+MapRegionName=map_test
+MapLevelName=lv001
+GeoWidth=100
+GeoHeight=1e2
+
+[MapTrackerNavMesh.Vertices]
+V3 = X20.00049,Y-0.5,T1,E0,F(CR)
+V1=X0,Y0,T0,E100,F()
+V2=X10.25,Y3,T0,E200,F(ST)
+
+[MapTrackerNavMesh.Edges]
+E2=S2,D3,B0,C10.5000,F()
+
+E1=S1,D2,B1,C10,F()
+`
+
+const testCanonicalNavMeshText = `[MapTrackerNavMesh.Meta]
+Version=1
+Encoding=UTF-8
+Name=map_test_lv001
+Description=Test navmesh
+MapRegionName=map_test
+MapLevelName=lv001
+GeoWidth=100.0
+GeoHeight=100.0
+
+[MapTrackerNavMesh.Vertices]
+V1=X0.0,Y0.0,T0,E100,F()
+V2=X10.25,Y3.0,T0,E200,F(TS)
+V3=X20.0,Y-0.5,T1,E0,F(RC)
+
+[MapTrackerNavMesh.Edges]
+E1=S1,D2,B1,C10,F()
+E2=S2,D3,B0,C10.5,F()
+`
+
+func TestNavMeshWriteToCanonicalizes(t *testing.T) {
+	mesh := mustParseNavMesh(t, testMessyNavMeshText)
+	var buf bytes.Buffer
+	n, err := mesh.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Fatalf("WriteTo() n = %d, written = %d", n, buf.Len())
+	}
+	if buf.String() != testCanonicalNavMeshText {
+		t.Fatalf("WriteTo() output =\n%s\nwant\n%s", buf.String(), testCanonicalNavMeshText)
+	}
+}
+
+func TestNavMeshWriteToSkipsRuntimeData(t *testing.T) {
+	mesh := mustParseNavMesh(t, testCanonicalNavMeshText)
+	id, _ := mesh.AddRuntimeVertex(5, 5, 0, 0, NavMeshVertexFlagZipline)
+	mesh.AddRuntimeEdge(-1, 1, id, true, 7, NavMeshEdgeFlagZipline)
+	mesh.AddTemporaryVertex(1, 1, 1, 10)
+
+	var buf bytes.Buffer
+	if _, err := mesh.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if buf.String() != testCanonicalNavMeshText {
+		t.Fatalf("WriteTo() output =\n%s", buf.String())
+	}
+}
+
+func TestNavMeshWriteToRejectsUnsupportedData(t *testing.T) {
+	mesh := mustParseNavMesh(t, testCanonicalNavMeshText)
+	vertex := mesh.Vertices[1]
+	vertex.Flags |= NavMeshVertexFlagZipline
+	mesh.Vertices[1] = vertex
+	if _, err := mesh.WriteTo(&bytes.Buffer{}); err == nil {
+		t.Fatalf("WriteTo() with zipline vertex error = nil")
+	}
+
+	mesh = mustParseNavMesh(t, testCanonicalNavMeshText)
+	mesh.Meta.Description = "line\nbreak"
+	if _, err := mesh.WriteTo(&bytes.Buffer{}); err == nil {
+		t.Fatalf("WriteTo() with multi-line description error = nil")
+	}
+}
+
+func TestFormatNavMeshVertexFlagsRoundTrip(t *testing.T) {
+	for _, text := range []string{"", "T", "TS", "RC", "RD", "THSRCD"} {
+		flags, err := ParseNavMeshVertexFlags(text)
+		if err != nil {
+			t.Fatalf("ParseNavMeshVertexFlags(%q) error = %v", text, err)
+		}
+		formatted, err := FormatNavMeshVertexFlags(flags)
+		if err != nil {
+			t.Fatalf("FormatNavMeshVertexFlags(%d) error = %v", flags, err)
+		}
+		if formatted != text {
+			t.Fatalf("FormatNavMeshVertexFlags(%d) = %q, want %q", flags, formatted, text)
+		}
+	}
+}
+
+// TestNavMeshWriteToRoundTripsAssets uses every shipped NavMesh file as its own golden output.
+func TestNavMeshWriteToRoundTripsAssets(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "..", "..", "assets", "data", "MapTrackerNavMesh", "*.mtnm"))
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("no NavMesh assets found")
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			golden, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			mesh, err := ParseNavMesh(bytes.NewReader(golden))
+			if err != nil {
+				t.Fatalf("ParseNavMesh() error = %v", err)
+			}
+			var buf bytes.Buffer
+			if _, err := mesh.WriteTo(&buf); err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), golden) {
+				t.Fatalf("WriteTo() output differs from %s at line %d", path, firstDiffLine(buf.String(), string(golden)))
+			}
+
+			reparsed, err := ParseNavMesh(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("ParseNavMesh() of written output error = %v", err)
+			}
+			if !reflect.DeepEqual(reparsed, mesh) {
+				t.Fatalf("reparsed NavMesh differs from the original")
+			}
+		})
+	}
+}
+
+func firstDiffLine(a, b string) int {
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+	for i := 0; i < len(linesA) && i < len(linesB); i++ {
+		if linesA[i] != linesB[i] {
+			return i + 1
+		}
+	}
+	return min(len(linesA), len(linesB)) + 1
+}
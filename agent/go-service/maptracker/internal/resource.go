@@ -35,6 +35,10 @@ const (
 	MAP_DIR                = "resource/image/MapTracker/map"
 
 	RAW_MAP_BBOX_EXPAND_PX = 40 // 2x minimap radius
+
+	MAP_PYRAMID_LEVELS = 2 // Downsampled levels for coarse-to-fine full search
+
+	MAP_FEATURE_FAST_THRESHOLD = 16 // FAST threshold for map keypoints, slightly looser than the minimap's
 )
 
 // MapTrackerResource stores globally shared map resources for maptracker.
@@ -58,6 +62,11 @@ type MapCache struct {
 	OffsetY int
 
 	cachedIntegralArray *minicv.IntegralArray
+	cachedPyramid       *minicv.ImagePyramid
+	cachedFeatures      *minicv.FeatureSet
+
+	// pyramidMu serializes building the pyramid of this map only, see GetPyramid.
+	pyramidMu *sync.Mutex
 }
 
 // GetIntegralArray lazily initializes integral array when first needed.
@@ -72,6 +81,61 @@ func (m *MapCache) GetIntegralArray() minicv.IntegralArray {
 	return *m.cachedIntegralArray
 }
 
+// GetPyramid lazily initializes the image pyramid when first needed.
+// The pyramid is built under a per-map lock, so that different maps can be processed concurrently.
+func (m *MapCache) GetPyramid() *minicv.ImagePyramid {
+	Resource.IntegralCacheMu.Lock()
+	if m.pyramidMu == nil {
+		m.pyramidMu = &sync.Mutex{}
+	}
+	mu := m.pyramidMu
+	Resource.IntegralCacheMu.Unlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	Resource.IntegralCacheMu.Lock()
+	pyramid, integral := m.cachedPyramid, m.cachedIntegralArray
+	Resource.IntegralCacheMu.Unlock()
+	if pyramid != nil {
+		return pyramid
+	}
+
+	if integral == nil {
+		computed := minicv.GetIntegralArray(m.Img)
+		integral = &computed
+	}
+	pyramid = minicv.NewImagePyramid(m.Img, *integral, MAP_PYRAMID_LEVELS)
+
+	Resource.IntegralCacheMu.Lock()
+	defer Resource.IntegralCacheMu.Unlock()
+	if m.cachedIntegralArray == nil {
+		m.cachedIntegralArray = integral
+	}
+	m.cachedPyramid = pyramid
+	return pyramid
+}
+
+// GetFeatures lazily detects the map keypoints when first needed.
+// Detection runs outside the lock, so that different maps can be processed concurrently.
+func (m *MapCache) GetFeatures() *minicv.FeatureSet {
+	Resource.IntegralCacheMu.Lock()
+	features := m.cachedFeatures
+	Resource.IntegralCacheMu.Unlock()
+	if features != nil {
+		return features
+	}
+
+	features = minicv.DetectORB(m.Img, minicv.ORBOptions{FastThreshold: MAP_FEATURE_FAST_THRESHOLD})
+
+	Resource.IntegralCacheMu.Lock()
+	defer Resource.IntegralCacheMu.Unlock()
+	if m.cachedFeatures == nil {
+		m.cachedFeatures = features
+	}
+	return m.cachedFeatures
+}
+
 // InitRawMaps initializes global raw maps cache exactly once.
 func (r *MapTrackerResource) InitRawMaps(ctx *maa.Context) {
 	r.RawMapsOnce.Do(func() {
@@ -0,0 +1,43 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"image"
+	"sync"
+	"testing"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/minicv"
+)
+
+func TestMapCacheGetPyramidConcurrent(t *testing.T) {
+	maps := []MapCache{
+		{Name: "map_a", Img: image.NewRGBA(image.Rect(0, 0, 64, 64))},
+		{Name: "map_b", Img: image.NewRGBA(image.Rect(0, 0, 48, 48))},
+	}
+
+	const callsPerMap = 8
+	pyramids := make([][]*minicv.ImagePyramid, len(maps))
+	var wg sync.WaitGroup
+	for i := range maps {
+		pyramids[i] = make([]*minicv.ImagePyramid, callsPerMap)
+		for k := 0; k < callsPerMap; k++ {
+			wg.Add(1)
+			go func(i, k int) {
+				defer wg.Done()
+				pyramids[i][k] = maps[i].GetPyramid()
+			}(i, k)
+		}
+	}
+	wg.Wait()
+
+	for i := range maps {
+		for k := 1; k < callsPerMap; k++ {
+			if pyramids[i][k] != pyramids[i][0] {
+				t.Fatalf("%s: GetPyramid() built the pyramid more than once", maps[i].Name)
+			}
+		}
+		if maps[i].cachedIntegralArray == nil {
+			t.Fatalf("%s: integral array was not cached with the pyramid", maps[i].Name)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/atomicfile"
+)
+
+const (
+	// STUCK_SPOT_RECORD_PATH stores stuck spots learned by MapTrackerGoal under the working directory.
+	STUCK_SPOT_RECORD_PATH = "debug/record/MapTrackerStuckSpots.json"
+
+	stuckSpotRecordSchemaVersion = 1
+)
+
+// StuckSpot aggregates the stuck events observed on one NavMesh edge, so that recurring blockages can be fixed upstream.
+type StuckSpot struct {
+	MapName       string     `json:"map_name"`
+	EdgeID        int        `json:"edge_id"`
+	SourceID      int        `json:"source_id"`
+	DestinationID int        `json:"destination_id"`
+	Location      [2]float64 `json:"location"`
+	Count         int        `json:"count"`
+	FirstSeen     time.Time  `json:"first_seen"`
+	LastSeen      time.Time  `json:"last_seen"`
+}
+
+type stuckSpotRecordFile struct {
+	SchemaVersion int         `json:"schema_version"`
+	Spots         []StuckSpot `json:"spots"`
+}
+
+// LoadStuckSpots reads the stuck spots sorted by descending count. A missing file is treated as empty.
+func LoadStuckSpots(path string) ([]StuckSpot, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read stuck spot file: %w", err)
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var file stuckSpotRecordFile
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("parse stuck spot file: %w", err)
+	}
+	return file.Spots, nil
+}
+
+// RecordStuckSpot counts a stuck event on the edge at the given location and saves the spots.
+func RecordStuckSpot(path, mapName string, edge NavMeshEdge, location [2]float64, now time.Time) (StuckSpot, error) {
+	spots, err := LoadStuckSpots(path)
+	if err != nil {
+		return StuckSpot{}, err
+	}
+	mapName = MapCoreName(mapName)
+	index := -1
+	for i, spot := range spots {
+		if spot.MapName == mapName && spot.EdgeID == edge.ID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		index = len(spots)
+		spots = append(spots, StuckSpot{MapName: mapName, EdgeID: edge.ID, FirstSeen: now})
+	}
+	spot := &spots[index]
+	spot.SourceID = edge.SourceID
+	spot.DestinationID = edge.DestinationID
+	spot.Location = location
+	spot.Count++
+	spot.LastSeen = now
+	recorded := *spot
+
+	sort.SliceStable(spots, func(i, j int) bool {
+		if spots[i].Count != spots[j].Count {
+			return spots[i].Count > spots[j].Count
+		}
+		return spots[i].LastSeen.After(spots[j].LastSeen)
+	})
+	if err := atomicfile.WriteJSON(path, stuckSpotRecordFile{SchemaVersion: stuckSpotRecordSchemaVersion, Spots: spots}); err != nil {
+		return StuckSpot{}, err
+	}
+	return recorded, nil
+}
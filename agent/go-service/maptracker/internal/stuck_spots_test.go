@@ -0,0 +1,45 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordStuckSpotCountsRecurringEdges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "record", "stuck.json")
+	mesh := mustParseNavMesh(t, testSquareNavMeshText)
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	if _, err := RecordStuckSpot(path, "map_square_lv001_tier_1", mesh.Edges[1], [2]float64{5, 0}, now); err != nil {
+		t.Fatalf("RecordStuckSpot() error = %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		spot, err := RecordStuckSpot(path, "map_square_lv001", mesh.Edges[2], [2]float64{10, float64(i)}, now.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatalf("RecordStuckSpot() error = %v", err)
+		}
+		if spot.Count != i {
+			t.Fatalf("spot.Count = %d, want %d", spot.Count, i)
+		}
+	}
+
+	spots, err := LoadStuckSpots(path)
+	if err != nil {
+		t.Fatalf("LoadStuckSpots() error = %v", err)
+	}
+	if len(spots) != 2 {
+		t.Fatalf("len(spots) = %d, spots = %+v", len(spots), spots)
+	}
+	first := spots[0]
+	if first.MapName != "map_square_lv001" || first.EdgeID != 2 || first.Count != 2 || first.Location != [2]float64{10, 2} {
+		t.Fatalf("unexpected first spot: %+v", first)
+	}
+	if !first.FirstSeen.Equal(now.Add(time.Minute)) || !first.LastSeen.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("unexpected spot times: %+v", first)
+	}
+	if spots[1].EdgeID != 1 || spots[1].Count != 1 {
+		t.Fatalf("unexpected second spot: %+v", spots[1])
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/resource"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	WORLD_LEG_MOVE     = "Move"
+	WORLD_LEG_TELEPORT = "Teleport"
+
+	worldConnectCostFactor = 1.05
+	worldConnectDistance   = 20.0
+)
+
+var mapCoreNameRegexp = regexp.MustCompile(`^(.+?)(?:_tier_\w+)?$`)
+
+// MapCoreName returns the map name without its tier suffix.
+func MapCoreName(mapName string) string {
+	matches := mapCoreNameRegexp.FindStringSubmatch(mapName)
+	if len(matches) < 2 {
+		return mapName
+	}
+	return matches[1]
+}
+
+// WorldLeg is a single step of a cross-map route.
+type WorldLeg struct {
+	Kind    string
+	MapName string
+	From    [2]float64
+	To      [2]float64
+	Cost    float64
+}
+
+// WorldGraph links multiple NavMeshes through teleport anchors.
+type WorldGraph struct {
+	Meshes       map[string]*NavMesh
+	TeleportMaps map[string]bool
+	TeleportCost float64
+}
+
+type worldNode struct {
+	mapName  string
+	point    [2]float64
+	vertexID int
+}
+
+type worldLink struct {
+	kind string
+	cost float64
+}
+
+// LoadWorldGraph loads all NavMeshes and teleportable maps from resources.
+func LoadWorldGraph(teleportCost float64) (*WorldGraph, error) {
+	meshDir := resource.FindResource(NavMeshDataPath)
+	if meshDir == "" {
+		return nil, fmt.Errorf("navmesh directory not found: %s", NavMeshDataPath)
+	}
+	entries, err := os.ReadDir(meshDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read navmesh directory: %w", err)
+	}
+
+	meshes := map[string]*NavMesh{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".mtnm") {
+			continue
+		}
+		mapName := strings.TrimSuffix(entry.Name(), ".mtnm")
+		file, err := os.Open(filepath.Join(meshDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open navmesh file %s: %w", entry.Name(), err)
+		}
+		mesh, err := ParseNavMesh(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse navmesh file %s: %w", entry.Name(), err)
+		}
+		meshes[mapName] = mesh
+	}
+
+	externalData := map[string]struct {
+		SceneManagerNode string `json:"scene_manager_node,omitempty"`
+	}{}
+	if err := resource.ReadJsonResource(MAP_EXTERNAL_DATA_PATH, &externalData); err != nil {
+		log.Warn().Err(err).Msg("Failed to load map external data, teleport is limited to the current map")
+	}
+	teleportMaps := map[string]bool{}
+	for mapName, item := range externalData {
+		if item.SceneManagerNode != "" {
+			teleportMaps[mapName] = true
+		}
+	}
+
+	return NewWorldGraph(meshes, teleportMaps, teleportCost)
+}
+
+// NewWorldGraph creates a world graph from loaded NavMeshes keyed by map name.
+func NewWorldGraph(meshes map[string]*NavMesh, teleportMaps map[string]bool, teleportCost float64) (*WorldGraph, error) {
+	if teleportCost < 0 {
+		return nil, fmt.Errorf("teleport cost cannot be negative")
+	}
+	return &WorldGraph{Meshes: meshes, TeleportMaps: teleportMaps, TeleportCost: teleportCost}, nil
+}
+
+// PlanRoute finds the cheapest sequence of moves and teleports between two map locations.
+// Consecutive moves on the same map are merged into a single leg.
+func (g *WorldGraph) PlanRoute(startMap string, start [2]float64, targetMap string, target [2]float64) ([]WorldLeg, error) {
+	startMap, targetMap = MapCoreName(startMap), MapCoreName(targetMap)
+	if _, ok := g.Meshes[startMap]; !ok {
+		return nil, fmt.Errorf("start map %q has no NavMesh", startMap)
+	}
+	if _, ok := g.Meshes[targetMap]; !ok {
+		return nil, fmt.Errorf("target map %q has no NavMesh", targetMap)
+	}
+
+	nodes := g.buildNodes(startMap, start, targetMap, target)
+	links := map[[2]int]worldLink{}
+	addLink := func(from, to int, link worldLink) {
+		if old, ok := links[[2]int{from, to}]; ok && old.cost <= link.cost {
+			return
+		}
+		links[[2]int{from, to}] = link
+	}
+
+	for i := range nodes {
+		if i == 1 {
+			continue
+		}
+		for j := range nodes {
+			if i == j || j == 0 {
+				continue
+			}
+			if nodes[i].mapName == nodes[j].mapName {
+				if cost, ok := g.walkCost(nodes[i], nodes[j]); ok {
+					addLink(i, j, worldLink{kind: WORLD_LEG_MOVE, cost: cost})
+				}
+			}
+			if nodes[j].vertexID != 0 && g.canTeleport(nodes[i].mapName, nodes[j].mapName) {
+				addLink(i, j, worldLink{kind: WORLD_LEG_TELEPORT, cost: g.TeleportCost})
+			}
+		}
+	}
+	adjacency := map[int][]algoEdge{}
+	for pair, link := range links {
+		adjacency[pair[0]] = append(adjacency[pair[0]], algoEdge{to: pair[1], cost: link.cost})
+	}
+	for id := range adjacency {
+		edges := adjacency[id]
+		sort.Slice(edges, func(i, j int) bool { return edges[i].to < edges[j].to })
+	}
+
+	nodePath, err := dijkstraPath(adjacency, 0, 1)
+	if err != nil {
+		return nil, fmt.Errorf("no route from %s to %s", startMap, targetMap)
+	}
+
+	legs := make([]WorldLeg, 0, len(nodePath))
+	for i := 0; i+1 < len(nodePath); i++ {
+		from, to := nodePath[i], nodePath[i+1]
+		link := links[[2]int{from, to}]
+		if link.kind == WORLD_LEG_MOVE && len(legs) > 0 && legs[len(legs)-1].Kind == WORLD_LEG_MOVE {
+			legs[len(legs)-1].To = nodes[to].point
+			legs[len(legs)-1].Cost += link.cost
+			continue
+		}
+		legs = append(legs, WorldLeg{
+			Kind:    link.kind,
+			MapName: nodes[to].mapName,
+			From:    nodes[from].point,
+			To:      nodes[to].point,
+			Cost:    link.cost,
+		})
+	}
+	return legs, nil
+}
+
+func (g *WorldGraph) buildNodes(startMap string, start [2]float64, targetMap string, target [2]float64) []worldNode {
+	nodes := []worldNode{
+		{mapName: startMap, point: start},
+		{mapName: targetMap, point: target},
+	}
+
+	mapNames := make([]string, 0, len(g.Meshes))
+	for mapName := range g.Meshes {
+		mapNames = append(mapNames, mapName)
+	}
+	sort.Strings(mapNames)
+	for _, mapName := range mapNames {
+		mesh := g.Meshes[mapName]
+		ids := make([]int, 0)
+		for id, vertex := range mesh.Vertices {
+			if vertex.Flags&NavMeshVertexFlagTeleportAnchor != 0 && !mesh.DisabledVertices[id] {
+				ids = append(ids, id)
+			}
+		}
+		sort.Ints(ids)
+		for _, id := range ids {
+			vertex := mesh.Vertices[id]
+			nodes = append(nodes, worldNode{mapName: mapName, point: [2]float64{vertex.X, vertex.Y}, vertexID: id})
+		}
+	}
+	return nodes
+}
+
+func (g *WorldGraph) canTeleport(fromMap, toMap string) bool {
+	return fromMap == toMap || g.TeleportMaps[toMap]
+}
+
+func (g *WorldGraph) walkCost(from, to worldNode) (float64, bool) {
+	mesh := g.Meshes[from.mapName]
+	mesh.ClearTemporaryVertex()
+	defer mesh.ClearTemporaryVertex()
+
+	startID, targetID := from.vertexID, to.vertexID
+	if startID == 0 {
+		startID, _ = mesh.AddTemporaryVertex(from.point[0], from.point[1], worldConnectCostFactor, worldConnectDistance)
+	}
+	if targetID == 0 {
+		targetID, _ = mesh.AddTemporaryVertex(to.point[0], to.point[1], worldConnectCostFactor, worldConnectDistance)
+	}
+	if startID == targetID {
+		return 0, true
+	}
+	pathIDs, err := mesh.FindPathIDs(startID, targetID)
+	if err != nil {
+		return 0, false
+	}
+	return mesh.PathIDsCost(pathIDs), true
+}
+
+// FindNearestTeleportAnchor returns the teleport anchor with the shortest walking distance to the target.
+// It clears all temporary vertices of the NavMesh.
+func (m *NavMesh) FindNearestTeleportAnchor(target [2]float64) (NavMeshVertex, float64, error) {
+	m.ClearTemporaryVertex()
+	defer m.ClearTemporaryVertex()
+	targetID, _ := m.AddTemporaryVertex(target[0], target[1], worldConnectCostFactor, worldConnectDistance)
+
+	ids := make([]int, 0)
+	for id, vertex := range m.Vertices {
+		if vertex.Flags&NavMeshVertexFlagTeleportAnchor != 0 && !m.DisabledVertices[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	var (
+		best         NavMeshVertex
+		bestDistance = math.Inf(1)
+	)
+	for _, id := range ids {
+		path, err := m.FindPath(id, targetID)
+		if err != nil {
+			continue
+		}
+		if distance := PathTotalDistance(path); distance < bestDistance {
+			best, bestDistance = m.Vertices[id], distance
+		}
+	}
+	if math.IsInf(bestDistance, 1) {
+		return NavMeshVertex{}, 0, fmt.Errorf("no teleport anchor can reach the target")
+	}
+	return best, bestDistance, nil
+}
+
+// PathIDsCost returns the total search cost along a vertex ID path.
+func (m *NavMesh) PathIDsCost(pathIDs []int) float64 {
+	graph := m.cachedPathGraph()
+	cost := 0.0
+	for i := 0; i+1 < len(pathIDs); i++ {
+		fromID, toID := pathIDs[i], pathIDs[i+1]
+		_, fromTemporary := m.TemporaryVertices[fromID]
+		_, toTemporary := m.TemporaryVertices[toID]
+		if fromTemporary || toTemporary {
+			cost += m.temporaryEdgeCost(fromID, toID)
+			continue
+		}
+		best := math.Inf(1)
+		for _, edge := range graph.adjacency[fromID] {
+			if edge.to == toID {
+				best = math.Min(best, edge.cost)
+			}
+		}
+		if math.IsInf(best, 1) {
+			from, _ := m.VertexPoint(fromID)
+			to, _ := m.VertexPoint(toID)
+			best = math.Hypot(to[0]-from[0], to[1]-from[1])
+		}
+		cost += best
+	}
+	return cost
+}
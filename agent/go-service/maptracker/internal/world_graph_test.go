@@ -0,0 +1,146 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"strings"
+	"testing"
+)
+
+const testWorldNavMeshTextA = `[MapTrackerNavMesh.Meta]
+Version=1
+Encoding=UTF-8
+Name=map_a_lv001
+Description=Test navmesh
+MapRegionName=map_a
+MapLevelName=lv001
+GeoWidth=1000.0
+GeoHeight=1000.0
+
+[MapTrackerNavMesh.Vertices]
+V1=X0,Y0,T0,E0,F()
+V2=X10,Y0,T0,E0,F()
+V3=X20,Y0,T0,E0,F(TS)
+V4=X500,Y0,T0,E0,F()
+V5=X510,Y0,T0,E0,F(TS)
+
+[MapTrackerNavMesh.Edges]
+E1=S1,D2,B1,C10,F()
+E2=S2,D3,B1,C10,F()
+E3=S4,D5,B1,C10,F()
+`
+
+const testWorldNavMeshTextB = `[MapTrackerNavMesh.Meta]
+Version=1
+Encoding=UTF-8
+Name=map_b_lv001
+Description=Test navmesh
+MapRegionName=map_b
+MapLevelName=lv001
+GeoWidth=100.0
+GeoHeight=100.0
+
+[MapTrackerNavMesh.Vertices]
+V1=X0,Y0,T0,E0,F()
+V2=X10,Y0,T0,E900,F()
+V3=X20,Y0,T0,E0,F()
+
+[MapTrackerNavMesh.Edges]
+E1=S1,D2,B1,C10,F()
+E2=S2,D3,B1,C10,F()
+`
+
+func TestMapCoreName(t *testing.T) {
+	if name := MapCoreName("map02_lv002_tier_255"); name != "map02_lv002" {
+		t.Fatalf("MapCoreName() = %q", name)
+	}
+	if name := MapCoreName("map02_lv002"); name != "map02_lv002" {
+		t.Fatalf("MapCoreName() = %q", name)
+	}
+}
+
+func TestPlanRouteRejectsUnlinkedMaps(t *testing.T) {
+	world := newTestWorldGraph(t, nil)
+	if _, err := world.PlanRoute("map_a_lv001", [2]float64{1, 0}, "map_b_lv001", [2]float64{19, 0}); err == nil {
+		t.Fatalf("PlanRoute() error = nil")
+	}
+}
+
+func TestPlanRouteUsesTeleportAnchor(t *testing.T) {
+	world := newTestWorldGraph(t, nil)
+
+	// The target is not connected to the start on the NavMesh, but near a teleport anchor of the same map
+	legs, err := world.PlanRoute("map_a_lv001", [2]float64{1, 0}, "map_a_lv001", [2]float64{505, 0})
+	if err != nil {
+		t.Fatalf("PlanRoute() error = %v", err)
+	}
+	assertWorldLegKinds(t, legs, []string{WORLD_LEG_TELEPORT, WORLD_LEG_MOVE})
+	if legs[0].To != [2]float64{510, 0} {
+		t.Fatalf("teleport leg = %+v, want anchor at (510, 0)", legs[0])
+	}
+}
+
+func TestPlanRouteTeleportsToOtherMapWithSceneMapping(t *testing.T) {
+	world := newTestWorldGraph(t, map[string]bool{"map_a_lv001": true})
+
+	legs, err := world.PlanRoute("map_b_lv001", [2]float64{1, 0}, "map_a_lv001", [2]float64{1, 0})
+	if err != nil {
+		t.Fatalf("PlanRoute() error = %v", err)
+	}
+	assertWorldLegKinds(t, legs, []string{WORLD_LEG_TELEPORT, WORLD_LEG_MOVE})
+	if legs[0].MapName != "map_a_lv001" || legs[0].To != [2]float64{20, 0} {
+		t.Fatalf("unexpected teleport leg: %+v", legs[0])
+	}
+}
+
+func TestFindNearestTeleportAnchor(t *testing.T) {
+	mesh := mustParseNavMesh(t, testWorldNavMeshTextA)
+	anchor, distance, err := mesh.FindNearestTeleportAnchor([2]float64{508, 0})
+	if err != nil {
+		t.Fatalf("FindNearestTeleportAnchor() error = %v", err)
+	}
+	if anchor.ID != 5 || distance != 2 {
+		t.Fatalf("anchor = %+v, distance = %v", anchor, distance)
+	}
+	if len(mesh.TemporaryVertices) != 0 {
+		t.Fatalf("TemporaryVertices not cleared: %+v", mesh.TemporaryVertices)
+	}
+
+	mesh.DisableVertex(5)
+	if _, _, err := mesh.FindNearestTeleportAnchor([2]float64{508, 0}); err == nil {
+		t.Fatalf("FindNearestTeleportAnchor() with disabled anchor error = nil")
+	}
+}
+
+func newTestWorldGraph(t *testing.T, teleportMaps map[string]bool) *WorldGraph {
+	t.Helper()
+	meshes := map[string]*NavMesh{
+		"map_a_lv001": mustParseNavMesh(t, testWorldNavMeshTextA),
+		"map_b_lv001": mustParseNavMesh(t, testWorldNavMeshTextB),
+	}
+	world, err := NewWorldGraph(meshes, teleportMaps, 100)
+	if err != nil {
+		t.Fatalf("NewWorldGraph() error = %v", err)
+	}
+	return world
+}
+
+func mustParseNavMesh(t *testing.T, text string) *NavMesh {
+	t.Helper()
+	mesh, err := ParseNavMesh(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("ParseNavMesh() error = %v", err)
+	}
+	return mesh
+}
+
+func assertWorldLegKinds(t *testing.T, legs []WorldLeg, expected []string) {
+	t.Helper()
+	if len(legs) != len(expected) {
+		t.Fatalf("len(legs) = %d, legs = %+v, expected kinds = %+v", len(legs), legs, expected)
+	}
+	for i := range expected {
+		if legs[i].Kind != expected[i] {
+			t.Fatalf("legs = %+v, expected kinds = %+v", legs, expected)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/atomicfile"
+)
+
+const (
+	// ZIPLINE_CACHE_RECORD_PATH stores ziplines discovered on the big map under the working directory.
+	ZIPLINE_CACHE_RECORD_PATH = "debug/record/MapTrackerZiplines.json"
+	// ZIPLINE_CACHE_MERGE_DISTANCE is the map distance within which two sightings are the same zipline.
+	ZIPLINE_CACHE_MERGE_DISTANCE = 10.0
+	// ZIPLINE_CACHE_MAX_FAILURES is the number of failed travels after which a zipline is dropped,
+	// unless it is seen again on the big map in between.
+	ZIPLINE_CACHE_MAX_FAILURES = 2
+
+	ziplineCacheSchemaVersion = 1
+)
+
+// CachedZipline is a zipline discovered on the big map by a player.
+type CachedZipline struct {
+	UIDHash   string    `json:"uid_hash"`
+	MapName   string    `json:"map_name"`
+	X         float64   `json:"x"`
+	Y         float64   `json:"y"`
+	Conf      float64   `json:"conf"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// Failures counts the failed travels since the zipline was last seen.
+	Failures int `json:"failures,omitempty"`
+}
+
+// ZiplineSighting is a zipline matched on the big map.
+type ZiplineSighting struct {
+	X    float64
+	Y    float64
+	Conf float64
+}
+
+// ZiplineCache remembers the ziplines discovered by one player, so that they can be reused without panning the big map.
+// Ziplines are kept per player, since unlocked ziplines differ between accounts.
+type ZiplineCache struct {
+	path     string
+	uidHash  string
+	ziplines []CachedZipline
+}
+
+type ziplineCacheRecordFile struct {
+	SchemaVersion int             `json:"schema_version"`
+	Ziplines      []CachedZipline `json:"ziplines"`
+}
+
+// OpenZiplineCache loads the zipline cache of the player with the given hashed UID. A missing file is treated as empty.
+func OpenZiplineCache(path, uidHash string) (*ZiplineCache, error) {
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read zipline cache file: %w", err)
+	}
+	var file ziplineCacheRecordFile
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &file); err != nil {
+			return nil, fmt.Errorf("parse zipline cache file: %w", err)
+		}
+		if file.SchemaVersion > ziplineCacheSchemaVersion {
+			return nil, fmt.Errorf("unsupported zipline cache schema version: %d", file.SchemaVersion)
+		}
+	}
+	return &ZiplineCache{path: path, uidHash: uidHash, ziplines: file.Ziplines}, nil
+}
+
+// Ziplines returns the cached ziplines of the player on the map.
+func (c *ZiplineCache) Ziplines(mapName string) []CachedZipline {
+	if c == nil {
+		return nil
+	}
+	mapName = MapCoreName(mapName)
+	var result []CachedZipline
+	for _, z := range c.ziplines {
+		if z.UIDHash == c.uidHash && z.MapName == mapName {
+			result = append(result, z)
+		}
+	}
+	return result
+}
+
+// Record merges the sightings of a big-map discovery into the cache and saves it.
+// A zipline seen again has its failures reset.
+func (c *ZiplineCache) Record(mapName string, sightings []ZiplineSighting, now time.Time) error {
+	if c == nil {
+		return fmt.Errorf("zipline cache is nil")
+	}
+	mapName = MapCoreName(mapName)
+	for _, s := range sightings {
+		if i := c.find(mapName, s.X, s.Y); i >= 0 {
+			z := &c.ziplines[i]
+			if s.Conf >= z.Conf {
+				z.X, z.Y, z.Conf = s.X, s.Y, s.Conf
+			}
+			z.LastSeen = now
+			z.Failures = 0
+			continue
+		}
+		c.ziplines = append(c.ziplines, CachedZipline{
+			UIDHash:   c.uidHash,
+			MapName:   mapName,
+			X:         s.X,
+			Y:         s.Y,
+			Conf:      s.Conf,
+			FirstSeen: now,
+			LastSeen:  now,
+		})
+	}
+	return c.save()
+}
+
+// RecordFailure counts a failed travel involving the ziplines at the given points and saves the cache.
+// Ziplines reaching ZIPLINE_CACHE_MAX_FAILURES are dropped. Returns the number of dropped ziplines.
+func (c *ZiplineCache) RecordFailure(mapName string, points ...[2]float64) (int, error) {
+	if c == nil {
+		return 0, fmt.Errorf("zipline cache is nil")
+	}
+	mapName = MapCoreName(mapName)
+	for _, p := range points {
+		if i := c.find(mapName, p[0], p[1]); i >= 0 {
+			c.ziplines[i].Failures++
+		}
+	}
+	kept := c.ziplines[:0]
+	dropped := 0
+	for _, z := range c.ziplines {
+		if z.UIDHash == c.uidHash && z.Failures >= ZIPLINE_CACHE_MAX_FAILURES {
+			dropped++
+			continue
+		}
+		kept = append(kept, z)
+	}
+	c.ziplines = kept
+	return dropped, c.save()
+}
+
+// find returns the index of the player's nearest cached zipline within ZIPLINE_CACHE_MERGE_DISTANCE, or -1.
+func (c *ZiplineCache) find(mapName string, x, y float64) int {
+	best, bestDistance := -1, ZIPLINE_CACHE_MERGE_DISTANCE
+	for i, z := range c.ziplines {
+		if z.UIDHash != c.uidHash || z.MapName != mapName {
+			continue
+		}
+		if d := math.Hypot(z.X-x, z.Y-y); d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return best
+}
+
+func (c *ZiplineCache) save() error {
+	sort.SliceStable(c.ziplines, func(i, j int) bool {
+		a, b := c.ziplines[i], c.ziplines[j]
+		if a.UIDHash != b.UIDHash {
+			return a.UIDHash < b.UIDHash
+		}
+		return a.MapName < b.MapName
+	})
+	return atomicfile.WriteJSON(c.path, ziplineCacheRecordFile{SchemaVersion: ziplineCacheSchemaVersion, Ziplines: c.ziplines})
+}
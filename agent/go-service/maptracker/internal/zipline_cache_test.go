@@ -0,0 +1,84 @@
+// Copyright (c) 2026 Harry Huang
+package maptrackerinternal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestZiplineCacheRecordMergesSightingsPerPlayer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "record", "ziplines.json")
+	first := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	cache, err := OpenZiplineCache(path, "player-a")
+	if err != nil {
+		t.Fatalf("OpenZiplineCache() on missing file error = %v", err)
+	}
+	if err := cache.Record("map02_lv001", []ZiplineSighting{{X: 100, Y: 100, Conf: 0.7}, {X: 300, Y: 100, Conf: 0.8}}, first); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := cache.Record("map02_lv001", []ZiplineSighting{{X: 104, Y: 103, Conf: 0.9}}, second); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	reopened, err := OpenZiplineCache(path, "player-a")
+	if err != nil {
+		t.Fatalf("OpenZiplineCache() error = %v", err)
+	}
+	ziplines := reopened.Ziplines("map02_lv001")
+	if len(ziplines) != 2 {
+		t.Fatalf("len(ziplines) = %d, want 2", len(ziplines))
+	}
+	merged := ziplines[0]
+	if merged.X != 104 || merged.Y != 103 || merged.Conf != 0.9 || !merged.FirstSeen.Equal(first) || !merged.LastSeen.Equal(second) {
+		t.Fatalf("unexpected merged zipline: %+v", merged)
+	}
+	if merged.UIDHash != "player-a" || merged.MapName != "map02_lv001" {
+		t.Fatalf("unexpected zipline owner: %+v", merged)
+	}
+
+	other, err := OpenZiplineCache(path, "player-b")
+	if err != nil {
+		t.Fatalf("OpenZiplineCache() error = %v", err)
+	}
+	if ziplines := other.Ziplines("map02_lv001"); len(ziplines) != 0 {
+		t.Fatalf("ziplines of another player should not be visible: %+v", ziplines)
+	}
+}
+
+func TestZiplineCacheRecordFailureDropsAfterRepeatedFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ziplines.json")
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	cache, err := OpenZiplineCache(path, "player-a")
+	if err != nil {
+		t.Fatalf("OpenZiplineCache() error = %v", err)
+	}
+	if err := cache.Record("map02_lv001", []ZiplineSighting{{X: 100, Y: 100, Conf: 0.7}, {X: 300, Y: 100, Conf: 0.8}}, now); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	if dropped, err := cache.RecordFailure("map02_lv001", [2]float64{101, 99}); err != nil || dropped != 0 {
+		t.Fatalf("first RecordFailure() = %d, %v", dropped, err)
+	}
+	// Seeing the zipline again on the big map resets its failures
+	if err := cache.Record("map02_lv001", []ZiplineSighting{{X: 100, Y: 100, Conf: 0.6}}, now); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if dropped, err := cache.RecordFailure("map02_lv001", [2]float64{101, 99}); err != nil || dropped != 0 {
+		t.Fatalf("RecordFailure() after sighting = %d, %v", dropped, err)
+	}
+	if dropped, err := cache.RecordFailure("map02_lv001", [2]float64{101, 99}, [2]float64{500, 500}); err != nil || dropped != 1 {
+		t.Fatalf("RecordFailure() reaching the limit = %d, %v", dropped, err)
+	}
+
+	reopened, err := OpenZiplineCache(path, "player-a")
+	if err != nil {
+		t.Fatalf("OpenZiplineCache() error = %v", err)
+	}
+	ziplines := reopened.Ziplines("map02_lv001")
+	if len(ziplines) != 1 || ziplines[0].X != 300 || ziplines[0].Failures != 0 {
+		t.Fatalf("unexpected remaining ziplines: %+v", ziplines)
+	}
+}
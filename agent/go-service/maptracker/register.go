@@ -19,7 +19,10 @@ func Register() {
 	maa.AgentServerRegisterCustomAction("MapTrackerGoal", &maptrackerdefault.MapTrackerGoal{})
 	maa.AgentServerRegisterCustomAction("MapTrackerZipline", &maptrackerdefault.MapTrackerZipline{})
 	maa.AgentServerRegisterCustomAction("MapTrackerToward", &maptrackerdefault.MapTrackerToward{})
+	maa.AgentServerRegisterCustomAction("MapTrackerCollectRoute", &maptrackerdefault.MapTrackerCollectRoute{})
+	maa.AgentServerRegisterCustomAction("MapTrackerCalibrate", &maptrackerdefault.MapTrackerCalibrate{})
 	maa.AgentServerRegisterCustomAction("MapTrackerMoveCompatible", &maptrackercompatible.MapTrackerMoveCompatible{})
 	maa.AgentServerRegisterCustomAction("MapTrackerBigMapPick", &maptrackerbigmap.MapTrackerBigMapPick{})
 	maa.AgentServerRegisterCustomAction("MapTrackerBigMapZoom", &maptrackerbigmap.MapTrackerBigMapZoom{})
+	maa.AgentServerRegisterCustomAction("MapTrackerBigMapScanIcons", &maptrackerbigmap.MapTrackerBigMapScanIcons{})
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	maptrackerdefault "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/default"
+	"github.com/rs/zerolog/log"
+)
+
+// runCheckNavMesh 处理 `--check-navmesh [-fmt] <file|dir>...` 入口。
+// 它校验 NavMesh 文件（悬空边、不可达连通分量、重复实体 ID、非滑索点之间的滑索边），
+// 并将问题逐行输出到 stdout；指定 -fmt 时会以规范格式重写没有错误级问题的文件。存在错误级问题时以非零状态退出。
+func runCheckNavMesh(args []string) {
+	fs := flag.NewFlagSet("check-navmesh", flag.ExitOnError)
+	rewrite := fs.Bool("fmt", false, "rewrite files without error-level issues in the canonical format")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		log.Fatal().
+			Msg("Usage: go-service --check-navmesh [-fmt] <file|dir>...")
+	}
+
+	log.Info().
+		Strs("paths", fs.Args()).
+		Bool("fmt", *rewrite).
+		Msg("NavMesh check invoked")
+
+	errorCount, err := maptrackerdefault.RunNavMeshCheck(os.Stdout, fs.Args(), *rewrite)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("NavMesh check failed")
+	}
+	if errorCount > 0 {
+		log.Fatal().
+			Int("errors", errorCount).
+			Msg("NavMesh check found errors")
+	}
+
+	log.Info().
+		Msg("NavMesh check passed")
+}
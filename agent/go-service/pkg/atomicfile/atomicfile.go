@@ -0,0 +1,58 @@
+package atomicfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile 先写入 path 同目录下的临时文件再重命名覆盖 path，写入中途失败不会留下截断的文件。
+func WriteFile(path string, content []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	cleanup := true
+	defer func() {
+		if cleanup {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	cleanup = false
+
+	return nil
+}
+
+// WriteJSON 将 v 序列化为 4 空格缩进、以换行结尾的 JSON，创建所在目录后用 WriteFile 写入 path。
+func WriteJSON(path string, v any) error {
+	content, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return fmt.Errorf("marshal json: %w", err)
+	}
+	content = append(content, '\n')
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+	return WriteFile(path, content, 0644)
+}
@@ -0,0 +1,49 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteJSONReplacesFileWithoutLeftovers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "data.json")
+
+	if err := WriteJSON(path, map[string]int{"a": 1}); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	if err := WriteJSON(path, map[string]int{"b": 2}); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if want := "{\n    \"b\": 2\n}\n"; string(content) != want {
+		t.Fatalf("content = %q, want %q", content, want)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("directory has %d entries, want only the written file", len(entries))
+	}
+}
+
+func TestWriteFileKeepsOriginalOnFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// 目标路径所在目录不存在时写入失败，原文件保持不变
+	if err := WriteFile(filepath.Join(path, "missing", "data.json"), []byte("new"), 0644); err == nil {
+		t.Fatal("WriteFile() error = nil, want error")
+	}
+	if content, _ := os.ReadFile(path); string(content) != "old" {
+		t.Fatalf("content = %q, want %q", content, "old")
+	}
+}
@@ -76,6 +76,8 @@ type ControlAdaptor interface {
 
 // NewControlAdaptor creates a new ControlAdaptor instance.
 // The implementation type is determined by the controller info obtained from the Maa Controller.
+// The calibration profile of the controller, if any, is applied to the camera rotation
+// and to the movement returned by [ControlAdaptor.GetPlayerMovement], see [CalibrationOf].
 func NewControlAdaptor(ctx *maa.Context, ctrl *maa.Controller, w, h int) (ControlAdaptor, error) {
 	controlType, err := GetControlType(ctrl)
 	if err != nil {
@@ -84,11 +86,26 @@ func NewControlAdaptor(ctx *maa.Context, ctrl *maa.Controller, w, h int) (Contro
 
 	switch controlType {
 	case CONTROL_TYPE_WIN32:
-		return newDefaultDesktopControlAdaptor(ctx, ctrl, w, h), nil
+		dca, err := newConfiguredDesktopControlAdaptor(ctx, ctrl, w, h)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load desktop key bindings: %w", err)
+		}
+		dca.calibration = loadControllerCalibration(ctrl, controlType)
+		return dca, nil
 	case CONTROL_TYPE_WLROOTS:
-		return newWlrootsControlAdaptor(ctx, ctrl, w, h), nil
+		wca, err := newWlrootsControlAdaptor(ctx, ctrl, w, h)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load desktop key bindings: %w", err)
+		}
+		wca.calibration = loadControllerCalibration(ctrl, controlType)
+		return wca, nil
 	case CONTROL_TYPE_ADB:
-		return newADBControlAdaptor(ctx, ctrl, w, h), nil
+		aca := newADBControlAdaptor(ctx, ctrl, w, h)
+		aca.calibration = loadControllerCalibration(ctrl, controlType)
+		return aca, nil
+	case CONTROL_TYPE_DEBUG, CONTROL_TYPE_REPLAY:
+		// Recorded screenshots do not depend on the machine, so the default values are kept.
+		return newReplayControlAdaptor(ctx), nil
 	default:
 		return nil, fmt.Errorf("unsupported control type: %s", controlType)
 	}
@@ -98,21 +115,48 @@ func NewControlAdaptor(ctx *maa.Context, ctrl *maa.Controller, w, h int) (Contro
 
 // PlayerMovement represents different movement state in the game
 type PlayerMovement struct {
-	speed         float64 // Movement speed (px/s)
-	rotationSpeed float64 // Rotation adjustment response speed (degrees/s)
+	speed           float64 // Movement speed (px/s)
+	rotationSpeed   float64 // Rotation adjustment response speed (degrees/s)
+	calibratedSpeed float64 // Measured movement speed (px/s), zero if not calibrated, see [CalibrationProfile.Calibrate]
 }
 
 // Equals checks if this PlayerMovement is approximately equal to another one.
+// The calibrated speed is ignored, so a calibrated movement equals its predefined state.
 func (pm PlayerMovement) Equals(other PlayerMovement) bool {
 	return math.Abs(pm.speed-other.speed) <= 1e-6 && math.Abs(pm.rotationSpeed-other.rotationSpeed) <= 1e-6
 }
 
+// String returns a readable name of the predefined movement states.
+func (pm PlayerMovement) String() string {
+	switch {
+	case pm.Equals(MovementStop):
+		return "stop"
+	case pm.Equals(MovementWalk):
+		return "walk"
+	case pm.Equals(MovementRun):
+		return "run"
+	case pm.Equals(MovementSprint):
+		return "sprint"
+	default:
+		return fmt.Sprintf("%.1fpx/s", pm.speed)
+	}
+}
+
+// Speed returns the movement speed (px/s), which is the measured one if the movement is calibrated.
+func (pm PlayerMovement) Speed() float64 {
+	if pm.calibratedSpeed > 0 {
+		return pm.calibratedSpeed
+	}
+	return pm.speed
+}
+
 // EtaOfDistance returns the minimal estimated time to cover the given distance at this movement speed.
 func (pm PlayerMovement) EtaOfDistance(dist float64) time.Duration {
-	if pm.speed <= 1e-6 {
+	speed := pm.Speed()
+	if speed <= 1e-6 {
 		return time.Duration(math.MaxInt64)
 	}
-	return time.Duration(float64(time.Second) * dist / pm.speed)
+	return time.Duration(float64(time.Second) * dist / speed)
 }
 
 // EtaOfRotation returns the minimal estimated time to adjust the given rotation at this rotation speed.
@@ -125,7 +169,7 @@ func (pm PlayerMovement) EtaOfRotation(rot float64) time.Duration {
 
 // DistanceDuring returns the maximal distance that can be covered during the given duration at this movement speed.
 func (pm PlayerMovement) DistanceDuring(duration time.Duration) float64 {
-	return pm.speed * duration.Seconds()
+	return pm.Speed() * duration.Seconds()
 }
 
 // RotationDuring returns the maximal rotation adjustment that can be achieved during the given duration at this rotation speed.
@@ -134,10 +178,10 @@ func (pm PlayerMovement) RotationDuring(duration time.Duration) float64 {
 }
 
 var (
-	MovementStop   = PlayerMovement{0.0, 0.0}
-	MovementWalk   = PlayerMovement{2.0, 270.0}
-	MovementRun    = PlayerMovement{8.0, 540.0}
-	MovementSprint = PlayerMovement{12.0, 1080.0}
+	MovementStop   = PlayerMovement{speed: 0.0, rotationSpeed: 0.0}
+	MovementWalk   = PlayerMovement{speed: 2.0, rotationSpeed: 270.0}
+	MovementRun    = PlayerMovement{speed: 8.0, rotationSpeed: 540.0}
+	MovementSprint = PlayerMovement{speed: 12.0, rotationSpeed: 1080.0}
 )
 
 /* ******** Player Direction Enumeration ******** */
@@ -13,6 +13,7 @@ type ADBControlAdaptor struct {
 	w    int
 	h    int
 
+	calibration      *CalibrationProfile
 	pm               PlayerMovement
 	lastDirection    PlayerDirection
 	lastMotionIsWalk bool
@@ -76,12 +77,18 @@ func (aca *ADBControlAdaptor) KeyType(keyCode int, delayMillis int) {
 }
 
 func (aca *ADBControlAdaptor) RotateCamera(dx, dy int) {
+	scale := aca.calibration.GetCameraScale()
+	dx, dy = scaleCameraDelta(dx, scale), scaleCameraDelta(dy, scale)
 	cx, cy := aca.w/4*3, aca.h/2
 	aca.Swipe(cameraContact, cx, cy, dx, dy, defaultTouchActionDelayMillis*5/2, 0)
 }
 
 func (aca *ADBControlAdaptor) GetPlayerMovement() PlayerMovement {
-	return aca.pm
+	return aca.calibration.Calibrate(aca.pm)
+}
+
+func (aca *ADBControlAdaptor) calibrationProfile() *CalibrationProfile {
+	return aca.calibration
 }
 
 func (aca *ADBControlAdaptor) SetPlayerMovement(movement PlayerMovement, policy PlayerMovementPolicy) {
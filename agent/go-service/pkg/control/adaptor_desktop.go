@@ -25,6 +25,7 @@ type desktopControlAdaptor struct {
 	h    int
 
 	keys             desktopKeyBindings
+	calibration      *CalibrationProfile
 	pm               PlayerMovement
 	lastDirection    PlayerDirection
 	lastMotionIsWalk bool
@@ -100,6 +101,8 @@ func (dca *desktopControlAdaptor) KeyType(keyCode int, delayMillis int) {
 }
 
 func (dca *desktopControlAdaptor) RotateCamera(dx, dy int) {
+	scale := dca.calibration.GetCameraScale()
+	dx, dy = scaleCameraDelta(dx, scale), scaleCameraDelta(dy, scale)
 	cx, cy := dca.w/2, dca.h/2
 	fromX, fromY := cx+dca.cursorDX, cy+dca.cursorDY
 	dca.SwipeHover(0, fromX, fromY, dx, dy, defaultDesktopKeyActionDelayMillis*3, defaultDesktopKeyActionDelayMillis)
@@ -108,7 +111,11 @@ func (dca *desktopControlAdaptor) RotateCamera(dx, dy int) {
 }
 
 func (dca *desktopControlAdaptor) GetPlayerMovement() PlayerMovement {
-	return dca.pm
+	return dca.calibration.Calibrate(dca.pm)
+}
+
+func (dca *desktopControlAdaptor) calibrationProfile() *CalibrationProfile {
+	return dca.calibration
 }
 
 func (dca *desktopControlAdaptor) SetPlayerMovement(movement PlayerMovement, policy PlayerMovementPolicy) {
@@ -261,6 +268,11 @@ func defaultDesktopKeyBindings() desktopKeyBindings {
 	}
 }
 
-func newDefaultDesktopControlAdaptor(ctx *maa.Context, ctrl *maa.Controller, w, h int) *desktopControlAdaptor {
-	return newDesktopControlAdaptor(ctx, ctrl, w, h, defaultDesktopKeyBindings())
+// newConfiguredDesktopControlAdaptor creates a desktop adaptor with the key bindings configured by the user.
+func newConfiguredDesktopControlAdaptor(ctx *maa.Context, ctrl *maa.Controller, w, h int) (*desktopControlAdaptor, error) {
+	keys, err := loadDesktopKeyBindings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newDesktopControlAdaptor(ctx, ctrl, w, h, keys), nil
 }
@@ -0,0 +1,269 @@
+// Copyright (c) 2026 Harry Huang
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// CONTROL_TRACE_PATH stores the actions intended by replay adaptors, one JSON object per line.
+const CONTROL_TRACE_PATH = "debug/record/ControlTrace.jsonl"
+
+// ControlTraceEntry represents one control action intended by a replay adaptor.
+type ControlTraceEntry struct {
+	Time     time.Time      `json:"time"`
+	Action   string         `json:"action"`
+	Args     map[string]int `json:"args,omitempty"`
+	Movement string         `json:"movement"`
+}
+
+// controlTrace is the trace file shared by all replay adaptors. It is opened on the first
+// traced action and closed by [CloseControlTrace] at the end of each task.
+var controlTrace = struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	err  error
+}{path: CONTROL_TRACE_PATH}
+
+// replayControlAdaptor never sends input to the controller. It keeps the movement state
+// like a real adaptor does and writes every intended action to the control trace, so that
+// pipelines can run headlessly against debug or replay controllers fed with recorded screenshots.
+// Delays are recorded but not waited for, since screenshots do not depend on the input.
+type replayControlAdaptor struct {
+	ctx *maa.Context
+
+	pm            PlayerMovement
+	lastDirection PlayerDirection
+}
+
+func newReplayControlAdaptor(ctx *maa.Context) *replayControlAdaptor {
+	return &replayControlAdaptor{ctx: ctx, pm: MovementStop}
+}
+
+func (rca *replayControlAdaptor) Ctx() *maa.Context {
+	return rca.ctx
+}
+
+func (rca *replayControlAdaptor) TouchDown(contact, x, y int, delayMillis int) {
+	rca.trace("TouchDown", map[string]int{"contact": contact, "x": x, "y": y, "delay": delayMillis})
+}
+
+func (rca *replayControlAdaptor) TouchUp(contact int, delayMillis int) {
+	rca.trace("TouchUp", map[string]int{"contact": contact, "delay": delayMillis})
+}
+
+func (rca *replayControlAdaptor) TouchClick(contact, x, y int, durationMillis, delayMillis int) {
+	rca.trace("TouchClick", map[string]int{"contact": contact, "x": x, "y": y, "duration": durationMillis, "delay": delayMillis})
+}
+
+func (rca *replayControlAdaptor) TouchMove(contact, x, y int, delayMillis int) {
+	rca.trace("TouchMove", map[string]int{"contact": contact, "x": x, "y": y, "delay": delayMillis})
+}
+
+func (rca *replayControlAdaptor) Swipe(contact, x, y, dx, dy int, durationMillis, delayMillis int) {
+	rca.trace("Swipe", map[string]int{"contact": contact, "x": x, "y": y, "dx": dx, "dy": dy, "duration": durationMillis, "delay": delayMillis})
+}
+
+func (rca *replayControlAdaptor) SwipeHover(contact, x, y, dx, dy int, durationMillis, delayMillis int) {
+	rca.trace("SwipeHover", map[string]int{"contact": contact, "x": x, "y": y, "dx": dx, "dy": dy, "duration": durationMillis, "delay": delayMillis})
+}
+
+func (rca *replayControlAdaptor) KeyDown(keyCode int, delayMillis int) {
+	rca.trace("KeyDown", map[string]int{"key": keyCode, "delay": delayMillis})
+}
+
+func (rca *replayControlAdaptor) KeyUp(keyCode int, delayMillis int) {
+	rca.trace("KeyUp", map[string]int{"key": keyCode, "delay": delayMillis})
+}
+
+func (rca *replayControlAdaptor) KeyType(keyCode int, delayMillis int) {
+	rca.trace("KeyType", map[string]int{"key": keyCode, "delay": delayMillis})
+}
+
+func (rca *replayControlAdaptor) RotateCamera(dx, dy int) {
+	rca.trace("RotateCamera", map[string]int{"dx": dx, "dy": dy})
+}
+
+func (rca *replayControlAdaptor) GetPlayerMovement() PlayerMovement {
+	return rca.pm
+}
+
+func (rca *replayControlAdaptor) SetPlayerMovement(movement PlayerMovement, policy PlayerMovementPolicy) {
+	if movement.Equals(rca.pm) && policy < PolicyActive {
+		return
+	}
+	rca.pm = movement
+	rca.trace("SetPlayerMovement", map[string]int{"policy": int(policy)})
+}
+
+func (rca *replayControlAdaptor) SetPlayerDirection(direction PlayerDirection) {
+	if direction == rca.lastDirection {
+		return
+	}
+	rca.lastDirection = direction
+	rca.trace("SetPlayerDirection", map[string]int{"direction": int(direction)})
+}
+
+func (rca *replayControlAdaptor) PlayerJump() {
+	rca.trace("PlayerJump", nil)
+}
+
+func (rca *replayControlAdaptor) ResetCursor(policy CursorResetPolicy) {
+	rca.trace("ResetCursor", map[string]int{"policy": int(policy)})
+}
+
+func (rca *replayControlAdaptor) AggressivelyResetPlayerMovement() {
+	rca.pm = MovementStop
+	rca.lastDirection = DirectionF
+	rca.trace("AggressivelyResetPlayerMovement", nil)
+}
+
+// trace logs the intended action and appends it to the control trace file.
+func (rca *replayControlAdaptor) trace(action string, args map[string]int) {
+	entry := ControlTraceEntry{Time: time.Now(), Action: action, Args: args, Movement: rca.pm.String()}
+	log.Debug().
+		Str("action", action).
+		Interface("args", args).
+		Str("movement", entry.Movement).
+		Msg("Replay control action")
+	if err := appendControlTrace(entry); err != nil {
+		log.Warn().Err(err).Str("action", action).Msg("Failed to write control trace")
+	}
+}
+
+func appendControlTrace(entry ControlTraceEntry) error {
+	controlTrace.mu.Lock()
+	defer controlTrace.mu.Unlock()
+	if controlTrace.file == nil && controlTrace.err == nil {
+		if err := os.MkdirAll(filepath.Dir(controlTrace.path), 0o755); err != nil {
+			controlTrace.err = fmt.Errorf("failed to create control trace directory: %w", err)
+		} else {
+			controlTrace.file, controlTrace.err = os.OpenFile(controlTrace.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		}
+	}
+	if controlTrace.err != nil {
+		return controlTrace.err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = controlTrace.file.Write(append(line, '\n'))
+	return err
+}
+
+// CloseControlTrace closes the control trace file, if open. The next traced action reopens it,
+// so it is safe to call at the end of every task.
+func CloseControlTrace() error {
+	controlTrace.mu.Lock()
+	defer controlTrace.mu.Unlock()
+	file := controlTrace.file
+	controlTrace.file, controlTrace.err = nil, nil
+	if file == nil {
+		return nil
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close control trace: %w", err)
+	}
+	return nil
+}
+
+// ReadControlTrace reads the entries of a control trace file in order.
+func ReadControlTrace(path string) ([]ControlTraceEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open control trace: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	entries := make([]ControlTraceEntry, 0)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry ControlTraceEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse control trace line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read control trace: %w", err)
+	}
+	return entries, nil
+}
+
+// ReplayControlTrace performs the traced actions on the adaptor in order, without waiting between them.
+// The movement of a SetPlayerMovement entry is restored from its recorded name, so only the predefined
+// movement states can be replayed.
+func ReplayControlTrace(ca ControlAdaptor, entries []ControlTraceEntry) error {
+	for i, entry := range entries {
+		if err := replayControlTraceEntry(ca, entry); err != nil {
+			return fmt.Errorf("control trace entry %d (%s): %w", i, entry.Action, err)
+		}
+	}
+	return nil
+}
+
+func replayControlTraceEntry(ca ControlAdaptor, entry ControlTraceEntry) error {
+	a := entry.Args
+	switch entry.Action {
+	case "TouchDown":
+		ca.TouchDown(a["contact"], a["x"], a["y"], a["delay"])
+	case "TouchUp":
+		ca.TouchUp(a["contact"], a["delay"])
+	case "TouchClick":
+		ca.TouchClick(a["contact"], a["x"], a["y"], a["duration"], a["delay"])
+	case "TouchMove":
+		ca.TouchMove(a["contact"], a["x"], a["y"], a["delay"])
+	case "Swipe":
+		ca.Swipe(a["contact"], a["x"], a["y"], a["dx"], a["dy"], a["duration"], a["delay"])
+	case "SwipeHover":
+		ca.SwipeHover(a["contact"], a["x"], a["y"], a["dx"], a["dy"], a["duration"], a["delay"])
+	case "KeyDown":
+		ca.KeyDown(a["key"], a["delay"])
+	case "KeyUp":
+		ca.KeyUp(a["key"], a["delay"])
+	case "KeyType":
+		ca.KeyType(a["key"], a["delay"])
+	case "RotateCamera":
+		ca.RotateCamera(a["dx"], a["dy"])
+	case "SetPlayerMovement":
+		movement, ok := predefinedPlayerMovement(entry.Movement)
+		if !ok {
+			return fmt.Errorf("unknown movement %q", entry.Movement)
+		}
+		ca.SetPlayerMovement(movement, PlayerMovementPolicy(a["policy"]))
+	case "SetPlayerDirection":
+		ca.SetPlayerDirection(PlayerDirection(a["direction"]))
+	case "PlayerJump":
+		ca.PlayerJump()
+	case "ResetCursor":
+		ca.ResetCursor(CursorResetPolicy(a["policy"]))
+	case "AggressivelyResetPlayerMovement":
+		ca.AggressivelyResetPlayerMovement()
+	default:
+		return fmt.Errorf("unknown action")
+	}
+	return nil
+}
+
+// predefinedPlayerMovement returns the predefined movement state of the name given by [PlayerMovement.String].
+func predefinedPlayerMovement(name string) (PlayerMovement, bool) {
+	for _, pm := range []PlayerMovement{MovementStop, MovementWalk, MovementRun, MovementSprint} {
+		if pm.String() == name {
+			return pm, true
+		}
+	}
+	return PlayerMovement{}, false
+}
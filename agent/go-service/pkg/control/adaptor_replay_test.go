@@ -0,0 +1,119 @@
+// Copyright (c) 2026 Harry Huang
+package control
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// useControlTracePath redirects the control trace to path until the test ends.
+func useControlTracePath(t *testing.T, path string) {
+	t.Helper()
+	if err := CloseControlTrace(); err != nil {
+		t.Fatalf("CloseControlTrace() error = %v", err)
+	}
+	controlTrace.mu.Lock()
+	controlTrace.path = path
+	controlTrace.mu.Unlock()
+	t.Cleanup(func() {
+		_ = CloseControlTrace()
+		controlTrace.mu.Lock()
+		controlTrace.path = CONTROL_TRACE_PATH
+		controlTrace.mu.Unlock()
+	})
+}
+
+// traceActions returns the recorded actions without their times, which differ between runs.
+func traceActions(entries []ControlTraceEntry) []ControlTraceEntry {
+	actions := make([]ControlTraceEntry, 0, len(entries))
+	for _, entry := range entries {
+		entry.Time = time.Time{}
+		actions = append(actions, entry)
+	}
+	return actions
+}
+
+func TestControlTraceRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	recordPath := filepath.Join(dir, "record", "ControlTrace.jsonl")
+	replayPath := filepath.Join(dir, "replay", "ControlTrace.jsonl")
+
+	useControlTracePath(t, recordPath)
+	rca := newReplayControlAdaptor(nil)
+	rca.AggressivelyResetPlayerMovement()
+	rca.ResetCursor(CursorResetActive)
+	rca.SetPlayerDirection(DirectionL)
+	rca.SetPlayerMovement(MovementRun, PolicyDefault)
+	rca.RotateCamera(40, -3)
+	rca.SetPlayerMovement(MovementSprint, PolicyActive)
+	rca.PlayerJump()
+	rca.KeyDown(0x57, 10)
+	rca.KeyUp(0x57, 10)
+	rca.KeyType(27, 1000)
+	rca.TouchClick(0, 640, 360, 50, 100)
+	rca.Swipe(1, 960, 360, -120, 0, 250, 0)
+	rca.SetPlayerMovement(MovementStop, PolicyDefault)
+	if err := CloseControlTrace(); err != nil {
+		t.Fatalf("CloseControlTrace() error = %v", err)
+	}
+
+	recorded, err := ReadControlTrace(recordPath)
+	if err != nil {
+		t.Fatalf("ReadControlTrace() error = %v", err)
+	}
+	if len(recorded) != 13 {
+		t.Fatalf("len(recorded) = %d, want 13: %+v", len(recorded), recorded)
+	}
+
+	// Replaying into another replay adaptor records the same trace
+	useControlTracePath(t, replayPath)
+	if err := ReplayControlTrace(newReplayControlAdaptor(nil), recorded); err != nil {
+		t.Fatalf("ReplayControlTrace() error = %v", err)
+	}
+	if err := CloseControlTrace(); err != nil {
+		t.Fatalf("CloseControlTrace() error = %v", err)
+	}
+	replayed, err := ReadControlTrace(replayPath)
+	if err != nil {
+		t.Fatalf("ReadControlTrace() error = %v", err)
+	}
+	if want, got := traceActions(recorded), traceActions(replayed); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed trace differs:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	if err := ReplayControlTrace(newReplayControlAdaptor(nil), []ControlTraceEntry{{Action: "Teleport"}}); err == nil {
+		t.Fatal("ReplayControlTrace() expected error for unknown action")
+	}
+	if err := ReplayControlTrace(newReplayControlAdaptor(nil), []ControlTraceEntry{{Action: "SetPlayerMovement", Movement: "3.0px/s"}}); err == nil {
+		t.Fatal("ReplayControlTrace() expected error for custom movement")
+	}
+}
+
+func TestCloseControlTraceReopens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ControlTrace.jsonl")
+	useControlTracePath(t, path)
+	rca := newReplayControlAdaptor(nil)
+
+	// Each task closes the trace at its end, and the next task appends to the same file
+	rca.PlayerJump()
+	if err := CloseControlTrace(); err != nil {
+		t.Fatalf("CloseControlTrace() error = %v", err)
+	}
+	if err := CloseControlTrace(); err != nil {
+		t.Fatalf("CloseControlTrace() on closed trace error = %v", err)
+	}
+	rca.KeyType(27, 0)
+	if err := CloseControlTrace(); err != nil {
+		t.Fatalf("CloseControlTrace() error = %v", err)
+	}
+
+	entries, err := ReadControlTrace(path)
+	if err != nil {
+		t.Fatalf("ReadControlTrace() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Action != "PlayerJump" || entries[1].Action != "KeyType" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+}
@@ -11,6 +11,7 @@ import (
 // WlrootsRelativeMoveScale compensates for the sensitivity difference between
 // the wlroots relative-move path and the default desktop hover-swipe path.
 // Empirically derived from the previous per-call ratio 2.6 / 2.0 = 1.3.
+// A calibration profile refines RotateCamera on top of this scale, see [CalibrationProfile].
 const WlrootsRelativeMoveScale = 1.3
 
 // wlrootsControlAdaptor reuses desktop key/movement behavior while overriding
@@ -19,10 +20,13 @@ type wlrootsControlAdaptor struct {
 	*desktopControlAdaptor
 }
 
-func newWlrootsControlAdaptor(ctx *maa.Context, ctrl *maa.Controller, w, h int) *wlrootsControlAdaptor {
-	return &wlrootsControlAdaptor{
-		desktopControlAdaptor: newDefaultDesktopControlAdaptor(ctx, ctrl, w, h),
+// newWlrootsControlAdaptor shares the desktop key bindings, since wlroots controllers use Win32 virtual-key codes.
+func newWlrootsControlAdaptor(ctx *maa.Context, ctrl *maa.Controller, w, h int) (*wlrootsControlAdaptor, error) {
+	dca, err := newConfiguredDesktopControlAdaptor(ctx, ctrl, w, h)
+	if err != nil {
+		return nil, err
 	}
+	return &wlrootsControlAdaptor{desktopControlAdaptor: dca}, nil
 }
 
 // SwipeHover on wlroots is implemented via relative mouse movement, so the
@@ -37,6 +41,8 @@ func (wca *wlrootsControlAdaptor) SwipeHover(_ /*contact*/, _ /*x*/, _ /*y*/, dx
 }
 
 func (wca *wlrootsControlAdaptor) RotateCamera(dx, dy int) {
+	scale := wca.calibration.GetCameraScale()
+	dx, dy = scaleCameraDelta(dx, scale), scaleCameraDelta(dy, scale)
 	// No screen-center anchor: wlroots SwipeHover ignores x/y and sends a relative move.
 	wca.SwipeHover(0, 0, 0, dx, dy, defaultDesktopKeyActionDelayMillis*3, defaultDesktopKeyActionDelayMillis)
 }
@@ -0,0 +1,214 @@
+// Copyright (c) 2026 Harry Huang
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/atomicfile"
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// CALIBRATION_PROFILE_PATH stores the calibration profiles under the working directory.
+	CALIBRATION_PROFILE_PATH = "debug/record/ControlCalibration.json"
+
+	// CAMERA_REFERENCE_DEGREES_PER_PIXEL is the camera yaw per RotateCamera pixel assumed by callers,
+	// from which the default rotation speed of MapTracker is derived.
+	// Calibrated adaptors scale the rotation deltas so that the camera turns at this rate.
+	CAMERA_REFERENCE_DEGREES_PER_PIXEL = 0.5
+
+	calibrationProfileSchemaVersion = 1
+)
+
+// CalibrationProfile stores the measured movement speeds and camera sensitivity
+// of one controller type at one screen resolution. Rotation response speeds are not
+// measured, so [PlayerMovement.EtaOfRotation] keeps the default values.
+type CalibrationProfile struct {
+	ControlType string `json:"control_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	// WalkSpeed, RunSpeed and SprintSpeed are the measured movement speeds (px/s on the map).
+	// Zero means not measured, in which case the default speed is used.
+	WalkSpeed   float64 `json:"walk_speed,omitempty"`
+	RunSpeed    float64 `json:"run_speed,omitempty"`
+	SprintSpeed float64 `json:"sprint_speed,omitempty"`
+	// CameraScale multiplies the RotateCamera deltas, see [CAMERA_REFERENCE_DEGREES_PER_PIXEL].
+	// Zero means not measured, in which case no scaling is applied.
+	CameraScale  float64   `json:"camera_scale,omitempty"`
+	CalibratedAt time.Time `json:"calibrated_at"`
+}
+
+type calibrationProfileFile struct {
+	SchemaVersion int                  `json:"schema_version"`
+	Profiles      []CalibrationProfile `json:"profiles"`
+}
+
+// calibratedAdaptor is implemented by the adaptors which apply a calibration profile.
+type calibratedAdaptor interface {
+	calibrationProfile() *CalibrationProfile
+}
+
+// CalibrationOf returns the calibration profile applied by the adaptor,
+// or nil if the controller is not calibrated or the adaptor does not support calibration.
+func CalibrationOf(ca ControlAdaptor) *CalibrationProfile {
+	if c, ok := ca.(calibratedAdaptor); ok {
+		return c.calibrationProfile()
+	}
+	return nil
+}
+
+// Calibrate returns the movement with its measured speed, if any. A nil profile returns the movement unchanged.
+func (p *CalibrationProfile) Calibrate(pm PlayerMovement) PlayerMovement {
+	if speed, ok := p.speedOf(pm); ok {
+		pm.calibratedSpeed = speed
+	}
+	return pm
+}
+
+// GetCameraScale returns the RotateCamera scale of the profile, which is 1 for nil or unmeasured profiles.
+func (p *CalibrationProfile) GetCameraScale() float64 {
+	if p == nil || p.CameraScale <= 0 {
+		return 1.0
+	}
+	return p.CameraScale
+}
+
+// speedOf returns the measured speed of the predefined movement state, if any.
+func (p *CalibrationProfile) speedOf(pm PlayerMovement) (float64, bool) {
+	if p == nil {
+		return 0, false
+	}
+	var speed float64
+	switch {
+	case pm.Equals(MovementWalk):
+		speed = p.WalkSpeed
+	case pm.Equals(MovementRun):
+		speed = p.RunSpeed
+	case pm.Equals(MovementSprint):
+		speed = p.SprintSpeed
+	}
+	return speed, speed > 0
+}
+
+func (p *CalibrationProfile) validate() error {
+	for name, value := range map[string]float64{
+		"walk_speed":   p.WalkSpeed,
+		"run_speed":    p.RunSpeed,
+		"sprint_speed": p.SprintSpeed,
+		"camera_scale": p.CameraScale,
+	} {
+		if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+			return fmt.Errorf("%s %v is invalid", name, value)
+		}
+	}
+	if p.CameraScale > 0 && (p.CameraScale < 0.1 || p.CameraScale > 10) {
+		return fmt.Errorf("camera_scale %v is out of range", p.CameraScale)
+	}
+	return nil
+}
+
+// LoadCalibrationProfile loads the profile of the given controller type and resolution.
+// It returns nil without error if the file or the profile does not exist.
+func LoadCalibrationProfile(path, controlType string, w, h int) (*CalibrationProfile, error) {
+	file, err := readCalibrationProfileFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range file.Profiles {
+		if profile.ControlType == controlType && profile.Width == w && profile.Height == h {
+			if err := profile.validate(); err != nil {
+				return nil, fmt.Errorf("calibration profile of %s %dx%d: %w", controlType, w, h, err)
+			}
+			return &profile, nil
+		}
+	}
+	return nil, nil
+}
+
+// SaveCalibrationProfile saves the profile, replacing the one of the same controller type and resolution.
+func SaveCalibrationProfile(path string, profile CalibrationProfile) error {
+	if err := profile.validate(); err != nil {
+		return err
+	}
+	file, err := readCalibrationProfileFile(path)
+	if err != nil {
+		return err
+	}
+	profiles := make([]CalibrationProfile, 0, len(file.Profiles)+1)
+	for _, p := range file.Profiles {
+		if p.ControlType != profile.ControlType || p.Width != profile.Width || p.Height != profile.Height {
+			profiles = append(profiles, p)
+		}
+	}
+	profiles = append(profiles, profile)
+
+	if err := atomicfile.WriteJSON(path, calibrationProfileFile{SchemaVersion: calibrationProfileSchemaVersion, Profiles: profiles}); err != nil {
+		return fmt.Errorf("write calibration profile file: %w", err)
+	}
+	return nil
+}
+
+func readCalibrationProfileFile(path string) (calibrationProfileFile, error) {
+	var file calibrationProfileFile
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return file, nil
+		}
+		return file, fmt.Errorf("read calibration profile file: %w", err)
+	}
+	if err := json.Unmarshal(b, &file); err != nil {
+		return file, fmt.Errorf("parse calibration profile file: %w", err)
+	}
+	// Refuse a file of another schema rather than treating it as empty, so that saving never drops its profiles.
+	if file.SchemaVersion != calibrationProfileSchemaVersion {
+		return calibrationProfileFile{}, fmt.Errorf("unsupported calibration profile schema version: %d", file.SchemaVersion)
+	}
+	return file, nil
+}
+
+// LoadControllerCalibration loads the profile of the controller, for estimates made without a [ControlAdaptor].
+// It returns nil if the controller is not calibrated or replays recorded screenshots.
+func LoadControllerCalibration(ctrl *maa.Controller) *CalibrationProfile {
+	controlType, err := GetControlType(ctrl)
+	if err != nil || controlType == CONTROL_TYPE_DEBUG || controlType == CONTROL_TYPE_REPLAY {
+		return nil
+	}
+	return loadControllerCalibration(ctrl, controlType)
+}
+
+// loadControllerCalibration loads the profile of the controller.
+// Failures are not fatal, the adaptor falls back to the default speeds and sensitivity.
+func loadControllerCalibration(ctrl *maa.Controller, controlType string) *CalibrationProfile {
+	w, h, err := ctrl.GetResolution()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get resolution, calibration profile is not applied")
+		return nil
+	}
+	profile, err := LoadCalibrationProfile(CALIBRATION_PROFILE_PATH, controlType, int(w), int(h))
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load calibration profile, using default values")
+		return nil
+	}
+	return profile
+}
+
+// scaleCameraDelta scales a camera rotation delta, keeping a nonzero delta nonzero.
+func scaleCameraDelta(d int, scale float64) int {
+	if d == 0 || scale == 1.0 {
+		return d
+	}
+	scaled := int(math.Round(float64(d) * scale))
+	if scaled == 0 {
+		if d > 0 {
+			return 1
+		}
+		return -1
+	}
+	return scaled
+}
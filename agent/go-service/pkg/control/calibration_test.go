@@ -0,0 +1,123 @@
+// Copyright (c) 2026 Harry Huang
+package control
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCalibrationProfileSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "record", "ControlCalibration.json")
+	if profile, err := LoadCalibrationProfile(path, CONTROL_TYPE_WIN32, 1280, 720); err != nil || profile != nil {
+		t.Fatalf("LoadCalibrationProfile() on missing file = %+v, %v", profile, err)
+	}
+
+	calibratedAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	profiles := []CalibrationProfile{
+		{ControlType: CONTROL_TYPE_WIN32, Width: 1280, Height: 720, RunSpeed: 7.5, CameraScale: 1.2, CalibratedAt: calibratedAt},
+		{ControlType: CONTROL_TYPE_WIN32, Width: 1920, Height: 1080, RunSpeed: 8.5, CalibratedAt: calibratedAt},
+		{ControlType: CONTROL_TYPE_ADB, Width: 1280, Height: 720, WalkSpeed: 1.8, CalibratedAt: calibratedAt},
+	}
+	for _, profile := range profiles {
+		if err := SaveCalibrationProfile(path, profile); err != nil {
+			t.Fatalf("SaveCalibrationProfile() error = %v", err)
+		}
+	}
+	// Saving again replaces the profile of the same controller type and resolution
+	profiles[0].RunSpeed = 7.8
+	if err := SaveCalibrationProfile(path, profiles[0]); err != nil {
+		t.Fatalf("SaveCalibrationProfile() error = %v", err)
+	}
+
+	for _, want := range profiles {
+		got, err := LoadCalibrationProfile(path, want.ControlType, want.Width, want.Height)
+		if err != nil {
+			t.Fatalf("LoadCalibrationProfile(%s %dx%d) error = %v", want.ControlType, want.Width, want.Height, err)
+		}
+		if got == nil || *got != want {
+			t.Fatalf("LoadCalibrationProfile(%s %dx%d) = %+v, want %+v", want.ControlType, want.Width, want.Height, got, want)
+		}
+	}
+	if profile, err := LoadCalibrationProfile(path, CONTROL_TYPE_WLROOTS, 1280, 720); err != nil || profile != nil {
+		t.Fatalf("LoadCalibrationProfile() of uncalibrated controller = %+v, %v", profile, err)
+	}
+	file, err := readCalibrationProfileFile(path)
+	if err != nil || len(file.Profiles) != len(profiles) {
+		t.Fatalf("readCalibrationProfileFile() = %+v, %v", file, err)
+	}
+}
+
+func TestCalibrationProfileRejectsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ControlCalibration.json")
+	for _, profile := range []CalibrationProfile{
+		{ControlType: CONTROL_TYPE_WIN32, Width: 1280, Height: 720, RunSpeed: -1},
+		{ControlType: CONTROL_TYPE_WIN32, Width: 1280, Height: 720, CameraScale: 20},
+	} {
+		if err := SaveCalibrationProfile(path, profile); err == nil {
+			t.Fatalf("SaveCalibrationProfile(%+v) expected error", profile)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("invalid profile was written: %v", err)
+	}
+}
+
+func TestCalibrationProfileSchemaMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ControlCalibration.json")
+	raw := []byte(`{"schema_version": 2, "profiles": [{"control_type": "win32", "width": 1280, "height": 720, "run_speed": 7.5}]}`)
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := LoadCalibrationProfile(path, CONTROL_TYPE_WIN32, 1280, 720); err == nil {
+		t.Fatal("LoadCalibrationProfile() expected schema version error")
+	}
+	profile := CalibrationProfile{ControlType: CONTROL_TYPE_ADB, Width: 1280, Height: 720, RunSpeed: 8}
+	if err := SaveCalibrationProfile(path, profile); err == nil {
+		t.Fatal("SaveCalibrationProfile() expected schema version error")
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(raw) {
+		t.Fatalf("file of another schema was overwritten:\n%s", got)
+	}
+}
+
+func TestCalibrationOf(t *testing.T) {
+	profile := &CalibrationProfile{WalkSpeed: 1.5, SprintSpeed: 13, CameraScale: 1.2}
+	dca := &desktopControlAdaptor{calibration: profile, pm: MovementSprint}
+	wca := &wlrootsControlAdaptor{desktopControlAdaptor: &desktopControlAdaptor{pm: MovementRun}}
+
+	if got := CalibrationOf(dca); got != profile {
+		t.Fatalf("CalibrationOf(desktop) = %+v, want %+v", got, profile)
+	}
+	if got := CalibrationOf(wca); got != nil {
+		t.Fatalf("CalibrationOf(uncalibrated wlroots) = %+v, want nil", got)
+	}
+	if got := CalibrationOf(&replayControlAdaptor{}); got != nil {
+		t.Fatalf("CalibrationOf(replay) = %+v, want nil", got)
+	}
+
+	// Adaptors do not share their profiles
+	if speed := dca.GetPlayerMovement().Speed(); speed != 13 {
+		t.Fatalf("calibrated sprint speed = %v, want 13", speed)
+	}
+	if speed := wca.GetPlayerMovement().Speed(); speed != MovementRun.speed {
+		t.Fatalf("uncalibrated run speed = %v, want %v", speed, MovementRun.speed)
+	}
+	if pm := dca.GetPlayerMovement(); !pm.Equals(MovementSprint) {
+		t.Fatalf("calibrated movement %v does not equal %v", pm, MovementSprint)
+	}
+
+	// Unmeasured speeds and nil profiles keep the default values
+	if speed := profile.Calibrate(MovementRun).Speed(); speed != MovementRun.speed {
+		t.Fatalf("unmeasured run speed = %v, want %v", speed, MovementRun.speed)
+	}
+	var none *CalibrationProfile
+	if speed := none.Calibrate(MovementWalk).Speed(); speed != MovementWalk.speed || none.GetCameraScale() != 1.0 {
+		t.Fatalf("nil profile changed the walk speed to %v or camera scale to %v", speed, none.GetCameraScale())
+	}
+	if eta := profile.Calibrate(MovementWalk).EtaOfDistance(3); eta != 2*time.Second {
+		t.Fatalf("calibrated walk eta = %v, want 2s", eta)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2026 Harry Huang
+package controltest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+)
+
+// DefaultActionDelay is the default fake time consumed by each player-level action.
+const DefaultActionDelay = 30 * time.Millisecond
+
+// Adaptor is an in-memory [control.ControlAdaptor] which records every call with its fake time.
+// Player-level actions drive the simulated player if one is attached, and delays advance the clock.
+type Adaptor struct {
+	Clock  *Clock
+	Player *Player
+	// ActionDelay is the fake time consumed by each player-level action, like real adaptors do.
+	ActionDelay time.Duration
+
+	mu            sync.Mutex
+	calls         []control.ControlTraceEntry
+	pm            control.PlayerMovement
+	lastDirection control.PlayerDirection
+}
+
+var _ control.ControlAdaptor = &Adaptor{}
+
+// NewAdaptor creates an adaptor on the clock. The player may be nil, and is advanced by the clock otherwise.
+func NewAdaptor(clock *Clock, player *Player) *Adaptor {
+	if player != nil {
+		clock.OnAdvance(func(_ time.Time, dt time.Duration) { player.Advance(dt) })
+	}
+	return &Adaptor{Clock: clock, Player: player, ActionDelay: DefaultActionDelay, pm: control.MovementStop}
+}
+
+// Calls returns a copy of all recorded calls in order.
+func (a *Adaptor) Calls() []control.ControlTraceEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]control.ControlTraceEntry(nil), a.calls...)
+}
+
+// CallsOf returns the recorded calls of the given action in order.
+func (a *Adaptor) CallsOf(action string) []control.ControlTraceEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	calls := make([]control.ControlTraceEntry, 0)
+	for _, call := range a.calls {
+		if call.Action == action {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (a *Adaptor) record(action string, args map[string]int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls = append(a.calls, control.ControlTraceEntry{Time: a.Clock.Now(), Action: action, Args: args, Movement: a.pm.String()})
+}
+
+func (a *Adaptor) sleepMillis(millis int) {
+	a.Clock.Sleep(time.Duration(millis) * time.Millisecond)
+}
+
+func (a *Adaptor) Ctx() *maa.Context {
+	return nil
+}
+
+func (a *Adaptor) TouchDown(contact, x, y int, delayMillis int) {
+	a.record("TouchDown", map[string]int{"contact": contact, "x": x, "y": y, "delay": delayMillis})
+	a.sleepMillis(delayMillis)
+}
+
+func (a *Adaptor) TouchUp(contact int, delayMillis int) {
+	a.record("TouchUp", map[string]int{"contact": contact, "delay": delayMillis})
+	a.sleepMillis(delayMillis)
+}
+
+func (a *Adaptor) TouchClick(contact, x, y int, durationMillis, delayMillis int) {
+	a.record("TouchClick", map[string]int{"contact": contact, "x": x, "y": y, "duration": durationMillis, "delay": delayMillis})
+	a.sleepMillis(durationMillis + delayMillis)
+}
+
+func (a *Adaptor) TouchMove(contact, x, y int, delayMillis int) {
+	a.record("TouchMove", map[string]int{"contact": contact, "x": x, "y": y, "delay": delayMillis})
+	a.sleepMillis(delayMillis)
+}
+
+func (a *Adaptor) Swipe(contact, x, y, dx, dy int, durationMillis, delayMillis int) {
+	a.record("Swipe", map[string]int{"contact": contact, "x": x, "y": y, "dx": dx, "dy": dy, "duration": durationMillis, "delay": delayMillis})
+	a.sleepMillis(durationMillis + delayMillis)
+}
+
+func (a *Adaptor) SwipeHover(contact, x, y, dx, dy int, durationMillis, delayMillis int) {
+	a.record("SwipeHover", map[string]int{"contact": contact, "x": x, "y": y, "dx": dx, "dy": dy, "duration": durationMillis, "delay": delayMillis})
+	a.sleepMillis(durationMillis + delayMillis)
+}
+
+func (a *Adaptor) KeyDown(keyCode int, delayMillis int) {
+	a.record("KeyDown", map[string]int{"key": keyCode, "delay": delayMillis})
+	a.sleepMillis(delayMillis)
+}
+
+func (a *Adaptor) KeyUp(keyCode int, delayMillis int) {
+	a.record("KeyUp", map[string]int{"key": keyCode, "delay": delayMillis})
+	a.sleepMillis(delayMillis)
+}
+
+func (a *Adaptor) KeyType(keyCode int, delayMillis int) {
+	a.record("KeyType", map[string]int{"key": keyCode, "delay": delayMillis})
+	a.sleepMillis(delayMillis)
+}
+
+// RotateCamera turns the camera of the player at [control.CAMERA_REFERENCE_DEGREES_PER_PIXEL].
+func (a *Adaptor) RotateCamera(dx, dy int) {
+	a.record("RotateCamera", map[string]int{"dx": dx, "dy": dy})
+	if a.Player != nil {
+		a.Player.RotateCamera(float64(dx) * control.CAMERA_REFERENCE_DEGREES_PER_PIXEL)
+	}
+	a.Clock.Sleep(a.ActionDelay)
+}
+
+func (a *Adaptor) GetPlayerMovement() control.PlayerMovement {
+	return a.pm
+}
+
+func (a *Adaptor) SetPlayerMovement(movement control.PlayerMovement, policy control.PlayerMovementPolicy) {
+	if movement.Equals(a.pm) && policy < control.PolicyActive {
+		return
+	}
+	a.pm = movement
+	a.record("SetPlayerMovement", map[string]int{"policy": int(policy)})
+	if a.Player != nil {
+		a.Player.SetMovement(movement)
+	}
+	a.Clock.Sleep(a.ActionDelay)
+}
+
+func (a *Adaptor) SetPlayerDirection(direction control.PlayerDirection) {
+	if direction == a.lastDirection {
+		return
+	}
+	a.lastDirection = direction
+	a.record("SetPlayerDirection", map[string]int{"direction": int(direction)})
+	if a.Player != nil {
+		a.Player.SetDirection(direction)
+	}
+	a.Clock.Sleep(a.ActionDelay / 2)
+}
+
+func (a *Adaptor) PlayerJump() {
+	a.record("PlayerJump", nil)
+	if a.Player != nil {
+		a.Player.Jump()
+	}
+	a.Clock.Sleep(a.ActionDelay * 4)
+}
+
+func (a *Adaptor) ResetCursor(policy control.CursorResetPolicy) {
+	a.record("ResetCursor", map[string]int{"policy": int(policy)})
+}
+
+func (a *Adaptor) AggressivelyResetPlayerMovement() {
+	a.record("AggressivelyResetPlayerMovement", nil)
+	a.pm = control.MovementStop
+	a.lastDirection = control.DirectionF
+	if a.Player != nil {
+		a.Player.SetMovement(control.MovementStop)
+		a.Player.SetDirection(control.DirectionF)
+	}
+	a.Clock.Sleep(a.ActionDelay * 8)
+}
+
+// TotalRotateCamera returns the sum of all RotateCamera dx, which is convenient to assert steering.
+func (a *Adaptor) TotalRotateCamera() int {
+	total := 0
+	for _, call := range a.CallsOf("RotateCamera") {
+		total += call.Args["dx"]
+	}
+	return total
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2026 Harry Huang
+
+// Package controltest provides an in-memory ControlAdaptor, a simulated player and a scripted
+// screen source driven by a fake clock, so that movement logic can be tested headlessly.
+package controltest
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultClockStep is the default granularity in which the clock notifies its listeners.
+const DefaultClockStep = 10 * time.Millisecond
+
+// Clock is a fake clock which only moves when slept or advanced.
+// Listeners are notified in steps of at most Step, so that simulations stay fine-grained.
+type Clock struct {
+	mu        sync.Mutex
+	now       time.Time
+	Step      time.Duration
+	listeners []func(now time.Time, dt time.Duration)
+}
+
+// NewClock creates a fake clock starting at the given time.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start, Step: DefaultClockStep}
+}
+
+// Now returns the current fake time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Since returns the fake time elapsed since t.
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Sleep advances the clock by d instead of blocking.
+func (c *Clock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+// Advance moves the clock forward by d and notifies the listeners step by step.
+func (c *Clock) Advance(d time.Duration) {
+	for d > 0 {
+		dt := min(d, c.Step)
+		if dt <= 0 {
+			dt = d
+		}
+		c.mu.Lock()
+		c.now = c.now.Add(dt)
+		now := c.now
+		listeners := c.listeners
+		c.mu.Unlock()
+		for _, listener := range listeners {
+			listener(now, dt)
+		}
+		d -= dt
+	}
+}
+
+// OnAdvance registers a listener called after each step of the clock.
+func (c *Clock) OnAdvance(listener func(now time.Time, dt time.Duration)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
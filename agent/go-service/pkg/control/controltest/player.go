@@ -0,0 +1,131 @@
+// Copyright (c) 2026 Harry Huang
+package controltest
+
+import (
+	"math"
+	"time"
+
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+)
+
+// PLAYER_JUMP_DURATION is how long a jump lets the player pass jumpable obstacles.
+const PLAYER_JUMP_DURATION = 600 * time.Millisecond
+
+// Obstacle is an axis-aligned rectangle on the synthetic map that blocks the player.
+type Obstacle struct {
+	MinX, MinY, MaxX, MaxY float64
+	// Jumpable obstacles can be passed while the player is jumping.
+	Jumpable bool
+}
+
+func (o Obstacle) contains(x, y float64) bool {
+	return x >= o.MinX && x <= o.MaxX && y >= o.MinY && y <= o.MaxY
+}
+
+// Player simulates the player on a synthetic map.
+// Headings follow the MapTracker convention: 0 points to -Y and increases clockwise.
+//
+// The camera turns immediately on RotateCamera, while the body only turns toward the
+// movement direction while moving, at the rotation speed of the current movement.
+type Player struct {
+	X, Y      float64
+	Heading   float64
+	Camera    float64
+	Obstacles []Obstacle
+
+	movement  control.PlayerMovement
+	direction control.PlayerDirection
+	jumpLeft  time.Duration
+	blocked   bool
+}
+
+// NewPlayer creates a still player at (x, y) whose body and camera face the given heading.
+func NewPlayer(x, y, heading float64) *Player {
+	heading = normalizeDegrees(heading)
+	return &Player{X: x, Y: y, Heading: heading, Camera: heading, movement: control.MovementStop}
+}
+
+// Movement returns the current movement state of the player.
+func (p *Player) Movement() control.PlayerMovement {
+	return p.movement
+}
+
+// Blocked returns whether the last step of the player was blocked by an obstacle.
+func (p *Player) Blocked() bool {
+	return p.blocked
+}
+
+// SetMovement sets the movement state of the player.
+func (p *Player) SetMovement(movement control.PlayerMovement) {
+	p.movement = movement
+}
+
+// SetDirection sets the movement direction relative to the camera.
+func (p *Player) SetDirection(direction control.PlayerDirection) {
+	p.direction = direction
+}
+
+// RotateCamera turns the camera by the given degrees, clockwise for positive values.
+func (p *Player) RotateCamera(degrees float64) {
+	p.Camera = normalizeDegrees(p.Camera + degrees)
+}
+
+// Jump lets the player pass jumpable obstacles for [PLAYER_JUMP_DURATION].
+func (p *Player) Jump() {
+	p.jumpLeft = PLAYER_JUMP_DURATION
+}
+
+// Advance simulates the player for dt.
+func (p *Player) Advance(dt time.Duration) {
+	if p.jumpLeft > 0 {
+		p.jumpLeft -= dt
+	}
+	dist := p.movement.DistanceDuring(dt)
+	if dist <= 0 {
+		p.blocked = false
+		return
+	}
+
+	want := normalizeDegrees(p.Camera + directionOffset(p.direction))
+	turn := math.Remainder(want-p.Heading, 360)
+	maxTurn := p.movement.RotationDuring(dt)
+	turn = math.Max(-maxTurn, math.Min(maxTurn, turn))
+	p.Heading = normalizeDegrees(p.Heading + turn)
+
+	rad := p.Heading * math.Pi / 180.0
+	nx, ny := p.X+dist*math.Sin(rad), p.Y-dist*math.Cos(rad)
+	p.blocked = p.isBlocked(nx, ny)
+	if !p.blocked {
+		p.X, p.Y = nx, ny
+	}
+}
+
+func (p *Player) isBlocked(x, y float64) bool {
+	for _, o := range p.Obstacles {
+		if o.contains(x, y) && !(o.Jumpable && p.jumpLeft > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+func directionOffset(direction control.PlayerDirection) float64 {
+	switch direction {
+	case control.DirectionB:
+		return 180
+	case control.DirectionL:
+		return -90
+	case control.DirectionR:
+		return 90
+	default:
+		return 0
+	}
+}
+
+func normalizeDegrees(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2026 Harry Huang
+package controltest
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Frame is what location inference sees on one captured screen.
+type Frame struct {
+	Time time.Time
+	X, Y float64
+	Rot  int
+	Conf float64
+	// Err makes the inference of this frame fail.
+	Err error
+}
+
+// Screen is a scripted screen source. Scripted frames are returned first, one per capture,
+// then frames are observed from the simulated player with optional Gaussian location noise.
+type Screen struct {
+	Clock  *Clock
+	Player *Player
+	Script []Frame
+	// Noise is the standard deviation of the location noise of observed frames (px).
+	Noise float64
+	// Conf is the confidence of observed frames.
+	Conf float64
+
+	rng *rand.Rand
+}
+
+// NewScreen creates a screen observing the player, with noise drawn from the given seed.
+func NewScreen(clock *Clock, player *Player, seed uint64) *Screen {
+	return &Screen{Clock: clock, Player: player, Conf: 1.0, rng: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))}
+}
+
+// Capture returns the next scripted frame, or observes the player if the script is exhausted.
+func (s *Screen) Capture() Frame {
+	if len(s.Script) > 0 {
+		frame := s.Script[0]
+		s.Script = s.Script[1:]
+		if frame.Time.IsZero() && s.Clock != nil {
+			frame.Time = s.Clock.Now()
+		}
+		return frame
+	}
+	frame := Frame{Conf: s.Conf}
+	if s.Clock != nil {
+		frame.Time = s.Clock.Now()
+	}
+	if s.Player != nil {
+		frame.X, frame.Y = s.Player.X, s.Player.Y
+		frame.Rot = int(math.Round(s.Player.Heading)) % 360
+		if s.Noise > 0 && s.rng != nil {
+			frame.X += s.rng.NormFloat64() * s.Noise
+			frame.Y += s.rng.NormFloat64() * s.Noise
+		}
+	}
+	return frame
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2026 Harry Huang
+package control
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// DESKTOP_KEY_BINDINGS_NODE is the pipeline node whose attach holds the desktop key bindings.
+// The global Keymap option of the PI overrides its attach with the keys captured from the user.
+const DESKTOP_KEY_BINDINGS_NODE = "__ControlDesktopKeyBindings"
+
+// desktopKeyBindingsConfig is the JSON form of the desktop key bindings.
+// Each binding is either a virtual-key code or a virtual-key name (e.g. "W", "Shift", "F1").
+type desktopKeyBindingsConfig struct {
+	Forward  json.RawMessage `json:"forward"`
+	Left     json.RawMessage `json:"left"`
+	Backward json.RawMessage `json:"backward"`
+	Right    json.RawMessage `json:"right"`
+	Sprint   json.RawMessage `json:"sprint"`
+	Walk     json.RawMessage `json:"walk"`
+	Cursor   json.RawMessage `json:"cursor"`
+	Jump     json.RawMessage `json:"jump"`
+}
+
+// loadDesktopKeyBindings reads the desktop key bindings from the attach of [DESKTOP_KEY_BINDINGS_NODE].
+// The default bindings are used when the node is absent from the loaded resources,
+// while a node with missing or invalid bindings is reported as an error.
+func loadDesktopKeyBindings(ctx *maa.Context) (desktopKeyBindings, error) {
+	if ctx == nil {
+		return defaultDesktopKeyBindings(), nil
+	}
+	raw, err := ctx.GetNodeJSON(DESKTOP_KEY_BINDINGS_NODE)
+	if err != nil || raw == "" {
+		log.Warn().
+			Err(err).
+			Str("node", DESKTOP_KEY_BINDINGS_NODE).
+			Msg("Desktop key bindings node not found, using default bindings")
+		return defaultDesktopKeyBindings(), nil
+	}
+
+	var node struct {
+		Attach json.RawMessage `json:"attach"`
+	}
+	if err := json.Unmarshal([]byte(raw), &node); err != nil {
+		return desktopKeyBindings{}, fmt.Errorf("failed to parse node %s: %w", DESKTOP_KEY_BINDINGS_NODE, err)
+	}
+	keys, err := parseDesktopKeyBindings(node.Attach)
+	if err != nil {
+		return desktopKeyBindings{}, fmt.Errorf("invalid key bindings in node %s: %w", DESKTOP_KEY_BINDINGS_NODE, err)
+	}
+	return keys, nil
+}
+
+// parseDesktopKeyBindings parses and validates desktop key bindings from JSON.
+// All bindings are required and no two bindings may share the same key.
+func parseDesktopKeyBindings(data []byte) (desktopKeyBindings, error) {
+	var cfg desktopKeyBindingsConfig
+	if len(bytes.TrimSpace(data)) == 0 {
+		return desktopKeyBindings{}, fmt.Errorf("key bindings are missing")
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return desktopKeyBindings{}, fmt.Errorf("failed to parse key bindings: %w", err)
+	}
+
+	var keys desktopKeyBindings
+	items := []struct {
+		name  string
+		value json.RawMessage
+		code  *int
+	}{
+		{"forward", cfg.Forward, &keys.W},
+		{"left", cfg.Left, &keys.A},
+		{"backward", cfg.Backward, &keys.S},
+		{"right", cfg.Right, &keys.D},
+		{"sprint", cfg.Sprint, &keys.Shift},
+		{"walk", cfg.Walk, &keys.Ctrl},
+		{"cursor", cfg.Cursor, &keys.Alt},
+		{"jump", cfg.Jump, &keys.Space},
+	}
+	boundBy := map[int]string{}
+	for _, item := range items {
+		code, err := parseVirtualKey(item.value)
+		if err != nil {
+			return desktopKeyBindings{}, fmt.Errorf("key binding %q: %w", item.name, err)
+		}
+		if other, ok := boundBy[code]; ok {
+			return desktopKeyBindings{}, fmt.Errorf("key binding %q uses key 0x%02X already bound to %q", item.name, code, other)
+		}
+		boundBy[code] = item.name
+		*item.code = code
+	}
+	return keys, nil
+}
+
+// parseVirtualKey resolves a JSON number or string into a Win32 virtual-key code.
+// Numeric strings are accepted as well, since PI substitutes captured hotkeys into strings.
+func parseVirtualKey(value json.RawMessage) (int, error) {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		return 0, fmt.Errorf("binding is missing")
+	}
+
+	var code int
+	if err := json.Unmarshal(value, &code); err != nil {
+		var name string
+		if err := json.Unmarshal(value, &name); err != nil {
+			return 0, fmt.Errorf("binding must be a virtual-key code or name, got %s", value)
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return 0, fmt.Errorf("binding is missing")
+		}
+		if parsed, err := strconv.ParseInt(name, 0, 32); err == nil && len(name) > 1 {
+			code = int(parsed)
+		} else if vk, ok := virtualKeyCode(name); ok {
+			code = vk
+		} else {
+			return 0, fmt.Errorf("unknown virtual-key name %q", name)
+		}
+	}
+	if code <= 0 || code > 0xFE {
+		return 0, fmt.Errorf("virtual-key code %d is out of range", code)
+	}
+	return code, nil
+}
+
+// virtualKeyCode returns the Win32 virtual-key code of a key name, ignoring case.
+// Names follow the key labels used by the game settings and PI hotkey options.
+func virtualKeyCode(name string) (int, bool) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if len(name) == 1 && (name[0] >= 'A' && name[0] <= 'Z' || name[0] >= '0' && name[0] <= '9') {
+		return int(name[0]), true
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(name, "F")); err == nil && strings.HasPrefix(name, "F") && n >= 1 && n <= 24 {
+		return 0x6F + n, true
+	}
+	code, ok := virtualKeyNames[name]
+	return code, ok
+}
+
+// virtualKeyNames maps upper-case key names besides letters, digits and function keys to virtual-key codes.
+var virtualKeyNames = map[string]int{
+	"BACKSPACE": 0x08,
+	"TAB":       0x09,
+	"ENTER":     0x0D,
+	"SHIFT":     0x10,
+	"CTRL":      0x11,
+	"CONTROL":   0x11,
+	"ALT":       0x12,
+	"CAPSLOCK":  0x14,
+	"ESC":       0x1B,
+	"ESCAPE":    0x1B,
+	"SPACE":     0x20,
+	"PAGEUP":    0x21,
+	"PAGEDOWN":  0x22,
+	"END":       0x23,
+	"HOME":      0x24,
+	"LEFT":      0x25,
+	"UP":        0x26,
+	"RIGHT":     0x27,
+	"DOWN":      0x28,
+	"INSERT":    0x2D,
+	"DELETE":    0x2E,
+	"LSHIFT":    0xA0,
+	"RSHIFT":    0xA1,
+	"LCTRL":     0xA2,
+	"RCTRL":     0xA3,
+	"LALT":      0xA4,
+	"RALT":      0xA5,
+	";":         0xBA,
+	"=":         0xBB,
+	",":         0xBC,
+	"-":         0xBD,
+	".":         0xBE,
+	"/":         0xBF,
+	"`":         0xC0,
+	"[":         0xDB,
+	"\\":        0xDC,
+	"]":         0xDD,
+	"'":         0xDE,
+}
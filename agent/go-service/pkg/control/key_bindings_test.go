@@ -0,0 +1,137 @@
+// Copyright (c) 2026 Harry Huang
+package control
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVirtualKey(t *testing.T) {
+	testCases := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		// Names, ignoring case and surrounding spaces
+		{value: `"W"`, want: 0x57},
+		{value: `"w"`, want: 0x57},
+		{value: `"7"`, want: 0x37},
+		{value: `" Shift "`, want: 0x10},
+		{value: `"control"`, want: 0x11},
+		{value: `"Space"`, want: 0x20},
+		{value: `"F1"`, want: 0x70},
+		{value: `"f24"`, want: 0x87},
+		{value: `"RAlt"`, want: 0xA5},
+		{value: `"\\"`, want: 0xDC},
+		// Numeric and hex codes, as JSON numbers or strings substituted by PI
+		{value: `87`, want: 0x57},
+		{value: `"87"`, want: 0x57},
+		{value: `"0x57"`, want: 0x57},
+		{value: `"0XA0"`, want: 0xA0},
+		{value: `254`, want: 0xFE},
+		// Unknown names
+		{value: `"Jump"`, wantErr: true},
+		{value: `"F25"`, wantErr: true},
+		{value: `"F0"`, wantErr: true},
+		{value: `"0xZZ"`, wantErr: true},
+		// Out of range codes
+		{value: `0`, wantErr: true},
+		{value: `-1`, wantErr: true},
+		{value: `255`, wantErr: true},
+		{value: `"0x100"`, wantErr: true},
+		// Missing or malformed bindings
+		{value: ``, wantErr: true},
+		{value: `null`, wantErr: true},
+		{value: `"  "`, wantErr: true},
+		{value: `1.5`, wantErr: true},
+		{value: `true`, wantErr: true},
+		{value: `["W"]`, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseVirtualKey([]byte(tc.value))
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("parseVirtualKey(%s) = 0x%02X, expected error", tc.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Fatalf("parseVirtualKey(%s) = (0x%02X, %v), want 0x%02X", tc.value, got, err, tc.want)
+		}
+	}
+}
+
+func TestParseDesktopKeyBindings(t *testing.T) {
+	const defaults = `{"forward": "W", "left": "A", "backward": "S", "right": "D", "sprint": "Shift", "walk": "Ctrl", "cursor": "Alt", "jump": "Space"}`
+	testCases := []struct {
+		name    string
+		data    string
+		want    desktopKeyBindings
+		wantErr string
+	}{
+		{
+			name: "default names",
+			data: defaults,
+			want: defaultDesktopKeyBindings(),
+		},
+		{
+			name: "mixed names and codes",
+			data: `{"forward": "Up", "left": 37, "backward": "0x28", "right": "39", "sprint": "RShift", "walk": "c", "cursor": "F1", "jump": "0"}`,
+			want: desktopKeyBindings{W: 0x26, A: 0x25, S: 0x28, D: 0x27, Shift: 0xA1, Ctrl: 0x43, Alt: 0x70, Space: 0x30},
+		},
+		{
+			name:    "empty attach",
+			data:    `  `,
+			wantErr: "missing",
+		},
+		{
+			name:    "malformed json",
+			data:    `{"forward": "W",`,
+			wantErr: "failed to parse",
+		},
+		{
+			name:    "missing binding",
+			data:    `{"forward": "W", "left": "A", "backward": "S", "right": "D", "sprint": "Shift", "walk": "Ctrl", "cursor": "Alt"}`,
+			wantErr: `"jump"`,
+		},
+		{
+			name:    "unknown name",
+			data:    strings.Replace(defaults, `"Space"`, `"Jump"`, 1),
+			wantErr: `unknown virtual-key name "Jump"`,
+		},
+		{
+			name:    "duplicate name",
+			data:    strings.Replace(defaults, `"jump": "Space"`, `"jump": "W"`, 1),
+			wantErr: `already bound to "forward"`,
+		},
+		{
+			name:    "duplicate between name and hex code",
+			data:    strings.Replace(defaults, `"walk": "Ctrl"`, `"walk": "0x10"`, 1),
+			wantErr: `already bound to "sprint"`,
+		},
+		{
+			name:    "duplicate between alias names",
+			data:    strings.Replace(strings.Replace(defaults, `"walk": "Ctrl"`, `"walk": "Control"`, 1), `"cursor": "Alt"`, `"cursor": "ctrl"`, 1),
+			wantErr: `already bound to "walk"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseDesktopKeyBindings([]byte(tc.data))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("parseDesktopKeyBindings() error = %v, want error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDesktopKeyBindings() error = %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("parseDesktopKeyBindings() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -16,6 +16,10 @@ const (
 	CONTROL_TYPE_WIN32   = "win32"
 	CONTROL_TYPE_WLROOTS = "wlroots"
 	CONTROL_TYPE_ADB     = "adb"
+	// CONTROL_TYPE_DEBUG and CONTROL_TYPE_REPLAY are MaaFramework's image-folder debug controller
+	// and recording replay controller, which feed recorded screenshots and ignore input.
+	CONTROL_TYPE_DEBUG  = "dbg"
+	CONTROL_TYPE_REPLAY = "replay"
 )
 
 type maaControllerInfoDto struct {
@@ -49,6 +53,12 @@ func GetControlType(ctrl *maa.Controller) (string, error) {
 		if strings.Contains(infoStr, CONTROL_TYPE_ADB) {
 			return CONTROL_TYPE_ADB, nil
 		}
+		if strings.Contains(infoStr, CONTROL_TYPE_REPLAY) {
+			return CONTROL_TYPE_REPLAY, nil
+		}
+		if strings.Contains(infoStr, CONTROL_TYPE_DEBUG) {
+			return CONTROL_TYPE_DEBUG, nil
+		}
 		return "", fmt.Errorf("failed to parse controller info via JSON: %w, and fallback parsing also failed", err)
 	}
 	if info.Type == "" {
@@ -64,6 +74,12 @@ func GetControlType(ctrl *maa.Controller) (string, error) {
 	if info.Type == CONTROL_TYPE_ADB {
 		return CONTROL_TYPE_ADB, nil
 	}
+	if info.Type == CONTROL_TYPE_DEBUG {
+		return CONTROL_TYPE_DEBUG, nil
+	}
+	if info.Type == CONTROL_TYPE_REPLAY {
+		return CONTROL_TYPE_REPLAY, nil
+	}
 	return "", fmt.Errorf("unsupported controller type: %s", info.Type)
 }
 
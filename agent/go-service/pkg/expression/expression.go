@@ -0,0 +1,386 @@
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// 表达式使用 Go 语法的子集：整数字面量、true / false、括号、+ - ! 一元运算，
+// 整数的四则、取余与比较，布尔的 && ||，以及要求两侧类型相同的 == / !=。
+// 标识符与函数调用由调用方通过 Env 提供取值、通过 Schema 提供类型。
+
+var (
+	IntMax = int(^uint(0) >> 1)
+	IntMin = -IntMax - 1
+)
+
+// Type 是表达式值的类型。
+type Type int
+
+const (
+	TypeInt Type = iota + 1
+	TypeBool
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeInt:
+		return "int"
+	case TypeBool:
+		return "bool"
+	}
+	return "unknown"
+}
+
+// TypeOf 返回值对应的表达式类型，不是 int / bool 时返回 0。
+func TypeOf(value any) Type {
+	switch value.(type) {
+	case int:
+		return TypeInt
+	case bool:
+		return TypeBool
+	}
+	return 0
+}
+
+// Env 为表达式中的标识符与函数调用提供取值。
+type Env interface {
+	// Variable 返回变量的值（int 或 bool），变量不存在时 ok 为 false。
+	Variable(name string) (value any, ok bool)
+	// Call 以已求值的参数调用函数。
+	Call(name string, args []any) (any, error)
+}
+
+// Function 是函数签名。
+type Function struct {
+	Params []Type
+	Result Type
+}
+
+// Schema 描述 Env 中变量的类型与函数签名，供 Check 在不求值的情况下做类型检查。
+type Schema struct {
+	Variables map[string]Type
+	Functions map[string]Function
+}
+
+// Parse 解析表达式。
+func Parse(expression string) (ast.Expr, error) {
+	return parser.ParseExpr(expression)
+}
+
+// Check 对表达式做静态类型检查，返回结果类型。schema 为 nil 时表达式只能包含字面量。
+// 只检查名称与类型，除零等取决于运行时数值的错误留到 Evaluate 时报告。
+func Check(expr ast.Expr, schema *Schema) (Type, error) {
+	switch node := expr.(type) {
+	case *ast.BasicLit:
+		if node.Kind != token.INT {
+			return 0, fmt.Errorf("unsupported literal kind %s", node.Kind.String())
+		}
+		if _, err := ParseIntLiteral(node.Value); err != nil {
+			return 0, err
+		}
+		return TypeInt, nil
+	case *ast.Ident:
+		if node.Name == "true" || node.Name == "false" {
+			return TypeBool, nil
+		}
+		if schema != nil {
+			if t, ok := schema.Variables[node.Name]; ok {
+				return t, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown variable %q", node.Name)
+	case *ast.CallExpr:
+		name, ok := node.Fun.(*ast.Ident)
+		if !ok {
+			return 0, fmt.Errorf("unsupported function expression %T", node.Fun)
+		}
+		var fn Function
+		if schema != nil {
+			fn, ok = schema.Functions[name.Name]
+		}
+		if !ok {
+			return 0, fmt.Errorf("unknown function %q", name.Name)
+		}
+		if len(node.Args) != len(fn.Params) {
+			return 0, fmt.Errorf("function %s expects %d argument(s), got %d", name.Name, len(fn.Params), len(node.Args))
+		}
+		for i, arg := range node.Args {
+			t, err := Check(arg, schema)
+			if err != nil {
+				return 0, err
+			}
+			if t != fn.Params[i] {
+				return 0, fmt.Errorf("function %s expects %s argument, got %s", name.Name, fn.Params[i], t)
+			}
+		}
+		return fn.Result, nil
+	case *ast.ParenExpr:
+		return Check(node.X, schema)
+	case *ast.UnaryExpr:
+		t, err := Check(node.X, schema)
+		if err != nil {
+			return 0, err
+		}
+		switch node.Op {
+		case token.ADD, token.SUB:
+			if t != TypeInt {
+				return 0, fmt.Errorf("operator %s expects int, got %s", node.Op.String(), t)
+			}
+			return TypeInt, nil
+		case token.NOT:
+			if t != TypeBool {
+				return 0, fmt.Errorf("operator ! expects bool, got %s", t)
+			}
+			return TypeBool, nil
+		default:
+			return 0, fmt.Errorf("unsupported unary operator %s", node.Op.String())
+		}
+	case *ast.BinaryExpr:
+		left, err := Check(node.X, schema)
+		if err != nil {
+			return 0, err
+		}
+		right, err := Check(node.Y, schema)
+		if err != nil {
+			return 0, err
+		}
+		return checkBinary(left, right, node.Op)
+	default:
+		return 0, fmt.Errorf("unsupported expression type %T", expr)
+	}
+}
+
+func checkBinary(left, right Type, op token.Token) (Type, error) {
+	switch op {
+	case token.ADD, token.SUB, token.MUL, token.QUO, token.REM:
+		if left != TypeInt || right != TypeInt {
+			return 0, fmt.Errorf("operator %s expects int operands, got %s and %s", op.String(), left, right)
+		}
+		return TypeInt, nil
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+		if left != TypeInt || right != TypeInt {
+			return 0, fmt.Errorf("operator %s expects int operands, got %s and %s", op.String(), left, right)
+		}
+		return TypeBool, nil
+	case token.EQL, token.NEQ:
+		if left != right {
+			return 0, fmt.Errorf("operator %s expects same-type operands, got %s and %s", op.String(), left, right)
+		}
+		return TypeBool, nil
+	case token.LAND, token.LOR:
+		if left != TypeBool || right != TypeBool {
+			return 0, fmt.Errorf("operator %s expects bool operands, got %s and %s", op.String(), left, right)
+		}
+		return TypeBool, nil
+	}
+	return 0, fmt.Errorf("unsupported binary operator %s", op.String())
+}
+
+// Evaluate 对表达式求值。env 为 nil 时表达式只能包含字面量。
+func Evaluate(expr ast.Expr, env Env) (any, error) {
+	switch node := expr.(type) {
+	case *ast.BasicLit:
+		if node.Kind != token.INT {
+			return nil, fmt.Errorf("unsupported literal kind %s", node.Kind.String())
+		}
+		return ParseIntLiteral(node.Value)
+	case *ast.Ident:
+		switch node.Name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		if env != nil {
+			if value, ok := env.Variable(node.Name); ok {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown variable %q", node.Name)
+	case *ast.CallExpr:
+		name, ok := node.Fun.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("unsupported function expression %T", node.Fun)
+		}
+		if env == nil {
+			return nil, fmt.Errorf("unknown function %q", name.Name)
+		}
+		args := make([]any, 0, len(node.Args))
+		for _, arg := range node.Args {
+			value, err := Evaluate(arg, env)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, value)
+		}
+		return env.Call(name.Name, args)
+	case *ast.ParenExpr:
+		return Evaluate(node.X, env)
+	case *ast.UnaryExpr:
+		value, err := Evaluate(node.X, env)
+		if err != nil {
+			return nil, err
+		}
+		switch node.Op {
+		case token.ADD:
+			intValue, ok := value.(int)
+			if !ok {
+				return nil, fmt.Errorf("operator + expects int, got %T", value)
+			}
+			return intValue, nil
+		case token.SUB:
+			intValue, ok := value.(int)
+			if !ok {
+				return nil, fmt.Errorf("operator - expects int, got %T", value)
+			}
+			return -intValue, nil
+		case token.NOT:
+			boolValue, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("operator ! expects bool, got %T", value)
+			}
+			return !boolValue, nil
+		default:
+			return nil, fmt.Errorf("unsupported unary operator %s", node.Op.String())
+		}
+	case *ast.BinaryExpr:
+		left, err := Evaluate(node.X, env)
+		if err != nil {
+			return nil, err
+		}
+		right, err := Evaluate(node.Y, env)
+		if err != nil {
+			return nil, err
+		}
+		return evaluateBinary(left, right, node.Op)
+	default:
+		return nil, fmt.Errorf("unsupported expression type %T", expr)
+	}
+}
+
+func evaluateBinary(left any, right any, op token.Token) (any, error) {
+	switch op {
+	case token.ADD, token.SUB, token.MUL, token.QUO, token.REM,
+		token.LSS, token.LEQ, token.GTR, token.GEQ:
+		leftInt, rightInt, err := requireInts(left, right, op)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case token.ADD:
+			return leftInt + rightInt, nil
+		case token.SUB:
+			return leftInt - rightInt, nil
+		case token.MUL:
+			return leftInt * rightInt, nil
+		case token.QUO:
+			if rightInt == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return leftInt / rightInt, nil
+		case token.REM:
+			if rightInt == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return leftInt % rightInt, nil
+		case token.LSS:
+			return leftInt < rightInt, nil
+		case token.LEQ:
+			return leftInt <= rightInt, nil
+		case token.GTR:
+			return leftInt > rightInt, nil
+		case token.GEQ:
+			return leftInt >= rightInt, nil
+		}
+	case token.EQL, token.NEQ:
+		switch leftValue := left.(type) {
+		case int:
+			rightValue, ok := right.(int)
+			if !ok {
+				return nil, fmt.Errorf("operator %s expects same-type operands, got %T and %T", op.String(), left, right)
+			}
+			if op == token.EQL {
+				return leftValue == rightValue, nil
+			}
+			return leftValue != rightValue, nil
+		case bool:
+			rightValue, ok := right.(bool)
+			if !ok {
+				return nil, fmt.Errorf("operator %s expects same-type operands, got %T and %T", op.String(), left, right)
+			}
+			if op == token.EQL {
+				return leftValue == rightValue, nil
+			}
+			return leftValue != rightValue, nil
+		default:
+			return nil, fmt.Errorf("unsupported equality operand type %T", left)
+		}
+	case token.LAND, token.LOR:
+		leftBool, rightBool, err := requireBools(left, right, op)
+		if err != nil {
+			return nil, err
+		}
+		if op == token.LAND {
+			return leftBool && rightBool, nil
+		}
+		return leftBool || rightBool, nil
+	}
+
+	return nil, fmt.Errorf("unsupported binary operator %s", op.String())
+}
+
+func requireInts(left any, right any, op token.Token) (int, int, error) {
+	leftInt, ok := left.(int)
+	if !ok {
+		return 0, 0, fmt.Errorf("operator %s expects int operands, got %T and %T", op.String(), left, right)
+	}
+	rightInt, ok := right.(int)
+	if !ok {
+		return 0, 0, fmt.Errorf("operator %s expects int operands, got %T and %T", op.String(), left, right)
+	}
+	return leftInt, rightInt, nil
+}
+
+func requireBools(left any, right any, op token.Token) (bool, bool, error) {
+	leftBool, ok := left.(bool)
+	if !ok {
+		return false, false, fmt.Errorf("operator %s expects bool operands, got %T and %T", op.String(), left, right)
+	}
+	rightBool, ok := right.(bool)
+	if !ok {
+		return false, false, fmt.Errorf("operator %s expects bool operands, got %T and %T", op.String(), left, right)
+	}
+	return leftBool, rightBool, nil
+}
+
+// ParseIntLiteral 解析整数字面量，超出 int 范围时截断到 IntMax / IntMin。
+func ParseIntLiteral(raw string) (int, error) {
+	value, err := strconv.Atoi(raw)
+	if err == nil {
+		return value, nil
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) && numErr.Err == strconv.ErrRange {
+		clamped := IntMax
+		if strings.HasPrefix(strings.TrimSpace(raw), "-") {
+			clamped = IntMin
+		}
+		log.Warn().
+			Str("component", "Expression").
+			Str("literal", raw).
+			Int("clamped_value", clamped).
+			Msg("expression integer literal out of int range, clamped")
+		return clamped, nil
+	}
+
+	return 0, err
+}
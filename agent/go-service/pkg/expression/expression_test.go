@@ -0,0 +1,162 @@
+package expression
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// testEnv 提供 hp、boss 两个变量与 has(n) 函数。
+type testEnv struct {
+	hp   int
+	boss bool
+	list []int
+}
+
+func (e *testEnv) Variable(name string) (any, bool) {
+	switch name {
+	case "hp":
+		return e.hp, true
+	case "boss":
+		return e.boss, true
+	}
+	return nil, false
+}
+
+func (e *testEnv) Call(name string, args []any) (any, error) {
+	if name != "has" {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	return slices.Contains(e.list, args[0].(int)), nil
+}
+
+var testSchema = &Schema{
+	Variables: map[string]Type{"hp": TypeInt, "boss": TypeBool},
+	Functions: map[string]Function{"has": {Params: []Type{TypeInt}, Result: TypeBool}},
+}
+
+func TestEvaluate(t *testing.T) {
+	env := &testEnv{hp: 30, boss: true, list: []int{2}}
+	testCases := []struct {
+		expr string
+		want any
+	}{
+		{expr: "1 + 2 * 3", want: 7},
+		{expr: "-(7 % 4) + +1", want: -2},
+		{expr: "hp / 4 >= 7 && boss", want: true},
+		{expr: "has(hp / 15) && !has(1)", want: true},
+		{expr: "boss == false || hp != 30", want: false},
+		{expr: "99999999999999999999999999999 < 7920000", want: false},
+	}
+
+	for _, tc := range testCases {
+		parsed, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tc.expr, err)
+		}
+		if _, err := Check(parsed, testSchema); err != nil {
+			t.Fatalf("Check(%q) error = %v", tc.expr, err)
+		}
+		got, err := Evaluate(parsed, env)
+		if err != nil {
+			t.Fatalf("Evaluate(%q) error = %v", tc.expr, err)
+		}
+		if got != tc.want {
+			t.Fatalf("Evaluate(%q) = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		expr    string
+		want    Type
+		wantErr bool
+	}{
+		{expr: "hp / (hp - 30) > 0", want: TypeBool},
+		{expr: "hp + 1", want: TypeInt},
+		{expr: "mana > 1", wantErr: true},
+		{expr: "ready(1)", wantErr: true},
+		{expr: "has(boss)", wantErr: true},
+		{expr: "has(1, 2)", wantErr: true},
+		{expr: "hp && boss", wantErr: true},
+		{expr: "hp == boss", wantErr: true},
+		{expr: "!hp", wantErr: true},
+		{expr: "1.5 > hp", wantErr: true},
+		{expr: `"a" == "a"`, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		parsed, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tc.expr, err)
+		}
+		got, err := Check(parsed, testSchema)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("Check(%q) expected error, got %s", tc.expr, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Fatalf("Check(%q) = (%s, %v), want %s", tc.expr, got, err, tc.want)
+		}
+	}
+
+	// 除零取决于运行时数值，只在求值时报告
+	parsed, _ := Parse("hp / (hp - 30) > 0")
+	if _, err := Evaluate(parsed, &testEnv{hp: 30}); err == nil {
+		t.Fatal("Evaluate() expected division by zero")
+	}
+}
+
+func TestEvaluateWithoutEnv(t *testing.T) {
+	for _, expr := range []string{"hp > 1", "has(1)"} {
+		parsed, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", expr, err)
+		}
+		if _, err := Evaluate(parsed, nil); err == nil {
+			t.Fatalf("Evaluate(%q, nil) expected error", expr)
+		}
+		if _, err := Check(parsed, nil); err == nil {
+			t.Fatalf("Check(%q, nil) expected error", expr)
+		}
+	}
+}
+
+func TestParseIntLiteral(t *testing.T) {
+	testCases := []struct {
+		name string
+		raw  string
+		want int
+	}{
+		{
+			name: "plain integer",
+			raw:  "300000000",
+			want: 300000000,
+		},
+		{
+			name: "positive overflow clamps to max int",
+			raw:  "99999999999999999999999999999",
+			want: IntMax,
+		},
+		{
+			name: "negative overflow clamps to min int",
+			raw:  "-99999999999999999999999999999",
+			want: IntMin,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseIntLiteral(tc.raw)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("ParseIntLiteral(%q) = %d, want %d", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
@@ -50,6 +50,7 @@ var htmlTemplates = map[string]string{
 	"autoecofarm.interruptible_sleep":         "HTML/interruptible-sleep.html",
 	"autoecofarm.interruptible_sleep_done":    "HTML/interruptible-sleep-done.html",
 	"autoecofarm.interruptible_sleep_stopped": "HTML/interruptible-sleep-stopped.html",
+	"autofight.fight_report":                  "HTML/autofight-fight-report.html",
 }
 
 var (
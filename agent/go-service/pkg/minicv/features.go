@@ -0,0 +1,337 @@
+package minicv
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"sort"
+	"sync"
+)
+
+const (
+	// ORB_PATCH_RADIUS is the radius of the patch used for orientation and descriptors.
+	ORB_PATCH_RADIUS = 8
+	// ORB_DEFAULT_FAST_THRESHOLD is the default intensity threshold of the FAST-9 corner test.
+	ORB_DEFAULT_FAST_THRESHOLD = 20
+
+	orbHarrisK         = 0.04
+	orbHarrisBlockSize = 7
+	orbBorder          = ORB_PATCH_RADIUS + 2
+)
+
+// Keypoint is a detected corner with its orientation.
+type Keypoint struct {
+	X, Y int
+	// Angle is the orientation of the patch intensity centroid in radians.
+	Angle    float64
+	Response float64
+}
+
+// Descriptor is a 256-bit binary descriptor compared by Hamming distance.
+type Descriptor [4]uint64
+
+// Distance returns the Hamming distance between two descriptors.
+func (d *Descriptor) Distance(o *Descriptor) int {
+	return bits.OnesCount64(d[0]^o[0]) + bits.OnesCount64(d[1]^o[1]) +
+		bits.OnesCount64(d[2]^o[2]) + bits.OnesCount64(d[3]^o[3])
+}
+
+// FeatureSet holds keypoints and their descriptors at the same indices.
+type FeatureSet struct {
+	Keypoints   []Keypoint
+	Descriptors []Descriptor
+}
+
+// ORBOptions controls [DetectORB].
+type ORBOptions struct {
+	// FastThreshold is the intensity threshold of the FAST-9 test. Zero means the default.
+	FastThreshold int
+	// MaxKeypoints keeps only the strongest keypoints if positive.
+	MaxKeypoints int
+	// Circle, if not nil, keeps only keypoints whose whole patch lies inside the circle.
+	Circle *Circle
+}
+
+// fastCircle is the Bresenham circle of radius 3 used by the FAST test.
+var fastCircle = [16][2]int{
+	{0, -3}, {1, -3}, {2, -2}, {3, -1}, {3, 0}, {3, 1}, {2, 2}, {1, 3},
+	{0, 3}, {-1, 3}, {-2, 2}, {-3, 1}, {-3, 0}, {-3, -1}, {-2, -2}, {-1, -3},
+}
+
+// briefPattern holds the rotation-steered sampling pairs, deterministic across runs.
+var briefPattern = sync.OnceValue(func() [256][4]float64 {
+	var pattern [256][4]float64
+	rng := rand.New(rand.NewPCG(0x4f5242, 0x42524945))
+	sigma := float64(ORB_PATCH_RADIUS) / 2.0
+	maxR := float64(ORB_PATCH_RADIUS) - 0.5
+	sample := func() (float64, float64) {
+		for {
+			x, y := rng.NormFloat64()*sigma, rng.NormFloat64()*sigma
+			if math.Hypot(x, y) <= maxR {
+				return x, y
+			}
+		}
+	}
+	for i := range pattern {
+		x1, y1 := sample()
+		x2, y2 := sample()
+		pattern[i] = [4]float64{x1, y1, x2, y2}
+	}
+	return pattern
+})
+
+// ImageGray converts the image to 8-bit luminance.
+func ImageGray(img *image.RGBA) *image.Gray {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	gray := image.NewGray(image.Rect(0, 0, w, h))
+	for y := range h {
+		off := y * img.Stride
+		dst := gray.Pix[y*gray.Stride : y*gray.Stride+w]
+		for x := range w {
+			r, g, b := uint32(img.Pix[off]), uint32(img.Pix[off+1]), uint32(img.Pix[off+2])
+			dst[x] = uint8((r*77 + g*150 + b*29) >> 8)
+			off += 4
+		}
+	}
+	return gray
+}
+
+// grayBlur5 smooths the gray image with a separable [1 4 6 4 1] kernel, clamping at the borders.
+func grayBlur5(gray *image.Gray) *image.Gray {
+	w, h := gray.Rect.Dx(), gray.Rect.Dy()
+	kernel := [5]int{1, 4, 6, 4, 1}
+	tmp := make([]int, w*h)
+	for y := range h {
+		row := gray.Pix[y*gray.Stride:]
+		for x := range w {
+			sum := 0
+			for k, kv := range kernel {
+				sum += kv * int(row[min(max(x+k-2, 0), w-1)])
+			}
+			tmp[y*w+x] = sum
+		}
+	}
+	dst := image.NewGray(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			sum := 0
+			for k, kv := range kernel {
+				sum += kv * tmp[min(max(y+k-2, 0), h-1)*w+x]
+			}
+			dst.Pix[y*dst.Stride+x] = uint8((sum + 128) >> 8)
+		}
+	}
+	return dst
+}
+
+// isFastCorner runs the FAST-9 segment test at (x, y).
+func isFastCorner(gray *image.Gray, x, y, threshold int) bool {
+	pix, stride := gray.Pix, gray.Stride
+	p := int(pix[y*stride+x])
+	var brighter, darker uint32
+	for i, o := range fastCircle {
+		v := int(pix[(y+o[1])*stride+x+o[0]])
+		if v > p+threshold {
+			brighter |= 1 << i
+		} else if v < p-threshold {
+			darker |= 1 << i
+		}
+	}
+	return hasContiguousBits(brighter, 9) || hasContiguousBits(darker, 9)
+}
+
+// hasContiguousBits reports whether the circular 16-bit mask has n contiguous set bits.
+func hasContiguousBits(mask uint32, n int) bool {
+	if bits.OnesCount32(mask) < n {
+		return false
+	}
+	m := mask | mask<<16
+	acc := m
+	for i := 1; i < n; i++ {
+		acc &= m >> i
+	}
+	return acc != 0
+}
+
+// harrisResponse computes the Harris corner response over a block centered at (x, y).
+func harrisResponse(gray *image.Gray, x, y int) float64 {
+	pix, stride := gray.Pix, gray.Stride
+	at := func(px, py int) float64 { return float64(pix[py*stride+px]) }
+	r := orbHarrisBlockSize / 2
+	var a, b, c float64
+	for py := y - r; py <= y+r; py++ {
+		for px := x - r; px <= x+r; px++ {
+			ix := at(px+1, py-1) + 2*at(px+1, py) + at(px+1, py+1) - at(px-1, py-1) - 2*at(px-1, py) - at(px-1, py+1)
+			iy := at(px-1, py+1) + 2*at(px, py+1) + at(px+1, py+1) - at(px-1, py-1) - 2*at(px, py-1) - at(px+1, py-1)
+			a += ix * ix
+			b += iy * iy
+			c += ix * iy
+		}
+	}
+	return a*b - c*c - orbHarrisK*(a+b)*(a+b)
+}
+
+// patchOrientation returns the angle of the intensity centroid within the patch circle.
+func patchOrientation(gray *image.Gray, x, y int) float64 {
+	pix, stride := gray.Pix, gray.Stride
+	var m10, m01 float64
+	r2 := ORB_PATCH_RADIUS * ORB_PATCH_RADIUS
+	for dy := -ORB_PATCH_RADIUS; dy <= ORB_PATCH_RADIUS; dy++ {
+		for dx := -ORB_PATCH_RADIUS; dx <= ORB_PATCH_RADIUS; dx++ {
+			if dx*dx+dy*dy > r2 {
+				continue
+			}
+			v := float64(pix[(y+dy)*stride+x+dx])
+			m10 += float64(dx) * v
+			m01 += float64(dy) * v
+		}
+	}
+	return math.Atan2(m01, m10)
+}
+
+// computeBRIEF computes the steered binary descriptor of the keypoint on the smoothed image.
+func computeBRIEF(blurred *image.Gray, kp Keypoint) Descriptor {
+	pix, stride := blurred.Pix, blurred.Stride
+	cosA, sinA := math.Cos(kp.Angle), math.Sin(kp.Angle)
+	at := func(px, py float64) uint8 {
+		rx := int(math.Round(px*cosA - py*sinA))
+		ry := int(math.Round(px*sinA + py*cosA))
+		return pix[(kp.Y+ry)*stride+kp.X+rx]
+	}
+	var desc Descriptor
+	for i, p := range briefPattern() {
+		if at(p[0], p[1]) < at(p[2], p[3]) {
+			desc[i/64] |= 1 << (i % 64)
+		}
+	}
+	return desc
+}
+
+// DetectORB detects FAST-9 corners ranked by Harris response, with intensity-centroid orientation
+// and rotation-steered BRIEF descriptors, in the spirit of ORB at a single scale.
+func DetectORB(img *image.RGBA, opts ORBOptions) *FeatureSet {
+	if img == nil {
+		return &FeatureSet{}
+	}
+	threshold := opts.FastThreshold
+	if threshold <= 0 {
+		threshold = ORB_DEFAULT_FAST_THRESHOLD
+	}
+
+	gray := ImageGray(img)
+	w, h := gray.Rect.Dx(), gray.Rect.Dy()
+	if w <= 2*orbBorder || h <= 2*orbBorder {
+		return &FeatureSet{}
+	}
+
+	inside := func(x, y int) bool {
+		if opts.Circle == nil {
+			return true
+		}
+		c := opts.Circle
+		limit := float64(c.Radius - ORB_PATCH_RADIUS - 1)
+		return limit > 0 && math.Hypot(float64(x-c.X), float64(y-c.Y)) <= limit
+	}
+
+	// Score FAST corners by Harris response, row-parallel
+	scores := make([]float64, w*h)
+	rows := h - 2*orbBorder
+	workerCount := min(8, rows)
+	runMatchWorkers(workerCount, func(id int) {
+		for y := orbBorder + id; y < h-orbBorder; y += workerCount {
+			for x := orbBorder; x < w-orbBorder; x++ {
+				if inside(x, y) && isFastCorner(gray, x, y, threshold) {
+					if r := harrisResponse(gray, x, y); r > 0 {
+						scores[y*w+x] = r
+					}
+				}
+			}
+		}
+	})
+
+	// Non-maximum suppression in 3x3 neighborhoods
+	var kps []Keypoint
+	for y := orbBorder; y < h-orbBorder; y++ {
+		for x := orbBorder; x < w-orbBorder; x++ {
+			s := scores[y*w+x]
+			if s <= 0 {
+				continue
+			}
+			isMax := true
+			for dy := -1; dy <= 1 && isMax; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					n := scores[(y+dy)*w+x+dx]
+					if n > s || (n == s && (dy < 0 || (dy == 0 && dx < 0))) {
+						isMax = false
+						break
+					}
+				}
+			}
+			if isMax {
+				kps = append(kps, Keypoint{X: x, Y: y, Response: s})
+			}
+		}
+	}
+
+	sort.SliceStable(kps, func(i, j int) bool { return kps[i].Response > kps[j].Response })
+	if opts.MaxKeypoints > 0 && len(kps) > opts.MaxKeypoints {
+		kps = kps[:opts.MaxKeypoints]
+	}
+
+	blurred := grayBlur5(gray)
+	descs := make([]Descriptor, len(kps))
+	for i := range kps {
+		kps[i].Angle = patchOrientation(gray, kps[i].X, kps[i].Y)
+		descs[i] = computeBRIEF(blurred, kps[i])
+	}
+	return &FeatureSet{Keypoints: kps, Descriptors: descs}
+}
+
+// FeatureMatch pairs a query keypoint with a train keypoint.
+type FeatureMatch struct {
+	QueryIdx int
+	TrainIdx int
+	Distance int
+}
+
+// MatchDescriptors finds the nearest train descriptor of each query descriptor, keeping matches
+// within maxDistance that pass the ratio test against the second nearest (ratio <= 0 disables it).
+func MatchDescriptors(query, train *FeatureSet, maxDistance int, ratio float64) []FeatureMatch {
+	if query == nil || train == nil || len(query.Descriptors) == 0 || len(train.Descriptors) == 0 {
+		return nil
+	}
+
+	results := make([]FeatureMatch, len(query.Descriptors))
+	workerCount := min(8, len(query.Descriptors))
+	runMatchWorkers(workerCount, func(id int) {
+		for qi := id; qi < len(query.Descriptors); qi += workerCount {
+			q := &query.Descriptors[qi]
+			best, second, bestIdx := math.MaxInt, math.MaxInt, -1
+			for ti := range train.Descriptors {
+				d := q.Distance(&train.Descriptors[ti])
+				if d < best {
+					best, second, bestIdx = d, best, ti
+				} else if d < second {
+					second = d
+				}
+			}
+			results[qi] = FeatureMatch{QueryIdx: qi, TrainIdx: -1}
+			if bestIdx < 0 || best > maxDistance {
+				continue
+			}
+			if ratio > 0 && second != math.MaxInt && float64(best) >= ratio*float64(second) {
+				continue
+			}
+			results[qi] = FeatureMatch{QueryIdx: qi, TrainIdx: bestIdx, Distance: best}
+		}
+	})
+
+	matches := make([]FeatureMatch, 0, len(results))
+	for _, m := range results {
+		if m.TrainIdx >= 0 {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
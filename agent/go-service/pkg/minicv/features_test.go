@@ -0,0 +1,195 @@
+package minicv
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// generateFeatureTestImage draws random flat shapes, which give stable corners unlike per-pixel noise.
+func generateFeatureTestImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = 96, 104, 112, 255
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	randColor := func() color.RGBA {
+		return color.RGBA{R: uint8(rng.IntN(256)), G: uint8(rng.IntN(256)), B: uint8(rng.IntN(256)), A: 255}
+	}
+	for range w * h / 900 {
+		x, y := rng.IntN(w), rng.IntN(h)
+		rw, rh := 6+rng.IntN(24), 6+rng.IntN(24)
+		c := randColor()
+		for py := max(y, 0); py < min(y+rh, h); py++ {
+			for px := max(x, 0); px < min(x+rw, w); px++ {
+				img.SetRGBA(px, py, c)
+			}
+		}
+	}
+	for range w * h / 2000 {
+		ImageDrawFilledCircle(img, rng.IntN(w), rng.IntN(h), 3+rng.IntN(8), randColor())
+	}
+	for range w * h / 6000 {
+		ImageDrawLine(img, rng.IntN(w), rng.IntN(h), rng.IntN(w), rng.IntN(h), randColor(), 2)
+	}
+	return img
+}
+
+// matchFeatureTransform estimates the transform mapping the query image onto the train image.
+func matchFeatureTransform(t *testing.T, query *image.RGBA, queryCircle *Circle, train *image.RGBA) (SimilarityTransform, int) {
+	t.Helper()
+	qf := DetectORB(query, ORBOptions{Circle: queryCircle, MaxKeypoints: 300})
+	tf := DetectORB(train, ORBOptions{})
+	if len(qf.Keypoints) < 20 || len(tf.Keypoints) < 100 {
+		t.Fatalf("too few keypoints: query=%d, train=%d", len(qf.Keypoints), len(tf.Keypoints))
+	}
+	matches := MatchDescriptors(qf, tf, 64, 0.8)
+	src := make([][2]float64, len(matches))
+	dst := make([][2]float64, len(matches))
+	for i, m := range matches {
+		q, k := qf.Keypoints[m.QueryIdx], tf.Keypoints[m.TrainIdx]
+		src[i] = [2]float64{float64(q.X), float64(q.Y)}
+		dst[i] = [2]float64{float64(k.X), float64(k.Y)}
+	}
+	transform, inliers, ok := EstimateSimilarityRANSAC(src, dst, RANSACOptions{
+		InlierThreshold: 3,
+		MinInliers:      8,
+		MinScale:        0.8,
+		MaxScale:        1.25,
+		Seed:            7,
+	})
+	if !ok {
+		t.Fatalf("RANSAC failed with %d matches", len(matches))
+	}
+	count := 0
+	for _, in := range inliers {
+		if in {
+			count++
+		}
+	}
+	return transform, count
+}
+
+func TestHasContiguousBits(t *testing.T) {
+	cases := []struct {
+		mask uint32
+		want bool
+	}{
+		{0x01FF, true},
+		{0x00FF, false},
+		{0xF01F, true}, // wraps around
+		{0xAAAA, false},
+		{0xFFFF, true},
+		{0x0000, false},
+	}
+	for _, c := range cases {
+		if got := hasContiguousBits(c.mask, 9); got != c.want {
+			t.Fatalf("hasContiguousBits(%#04x, 9): got=%v, want=%v", c.mask, got, c.want)
+		}
+	}
+}
+
+func TestDetectORBCircle(t *testing.T) {
+	img := generateFeatureTestImage(200, 200)
+	circle := Circle{X: 100, Y: 100, Radius: 60}
+	fs := DetectORB(img, ORBOptions{Circle: &circle, MaxKeypoints: 50})
+	if len(fs.Keypoints) == 0 || len(fs.Keypoints) > 50 || len(fs.Keypoints) != len(fs.Descriptors) {
+		t.Fatalf("unexpected feature count: keypoints=%d, descriptors=%d", len(fs.Keypoints), len(fs.Descriptors))
+	}
+	for i, kp := range fs.Keypoints {
+		if math.Hypot(float64(kp.X-circle.X), float64(kp.Y-circle.Y)) > float64(circle.Radius-ORB_PATCH_RADIUS) {
+			t.Fatalf("keypoint outside circle: %+v", kp)
+		}
+		if i > 0 && kp.Response > fs.Keypoints[i-1].Response {
+			t.Fatalf("keypoints not sorted by response at %d", i)
+		}
+	}
+
+	if fs := DetectORB(nil, ORBOptions{}); len(fs.Keypoints) != 0 {
+		t.Fatalf("expected no keypoints for nil image")
+	}
+	if fs := DetectORB(image.NewRGBA(image.Rect(0, 0, 10, 10)), ORBOptions{}); len(fs.Keypoints) != 0 {
+		t.Fatalf("expected no keypoints for tiny image")
+	}
+}
+
+func TestFeatureLocalizationTranslation(t *testing.T) {
+	mapImg := generateFeatureTestImage(480, 360)
+	crop := cropAsTemplate(mapImg, 210, 120, 120, 120)
+
+	transform, inliers := matchFeatureTransform(t, crop, &Circle{X: 60, Y: 60, Radius: 60}, mapImg)
+	if inliers < 20 {
+		t.Fatalf("too few inliers: %d", inliers)
+	}
+	x, y := transform.Apply(60, 60)
+	if math.Hypot(x-270, y-180) > 1.5 || math.Abs(transform.Angle) > 1 || math.Abs(transform.Scale-1) > 0.02 {
+		t.Fatalf("unexpected transform: %+v, center=(%.2f, %.2f)", transform, x, y)
+	}
+}
+
+func TestFeatureLocalizationRotationAndOcclusion(t *testing.T) {
+	mapImg := generateFeatureTestImage(480, 360)
+	crop := ImageRotate(cropAsTemplate(mapImg, 150, 100, 140, 140), 30)
+	// Hide a third of the view, as UI overlays on the minimap would
+	for y := range 140 {
+		for x := 93; x < 140; x++ {
+			crop.SetRGBA(x, y, color.RGBA{A: 255})
+		}
+	}
+
+	transform, inliers := matchFeatureTransform(t, crop, &Circle{X: 70, Y: 70, Radius: 70}, mapImg)
+	if inliers < 10 {
+		t.Fatalf("too few inliers: %d", inliers)
+	}
+	x, y := transform.Apply(70, 70)
+	if math.Hypot(x-220, y-170) > 2.5 || math.Abs(transform.Angle+30) > 2 || math.Abs(transform.Scale-1) > 0.05 {
+		t.Fatalf("unexpected transform: %+v, center=(%.2f, %.2f)", transform, x, y)
+	}
+}
+
+func TestEstimateSimilarityRANSAC(t *testing.T) {
+	want := SimilarityTransform{Scale: 1.2, Angle: -35, TX: 40, TY: -12}
+	rng := rand.New(rand.NewPCG(3, 4))
+	var src, dst [][2]float64
+	for range 60 {
+		x, y := rng.Float64()*200, rng.Float64()*200
+		tx, ty := want.Apply(x, y)
+		src = append(src, [2]float64{x, y})
+		dst = append(dst, [2]float64{tx + rng.NormFloat64()*0.3, ty + rng.NormFloat64()*0.3})
+	}
+	for range 40 {
+		src = append(src, [2]float64{rng.Float64() * 200, rng.Float64() * 200})
+		dst = append(dst, [2]float64{rng.Float64() * 300, rng.Float64() * 300})
+	}
+
+	got, inliers, ok := EstimateSimilarityRANSAC(src, dst, RANSACOptions{InlierThreshold: 2, MinInliers: 10, Seed: 1})
+	if !ok {
+		t.Fatalf("RANSAC failed")
+	}
+	for i := range 60 {
+		if !inliers[i] {
+			t.Fatalf("true correspondence %d not an inlier", i)
+		}
+	}
+	if math.Abs(got.Scale-want.Scale) > 0.01 || math.Abs(got.Angle-want.Angle) > 0.5 ||
+		math.Abs(got.TX-want.TX) > 1 || math.Abs(got.TY-want.TY) > 1 {
+		t.Fatalf("unexpected transform: got=%+v, want=%+v", got, want)
+	}
+
+	if _, _, ok := EstimateSimilarityRANSAC(src, dst, RANSACOptions{MinInliers: 10, MaxScale: 1.1, Seed: 1}); ok {
+		t.Fatalf("expected no model within the scale bounds")
+	}
+	if _, _, ok := EstimateSimilarityRANSAC(src[:1], dst[:1], RANSACOptions{}); ok {
+		t.Fatalf("expected failure with a single correspondence")
+	}
+}
+
+func BenchmarkDetectORB(b *testing.B) {
+	img := generateFeatureTestImage(960, 780)
+	b.ResetTimer()
+	for range b.N {
+		DetectORB(img, ORBOptions{})
+	}
+}
@@ -0,0 +1,189 @@
+package minicv
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+const (
+	// NCC_FFT_MIN_TEMPLATE_AREA is the minimum template area (px) for which the FFT path is considered.
+	NCC_FFT_MIN_TEMPLATE_AREA = 32 * 32
+	// NCC_FFT_MAX_PADDED_AREA caps the padded FFT size (px) to bound memory usage.
+	NCC_FFT_MAX_PADDED_AREA = 1 << 22
+)
+
+// fftPlan stores the bit-reversal permutation and twiddle factors of a radix-2 FFT of length n.
+type fftPlan struct {
+	n     int
+	rev   []int
+	roots []complex128
+}
+
+func newFFTPlan(n int) *fftPlan {
+	logN := bits.TrailingZeros(uint(n))
+	rev := make([]int, n)
+	for i := range n {
+		rev[i] = int(bits.Reverse(uint(i)) >> (bits.UintSize - logN))
+	}
+	roots := make([]complex128, n/2)
+	for k := range roots {
+		s, c := math.Sincos(-2.0 * math.Pi * float64(k) / float64(n))
+		roots[k] = complex(c, s)
+	}
+	return &fftPlan{n: n, rev: rev, roots: roots}
+}
+
+// transform runs an in-place unnormalized FFT, or its conjugate transform if inverse is set.
+func (p *fftPlan) transform(a []complex128, inverse bool) {
+	n := p.n
+	if n <= 1 {
+		return
+	}
+	for i, j := range p.rev {
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half, step := size/2, n/size
+		for start := 0; start < n; start += size {
+			for k := range half {
+				w := p.roots[k*step]
+				if inverse {
+					w = cmplx.Conj(w)
+				}
+				u, v := a[start+k], a[start+k+half]*w
+				a[start+k], a[start+k+half] = u+v, u-v
+			}
+		}
+	}
+}
+
+// fft2D runs an in-place unnormalized 2D FFT over row-major data of size w x h.
+func fft2D(data []complex128, w, h int, rowPlan, colPlan *fftPlan, inverse bool) {
+	workerCount := min(8, h)
+	runMatchWorkers(workerCount, func(id int) {
+		for y := id; y < h; y += workerCount {
+			rowPlan.transform(data[y*w:(y+1)*w], inverse)
+		}
+	})
+	workerCount = min(8, w)
+	runMatchWorkers(workerCount, func(id int) {
+		col := make([]complex128, h)
+		for x := id; x < w; x += workerCount {
+			for y := range h {
+				col[y] = data[y*w+x]
+			}
+			colPlan.transform(col, inverse)
+			for y := range h {
+				data[y*w+x] = col[y]
+			}
+		}
+	})
+}
+
+func nextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(n-1))
+}
+
+// shouldUseNCCFFT decides whether the FFT path is expected to be faster than direct NCC computation.
+func shouldUseNCCFFT(iw, ih, tw, th int) bool {
+	if tw*th < NCC_FFT_MIN_TEMPLATE_AREA {
+		return false
+	}
+	pw, ph := nextPowerOfTwo(iw), nextPowerOfTwo(ih)
+	padded := pw * ph
+	if padded > NCC_FFT_MAX_PADDED_AREA {
+		return false
+	}
+	// Seven transforms of O(n log n) against the direct O(positions * template) dot products
+	directCost := float64((iw-tw+1)*(ih-th+1)) * float64(tw*th)
+	fftCost := 7.0 * 4.0 * float64(padded) * math.Log2(float64(padded))
+	return directCost > fftCost
+}
+
+// ComputeNCCMatrixFFT computes the same matrix as [ComputeNCCMatrix] using FFT-based cross-correlation.
+// Its cost does not depend on the template size, which pays off for large templates.
+func ComputeNCCMatrixFFT(img *image.RGBA, imgIntArr IntegralArray, tpl *image.RGBA, tplStats StatsResult) [][]float64 {
+	if img == nil || tpl == nil || tplStats.Std < 1e-12 {
+		return nil
+	}
+
+	iw, ih := img.Rect.Dx(), img.Rect.Dy()
+	tw, th := tpl.Rect.Dx(), tpl.Rect.Dy()
+	mw, mh := iw-tw+1, ih-th+1
+	if tw <= 0 || th <= 0 || mw <= 0 || mh <= 0 {
+		return nil
+	}
+
+	// The padded size covers the whole image, so valid positions never wrap around
+	pw, ph := nextPowerOfTwo(iw), nextPowerOfTwo(ih)
+	rowPlan, colPlan := newFFTPlan(pw), newFFTPlan(ph)
+	acc := make([]complex128, pw*ph)
+	imgBuf := make([]complex128, pw*ph)
+	tplBuf := make([]complex128, pw*ph)
+
+	// Correlating with the zero-mean template yields the NCC numerator directly
+	for c := range 3 {
+		clear(imgBuf)
+		for y := range ih {
+			off := y * img.Stride
+			for x := range iw {
+				imgBuf[y*pw+x] = complex(float64(img.Pix[off+c]), 0)
+				off += 4
+			}
+		}
+		clear(tplBuf)
+		for y := range th {
+			off := y * tpl.Stride
+			for x := range tw {
+				tplBuf[y*pw+x] = complex(float64(tpl.Pix[off+c])-tplStats.Mean, 0)
+				off += 4
+			}
+		}
+		fft2D(imgBuf, pw, ph, rowPlan, colPlan, false)
+		fft2D(tplBuf, pw, ph, rowPlan, colPlan, false)
+		for i := range acc {
+			acc[i] += imgBuf[i] * cmplx.Conj(tplBuf[i])
+		}
+	}
+	fft2D(acc, pw, ph, rowPlan, colPlan, true)
+
+	norm := 1.0 / float64(pw*ph)
+	matrix := make([][]float64, mh)
+	for y := range mh {
+		row := make([]float64, mw)
+		for x := range mw {
+			imgStats := imgIntArr.GetAreaStats(x, y, tw, th)
+			stdProd := imgStats.Std * tplStats.Std
+			if stdProd < 1e-12 {
+				continue
+			}
+			row[x] = real(acc[y*pw+x]) * norm / stdProd
+		}
+		matrix[y] = row
+	}
+	return matrix
+}
+
+// MatchTemplateFFT performs exhaustive template matching on the whole image using [ComputeNCCMatrixFFT].
+// Returns (x, y, val) of the best match, where x and y are subpixel-accurate coordinates.
+func MatchTemplateFFT(
+	img *image.RGBA,
+	imgIntArr IntegralArray,
+	tpl *image.RGBA,
+	tplStats StatsResult,
+) (x, y, val float64) {
+	matrix := ComputeNCCMatrixFFT(img, imgIntArr, tpl, tplStats)
+	if len(matrix) == 0 {
+		return 0, 0, 0
+	}
+	fx, fy, fm := findBestNCCInMatrix(matrix)
+	x, y = subpixelNCCInMatrix(matrix, fx, fy, fm)
+	return x, y, fm
+}
@@ -226,6 +226,123 @@ func TestBuildCircleMaskTemplate(t *testing.T) {
 	}
 }
 
+func TestComputeNCCMatrixFFT(t *testing.T) {
+	testCases := []struct {
+		name string
+		tplX int
+		tplY int
+		tplW int
+		tplH int
+	}{
+		{name: "square", tplX: 101, tplY: 63, tplW: 40, tplH: 40},
+		{name: "wide", tplX: 17, tplY: 150, tplW: 72, tplH: 33},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			img := generateMatchTestImage(300, 220)
+			decorateTargetArea(img, tc.tplX, tc.tplY, tc.tplW, tc.tplH)
+			imgIntArr := GetIntegralArray(img)
+			tpl := cropAsTemplate(img, tc.tplX, tc.tplY, tc.tplW, tc.tplH)
+			tplStats := GetImageStats(tpl)
+
+			want := computeNCCMatrixDirect(img, imgIntArr, tpl, tplStats)
+			got := ComputeNCCMatrixFFT(img, imgIntArr, tpl, tplStats)
+			if len(got) != len(want) || len(got[0]) != len(want[0]) {
+				t.Fatalf("unexpected matrix size: got=%dx%d, want=%dx%d", len(got[0]), len(got), len(want[0]), len(want))
+			}
+			maxDiff := 0.0
+			for y := range want {
+				for x := range want[y] {
+					maxDiff = max(maxDiff, math.Abs(got[y][x]-want[y][x]))
+				}
+			}
+			if maxDiff > 1e-6 {
+				t.Fatalf("FFT NCC deviates from direct NCC: maxDiff=%.3g", maxDiff)
+			}
+
+			x, y, score := MatchTemplateFFT(img, imgIntArr, tpl, tplStats)
+			assertMatchNear(t, x, y, tc.tplX, tc.tplY)
+			if score < 0.9999 {
+				t.Fatalf("unexpected match score: got=%.6f, want>=0.9999", score)
+			}
+		})
+	}
+}
+
+func TestMatchTemplatePyramid(t *testing.T) {
+	testCases := []struct {
+		name string
+		tplX int
+		tplY int
+		tplW int
+		tplH int
+	}{
+		{name: "center", tplX: 608, tplY: 328, tplW: 64, tplH: 64},
+		{name: "near_edge", tplX: 9, tplY: 14, tplW: 64, tplH: 64},
+		{name: "odd_size", tplX: 901, tplY: 477, tplW: 45, tplH: 39},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			img := generateMatchTestImage(1280, 720)
+			decorateTargetArea(img, tc.tplX, tc.tplY, tc.tplW, tc.tplH)
+			imgIntArr := GetIntegralArray(img)
+			tpl := cropAsTemplate(img, tc.tplX, tc.tplY, tc.tplW, tc.tplH)
+			tplStats := GetImageStats(tpl)
+			pyr := NewImagePyramid(img, imgIntArr, 2)
+
+			matrix := computeNCCMatrixDirect(img, imgIntArr, tpl, tplStats)
+			wantX, wantY, wantScore := findBestNCCInMatrix(matrix)
+			x, y, score := MatchTemplatePyramid(pyr, tpl, tplStats, [4]int{0, 0, 1280, 720})
+
+			assertMatchNear(t, x, y, wantX, wantY)
+			assertMatchNear(t, x, y, tc.tplX, tc.tplY)
+			if math.Abs(score-wantScore) > 1e-9 {
+				t.Fatalf("pyramid score deviates from exhaustive score: got=%.6f, want=%.6f", score, wantScore)
+			}
+		})
+	}
+}
+
+func TestMatchTemplatePyramidInArea(t *testing.T) {
+	img := generateMatchTestImage(512, 384)
+	decorateTargetArea(img, 81, 68, 48, 48)
+	for row := range 48 {
+		srcOff := (68+row)*img.Stride + 81*4
+		dstOff := (244+row)*img.Stride + 321*4
+		copy(img.Pix[dstOff:dstOff+48*4], img.Pix[srcOff:srcOff+48*4])
+	}
+	imgIntArr := GetIntegralArray(img)
+	tpl := cropAsTemplate(img, 81, 68, 48, 48)
+	tplStats := GetImageStats(tpl)
+	pyr := NewImagePyramid(img, imgIntArr, 3)
+
+	// Only the second copy has its center inside the area
+	x, y, score := MatchTemplatePyramid(pyr, tpl, tplStats, [4]int{300, 220, 80, 80})
+	assertMatchNear(t, x, y, 321, 244)
+	if score < 0.9999 {
+		t.Fatalf("unexpected match score: got=%.6f, want>=0.9999", score)
+	}
+
+	x, y, score = MatchTemplatePyramid(pyr, tpl, tplStats, [4]int{600, 600, 10, 10})
+	assertZeroMatch(t, x, y, score)
+}
+
+func TestMatchTemplatePyramidSmallTemplate(t *testing.T) {
+	img := generateMatchTestImage(256, 256)
+	imgIntArr := GetIntegralArray(img)
+	tpl := cropAsTemplate(img, 40, 50, 12, 12)
+	tplStats := GetImageStats(tpl)
+
+	// Templates too small to downsample fall back to the full resolution search
+	x, y, _ := MatchTemplatePyramid(NewImagePyramid(img, imgIntArr, 2), tpl, tplStats, [4]int{0, 0, 256, 256})
+	wantX, wantY, _ := MatchTemplateInArea(img, imgIntArr, tpl, tplStats, [4]int{0, 0, 256, 256})
+	if x != wantX || y != wantY {
+		t.Fatalf("unexpected fallback result: got=(%.3f, %.3f), want=(%.3f, %.3f)", x, y, wantX, wantY)
+	}
+}
+
 func BenchmarkMatchTemplate(b *testing.B) {
 	img := generateBenchmarkImage(1280, 720)
 	imgIntArr := GetIntegralArray(img)
@@ -286,3 +403,54 @@ func BenchmarkMatchTemplateWithMask(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkMatchTemplatePyramid(b *testing.B) {
+	img := generateBenchmarkImage(1280, 720)
+	imgIntArr := GetIntegralArray(img)
+	pyr := NewImagePyramid(img, imgIntArr, 2)
+
+	benchmarks := []struct {
+		name string
+		tplW int
+		tplH int
+		tplX int
+		tplY int
+	}{
+		{name: "tpl_32x32", tplW: 32, tplH: 32, tplX: 160, tplY: 120},
+		{name: "tpl_64x64", tplW: 64, tplH: 64, tplX: 480, tplY: 240},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			tpl := cropAsTemplate(img, bm.tplX, bm.tplY, bm.tplW, bm.tplH)
+			tplStats := GetImageStats(tpl)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_, _, _ = MatchTemplatePyramid(pyr, tpl, tplStats, [4]int{0, 0, 1280, 720})
+			}
+		})
+	}
+}
+
+func BenchmarkComputeNCCMatrix(b *testing.B) {
+	img := generateBenchmarkImage(640, 360)
+	imgIntArr := GetIntegralArray(img)
+	tpl := cropAsTemplate(img, 200, 100, 96, 96)
+	tplStats := GetImageStats(tpl)
+
+	b.Run("direct", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = computeNCCMatrixDirect(img, imgIntArr, tpl, tplStats)
+		}
+	})
+	b.Run("fft", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = ComputeNCCMatrixFFT(img, imgIntArr, tpl, tplStats)
+		}
+	})
+}
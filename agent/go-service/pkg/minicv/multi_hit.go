@@ -0,0 +1,208 @@
+package minicv
+
+import (
+	"image"
+	"math"
+	"sort"
+	"sync"
+)
+
+const (
+	// DEFAULT_MASK_COLOR_RGB888 fills the area outside a circle mask when no mask color is given.
+	DEFAULT_MASK_COLOR_RGB888 = 0x00FF00
+	// DEFAULT_NMS_IOU_THRESHOLD is the default IoU above which overlapping hits are suppressed.
+	DEFAULT_NMS_IOU_THRESHOLD = 0.3
+)
+
+// MultiHitOptions controls the transforms, masks and suppression of [MatchTemplateMultiHitTransformed].
+type MultiHitOptions struct {
+	// Angles are the rotations (degrees) to search. Empty means no rotation.
+	Angles []float64
+	// Scales are the template scales to search. Empty means the original size.
+	Scales []float64
+	// UseColorMask ignores template pixels of MaskColorRGB888.
+	UseColorMask    bool
+	MaskColorRGB888 int32
+	// CircleMask, if not nil, ignores template pixels outside the circle (in unscaled template coordinates).
+	CircleMask *Circle
+	// Threshold is the minimum NCC value of a hit.
+	Threshold float64
+	// MaxHits is the maximum number of hits returned.
+	MaxHits int
+	// IoUThreshold suppresses a hit overlapping a better one above this IoU. Zero means the default.
+	IoUThreshold float64
+}
+
+// MultiHit represents a template match found under a rotation and a scale.
+type MultiHit struct {
+	// X and Y are the center of the hit in image coordinates.
+	X, Y float64
+	// W and H are the size of the scaled template.
+	W, H float64
+	Val  float64
+	// Angle is the rotation (degrees) of the hit relative to the template, counter-clockwise on screen.
+	Angle float64
+	// Scale is the template scale of the hit.
+	Scale float64
+}
+
+// IoU returns the intersection over union of the axis-aligned boxes of two hits.
+func (h MultiHit) IoU(o MultiHit) float64 {
+	ix := math.Min(h.X+h.W/2, o.X+o.W/2) - math.Max(h.X-h.W/2, o.X-o.W/2)
+	iy := math.Min(h.Y+h.H/2, o.Y+o.H/2) - math.Max(h.Y-h.H/2, o.Y-o.H/2)
+	if ix <= 0 || iy <= 0 {
+		return 0
+	}
+	inter := ix * iy
+	union := h.W*h.H + o.W*o.H - inter
+	if union <= 0 {
+		return 0
+	}
+	return inter / union
+}
+
+// SuppressHitsByIoU performs greedy non-maximum suppression, returning hits sorted by descending value
+// where no hit overlaps a better kept hit above the IoU threshold.
+func SuppressHitsByIoU(hits []MultiHit, iouThreshold float64) []MultiHit {
+	sorted := make([]MultiHit, len(hits))
+	copy(sorted, hits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Val > sorted[j].Val
+	})
+
+	kept := make([]MultiHit, 0, len(sorted))
+	for _, h := range sorted {
+		suppressed := false
+		for _, k := range kept {
+			if h.IoU(k) > iouThreshold {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
+
+// multiHitTemplate is a template prepared for one scale.
+type multiHitTemplate struct {
+	img   *image.RGBA
+	stats StatsResult
+	scale float64
+}
+
+// MatchTemplateMultiHitTransformed returns all hits of the template across the given rotations and scales.
+// Rotations are searched by rotating the image around its center, so the template is never resampled
+// by rotation; scales are searched by resizing the template. Overlapping hits across all transforms
+// are merged by IoU-based non-maximum suppression.
+func MatchTemplateMultiHitTransformed(img *image.RGBA, imgIntArr IntegralArray, tpl *image.RGBA, opts MultiHitOptions) []MultiHit {
+	if img == nil || tpl == nil || opts.MaxHits <= 0 {
+		return nil
+	}
+
+	angles := opts.Angles
+	if len(angles) == 0 {
+		angles = []float64{0}
+	}
+	scales := opts.Scales
+	if len(scales) == 0 {
+		scales = []float64{1}
+	}
+	iouThreshold := opts.IoUThreshold
+	if iouThreshold <= 0 {
+		iouThreshold = DEFAULT_NMS_IOU_THRESHOLD
+	}
+	useMask := opts.UseColorMask || opts.CircleMask != nil
+	maskColor := opts.MaskColorRGB888
+	if !opts.UseColorMask {
+		maskColor = DEFAULT_MASK_COLOR_RGB888
+	}
+
+	// Prepare the templates of all scales
+	tpls := make([]multiHitTemplate, 0, len(scales))
+	for _, scale := range scales {
+		if scale <= 0 {
+			continue
+		}
+		scaled := ImageScale(tpl, scale)
+		if opts.CircleMask != nil {
+			c := opts.CircleMask
+			circle := Circle{
+				X:      int(math.Round(float64(c.X) * scale)),
+				Y:      int(math.Round(float64(c.Y) * scale)),
+				Radius: int(math.Round(float64(c.Radius) * scale)),
+			}
+			scaled = BuildCircleMaskTemplate(scaled, circle, maskColor)
+		}
+		stats := GetImageStats(scaled)
+		if stats.Std < 1e-12 {
+			continue
+		}
+		tpls = append(tpls, multiHitTemplate{scaled, stats, scale})
+	}
+	if len(tpls) == 0 {
+		return nil
+	}
+
+	cx := float64(img.Rect.Dx()) / 2.0
+	cy := float64(img.Rect.Dy()) / 2.0
+
+	// Search each rotation with plain goroutines, since the NCC matrix itself uses the worker pool
+	results := make([][]MultiHit, len(angles))
+	workerCount := min(len(angles), 8)
+	var wg sync.WaitGroup
+	wg.Add(workerCount)
+	for workerID := range workerCount {
+		go func(id int) {
+			defer wg.Done()
+			for idx := id; idx < len(angles); idx += workerCount {
+				angle := angles[idx]
+				src, srcIntArr := img, imgIntArr
+				if angle != 0 {
+					src = ImageRotate(img, angle)
+					srcIntArr = GetIntegralArray(src)
+				}
+				rad := angle * math.Pi / 180.0
+				cosA, sinA := math.Cos(rad), math.Sin(rad)
+
+				var hits []MultiHit
+				for _, t := range tpls {
+					var matches []MatchTemplateHit
+					if useMask {
+						matches = MatchTemplateMultiHitWithMask(src, srcIntArr, t.img, t.stats, maskColor, opts.Threshold, opts.MaxHits)
+					} else {
+						matches = MatchTemplateMultiHit(src, srcIntArr, t.img, t.stats, opts.Threshold, opts.MaxHits)
+					}
+					tw, th := float64(t.img.Rect.Dx()), float64(t.img.Rect.Dy())
+					for _, m := range matches {
+						// Map the center back from the rotated image, see ImageRotate
+						dx, dy := m.X+tw/2-cx, m.Y+th/2-cy
+						hits = append(hits, MultiHit{
+							X:     dx*cosA + dy*sinA + cx,
+							Y:     -dx*sinA + dy*cosA + cy,
+							W:     tw,
+							H:     th,
+							Val:   m.Val,
+							Angle: angle,
+							Scale: t.scale,
+						})
+					}
+				}
+				results[idx] = hits
+			}
+		}(workerID)
+	}
+	wg.Wait()
+
+	var all []MultiHit
+	for _, hits := range results {
+		all = append(all, hits...)
+	}
+	kept := SuppressHitsByIoU(all, iouThreshold)
+	if len(kept) > opts.MaxHits {
+		kept = kept[:opts.MaxHits]
+	}
+	return kept
+}
@@ -0,0 +1,141 @@
+package minicv
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// rotateCCW90 rotates a square image by 90 degrees counter-clockwise on screen.
+func rotateCCW90(src *image.RGBA) *image.RGBA {
+	w := src.Rect.Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, w, w))
+	for y := range w {
+		for x := range w {
+			dst.SetRGBA(y, w-1-x, src.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
+
+func pasteImage(dst, src *image.RGBA, x, y int) {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	for row := range h {
+		srcOff := row * src.Stride
+		dstOff := (y+row)*dst.Stride + x*4
+		copy(dst.Pix[dstOff:dstOff+w*4], src.Pix[srcOff:srcOff+w*4])
+	}
+}
+
+func assertMultiHitNear(t *testing.T, hit MultiHit, wantX, wantY, tolerance float64) {
+	t.Helper()
+	if math.Hypot(hit.X-wantX, hit.Y-wantY) > tolerance {
+		t.Fatalf("unexpected hit center: got=(%.2f, %.2f), want=(%.1f, %.1f)", hit.X, hit.Y, wantX, wantY)
+	}
+}
+
+func TestMatchTemplateMultiHitTransformedRotation(t *testing.T) {
+	img := generateMatchTestImage(320, 240)
+	decorateTargetArea(img, 40, 50, 40, 40)
+	tpl := cropAsTemplate(img, 40, 50, 40, 40)
+	pasteImage(img, rotateCCW90(tpl), 200, 120)
+	imgIntArr := GetIntegralArray(img)
+
+	hits := MatchTemplateMultiHitTransformed(img, imgIntArr, tpl, MultiHitOptions{
+		Angles:     []float64{0, 90, 180, 270},
+		CircleMask: &Circle{X: 20, Y: 20, Radius: 18},
+		Threshold:  0.8,
+		MaxHits:    4,
+	})
+	if len(hits) != 2 {
+		t.Fatalf("unexpected hit count: got=%d, want=2 (%+v)", len(hits), hits)
+	}
+	for _, hit := range hits {
+		switch hit.Angle {
+		case 0:
+			assertMultiHitNear(t, hit, 60, 70, 1.5)
+		case 90:
+			assertMultiHitNear(t, hit, 220, 140, 1.5)
+		default:
+			t.Fatalf("unexpected hit angle: %+v", hit)
+		}
+		if hit.Scale != 1 || hit.W != 40 || hit.H != 40 {
+			t.Fatalf("unexpected hit size: %+v", hit)
+		}
+	}
+}
+
+func TestMatchTemplateMultiHitTransformedScale(t *testing.T) {
+	img := generateMatchTestImage(320, 240)
+	decorateTargetArea(img, 30, 30, 32, 32)
+	tpl := cropAsTemplate(img, 30, 30, 32, 32)
+	pasteImage(img, ImageScale(tpl, 1.5), 180, 100)
+	imgIntArr := GetIntegralArray(img)
+
+	hits := MatchTemplateMultiHitTransformed(img, imgIntArr, tpl, MultiHitOptions{
+		Scales:    []float64{1.0, 1.5},
+		Threshold: 0.9,
+		MaxHits:   8,
+	})
+	if len(hits) != 2 {
+		t.Fatalf("unexpected hit count: got=%d, want=2 (%+v)", len(hits), hits)
+	}
+	for _, hit := range hits {
+		switch hit.Scale {
+		case 1.0:
+			assertMultiHitNear(t, hit, 46, 46, 0.5)
+		case 1.5:
+			assertMultiHitNear(t, hit, 204, 124, 0.5)
+			if hit.W != 48 || hit.H != 48 {
+				t.Fatalf("unexpected hit size: %+v", hit)
+			}
+		default:
+			t.Fatalf("unexpected hit scale: %+v", hit)
+		}
+	}
+}
+
+func TestMatchTemplateMultiHitTransformedColorMask(t *testing.T) {
+	img := generateMatchTestImage(512, 384)
+	decorateMaskedTargetArea(img, 81, 68, 64, 48)
+	pasteImage(img, cropAsTemplate(img, 81, 68, 64, 48), 321, 244)
+	imgIntArr := GetIntegralArray(img)
+	tpl := cropAsTemplate(img, 81, 68, 64, 48)
+	maskTemplateOuterRing(tpl)
+
+	hits := MatchTemplateMultiHitTransformed(img, imgIntArr, tpl, MultiHitOptions{
+		UseColorMask:    true,
+		MaskColorRGB888: 0x00FF00,
+		Threshold:       0.9999,
+		MaxHits:         4,
+	})
+	if len(hits) != 2 {
+		t.Fatalf("unexpected hit count: got=%d, want=2 (%+v)", len(hits), hits)
+	}
+	assertMultiHitNear(t, hits[0], 81+32, 68+24, 0.1)
+	assertMultiHitNear(t, hits[1], 321+32, 244+24, 0.1)
+}
+
+func TestSuppressHitsByIoU(t *testing.T) {
+	hits := []MultiHit{
+		{X: 10, Y: 10, W: 10, H: 10, Val: 0.7},
+		{X: 12, Y: 10, W: 10, H: 10, Val: 0.9},
+		{X: 30, Y: 10, W: 10, H: 10, Val: 0.8},
+		{X: 31, Y: 11, W: 12, H: 12, Val: 0.6, Angle: 90},
+	}
+
+	kept := SuppressHitsByIoU(hits, 0.3)
+	if len(kept) != 2 {
+		t.Fatalf("unexpected kept count: got=%d, want=2 (%+v)", len(kept), kept)
+	}
+	if kept[0].Val != 0.9 || kept[1].Val != 0.8 {
+		t.Fatalf("unexpected kept hits: %+v", kept)
+	}
+
+	if iou := hits[0].IoU(hits[2]); iou != 0 {
+		t.Fatalf("unexpected IoU of disjoint hits: %.3f", iou)
+	}
+	if iou := hits[0].IoU(hits[1]); math.Abs(iou-80.0/120.0) > 1e-9 {
+		t.Fatalf("unexpected IoU: got=%.6f, want=%.6f", iou, 80.0/120.0)
+	}
+}
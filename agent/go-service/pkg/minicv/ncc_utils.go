@@ -158,10 +158,22 @@ func ComputeNCCWithMaskSpans(
 }
 
 // ComputeNCCMatrix computes normalized cross-correlation for every valid template top-left position.
+// Large templates are correlated in the frequency domain, see [ComputeNCCMatrixFFT].
 func ComputeNCCMatrix(img *image.RGBA, imgIntArr IntegralArray, tpl *image.RGBA, tplStats StatsResult) [][]float64 {
 	if img == nil || tpl == nil || tplStats.Std < 1e-12 {
 		return nil
 	}
+	if shouldUseNCCFFT(img.Rect.Dx(), img.Rect.Dy(), tpl.Rect.Dx(), tpl.Rect.Dy()) {
+		return ComputeNCCMatrixFFT(img, imgIntArr, tpl, tplStats)
+	}
+	return computeNCCMatrixDirect(img, imgIntArr, tpl, tplStats)
+}
+
+// computeNCCMatrixDirect computes the NCC matrix position by position.
+func computeNCCMatrixDirect(img *image.RGBA, imgIntArr IntegralArray, tpl *image.RGBA, tplStats StatsResult) [][]float64 {
+	if img == nil || tpl == nil || tplStats.Std < 1e-12 {
+		return nil
+	}
 
 	iw, ih := img.Rect.Dx(), img.Rect.Dy()
 	tw, th := tpl.Rect.Dx(), tpl.Rect.Dy()
@@ -0,0 +1,202 @@
+package minicv
+
+import (
+	"image"
+)
+
+const (
+	// PYRAMID_MIN_TEMPLATE_SIZE is the minimum template side length kept at the coarsest pyramid level.
+	PYRAMID_MIN_TEMPLATE_SIZE = 8
+	// PYRAMID_CANDIDATES is the number of coarse candidates refined down to full resolution.
+	PYRAMID_CANDIDATES = 8
+	// PYRAMID_REFINE_RADIUS is the search radius (px) around an upsampled candidate at each finer level.
+	PYRAMID_REFINE_RADIUS = 2
+)
+
+// ImagePyramid holds successively 2x downsampled copies of an image along with their integral arrays.
+// Level 0 is the original image.
+type ImagePyramid struct {
+	Levels    []*image.RGBA
+	Integrals []IntegralArray
+}
+
+// NewImagePyramid builds a pyramid with at most the given number of downsampled levels above the original image.
+// Downsampling stops early once a level would be smaller than the minimum template size.
+func NewImagePyramid(img *image.RGBA, imgIntArr IntegralArray, levels int) *ImagePyramid {
+	pyr := &ImagePyramid{Levels: []*image.RGBA{img}, Integrals: []IntegralArray{imgIntArr}}
+	cur := img
+	for range max(0, levels) {
+		if cur.Rect.Dx()/2 < PYRAMID_MIN_TEMPLATE_SIZE || cur.Rect.Dy()/2 < PYRAMID_MIN_TEMPLATE_SIZE {
+			break
+		}
+		cur = ImageDownsample2x(cur)
+		pyr.Levels = append(pyr.Levels, cur)
+		pyr.Integrals = append(pyr.Integrals, GetIntegralArray(cur))
+	}
+	return pyr
+}
+
+// ImageDownsample2x halves the image size by averaging each 2x2 block.
+// An odd last row or column is dropped.
+func ImageDownsample2x(img *image.RGBA) *image.RGBA {
+	w, h := img.Rect.Dx()/2, img.Rect.Dy()/2
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	spx, ss := img.Pix, img.Stride
+	dpx, ds := dst.Pix, dst.Stride
+
+	for y := range h {
+		s0 := 2 * y * ss
+		s1 := s0 + ss
+		d := y * ds
+		for range w {
+			for c := range 4 {
+				sum := uint32(spx[s0+c]) + uint32(spx[s0+4+c]) + uint32(spx[s1+c]) + uint32(spx[s1+4+c])
+				dpx[d+c] = uint8((sum + 2) >> 2)
+			}
+			s0 += 8
+			s1 += 8
+			d += 4
+		}
+	}
+	return dst
+}
+
+// MatchTemplatePyramid performs coarse-to-fine template matching such that the center of the template
+// remains within the specified area's rectangle (x, y, w, h).
+// The coarsest usable level is searched exhaustively, then the best candidates are refined locally
+// at each finer level. Falls back to [MatchTemplateInArea] if the template is too small to downsample.
+// Returns (x, y, val) of the best match, where (x, y) is the top-left corner with subpixel accuracy.
+func MatchTemplatePyramid(
+	pyr *ImagePyramid,
+	tpl *image.RGBA,
+	tplStats StatsResult,
+	rect [4]int,
+) (x, y, val float64) {
+	if pyr == nil || len(pyr.Levels) == 0 || pyr.Levels[0] == nil || tpl == nil {
+		return 0, 0, 0
+	}
+
+	img := pyr.Levels[0]
+	ax, ay, aw, ah := rect[0], rect[1], rect[2], rect[3]
+	iw, ih := img.Rect.Dx(), img.Rect.Dy()
+	tw, th := tpl.Rect.Dx(), tpl.Rect.Dy()
+
+	// Search bounds for the top-left corner at level 0
+	minX, minY := max(0, ax-tw/2), max(0, ay-th/2)
+	maxX, maxY := min(iw-tw, ax+aw-tw/2), min(ih-th, ay+ah-th/2)
+	if minX > maxX || minY > maxY {
+		return 0, 0, 0
+	}
+
+	// Build template levels as deep as both pyramids allow
+	tpls := []*image.RGBA{tpl}
+	tplStatsList := []StatsResult{tplStats}
+	for len(tpls) < len(pyr.Levels) {
+		cur := tpls[len(tpls)-1]
+		if cur.Rect.Dx()/2 < PYRAMID_MIN_TEMPLATE_SIZE || cur.Rect.Dy()/2 < PYRAMID_MIN_TEMPLATE_SIZE {
+			break
+		}
+		next := ImageDownsample2x(cur)
+		nextStats := GetImageStats(next)
+		if nextStats.Std < 1e-12 {
+			break
+		}
+		tpls = append(tpls, next)
+		tplStatsList = append(tplStatsList, nextStats)
+	}
+	levels := len(tpls) - 1
+	if levels == 0 {
+		return MatchTemplateInArea(img, pyr.Integrals[0], tpl, tplStats, rect)
+	}
+
+	// levelBounds returns the top-left search bounds at the given level
+	levelBounds := func(l int) (int, int, int, int) {
+		lw, lh := pyr.Levels[l].Rect.Dx(), pyr.Levels[l].Rect.Dy()
+		ltw, lth := tpls[l].Rect.Dx(), tpls[l].Rect.Dy()
+		scale := 1 << l
+		return minX / scale, minY / scale,
+			min(lw-ltw, (maxX+scale-1)/scale), min(lh-lth, (maxY+scale-1)/scale)
+	}
+
+	// Exhaustive search at the coarsest level
+	cMinX, cMinY, cMaxX, cMaxY := levelBounds(levels)
+	if cMinX > cMaxX || cMinY > cMaxY {
+		return MatchTemplateInArea(img, pyr.Integrals[0], tpl, tplStats, rect)
+	}
+	cImg, cIntArr := pyr.Levels[levels], pyr.Integrals[levels]
+	cTpl, cTplStats := tpls[levels], tplStatsList[levels]
+	mw, mh := cMaxX-cMinX+1, cMaxY-cMinY+1
+	matrix := make([][]float64, mh)
+	for row := range mh {
+		matrix[row] = make([]float64, mw)
+	}
+	workerCount := min(8, mh)
+	runMatchWorkers(workerCount, func(id int) {
+		for row := id; row < mh; row += workerCount {
+			for col := range mw {
+				matrix[row][col] = ComputeNCC(cImg, cIntArr, cTpl, cTplStats, cMinX+col, cMinY+row)
+			}
+		}
+	})
+
+	// Collect distinct coarse candidates
+	type candidate struct {
+		x, y int
+		s    float64
+	}
+	ctw, cth := cTpl.Rect.Dx(), cTpl.Rect.Dy()
+	candidates := make([]candidate, 0, PYRAMID_CANDIDATES)
+	for len(candidates) < PYRAMID_CANDIDATES {
+		fx, fy, fm := findBestNCCInMatrix(matrix)
+		if fm <= -1.0 {
+			break
+		}
+		candidates = append(candidates, candidate{cMinX + fx, cMinY + fy, fm})
+		suppressNCCMatrix(matrix, fx-ctw/4, fy-cth/4, ctw/2+1, cth/2+1)
+	}
+
+	// Refine each candidate down to level 0
+	results := make([]candidate, len(candidates))
+	runMatchWorkers(len(candidates), func(id int) {
+		c := candidates[id]
+		for l := levels - 1; l >= 0; l-- {
+			lMinX, lMinY, lMaxX, lMaxY := levelBounds(l)
+			lImg, lIntArr := pyr.Levels[l], pyr.Integrals[l]
+			lTpl, lTplStats := tpls[l], tplStatsList[l]
+			cx, cy := c.x*2, c.y*2
+			best := candidate{min(max(cx, lMinX), lMaxX), min(max(cy, lMinY), lMaxY), -1.0}
+			for y := max(lMinY, cy-PYRAMID_REFINE_RADIUS); y <= min(lMaxY, cy+PYRAMID_REFINE_RADIUS); y++ {
+				for x := max(lMinX, cx-PYRAMID_REFINE_RADIUS); x <= min(lMaxX, cx+PYRAMID_REFINE_RADIUS); x++ {
+					s := ComputeNCC(lImg, lIntArr, lTpl, lTplStats, x, y)
+					if s > best.s {
+						best = candidate{x, y, s}
+					}
+				}
+			}
+			c = best
+		}
+		results[id] = c
+	})
+
+	bc := candidate{minX, minY, -1.0}
+	for _, r := range results {
+		if r.s > bc.s {
+			bc = r
+		}
+	}
+	if bc.s <= -1.0 {
+		return 0, 0, 0
+	}
+
+	fm, fx, fy := bc.s, bc.x, bc.y
+	evalOr := func(tx, ty int) float64 {
+		if tx < minX || tx > maxX || ty < minY || ty > maxY {
+			return fm
+		}
+		return ComputeNCC(img, pyr.Integrals[0], tpl, tplStats, tx, ty)
+	}
+	upNCC, downNCC := evalOr(fx, fy-1), evalOr(fx, fy+1)
+	leftNCC, rightNCC := evalOr(fx-1, fy), evalOr(fx+1, fy)
+
+	return float64(fx) + subpixelOffset(leftNCC, fm, rightNCC), float64(fy) + subpixelOffset(upNCC, fm, downNCC), fm
+}
@@ -0,0 +1,164 @@
+package minicv
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand/v2"
+)
+
+// SimilarityTransform maps (x, y) to Scale * Rotate(Angle) * (x, y) + (TX, TY).
+type SimilarityTransform struct {
+	Scale float64
+	// Angle is the rotation in degrees, clockwise on screen (y axis pointing down).
+	Angle  float64
+	TX, TY float64
+}
+
+// Apply maps the point by the transform.
+func (t SimilarityTransform) Apply(x, y float64) (float64, float64) {
+	rad := t.Angle * math.Pi / 180.0
+	a, b := t.Scale*math.Cos(rad), t.Scale*math.Sin(rad)
+	return a*x - b*y + t.TX, b*x + a*y + t.TY
+}
+
+func similarityFromComplex(z, t complex128) SimilarityTransform {
+	return SimilarityTransform{
+		Scale: cmplx.Abs(z),
+		Angle: cmplx.Phase(z) * 180.0 / math.Pi,
+		TX:    real(t),
+		TY:    imag(t),
+	}
+}
+
+// RANSACOptions controls [EstimateSimilarityRANSAC].
+type RANSACOptions struct {
+	// Iterations is the number of random minimal samples. Zero means 500.
+	Iterations int
+	// InlierThreshold is the maximum reprojection error (px) of an inlier. Zero means 3.
+	InlierThreshold float64
+	// MinInliers is the minimum number of inliers of an accepted model. Zero means 3.
+	MinInliers int
+	// MinScale and MaxScale bound the scale of accepted models if positive.
+	MinScale, MaxScale float64
+	// Seed makes the sampling deterministic.
+	Seed uint64
+}
+
+// fitSimilarity fits the least-squares similarity transform of the selected correspondences.
+func fitSimilarity(src, dst [][2]float64, selected []bool) (z, t complex128, ok bool) {
+	var ps, qs complex128
+	n := 0
+	for i := range src {
+		if selected[i] {
+			ps += complex(src[i][0], src[i][1])
+			qs += complex(dst[i][0], dst[i][1])
+			n++
+		}
+	}
+	if n < 2 {
+		return 0, 0, false
+	}
+	pm, qm := ps/complex(float64(n), 0), qs/complex(float64(n), 0)
+	var num complex128
+	var den float64
+	for i := range src {
+		if selected[i] {
+			p := complex(src[i][0], src[i][1]) - pm
+			q := complex(dst[i][0], dst[i][1]) - qm
+			num += cmplx.Conj(p) * q
+			den += real(p)*real(p) + imag(p)*imag(p)
+		}
+	}
+	if den < 1e-9 {
+		return 0, 0, false
+	}
+	z = num / complex(den, 0)
+	return z, qm - z*pm, true
+}
+
+// EstimateSimilarityRANSAC robustly estimates the similarity transform mapping src points to dst points.
+// Returns the transform refined on its inliers, the inlier mask, and whether a model was accepted.
+func EstimateSimilarityRANSAC(src, dst [][2]float64, opts RANSACOptions) (SimilarityTransform, []bool, bool) {
+	n := len(src)
+	if n < 2 || len(dst) != n {
+		return SimilarityTransform{}, nil, false
+	}
+	iterations := opts.Iterations
+	if iterations <= 0 {
+		iterations = 500
+	}
+	threshold := opts.InlierThreshold
+	if threshold <= 0 {
+		threshold = 3.0
+	}
+	minInliers := max(opts.MinInliers, 2)
+	if opts.MinInliers == 0 {
+		minInliers = 3
+	}
+	th2 := threshold * threshold
+
+	scaleOK := func(z complex128) bool {
+		s := cmplx.Abs(z)
+		return (opts.MinScale <= 0 || s >= opts.MinScale) && (opts.MaxScale <= 0 || s <= opts.MaxScale)
+	}
+	countInliers := func(z, t complex128, mask []bool) int {
+		count := 0
+		for i := range src {
+			r := z*complex(src[i][0], src[i][1]) + t - complex(dst[i][0], dst[i][1])
+			in := real(r)*real(r)+imag(r)*imag(r) <= th2
+			if mask != nil {
+				mask[i] = in
+			}
+			if in {
+				count++
+			}
+		}
+		return count
+	}
+
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x5deece66d))
+	var bestZ, bestT complex128
+	bestCount := 0
+	for range iterations {
+		i, j := rng.IntN(n), rng.IntN(n)
+		if i == j {
+			continue
+		}
+		p1, p2 := complex(src[i][0], src[i][1]), complex(src[j][0], src[j][1])
+		q1, q2 := complex(dst[i][0], dst[i][1]), complex(dst[j][0], dst[j][1])
+		if cmplx.Abs(p2-p1) < 1.0 {
+			continue
+		}
+		z := (q2 - q1) / (p2 - p1)
+		if !scaleOK(z) {
+			continue
+		}
+		t := q1 - z*p1
+		if count := countInliers(z, t, nil); count > bestCount {
+			bestZ, bestT, bestCount = z, t, count
+		}
+	}
+	if bestCount < minInliers {
+		return SimilarityTransform{}, nil, false
+	}
+
+	// Refine on the inliers, then recollect them with the refined model
+	mask := make([]bool, n)
+	countInliers(bestZ, bestT, mask)
+	if z, t, ok := fitSimilarity(src, dst, mask); ok && scaleOK(z) {
+		refined := make([]bool, n)
+		if countInliers(z, t, refined) >= bestCount {
+			bestZ, bestT, mask = z, t, refined
+		}
+	}
+	count := 0
+	for _, in := range mask {
+		if in {
+			count++
+		}
+	}
+	if count < minInliers {
+		return SimilarityTransform{}, nil, false
+	}
+	return similarityFromComplex(bestZ, bestT), mask, true
+}
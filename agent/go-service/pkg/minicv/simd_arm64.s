@@ -0,0 +1,89 @@
+//go:build arm64 && !purego
+
+#include "textflag.h"
+
+DATA ·rgba3Mask<>(SB)/8, $0x00FFFFFF00FFFFFF
+DATA ·rgba3Mask<>+8(SB)/8, $0x00FFFFFF00FFFFFF
+GLOBL ·rgba3Mask<>(SB), RODATA, $16
+
+TEXT ·dotRGBA3SIMD(SB), NOSPLIT, $0-32
+	MOVD imgPix+0(FP), R0
+	MOVD tplPix+8(FP), R1
+	MOVD pixels+16(FP), R2
+
+	MOVD ZR, R3
+	MOVD $·rgba3Mask<>(SB), R4
+	VLD1 (R4), [V7.B16]
+
+chunkLoop:
+	CBZ R2, done
+
+	// Keep each SIMD chunk within the range where the 32-bit UADDW accumulators
+	// cannot overflow, each lane gains at most 4*255*255 per iteration.
+	MOVD R2, R5
+	CMP $16384, R5
+	BLE chunkReady
+	MOVD $16384, R5
+
+chunkReady:
+	MOVD R5, R6
+	VEOR V5.B16, V5.B16, V5.B16
+
+	CMP $4, R6
+	BLT reduceChunk
+
+loop4:
+	VLD1.P 16(R0), [V1.B16]
+	VLD1.P 16(R1), [V2.B16]
+	// Clearing the alpha bytes of one operand is enough to drop their products
+	VAND V7.B16, V1.B16, V1.B16
+
+	VUMULL V1.B8, V2.B8, V3.H8
+	VUMULL2 V1.B16, V2.B16, V4.H8
+
+	VUADDW V3.H4, V5.S4, V5.S4
+	VUADDW2 V3.H8, V5.S4, V5.S4
+	VUADDW V4.H4, V5.S4, V5.S4
+	VUADDW2 V4.H8, V5.S4, V5.S4
+
+	SUB $4, R6
+	CMP $4, R6
+	BGE loop4
+
+reduceChunk:
+	// Reduce the chunk-local SIMD accumulators to a scalar before moving on to
+	// the next chunk so the running total stays in 64-bit R3.
+	VUADDLV V5.S4, V6
+	VMOV V6.D[0], R7
+	ADD R7, R3
+
+	CBZ R6, nextChunk
+
+tailLoop:
+	MOVBU 0(R0), R7
+	MOVBU 0(R1), R8
+	MUL R7, R8, R8
+	ADD R8, R3
+
+	MOVBU 1(R0), R7
+	MOVBU 1(R1), R8
+	MUL R7, R8, R8
+	ADD R8, R3
+
+	MOVBU 2(R0), R7
+	MOVBU 2(R1), R8
+	MUL R7, R8, R8
+	ADD R8, R3
+
+	ADD $4, R0
+	ADD $4, R1
+	SUB $1, R6
+	CBNZ R6, tailLoop
+
+nextChunk:
+	SUB R5, R2
+	B chunkLoop
+
+done:
+	MOVD R3, ret+24(FP)
+	RET
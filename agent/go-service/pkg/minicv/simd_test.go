@@ -1,6 +1,9 @@
 package minicv
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+)
 
 func dotRGBA3Reference(imgPix, tplPix []byte, pixels int) uint64 {
 	var dot uint64
@@ -38,3 +41,70 @@ func TestDotRGBA3WideRow(t *testing.T) {
 		t.Fatalf("unexpected dot product: got=%d want=%d", got, want)
 	}
 }
+
+func fillDotRGBA3TestPix(pix []byte, seed uint32) {
+	state := seed
+	for i := range pix {
+		state = state*1664525 + 1013904223
+		pix[i] = byte(state >> 24)
+	}
+}
+
+func TestDotRGBA3Lengths(t *testing.T) {
+	// Cover empty rows, SIMD remainders, chunk boundaries and unaligned offsets
+	lengths := []int{0, 1, 2, 3, 4, 5, 7, 8, 15, 16, 17, 63, 64, 65, 16383, 16384, 16385, 32771}
+	for _, offset := range []int{0, 4, 12} {
+		for _, pixels := range lengths {
+			imgPix := make([]byte, offset+pixels*4+4)
+			tplPix := make([]byte, offset+pixels*4+4)
+			fillDotRGBA3TestPix(imgPix, uint32(pixels*7+offset))
+			fillDotRGBA3TestPix(tplPix, uint32(pixels*13+offset+1))
+
+			got := dotRGBA3(&imgPix[offset], &tplPix[offset], pixels)
+			want := dotRGBA3Reference(imgPix[offset:], tplPix[offset:], pixels)
+			if got != want {
+				t.Fatalf("unexpected dot product: offset=%d pixels=%d got=%d want=%d", offset, pixels, got, want)
+			}
+		}
+	}
+}
+
+func TestDotRGBA3Saturated(t *testing.T) {
+	// All-255 input maximizes the per-lane accumulation within a chunk
+	const pixels = 16384*2 + 5
+
+	imgPix := make([]byte, pixels*4)
+	tplPix := make([]byte, pixels*4)
+	for i := range imgPix {
+		imgPix[i] = 255
+		tplPix[i] = 255
+	}
+
+	got := dotRGBA3(&imgPix[0], &tplPix[0], pixels)
+	want := uint64(pixels) * 3 * 255 * 255
+	if got != want {
+		t.Fatalf("unexpected dot product: got=%d want=%d", got, want)
+	}
+}
+
+func BenchmarkDotRGBA3(b *testing.B) {
+	for _, pixels := range []int{16, 64, 1280} {
+		imgPix := make([]byte, pixels*4)
+		tplPix := make([]byte, pixels*4)
+		fillDotRGBA3TestPix(imgPix, 1)
+		fillDotRGBA3TestPix(tplPix, 2)
+
+		b.Run(fmt.Sprintf("simd_%d", pixels), func(b *testing.B) {
+			b.SetBytes(int64(pixels * 4))
+			for i := 0; i < b.N; i++ {
+				_ = dotRGBA3(&imgPix[0], &tplPix[0], pixels)
+			}
+		})
+		b.Run(fmt.Sprintf("scalar_%d", pixels), func(b *testing.B) {
+			b.SetBytes(int64(pixels * 4))
+			for i := 0; i < b.N; i++ {
+				_ = dotRGBA3Reference(imgPix, tplPix, pixels)
+			}
+		})
+	}
+}
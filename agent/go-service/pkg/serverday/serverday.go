@@ -0,0 +1,46 @@
+package serverday
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// BoundaryHour 是服务器日的分界时刻，服务器时间 04:00 之前仍属于前一天。
+	BoundaryHour           = 4
+	defaultServerUTCOffset = 8 * 60 * 60
+)
+
+// DefaultLocation 是默认的服务器时区（UTC+8）。
+var DefaultLocation = time.FixedZone("UTC+8", defaultServerUTCOffset)
+
+// LocationFromUTCOffset 返回 UTC 偏移小时数对应的时区，offset 为空时返回 DefaultLocation。
+func LocationFromUTCOffset(offset *int) *time.Location {
+	if offset == nil {
+		return DefaultLocation
+	}
+
+	name := fmt.Sprintf("UTC%+d", *offset)
+	return time.FixedZone(name, *offset*60*60)
+}
+
+// AdjustedTime 返回 now 在服务器时区中的时间，并在分界时刻之前回退一天，
+// 使其日期即为所在的服务器日。loc 为空时使用 DefaultLocation。
+func AdjustedTime(now time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = DefaultLocation
+	}
+
+	serverTime := now.In(loc)
+	if serverTime.Hour() < BoundaryHour {
+		serverTime = serverTime.AddDate(0, 0, -1)
+	}
+
+	return serverTime
+}
+
+// DayStart 返回 now 所在服务器日的开始时刻。loc 为空时使用 DefaultLocation。
+func DayStart(now time.Time, loc *time.Location) time.Time {
+	serverTime := AdjustedTime(now, loc)
+	return time.Date(serverTime.Year(), serverTime.Month(), serverTime.Day(), BoundaryHour, 0, 0, 0, serverTime.Location())
+}
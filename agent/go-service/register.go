@@ -33,6 +33,7 @@ import (
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/seizedeliveryjobs"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/sellproduct"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/taskersink/aspectratio"
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/taskersink/controltrace"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/taskersink/cursormove"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/taskersink/hdrcheck"
 	"github.com/MaaXYZ/MaaEnd/agent/go-service/taskersink/processcheck"
@@ -51,6 +52,7 @@ func registerAll() {
 	hdrcheck.Register()
 	processcheck.Register()
 	cursormove.Register()
+	controltrace.Register()
 
 	// General Custom
 	subtask.Register()
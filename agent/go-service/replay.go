@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	maptrackerdefault "github.com/MaaXYZ/MaaEnd/agent/go-service/maptracker/default"
+	"github.com/rs/zerolog/log"
+)
+
+// runReplay 处理 `--replay <frames_dir> [-param <json>] [-format json|csv] [-o <file>]` 入口。
+// 它离线回放录制的小地图截图序列，运行完整的 MapTrackerInfer 推理流程，
+// 并输出逐帧误差、置信度与耗时。不连接 MaaFramework，可在无头 Linux 环境运行。
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	param := fs.String("param", "", "MapTrackerInfer custom_recognition_param in JSON")
+	format := fs.String("format", "json", "output format: json or csv")
+	output := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		log.Fatal().
+			Msg("Usage: go-service --replay [-param <json>] [-format json|csv] [-o <file>] <frames_dir>")
+	}
+	dir := fs.Arg(0)
+
+	var write func(*maptrackerdefault.InferReplayReport, io.Writer) error
+	switch *format {
+	case "json":
+		write = (*maptrackerdefault.InferReplayReport).WriteJSON
+	case "csv":
+		write = (*maptrackerdefault.InferReplayReport).WriteCSV
+	default:
+		log.Fatal().
+			Str("format", *format).
+			Msg("Unknown replay output format")
+	}
+
+	log.Info().
+		Str("dir", dir).
+		Str("param", *param).
+		Str("format", *format).
+		Msg("Replay invoked")
+
+	report, err := maptrackerdefault.RunInferReplay(dir, *param)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Replay failed")
+	}
+
+	var file *os.File
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err = os.Create(*output)
+		if err != nil {
+			log.Fatal().
+				Err(err).
+				Str("output", *output).
+				Msg("Failed to create replay output")
+		}
+		w = file
+	}
+
+	err = write(report, w)
+	if file != nil {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Failed to write replay output")
+	}
+
+	log.Info().
+		Int("frames", report.Summary.FrameCount).
+		Int("hits", report.Summary.HitCount).
+		Float64("meanLocError", report.Summary.MeanLocError).
+		Msg("Replay finished")
+}
@@ -0,0 +1,24 @@
+package controltrace
+
+import (
+	"github.com/MaaXYZ/MaaEnd/agent/go-service/pkg/control"
+	"github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// ControlTraceCloser closes the control trace written by replay adaptors when a task ends,
+// so that the trace of a finished task is complete on disk and the file is not held open between tasks.
+type ControlTraceCloser struct{}
+
+// OnTaskerTask handles tasker task events
+func (c *ControlTraceCloser) OnTaskerTask(_ *maa.Tasker, event maa.EventStatus, detail maa.TaskerTaskDetail) {
+	if event != maa.EventStatusSucceeded && event != maa.EventStatusFailed {
+		return
+	}
+	if err := control.CloseControlTrace(); err != nil {
+		log.Warn().
+			Err(err).
+			Uint64("task_id", detail.TaskID).
+			Msg("Failed to close control trace")
+	}
+}
@@ -0,0 +1,12 @@
+package controltrace
+
+import "github.com/MaaXYZ/maa-framework-go/v4"
+
+var (
+	_ maa.TaskerEventSink = &ControlTraceCloser{}
+)
+
+// Register registers the control trace closer as a tasker sink
+func Register() {
+	maa.AgentServerAddTaskerSink(&ControlTraceCloser{})
+}